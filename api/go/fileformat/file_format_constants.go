@@ -39,9 +39,10 @@ const (
 // Encryption type constants
 // Specification: package_file_format.md: 4.1.1.3 Compression and Encryption Types
 const (
-	EncryptionNone        = 0x00 // No encryption
-	EncryptionAES256GCM   = 0x01 // AES-256-GCM encryption
-	EncryptionQuantumSafe = 0x02 // Quantum-safe encryption (ML-KEM + ML-DSA)
+	EncryptionNone             = 0x00 // No encryption
+	EncryptionAES256GCM        = 0x01 // AES-256-GCM encryption
+	EncryptionQuantumSafe      = 0x02 // Quantum-safe hybrid encryption (ML-KEM + AES-256-GCM)
+	EncryptionChaCha20Poly1305 = 0x03 // ChaCha20-Poly1305 encryption
 )
 
 // Hash algorithm type constants
@@ -96,12 +97,18 @@ const (
 	FlagMetadataOnly       = 1 << 7 // Bit 7: Metadata-only package
 )
 
+// Package index flags (Flags field bits 16-23)
+// Specification: package_file_format.md: 2.5.5 Package Index Flags
+const (
+	FlagConfidentialIndex = 1 << 16 // Bit 16: FileEntry path sections and path metadata are encrypted
+)
+
 // Flags field bit masks
 // Specification: package_file_format.md: 2.5.1 Flags Field Encoding
 const (
 	FlagsMaskFeatures        = 0x000000FF // Bits 0-7: Package features
 	FlagsMaskCompressionType = 0x0000FF00 // Bits 8-15: Package compression type
-	FlagsMaskReserved1       = 0x00FF0000 // Bits 16-23: Reserved for future use
+	FlagsMaskReserved1       = 0x00FF0000 // Bits 16-23: Package index flags; unassigned bits reserved
 	FlagsMaskReserved2       = 0xFF000000 // Bits 24-31: Reserved for future use
 )
 
@@ -114,8 +121,10 @@ const (
 
 // Size constants for common data structures
 const (
-	FileEntryFixedSize = 64 // Fixed portion of FileEntry
-	IndexEntrySize     = 16 // Size of FileIndex entry (FileID + Offset)
+	FileEntryFixedSize      = 64 // Fixed portion of FileEntry
+	IndexEntrySize          = 16 // Size of FileIndex entry (FileID + Offset)
+	MetadataIndexHeaderSize = 8  // Size of MetadataIndex header (EntryCount + Reserved)
+	MetadataIndexEntrySize  = 32 // Size of MetadataIndex entry (FileID + block offsets and sizes)
 )
 
 // VendorID example constants
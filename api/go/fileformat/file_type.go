@@ -0,0 +1,527 @@
+// This file implements the NovusPack file type system: the FileType identifier,
+// its category ranges and specific constants, range-based category queries,
+// file type detection from name and content, and compression selection by
+// file type. This file should contain only file type classification logic;
+// applying the selected compression to FileEntry data belongs in the callers.
+//
+// Specification: file_type_system.md: 1. FileType System Specification
+
+package fileformat
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+)
+
+// FileType represents a file type identifier.
+//
+// Specification: file_type_system.md: 3.1 FileType Type
+type FileType uint16
+
+// File type range constants (2-byte: 0-65535)
+// Specification: file_type_system.md: 3.2 FileType Constants Ranges
+const (
+	FileTypeBinaryStart  = 0
+	FileTypeBinaryEnd    = 999
+	FileTypeTextStart    = 1000
+	FileTypeTextEnd      = 1999
+	FileTypeScriptStart  = 2000
+	FileTypeScriptEnd    = 3999
+	FileTypeConfigStart  = 4000
+	FileTypeConfigEnd    = 4999
+	FileTypeImageStart   = 5000
+	FileTypeImageEnd     = 6999
+	FileTypeAudioStart   = 7000
+	FileTypeAudioEnd     = 7999
+	FileTypeVideoStart   = 8000
+	FileTypeVideoEnd     = 9999
+	FileTypeSystemStart  = 10000
+	FileTypeSystemEnd    = 10999
+	FileTypeSpecialStart = 65000
+	FileTypeSpecialEnd   = 65535
+)
+
+// Binary file types (0-999)
+// Specification: file_type_system.md: 3.3.1 Binary File Types (0-999)
+const (
+	FileTypeBinary     FileType = 0 // Generic binary data
+	FileTypeExecutable FileType = 1 // Binary executables
+	FileTypeLibrary    FileType = 2 // Shared libraries
+	FileTypeArchive    FileType = 3 // Compressed archives
+)
+
+// Text file types (1000-1999)
+// Specification: file_type_system.md: 3.3.2 Text File Types (1000-1999)
+const (
+	FileTypeText     FileType = 1000 // Plain text files
+	FileTypeMarkdown FileType = 1001 // Markdown documents
+	FileTypeHTML     FileType = 1002 // HTML documents
+	FileTypeCSS      FileType = 1003 // CSS stylesheets
+	FileTypeCSV      FileType = 1004 // Comma-separated values
+	FileTypeSQL      FileType = 1005 // SQL scripts
+	FileTypeDiff     FileType = 1006 // Diff/patch files
+	FileTypeTeX      FileType = 1007 // TeX/LaTeX documents
+)
+
+// Script file types (2000-3999)
+// Specification: file_type_system.md: 3.3.3 Script File Types (2000-3999)
+const (
+	FileTypeScript       FileType = 2000 // Generic scripts
+	FileTypePython       FileType = 2001 // Python scripts
+	FileTypeJavaScript   FileType = 2002 // JavaScript code
+	FileTypeTypeScript   FileType = 2003 // TypeScript code
+	FileTypeShell        FileType = 2004 // Shell scripts
+	FileTypeLua          FileType = 2005 // Lua scripts
+	FileTypePerl         FileType = 2006 // Perl scripts
+	FileTypeRuby         FileType = 2007 // Ruby scripts
+	FileTypePHP          FileType = 2008 // PHP scripts
+	FileTypeJava         FileType = 2009 // Java source
+	FileTypeCSharp       FileType = 2010 // C# source
+	FileTypeGo           FileType = 2011 // Go source
+	FileTypeRust         FileType = 2012 // Rust source
+	FileTypeScala        FileType = 2013 // Scala source
+	FileTypeKotlin       FileType = 2014 // Kotlin source
+	FileTypeSwift        FileType = 2015 // Swift source
+	FileTypeCoffeeScript FileType = 2016 // CoffeeScript
+	FileTypeDart         FileType = 2017 // Dart scripts
+	FileTypeElixir       FileType = 2018 // Elixir scripts
+	FileTypeErlang       FileType = 2019 // Erlang scripts
+	FileTypeHaskell      FileType = 2020 // Haskell scripts
+	FileTypeClojure      FileType = 2021 // Clojure scripts
+	FileTypeFSharp       FileType = 2022 // F# scripts
+	FileTypeOCaml        FileType = 2023 // OCaml scripts
+	FileTypeR            FileType = 2024 // R scripts
+	FileTypeMATLAB       FileType = 2025 // MATLAB scripts
+	FileTypeJulia        FileType = 2026 // Julia scripts
+	FileTypePowerShell   FileType = 2027 // PowerShell scripts
+	FileTypeBatch        FileType = 2028 // Windows batch files
+	FileTypeVBScript     FileType = 2029 // VBScript
+	FileTypeAppleScript  FileType = 2030 // AppleScript
+	FileTypeAutoHotkey   FileType = 2031 // AutoHotkey scripts
+	FileTypeGroovy       FileType = 2032 // Groovy scripts
+	FileTypeCrystal      FileType = 2033 // Crystal scripts
+	FileTypeNim          FileType = 2034 // Nim scripts
+	FileTypeZig          FileType = 2035 // Zig scripts
+	FileTypeV            FileType = 2036 // V scripts
+	FileTypeD            FileType = 2037 // D scripts
+	FileTypeAda          FileType = 2038 // Ada scripts
+	FileTypeFortran      FileType = 2039 // Fortran scripts
+)
+
+// Config file types (4000-4999)
+// Specification: file_type_system.md: 3.3.4 Config File Types (4000-4999)
+const (
+	FileTypeYAML       FileType = 4000 // YAML configuration
+	FileTypeJSON       FileType = 4001 // JSON configuration
+	FileTypeXML        FileType = 4002 // XML configuration
+	FileTypeTOML       FileType = 4003 // TOML configuration
+	FileTypeHOCON      FileType = 4004 // HOCON configuration
+	FileTypeEDN        FileType = 4005 // EDN configuration
+	FileTypeCUE        FileType = 4006 // CUE configuration
+	FileTypeProperties FileType = 4007 // Properties files
+	FileTypeINI        FileType = 4008 // INI configuration
+)
+
+// Image file types (5000-6999)
+// Specification: file_type_system.md: 3.3.5 Image File Types (5000-6999)
+const (
+	FileTypeImage FileType = 5000 // Generic image
+	FileTypePNG   FileType = 5001 // PNG images
+	FileTypeJPEG  FileType = 5002 // JPEG images
+	FileTypeGIF   FileType = 5003 // GIF images
+	FileTypeBMP   FileType = 5004 // BMP images
+	FileTypeWebP  FileType = 5005 // WebP images
+	FileTypeTIFF  FileType = 5006 // TIFF images
+	FileTypeSVG   FileType = 5007 // SVG images
+	FileTypeRAW   FileType = 5008 // RAW images
+	FileTypeHEIC  FileType = 5009 // HEIC images
+	FileTypeAVIF  FileType = 5010 // AVIF images
+	FileTypeICO   FileType = 5011 // ICO images
+	FileTypeTGA   FileType = 5012 // TGA images
+	FileTypePSD   FileType = 5013 // PSD images
+	FileTypeXCF   FileType = 5014 // XCF images
+	FileTypeDDS   FileType = 5015 // DDS images
+	FileTypeEXR   FileType = 5016 // EXR images
+	FileTypeHDR   FileType = 5017 // HDR images
+	FileTypePPM   FileType = 5018 // PPM images
+	FileTypePGM   FileType = 5019 // PGM images
+	FileTypePBM   FileType = 5020 // PBM images
+	FileTypeXBM   FileType = 5021 // XBM images
+	FileTypeXPM   FileType = 5022 // XPM images
+	FileTypePCX   FileType = 5023 // PCX images
+	FileTypeTIF   FileType = 5024 // TIF images
+	FileTypeJPG   FileType = 5025 // JPG images
+	FileTypeJP2   FileType = 5026 // JP2 images
+	FileTypeJ2K   FileType = 5027 // J2K images
+	FileTypeJXR   FileType = 5028 // JXR images
+	FileTypeWDP   FileType = 5029 // WDP images
+)
+
+// Audio file types (7000-7999)
+// IDs 7012 and 7016 are not given names here: the specification reuses the
+// OGV and 3GP names for them, which are defined in the video range.
+// Specification: file_type_system.md: 3.3.6 Audio File Types (7000-7999)
+const (
+	FileTypeAudio  FileType = 7000 // Generic audio
+	FileTypeMP3    FileType = 7001 // MP3 audio
+	FileTypeWAV    FileType = 7002 // WAV audio
+	FileTypeOGG    FileType = 7003 // OGG audio
+	FileTypeFLAC   FileType = 7004 // FLAC audio
+	FileTypeAAC    FileType = 7005 // AAC audio
+	FileTypeWMA    FileType = 7006 // WMA audio
+	FileTypeAIFF   FileType = 7007 // AIFF audio
+	FileTypeALAC   FileType = 7008 // ALAC audio
+	FileTypeAPE    FileType = 7009 // APE audio
+	FileTypeOpus   FileType = 7010 // Opus audio
+	FileTypeM4A    FileType = 7011 // M4A audio
+	FileTypeVorbis FileType = 7013 // Vorbis audio
+	FileTypeSpeex  FileType = 7014 // Speex audio
+	FileTypeAMR    FileType = 7015 // AMR audio
+	FileTypeAC3    FileType = 7017 // AC3 audio
+	FileTypeDTS    FileType = 7018 // DTS audio
+	FileTypeMKA    FileType = 7019 // MKA audio
+)
+
+// Video file types (8000-9999)
+// Specification: file_type_system.md: 3.3.7 Video File Types (8000-9999)
+const (
+	FileTypeVideo FileType = 8000 // Generic video
+	FileTypeMP4   FileType = 8001 // MP4 video
+	FileTypeMKV   FileType = 8002 // MKV video
+	FileTypeAVI   FileType = 8003 // AVI video
+	FileTypeMOV   FileType = 8004 // MOV video
+	FileTypeWebM  FileType = 8005 // WebM video
+	FileTypeWMV   FileType = 8006 // WMV video
+	FileTypeFLV   FileType = 8007 // FLV video
+	FileTypeM4V   FileType = 8008 // M4V video
+	FileTypeMPEG  FileType = 8009 // MPEG video
+	FileType3GP   FileType = 8010 // 3GP video
+	FileTypeHEVC  FileType = 8011 // HEVC video
+	FileTypeAV1   FileType = 8012 // AV1 video
+	FileTypeDivX  FileType = 8013 // DivX video
+	FileTypeXvid  FileType = 8014 // Xvid video
+	FileTypeVP8   FileType = 8015 // VP8 video
+	FileTypeVP9   FileType = 8016 // VP9 video
+	FileTypeH264  FileType = 8017 // H.264 video
+	FileTypeH265  FileType = 8018 // H.265 video
+	FileTypeOGV   FileType = 8019 // OGV video
+	FileTypeASF   FileType = 8020 // ASF video
+	FileTypeRM    FileType = 8021 // RM video
+	FileTypeRMVB  FileType = 8022 // RMVB video
+	FileTypeVOB   FileType = 8023 // VOB video
+	FileTypeTS    FileType = 8024 // TS video
+	FileTypeM2TS  FileType = 8025 // M2TS video
+	FileTypeMTS   FileType = 8026 // MTS video
+	FileTypeM2V   FileType = 8027 // M2V video
+	FileTypeM1V   FileType = 8028 // M1V video
+	FileTypeMPG   FileType = 8029 // MPG video
+)
+
+// System file types (10000-10999)
+// Specification: file_type_system.md: 3.3.8 System File Types (10000-10999)
+const (
+	FileTypeRegular   FileType = 10000 // Regular files
+	FileTypeDirectory FileType = 10001 // Directories
+	FileTypeSymlink   FileType = 10002 // Symbolic links
+)
+
+// Special file types (65000-65535)
+// Specification: file_type_system.md: 3.3.9 Special File Types (65000-65535)
+const (
+	FileTypeMetadata              FileType = 65000 // Package metadata
+	FileTypeManifest              FileType = 65001 // Package manifest
+	FileTypeIndex                 FileType = 65002 // Package index
+	FileTypeSignature             FileType = 65003 // Package signature
+	FileTypeCompressionDictionary FileType = 65004 // Shared compression dictionaries
+	FileTypeKeyEnvelope           FileType = 65005 // Package key envelope
+	FileTypePassphraseKDF         FileType = 65006 // Passphrase key derivation parameters
+	FileTypeConfidentialIndex     FileType = 65007 // Confidential index key reference
+)
+
+// IsBinaryFile returns true if file type is within binary file range (0-999).
+//
+// Specification: file_type_system.md: 2.1.1 IsBinaryFile Function
+func IsBinaryFile(fileType FileType) bool {
+	return fileType <= FileTypeBinaryEnd
+}
+
+// IsTextFile returns true if file type is within text file range (1000-1999).
+//
+// Specification: file_type_system.md: 2.1.2 IsTextFile Function
+func IsTextFile(fileType FileType) bool {
+	return fileType >= FileTypeTextStart && fileType <= FileTypeTextEnd
+}
+
+// IsScriptFile returns true if file type is within script file range (2000-3999).
+//
+// Specification: file_type_system.md: 2.1.3 IsScriptFile Function
+func IsScriptFile(fileType FileType) bool {
+	return fileType >= FileTypeScriptStart && fileType <= FileTypeScriptEnd
+}
+
+// IsConfigFile returns true if file type is within config file range (4000-4999).
+//
+// Specification: file_type_system.md: 2.1.4 IsConfigFile Function
+func IsConfigFile(fileType FileType) bool {
+	return fileType >= FileTypeConfigStart && fileType <= FileTypeConfigEnd
+}
+
+// IsImageFile returns true if file type is within image file range (5000-6999).
+//
+// Specification: file_type_system.md: 2.1.5 IsImageFile Function
+func IsImageFile(fileType FileType) bool {
+	return fileType >= FileTypeImageStart && fileType <= FileTypeImageEnd
+}
+
+// IsAudioFile returns true if file type is within audio file range (7000-7999).
+//
+// Specification: file_type_system.md: 2.1.6 IsAudioFile Function
+func IsAudioFile(fileType FileType) bool {
+	return fileType >= FileTypeAudioStart && fileType <= FileTypeAudioEnd
+}
+
+// IsVideoFile returns true if file type is within video file range (8000-9999).
+//
+// Specification: file_type_system.md: 2.1.7 IsVideoFile Function
+func IsVideoFile(fileType FileType) bool {
+	return fileType >= FileTypeVideoStart && fileType <= FileTypeVideoEnd
+}
+
+// IsSystemFile returns true if file type is within system file range (10000-10999).
+//
+// Specification: file_type_system.md: 2.1.8 IsSystemFile Function
+func IsSystemFile(fileType FileType) bool {
+	return fileType >= FileTypeSystemStart && fileType <= FileTypeSystemEnd
+}
+
+// IsSpecialFile returns true if file type is within special file range (65000-65535).
+//
+// Specification: file_type_system.md: 2.1.9 IsSpecialFile Function
+func IsSpecialFile(fileType FileType) bool {
+	return fileType >= FileTypeSpecialStart
+}
+
+// precompressedFileTypes lists formats whose content is already compressed.
+// Compressing them again costs time and rarely saves space.
+var precompressedFileTypes = map[FileType]bool{
+	FileTypeArchive: true,
+	FileTypePNG:     true,
+	FileTypeJPEG:    true,
+	FileTypeJPG:     true,
+	FileTypeGIF:     true,
+	FileTypeWebP:    true,
+	FileTypeHEIC:    true,
+	FileTypeAVIF:    true,
+	FileTypeMP3:     true,
+	FileTypeOGG:     true,
+	FileTypeFLAC:    true,
+	FileTypeAAC:     true,
+	FileTypeOpus:    true,
+	FileTypeM4A:     true,
+	FileTypeVorbis:  true,
+	FileTypeMP4:     true,
+	FileTypeMKV:     true,
+	FileTypeMOV:     true,
+	FileTypeWebM:    true,
+	FileTypeOGV:     true,
+}
+
+// SelectCompressionType selects the appropriate compression algorithm based on file type.
+//
+// Already compressed formats (JPEG, PNG, GIF, MP3, MP4, OGG, FLAC, archives and
+// similar) return CompressionNone. Signature files are never compressed; other
+// special files use CompressionZstd. Text, script and config files use
+// CompressionZstd; image, audio and video files use CompressionLZ4. Everything
+// else defaults to CompressionZstd. data is accepted for content-aware selection
+// and is currently unused.
+//
+// Specification: file_type_system.md: 2.2.1 SelectCompressionType Function
+func SelectCompressionType(data []byte, fileType FileType) uint8 {
+	_ = data
+
+	if precompressedFileTypes[fileType] {
+		return CompressionNone
+	}
+
+	switch {
+	case IsSpecialFile(fileType):
+		if fileType == FileTypeSignature {
+			return CompressionNone
+		}
+		return CompressionZstd
+	case IsTextFile(fileType), IsScriptFile(fileType), IsConfigFile(fileType):
+		return CompressionZstd
+	case IsImageFile(fileType), IsAudioFile(fileType), IsVideoFile(fileType):
+		return CompressionLZ4
+	default:
+		return CompressionZstd
+	}
+}
+
+// DetermineFileType identifies the file type of name and data.
+//
+// Detection runs in stages: a few extensions that content sniffing confuses
+// (.ogg, .flac, .zip) are matched first, then well-known content signatures,
+// then the full extension table, then a text analysis of data. Files that match
+// nothing are classified as FileTypeBinary.
+//
+// Specification: file_type_system.md: 4.1.1 DetermineFileType Function (Detection Process)
+func DetermineFileType(name string, data []byte) FileType {
+	ext := strings.ToLower(filepath.Ext(name))
+
+	switch ext {
+	case ".ogg":
+		return FileTypeOGG
+	case ".flac":
+		return FileTypeFLAC
+	case ".zip":
+		return FileTypeArchive
+	}
+
+	if fileType, ok := detectFileTypeFromContent(data); ok {
+		return fileType
+	}
+
+	if fileType, ok := fileTypesByExtension[ext]; ok {
+		return fileType
+	}
+
+	if isTextContent(data) {
+		return FileTypeText
+	}
+
+	return FileTypeBinary
+}
+
+// contentSignature is a magic byte sequence at a fixed offset.
+type contentSignature struct {
+	offset   int
+	magic    []byte
+	fileType FileType
+}
+
+// contentSignatures lists the content signatures checked by DetermineFileType.
+// Only signatures long enough to be unambiguous are listed; short ones such as
+// "BM" and "MZ" are left to the extension table. RIFF containers are handled
+// separately because the form type follows the size field.
+var contentSignatures = []contentSignature{
+	{0, []byte("\x89PNG\r\n\x1a\n"), FileTypePNG},
+	{0, []byte{0xFF, 0xD8, 0xFF}, FileTypeJPEG},
+	{0, []byte("GIF87a"), FileTypeGIF},
+	{0, []byte("GIF89a"), FileTypeGIF},
+	{0, []byte("OggS"), FileTypeOGG},
+	{0, []byte("fLaC"), FileTypeFLAC},
+	{0, []byte("ID3"), FileTypeMP3},
+	{0, []byte{0x1A, 0x45, 0xDF, 0xA3}, FileTypeMKV},
+	{4, []byte("ftyp"), FileTypeMP4},
+	{0, []byte("PK\x03\x04"), FileTypeArchive},
+	{0, []byte{0x1F, 0x8B}, FileTypeArchive},
+	{0, []byte{0x28, 0xB5, 0x2F, 0xFD}, FileTypeArchive},
+	{0, []byte{0xFD, '7', 'z', 'X', 'Z', 0x00}, FileTypeArchive},
+	{0, []byte{'7', 'z', 0xBC, 0xAF, 0x27, 0x1C}, FileTypeArchive},
+	{0, []byte("\x7fELF"), FileTypeExecutable},
+}
+
+// detectFileTypeFromContent matches data against known content signatures.
+func detectFileTypeFromContent(data []byte) (FileType, bool) {
+	if len(data) >= 12 && bytes.Equal(data[:4], []byte("RIFF")) {
+		switch string(data[8:12]) {
+		case "WEBP":
+			return FileTypeWebP, true
+		case "WAVE":
+			return FileTypeWAV, true
+		case "AVI ":
+			return FileTypeAVI, true
+		}
+	}
+
+	for _, sig := range contentSignatures {
+		end := sig.offset + len(sig.magic)
+		if len(data) >= end && bytes.Equal(data[sig.offset:end], sig.magic) {
+			return sig.fileType, true
+		}
+	}
+	return FileTypeBinary, false
+}
+
+// fileTypesByExtension maps lowercase file extensions to file types.
+var fileTypesByExtension = map[string]FileType{
+	// Binary
+	".exe": FileTypeExecutable, ".bin": FileTypeBinary,
+	".dll": FileTypeLibrary, ".so": FileTypeLibrary, ".dylib": FileTypeLibrary,
+	".gz": FileTypeArchive, ".tgz": FileTypeArchive, ".zst": FileTypeArchive, ".xz": FileTypeArchive,
+	".7z": FileTypeArchive, ".rar": FileTypeArchive, ".bz2": FileTypeArchive, ".lz4": FileTypeArchive,
+
+	// Text
+	".txt": FileTypeText, ".text": FileTypeText, ".md": FileTypeMarkdown, ".markdown": FileTypeMarkdown,
+	".html": FileTypeHTML, ".htm": FileTypeHTML, ".css": FileTypeCSS, ".csv": FileTypeCSV,
+	".sql": FileTypeSQL, ".diff": FileTypeDiff, ".patch": FileTypeDiff, ".tex": FileTypeTeX,
+
+	// Script
+	".py": FileTypePython, ".js": FileTypeJavaScript, ".mjs": FileTypeJavaScript, ".ts": FileTypeTypeScript,
+	".sh": FileTypeShell, ".bash": FileTypeShell, ".lua": FileTypeLua, ".pl": FileTypePerl,
+	".rb": FileTypeRuby, ".php": FileTypePHP, ".java": FileTypeJava, ".cs": FileTypeCSharp,
+	".go": FileTypeGo, ".rs": FileTypeRust, ".scala": FileTypeScala, ".kt": FileTypeKotlin,
+	".swift": FileTypeSwift, ".coffee": FileTypeCoffeeScript, ".dart": FileTypeDart, ".ex": FileTypeElixir,
+	".exs": FileTypeElixir, ".erl": FileTypeErlang, ".hs": FileTypeHaskell, ".clj": FileTypeClojure,
+	".fs": FileTypeFSharp, ".ml": FileTypeOCaml, ".r": FileTypeR, ".m": FileTypeMATLAB,
+	".jl": FileTypeJulia, ".ps1": FileTypePowerShell, ".bat": FileTypeBatch, ".cmd": FileTypeBatch,
+	".vbs": FileTypeVBScript, ".applescript": FileTypeAppleScript, ".ahk": FileTypeAutoHotkey,
+	".groovy": FileTypeGroovy, ".cr": FileTypeCrystal, ".nim": FileTypeNim, ".zig": FileTypeZig,
+	".v": FileTypeV, ".d": FileTypeD, ".adb": FileTypeAda, ".ads": FileTypeAda,
+	".f90": FileTypeFortran, ".f": FileTypeFortran,
+
+	// Config
+	".yaml": FileTypeYAML, ".yml": FileTypeYAML, ".json": FileTypeJSON, ".xml": FileTypeXML,
+	".toml": FileTypeTOML, ".hocon": FileTypeHOCON, ".edn": FileTypeEDN,
+	".cue": FileTypeCUE, ".properties": FileTypeProperties, ".ini": FileTypeINI, ".cfg": FileTypeINI,
+
+	// Image
+	".png": FileTypePNG, ".jpeg": FileTypeJPEG, ".jpg": FileTypeJPG, ".gif": FileTypeGIF,
+	".bmp": FileTypeBMP, ".webp": FileTypeWebP, ".tiff": FileTypeTIFF, ".tif": FileTypeTIF,
+	".svg": FileTypeSVG, ".raw": FileTypeRAW, ".heic": FileTypeHEIC, ".avif": FileTypeAVIF,
+	".ico": FileTypeICO, ".tga": FileTypeTGA, ".psd": FileTypePSD, ".xcf": FileTypeXCF,
+	".dds": FileTypeDDS, ".exr": FileTypeEXR, ".hdr": FileTypeHDR, ".ppm": FileTypePPM,
+	".pgm": FileTypePGM, ".pbm": FileTypePBM, ".xbm": FileTypeXBM, ".xpm": FileTypeXPM,
+	".pcx": FileTypePCX, ".jp2": FileTypeJP2, ".j2k": FileTypeJ2K, ".jxr": FileTypeJXR,
+	".wdp": FileTypeWDP,
+
+	// Audio
+	".mp3": FileTypeMP3, ".wav": FileTypeWAV, ".aac": FileTypeAAC, ".wma": FileTypeWMA,
+	".aiff": FileTypeAIFF, ".aif": FileTypeAIFF, ".alac": FileTypeALAC, ".ape": FileTypeAPE,
+	".opus": FileTypeOpus, ".m4a": FileTypeM4A, ".spx": FileTypeSpeex, ".amr": FileTypeAMR,
+	".ac3": FileTypeAC3, ".dts": FileTypeDTS, ".mka": FileTypeMKA,
+
+	// Video
+	".mp4": FileTypeMP4, ".mkv": FileTypeMKV, ".avi": FileTypeAVI, ".mov": FileTypeMOV,
+	".webm": FileTypeWebM, ".wmv": FileTypeWMV, ".flv": FileTypeFLV, ".m4v": FileTypeM4V,
+	".mpeg": FileTypeMPEG, ".3gp": FileType3GP, ".hevc": FileTypeHEVC, ".divx": FileTypeDivX,
+	".ogv": FileTypeOGV, ".asf": FileTypeASF, ".rm": FileTypeRM, ".rmvb": FileTypeRMVB,
+	".vob": FileTypeVOB, ".m2ts": FileTypeM2TS, ".mts": FileTypeMTS, ".m2v": FileTypeM2V,
+	".m1v": FileTypeM1V, ".mpg": FileTypeMPG,
+}
+
+// textAnalysisLimit bounds how much of the data is inspected by isTextContent.
+const textAnalysisLimit = 8192
+
+// isTextContent reports whether data looks like text: non-empty, with no control
+// characters other than newline, carriage return and tab. Bytes >= 0x80 are
+// accepted so UTF-8 text is classified as text.
+func isTextContent(data []byte) bool {
+	if len(data) == 0 {
+		return false
+	}
+	if len(data) > textAnalysisLimit {
+		data = data[:textAnalysisLimit]
+	}
+	for _, b := range data {
+		if b < 0x20 && b != '\n' && b != '\r' && b != '\t' {
+			return false
+		}
+		if b == 0x7F {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package fileformat
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestFileTypeCategoryQueries tests the range-based category functions at their boundaries.
+func TestFileTypeCategoryQueries(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    func(FileType) bool
+		inRange  []FileType
+		outRange []FileType
+	}{
+		{"IsBinaryFile", IsBinaryFile, []FileType{0, 999}, []FileType{1000}},
+		{"IsTextFile", IsTextFile, []FileType{1000, 1999}, []FileType{999, 2000}},
+		{"IsScriptFile", IsScriptFile, []FileType{2000, 3999}, []FileType{1999, 4000}},
+		{"IsConfigFile", IsConfigFile, []FileType{4000, 4999}, []FileType{3999, 5000}},
+		{"IsImageFile", IsImageFile, []FileType{5000, 6999}, []FileType{4999, 7000}},
+		{"IsAudioFile", IsAudioFile, []FileType{7000, 7999}, []FileType{6999, 8000}},
+		{"IsVideoFile", IsVideoFile, []FileType{8000, 9999}, []FileType{7999, 10000}},
+		{"IsSystemFile", IsSystemFile, []FileType{10000, 10999}, []FileType{9999, 11000}},
+		{"IsSpecialFile", IsSpecialFile, []FileType{65000, 65535}, []FileType{64999}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, ft := range tt.inRange {
+				if !tt.query(ft) {
+					t.Errorf("%s(%d) = false, want true", tt.name, ft)
+				}
+			}
+			for _, ft := range tt.outRange {
+				if tt.query(ft) {
+					t.Errorf("%s(%d) = true, want false", tt.name, ft)
+				}
+			}
+		})
+	}
+}
+
+// TestSelectCompressionType tests compression selection for each file type category.
+func TestSelectCompressionType(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileType FileType
+		want     uint8
+	}{
+		{"JPEG", FileTypeJPEG, CompressionNone},
+		{"PNG", FileTypePNG, CompressionNone},
+		{"GIF", FileTypeGIF, CompressionNone},
+		{"MP3", FileTypeMP3, CompressionNone},
+		{"MP4", FileTypeMP4, CompressionNone},
+		{"OGG", FileTypeOGG, CompressionNone},
+		{"FLAC", FileTypeFLAC, CompressionNone},
+		{"archive", FileTypeArchive, CompressionNone},
+		{"signature", FileTypeSignature, CompressionNone},
+		{"metadata", FileTypeMetadata, CompressionZstd},
+		{"manifest", FileTypeManifest, CompressionZstd},
+		{"index", FileTypeIndex, CompressionZstd},
+		{"other special", FileTypeSpecialStart + 100, CompressionZstd},
+		{"text", FileTypeText, CompressionZstd},
+		{"script", FileTypeScript, CompressionZstd},
+		{"config", FileTypeYAML, CompressionZstd},
+		{"image", FileTypeBMP, CompressionLZ4},
+		{"audio", FileTypeWAV, CompressionLZ4},
+		{"video", FileTypeAVI, CompressionLZ4},
+		{"binary", FileTypeBinary, CompressionZstd},
+		{"system", FileTypeRegular, CompressionZstd},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SelectCompressionType([]byte("data"), tt.fileType); got != tt.want {
+				t.Errorf("SelectCompressionType(%d) = %d, want %d", tt.fileType, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDetermineFileType tests each detection stage.
+func TestDetermineFileType(t *testing.T) {
+	binary := []byte{0x00, 0x01, 0x02, 0x03}
+
+	tests := []struct {
+		name string
+		path string
+		data []byte
+		want FileType
+	}{
+		{"ogg extension wins over content", "/music/theme.ogg", []byte("\x89PNG\r\n\x1a\n"), FileTypeOGG},
+		{"flac extension", "/music/theme.FLAC", binary, FileTypeFLAC},
+		{"zip extension", "/bundle.zip", binary, FileTypeArchive},
+		{"png content", "/textures/albedo", []byte("\x89PNG\r\n\x1a\nrest"), FileTypePNG},
+		{"jpeg content", "/photo.dat", []byte{0xFF, 0xD8, 0xFF, 0xE0}, FileTypeJPEG},
+		{"ogg content", "/sound.bin", []byte("OggS\x00\x02"), FileTypeOGG},
+		{"zip content", "/pack.dat", []byte("PK\x03\x04rest"), FileTypeArchive},
+		{"mp4 content", "/clip", []byte("\x00\x00\x00\x18ftypmp42"), FileTypeMP4},
+		{"webp content", "/img", []byte("RIFF\x10\x00\x00\x00WEBPVP8 "), FileTypeWebP},
+		{"wav content", "/snd", []byte("RIFF\x10\x00\x00\x00WAVEfmt "), FileTypeWAV},
+		{"elf content", "/bin/tool", []byte("\x7fELF\x02\x01"), FileTypeExecutable},
+		{"yaml extension", "/config/settings.yml", []byte("key: value\n"), FileTypeYAML},
+		{"lua extension", "/scripts/init.lua", []byte("print('hi')\n"), FileTypeLua},
+		{"ini extension", "/app.cfg", []byte("[section]\n"), FileTypeINI},
+		{"js extension", "/app.js", []byte("console.log(1)\n"), FileTypeJavaScript},
+		{"text analysis", "/README", []byte("plain text\r\n\twith tabs\n"), FileTypeText},
+		{"utf-8 text analysis", "/notes", []byte("caf\xc3\xa9\n"), FileTypeText},
+		{"binary fallback", "/blob", binary, FileTypeBinary},
+		{"empty data", "/empty", nil, FileTypeBinary},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetermineFileType(tt.path, tt.data); got != tt.want {
+				t.Errorf("DetermineFileType(%q) = %d, want %d", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDetermineFileType_TextAnalysisLimit tests that only the leading bytes are analyzed.
+func TestDetermineFileType_TextAnalysisLimit(t *testing.T) {
+	data := append(bytes.Repeat([]byte("a"), textAnalysisLimit), 0x00)
+	if got := DetermineFileType("/long", data); got != FileTypeText {
+		t.Errorf("DetermineFileType() = %d, want %d", got, FileTypeText)
+	}
+}
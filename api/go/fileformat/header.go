@@ -40,7 +40,8 @@ type PackageHeader struct {
 	// Flags contains package-level features and options
 	// Bits 0-7: Package features
 	// Bits 8-15: Package compression type
-	// Bits 16-31: Reserved for future use
+	// Bits 16-23: Package index flags
+	// Bits 24-31: Reserved for future use
 	// Specification: package_file_format.md: 2.5 Package Features Flags
 	Flags uint32
 
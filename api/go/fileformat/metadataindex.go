@@ -0,0 +1,72 @@
+// This file implements the MetadataIndex structure representing the metadata index
+// section of a compressed NovusPack package. It contains the MetadataIndex and
+// MetadataIndexEntry type definitions. This file should contain only the metadata
+// index types as specified in package_file_format.md Section 5; reading and writing
+// compressed packages belongs in the package implementation.
+//
+// Specification: package_file_format.md: 5 Metadata Index Section
+
+package fileformat
+
+// MetadataIndexEntry locates the compressed blocks of one file entry.
+//
+// Size: 32 bytes (8 + 8 + 4 + 8 + 4)
+//
+// Specification: package_file_format.md: 5.1.2 Metadata Index Entry Format
+type MetadataIndexEntry struct {
+	// FileID is the unique file identifier
+	// Specification: package_file_format.md: 5.1.2 Metadata Index Entry Format
+	FileID uint64
+
+	// MetadataBlockOffset is the offset to the compressed FileEntry metadata block
+	// Specification: package_file_format.md: 5.1.2 Metadata Index Entry Format
+	MetadataBlockOffset uint64
+
+	// MetadataBlockSize is the size of the compressed metadata block in bytes
+	// Specification: package_file_format.md: 5.1.2 Metadata Index Entry Format
+	MetadataBlockSize uint32
+
+	// DataBlockOffset is the offset to the compressed file data block
+	// Specification: package_file_format.md: 5.1.2 Metadata Index Entry Format
+	DataBlockOffset uint64
+
+	// DataBlockSize is the size of the compressed data block in bytes (0 if the file has no stored data)
+	// Specification: package_file_format.md: 5.1.2 Metadata Index Entry Format
+	DataBlockSize uint32
+}
+
+// MetadataIndex represents the metadata index section of a compressed package.
+//
+// The metadata index is located at offset PackageHeaderSize (immediately after the
+// header) when the header compression type (flags bits 8-15) is non-zero.
+//
+// Size: 8 bytes + (32 * entry_count) bytes
+//
+// Specification: package_file_format.md: 5.1 Metadata Index Structure
+type MetadataIndex struct {
+	// EntryCount is the number of metadata index entries
+	// Specification: package_file_format.md: 5.1.1 Metadata Index Binary Format
+	EntryCount uint32
+
+	// Reserved is reserved for future use (must be 0)
+	// Specification: package_file_format.md: 5.1.1 Metadata Index Binary Format
+	Reserved uint32
+
+	// Entries contains one entry per file entry, in file index order
+	// Specification: package_file_format.md: 5.1.1 Metadata Index Binary Format
+	Entries []MetadataIndexEntry
+}
+
+// NewMetadataIndex creates and returns a new, empty MetadataIndex.
+//
+// Specification: package_file_format.md: 5.1 Metadata Index Structure
+func NewMetadataIndex() *MetadataIndex {
+	return &MetadataIndex{}
+}
+
+// Size returns the serialized size of the MetadataIndex in bytes.
+//
+// Specification: package_file_format.md: 5.1.1 Metadata Index Binary Format
+func (m *MetadataIndex) Size() int {
+	return MetadataIndexHeaderSize + MetadataIndexEntrySize*len(m.Entries)
+}
@@ -0,0 +1,26 @@
+package fileformat
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// TestMetadataIndexEntry_BinarySize verifies the entry serializes to MetadataIndexEntrySize bytes.
+func TestMetadataIndexEntry_BinarySize(t *testing.T) {
+	if got := binary.Size(MetadataIndexEntry{}); got != MetadataIndexEntrySize {
+		t.Errorf("binary.Size(MetadataIndexEntry{}) = %d, want %d", got, MetadataIndexEntrySize)
+	}
+}
+
+// TestMetadataIndex_Size verifies Size accounts for the header and every entry.
+func TestMetadataIndex_Size(t *testing.T) {
+	index := NewMetadataIndex()
+	if got := index.Size(); got != MetadataIndexHeaderSize {
+		t.Errorf("empty Size() = %d, want %d", got, MetadataIndexHeaderSize)
+	}
+
+	index.Entries = make([]MetadataIndexEntry, 3)
+	if got, want := index.Size(), MetadataIndexHeaderSize+3*MetadataIndexEntrySize; got != want {
+		t.Errorf("Size() = %d, want %d", got, want)
+	}
+}
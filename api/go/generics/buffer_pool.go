@@ -0,0 +1,458 @@
+// This file implements the generic buffer pool: BufferPool[T], BufferConfig,
+// BufferPoolStats, and DefaultBufferConfig. It contains size-class pooling,
+// the memory limit shared by buffers in use and idle buffers, and eviction of
+// idle buffers. This file should contain all code related to buffer pooling as
+// specified in api_streaming.md Section 2.
+//
+// Specification: api_streaming.md: 2. Buffer Management System
+
+package generics
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// Eviction policies accepted by BufferConfig.EvictionPolicy.
+const (
+	// EvictionPolicyLRU evicts the least recently used idle buffers when memory is needed.
+	EvictionPolicyLRU = "lru"
+
+	// EvictionPolicyTime evicts idle buffers only after EvictionTimeout; Get waits for
+	// buffers to be returned or to expire when memory is needed.
+	EvictionPolicyTime = "time"
+)
+
+// minBufferClass is the capacity, in elements, of the smallest size class.
+const minBufferClass = 64
+
+// BufferConfig configures buffer pool behavior and limits.
+//
+// Zero fields take the values of DefaultBufferConfig.
+//
+// Specification: api_streaming.md: 2.2.2. BufferConfig Struct
+type BufferConfig struct {
+	MaxTotalSize      int64         // Maximum total size in bytes of buffers in use and idle buffers
+	MaxBufferSize     int           // Largest buffer in bytes kept for reuse; larger buffers are not pooled
+	EvictionPolicy    string        // "lru" or "time" eviction policy
+	EvictionTimeout   time.Duration // Time after which unused buffers are evicted
+	FailWhenExhausted bool          // Fail Get instead of waiting when MaxTotalSize is reached
+}
+
+// DefaultBufferConfig returns a buffer configuration with default values.
+//
+// Specification: api_streaming.md: 2.6.1 DefaultBufferConfig Function
+func DefaultBufferConfig() *BufferConfig {
+	return &BufferConfig{
+		MaxTotalSize:    1 << 30,
+		MaxBufferSize:   1 << 20,
+		EvictionPolicy:  EvictionPolicyLRU,
+		EvictionTimeout: 5 * time.Minute,
+	}
+}
+
+// BufferPoolStats provides statistics about buffer pool usage.
+//
+// Specification: api_streaming.md: 2.2.3.1 BufferPoolStats Struct Type Definition
+type BufferPoolStats struct {
+	Hits          int64 // Get calls served with an idle buffer
+	Misses        int64 // Get calls that allocated a new buffer
+	Waits         int64 // Get calls that waited for memory to become available
+	Evictions     int64 // Idle buffers released to free memory or after EvictionTimeout
+	ActiveBuffers int   // Buffers currently handed out
+	IdleBuffers   int   // Buffers held for reuse
+	BytesInUse    int64 // Bytes of buffers currently handed out
+	BytesIdle     int64 // Bytes of buffers held for reuse
+	MaxTotalSize  int64 // Current memory limit in bytes
+}
+
+// Utilization returns the fraction of the memory limit held by the pool.
+//
+// Specification: api_streaming.md: 2.2.3.2 BufferPoolStats.Utilization Method
+func (s BufferPoolStats) Utilization() float64 {
+	if s.MaxTotalSize <= 0 {
+		return 0
+	}
+	return float64(s.BytesInUse+s.BytesIdle) / float64(s.MaxTotalSize)
+}
+
+// idleBuffer is a buffer held for reuse.
+type idleBuffer[T any] struct {
+	buf      []T
+	lastUsed time.Time
+}
+
+// BufferPool manages reusable buffers of any type.
+//
+// Buffers are grouped into size classes of power-of-two capacities so a returned
+// buffer can serve any later request of its class. The memory limit covers buffers
+// handed out by Get as well as idle buffers; when it is reached, Get evicts idle
+// buffers and then waits for buffers to be returned, or fails when
+// FailWhenExhausted is set. BufferPool is safe for concurrent use.
+//
+// Specification: api_streaming.md: 2.2.1.1 BufferPool Struct Type Definition
+type BufferPool[T any] struct {
+	mu       sync.Mutex
+	config   BufferConfig
+	elemSize int64
+	idle     map[int][]idleBuffer[T] // Idle buffers by capacity, least recently used first
+	released chan struct{}           // Closed when memory is released
+	closed   bool
+	stats    BufferPoolStats
+}
+
+// NewBufferPool creates a new buffer pool for the specified type.
+//
+// A nil config selects DefaultBufferConfig, and zero or unknown fields take their
+// default values.
+//
+// Specification: api_generics.md: 2.3.2 NewBufferPool Function
+func NewBufferPool[T any](config *BufferConfig) *BufferPool[T] {
+	defaults := DefaultBufferConfig()
+	cfg := *defaults
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.MaxTotalSize <= 0 {
+		cfg.MaxTotalSize = defaults.MaxTotalSize
+	}
+	if cfg.MaxBufferSize <= 0 {
+		cfg.MaxBufferSize = defaults.MaxBufferSize
+	}
+	if cfg.EvictionPolicy != EvictionPolicyTime {
+		cfg.EvictionPolicy = EvictionPolicyLRU
+	} else if cfg.EvictionTimeout <= 0 {
+		cfg.EvictionTimeout = defaults.EvictionTimeout
+	}
+
+	elemSize := int64(reflect.TypeFor[T]().Size())
+	if elemSize == 0 {
+		elemSize = 1
+	}
+	return &BufferPool[T]{
+		config:   cfg,
+		elemSize: elemSize,
+		idle:     make(map[int][]idleBuffer[T]),
+		released: make(chan struct{}),
+		stats:    BufferPoolStats{MaxTotalSize: cfg.MaxTotalSize},
+	}
+}
+
+// Get retrieves a buffer of the specified size from the pool.
+//
+// The returned slice has length size; its contents are not cleared. The buffer must
+// be returned with Put when no longer needed. When the memory limit is reached,
+// Get waits until enough memory is released or ctx is done.
+//
+// Returns *PackageError on failure:
+//   - ErrTypeValidation: Pool is closed, size is negative or larger than the memory
+//     limit, or the limit is reached and FailWhenExhausted is set
+//   - ErrTypeContext: ctx was cancelled while waiting for memory
+//
+// Specification: api_streaming.md: 2.2.1.3 BufferPool[T].Get Method
+func (bp *BufferPool[T]) Get(ctx context.Context, size int) ([]T, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeContext, "buffer request cancelled")
+	}
+	if size < 0 {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "invalid buffer size", nil, pkgerrors.ValidationErrorContext{
+			Field:    "size",
+			Value:    size,
+			Expected: "non-negative size",
+		})
+	}
+	if size == 0 {
+		return []T{}, nil
+	}
+
+	waited := false
+	for {
+		bp.mu.Lock()
+		if bp.closed {
+			bp.mu.Unlock()
+			return nil, bufferPoolClosedError()
+		}
+		capacity, cost, pooled := bp.sizeClass(size)
+		if cost > bp.config.MaxTotalSize {
+			bp.mu.Unlock()
+			return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "buffer size exceeds pool memory limit", nil, pkgerrors.ValidationErrorContext{
+				Field:    "size",
+				Value:    size,
+				Expected: fmt.Sprintf("at most %d bytes", bp.config.MaxTotalSize),
+			})
+		}
+
+		now := time.Now()
+		bp.expireIdle(now)
+		if pooled {
+			if buffers := bp.idle[capacity]; len(buffers) > 0 {
+				buf := buffers[len(buffers)-1].buf
+				buffers[len(buffers)-1] = idleBuffer[T]{}
+				bp.idle[capacity] = buffers[:len(buffers)-1]
+				bp.stats.IdleBuffers--
+				bp.stats.BytesIdle -= cost
+				bp.checkOut(cost)
+				bp.stats.Hits++
+				bp.mu.Unlock()
+				return buf[:size], nil
+			}
+		}
+
+		if bp.reserve(cost) {
+			bp.checkOut(cost)
+			bp.stats.Misses++
+			bp.mu.Unlock()
+			return make([]T, size, capacity), nil
+		}
+		if bp.config.FailWhenExhausted {
+			bp.mu.Unlock()
+			return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "buffer pool memory limit reached", nil, pkgerrors.ValidationErrorContext{
+				Field:    "MaxTotalSize",
+				Value:    bp.config.MaxTotalSize,
+				Expected: fmt.Sprintf("%d bytes available", cost),
+			})
+		}
+		if !waited {
+			bp.stats.Waits++
+			waited = true
+		}
+		released := bp.released
+		expiry := bp.nextExpiry(now)
+		bp.mu.Unlock()
+
+		if err := waitForRelease(ctx, released, expiry); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Put returns a buffer to the pool for reuse.
+//
+// buf must have been obtained from Get on this pool and must not be used after Put.
+// Buffers larger than MaxBufferSize are released rather than kept.
+//
+// Specification: api_streaming.md: 2.2.1.4 BufferPool[T].Put Method
+func (bp *BufferPool[T]) Put(buf []T) {
+	capacity := cap(buf)
+	if capacity == 0 {
+		return
+	}
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+
+	cost := int64(capacity) * bp.elemSize
+	bp.stats.ActiveBuffers = max(bp.stats.ActiveBuffers-1, 0)
+	bp.stats.BytesInUse = max(bp.stats.BytesInUse-cost, 0)
+	defer bp.signal()
+
+	classCapacity, _, pooled := bp.sizeClass(capacity)
+	if bp.closed || !pooled || classCapacity != capacity || !bp.reserve(cost) {
+		return
+	}
+	bp.idle[capacity] = append(bp.idle[capacity], idleBuffer[T]{buf: buf[:capacity], lastUsed: time.Now()})
+	bp.stats.IdleBuffers++
+	bp.stats.BytesIdle += cost
+}
+
+// GetStats returns statistics about buffer pool usage.
+//
+// Specification: api_streaming.md: 2.3.1.4 BufferPool[T].GetStats Method
+func (bp *BufferPool[T]) GetStats() BufferPoolStats {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	bp.expireIdle(time.Now())
+	return bp.stats
+}
+
+// TotalSize returns the total size in bytes of buffers in use and idle buffers.
+//
+// Specification: api_streaming.md: 2.3.2.1 BufferPool[T].TotalSize Method
+func (bp *BufferPool[T]) TotalSize() int64 {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	return bp.stats.BytesInUse + bp.stats.BytesIdle
+}
+
+// SetMaxTotalSize sets the maximum total size for all buffers in the pool.
+//
+// Lowering the limit evicts idle buffers as needed; buffers in use are not reclaimed,
+// so Get waits until enough of them are returned.
+//
+// Returns *PackageError with ErrTypeValidation if maxSize is not positive.
+//
+// Specification: api_streaming.md: 2.3.2.2 BufferPool[T].SetMaxTotalSize Method
+func (bp *BufferPool[T]) SetMaxTotalSize(maxSize int64) error {
+	if maxSize <= 0 {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "invalid maximum total size", nil, pkgerrors.ValidationErrorContext{
+			Field:    "maxSize",
+			Value:    maxSize,
+			Expected: "positive size in bytes",
+		})
+	}
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	bp.config.MaxTotalSize = maxSize
+	bp.stats.MaxTotalSize = maxSize
+	for bp.stats.BytesInUse+bp.stats.BytesIdle > maxSize && bp.evictOldest() {
+	}
+	bp.signal()
+	return nil
+}
+
+// Close releases all idle buffers and fails later and waiting Get calls.
+// Buffers in use may still be passed to Put. Close is idempotent.
+//
+// Specification: api_streaming.md: 2.3.2.3 BufferPool[T].Close Method
+func (bp *BufferPool[T]) Close() {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	if bp.closed {
+		return
+	}
+	bp.closed = true
+	clear(bp.idle)
+	bp.stats.IdleBuffers = 0
+	bp.stats.BytesIdle = 0
+	close(bp.released)
+}
+
+// sizeClass returns the capacity and cost in bytes of the buffer serving a request
+// of size elements, and whether buffers of that capacity are kept for reuse.
+func (bp *BufferPool[T]) sizeClass(size int) (capacity int, cost int64, pooled bool) {
+	if int64(size) > bp.config.MaxTotalSize/bp.elemSize {
+		return size, bp.config.MaxTotalSize + 1, false
+	}
+	capacity = minBufferClass
+	if size > minBufferClass {
+		capacity = 1 << bits.Len(uint(size-1))
+	}
+	cost = int64(capacity) * bp.elemSize
+	if cost > int64(bp.config.MaxBufferSize) || int64(capacity) > bp.config.MaxTotalSize/bp.elemSize {
+		return size, int64(size) * bp.elemSize, false
+	}
+	return capacity, cost, true
+}
+
+// reserve reports whether cost bytes fit within the memory limit, evicting idle
+// buffers as the eviction policy allows to make room.
+func (bp *BufferPool[T]) reserve(cost int64) bool {
+	for bp.stats.BytesInUse+bp.stats.BytesIdle+cost > bp.config.MaxTotalSize {
+		if bp.config.EvictionPolicy == EvictionPolicyTime || !bp.evictOldest() {
+			return false
+		}
+	}
+	return true
+}
+
+// checkOut records a buffer of cost bytes as handed out.
+func (bp *BufferPool[T]) checkOut(cost int64) {
+	bp.stats.ActiveBuffers++
+	bp.stats.BytesInUse += cost
+}
+
+// evictOldest releases the least recently used idle buffer.
+// Returns false if there are no idle buffers.
+func (bp *BufferPool[T]) evictOldest() bool {
+	oldest := -1
+	for capacity, buffers := range bp.idle {
+		if len(buffers) > 0 && (oldest < 0 || buffers[0].lastUsed.Before(bp.idle[oldest][0].lastUsed)) {
+			oldest = capacity
+		}
+	}
+	if oldest < 0 {
+		return false
+	}
+	bp.dropIdle(oldest, 1)
+	return true
+}
+
+// expireIdle releases idle buffers unused for at least EvictionTimeout.
+func (bp *BufferPool[T]) expireIdle(now time.Time) {
+	if bp.config.EvictionTimeout <= 0 {
+		return
+	}
+	for capacity, buffers := range bp.idle {
+		n := 0
+		for n < len(buffers) && now.Sub(buffers[n].lastUsed) >= bp.config.EvictionTimeout {
+			n++
+		}
+		bp.dropIdle(capacity, n)
+	}
+}
+
+// dropIdle releases the n least recently used idle buffers of a capacity.
+func (bp *BufferPool[T]) dropIdle(capacity, n int) {
+	if n == 0 {
+		return
+	}
+	buffers := bp.idle[capacity]
+	clear(buffers[:n])
+	if n == len(buffers) {
+		delete(bp.idle, capacity)
+	} else {
+		bp.idle[capacity] = buffers[n:]
+	}
+	bp.stats.IdleBuffers -= n
+	bp.stats.BytesIdle -= int64(n) * int64(capacity) * bp.elemSize
+	bp.stats.Evictions += int64(n)
+}
+
+// nextExpiry returns how long until the next idle buffer expires under the time
+// eviction policy, or 0 if no buffer is waiting to expire.
+func (bp *BufferPool[T]) nextExpiry(now time.Time) time.Duration {
+	if bp.config.EvictionPolicy != EvictionPolicyTime || bp.config.EvictionTimeout <= 0 {
+		return 0
+	}
+	var next time.Duration
+	for _, buffers := range bp.idle {
+		if len(buffers) == 0 {
+			continue
+		}
+		if d := bp.config.EvictionTimeout - now.Sub(buffers[0].lastUsed); next == 0 || d < next {
+			next = max(d, time.Millisecond)
+		}
+	}
+	return next
+}
+
+// signal wakes Get calls waiting for memory.
+func (bp *BufferPool[T]) signal() {
+	if bp.closed {
+		return
+	}
+	close(bp.released)
+	bp.released = make(chan struct{})
+}
+
+// waitForRelease waits until released is closed, expiry elapses (when positive), or
+// ctx is done.
+func waitForRelease(ctx context.Context, released <-chan struct{}, expiry time.Duration) error {
+	var expired <-chan time.Time
+	if expiry > 0 {
+		timer := time.NewTimer(expiry)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case <-ctx.Done():
+		return pkgerrors.WrapError(ctx.Err(), pkgerrors.ErrTypeContext, "buffer request cancelled while waiting for memory")
+	case <-released:
+	case <-expired:
+	}
+	return nil
+}
+
+// bufferPoolClosedError reports use of a closed pool.
+func bufferPoolClosedError() error {
+	return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "buffer pool is closed", nil, pkgerrors.ValidationErrorContext{
+		Field:    "BufferPool",
+		Value:    "closed",
+		Expected: "open buffer pool",
+	})
+}
@@ -0,0 +1,290 @@
+package generics
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// assertBufferPoolError checks that err is a *PackageError of the expected type.
+func assertBufferPoolError(t *testing.T, err error, want pkgerrors.ErrorType) {
+	t.Helper()
+	var pkgErr *pkgerrors.PackageError
+	if !errors.As(err, &pkgErr) {
+		t.Fatalf("error = %v, want *PackageError of type %v", err, want)
+	}
+	if pkgErr.Type != want {
+		t.Errorf("error type = %v, want %v", pkgErr.Type, want)
+	}
+}
+
+// TestDefaultBufferConfig tests the default buffer configuration values.
+func TestDefaultBufferConfig(t *testing.T) {
+	config := DefaultBufferConfig()
+	if config.MaxTotalSize != 1<<30 || config.MaxBufferSize != 1<<20 {
+		t.Errorf("limits = %d, %d; want 1 GiB, 1 MiB", config.MaxTotalSize, config.MaxBufferSize)
+	}
+	if config.EvictionPolicy != EvictionPolicyLRU || config.EvictionTimeout != 5*time.Minute {
+		t.Errorf("eviction = %q, %v; want lru, 5m", config.EvictionPolicy, config.EvictionTimeout)
+	}
+	if config.FailWhenExhausted {
+		t.Error("FailWhenExhausted should default to false")
+	}
+}
+
+// TestBufferPool_SizeClassesAndReuse tests size-class rounding, reuse and statistics.
+func TestBufferPool_SizeClassesAndReuse(t *testing.T) {
+	ctx := context.Background()
+	pool := NewBufferPool[byte](nil)
+
+	buf, err := pool.Get(ctx, 3000)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(buf) != 3000 || cap(buf) != 4096 {
+		t.Errorf("Get(3000) len/cap = %d/%d, want 3000/4096", len(buf), cap(buf))
+	}
+	if got := pool.TotalSize(); got != 4096 {
+		t.Errorf("TotalSize() = %d, want 4096", got)
+	}
+	pool.Put(buf)
+
+	again, err := pool.Get(ctx, 2049)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if &again[0] != &buf[0] {
+		t.Error("Get() of the same size class did not reuse the returned buffer")
+	}
+	small, err := pool.Get(ctx, 10)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if cap(small) != minBufferClass {
+		t.Errorf("Get(10) cap = %d, want %d", cap(small), minBufferClass)
+	}
+
+	stats := pool.GetStats()
+	if stats.Hits != 1 || stats.Misses != 2 || stats.ActiveBuffers != 2 || stats.BytesInUse != 4096+minBufferClass {
+		t.Errorf("GetStats() = %+v, want 1 hit, 2 misses, 2 active buffers", stats)
+	}
+	if stats.Utilization() <= 0 {
+		t.Errorf("Utilization() = %v, want > 0", stats.Utilization())
+	}
+
+	pool.Put(again)
+	pool.Put(small)
+	stats = pool.GetStats()
+	if stats.ActiveBuffers != 0 || stats.BytesInUse != 0 || stats.IdleBuffers != 2 {
+		t.Errorf("GetStats() after Put = %+v, want 0 active, 2 idle", stats)
+	}
+
+	empty, err := pool.Get(ctx, 0)
+	if err != nil || len(empty) != 0 {
+		t.Errorf("Get(0) = %d elements, %v; want empty buffer", len(empty), err)
+	}
+}
+
+// TestBufferPool_OversizeBuffersNotPooled tests buffers above MaxBufferSize.
+func TestBufferPool_OversizeBuffersNotPooled(t *testing.T) {
+	ctx := context.Background()
+	pool := NewBufferPool[byte](&BufferConfig{MaxTotalSize: 1 << 20, MaxBufferSize: 1024})
+
+	buf, err := pool.Get(ctx, 5000)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if cap(buf) != 5000 || pool.TotalSize() != 5000 {
+		t.Errorf("oversize Get cap = %d, TotalSize = %d; want exact 5000", cap(buf), pool.TotalSize())
+	}
+	pool.Put(buf)
+	if stats := pool.GetStats(); stats.IdleBuffers != 0 || pool.TotalSize() != 0 {
+		t.Errorf("oversize buffer kept after Put: %+v", stats)
+	}
+}
+
+// TestBufferPool_TypedElements tests that memory accounting uses the element size.
+func TestBufferPool_TypedElements(t *testing.T) {
+	pool := NewBufferPool[uint64](&BufferConfig{MaxTotalSize: 1024})
+	buf, err := pool.Get(context.Background(), 100)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if cap(buf) != 128 || pool.TotalSize() != 128*8 {
+		t.Errorf("cap = %d, TotalSize = %d; want 128 elements, 1024 bytes", cap(buf), pool.TotalSize())
+	}
+	_, err = pool.Get(context.Background(), 200)
+	assertBufferPoolError(t, err, pkgerrors.ErrTypeValidation)
+}
+
+// TestBufferPool_MemoryLimit tests eviction, failing and waiting at the memory limit.
+func TestBufferPool_MemoryLimit(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("lru evicts idle buffers", func(t *testing.T) {
+		pool := NewBufferPool[byte](&BufferConfig{MaxTotalSize: 8192, MaxBufferSize: 8192})
+		a, _ := pool.Get(ctx, 4096)
+		b, _ := pool.Get(ctx, 4096)
+		pool.Put(a)
+		pool.Put(b)
+		if _, err := pool.Get(ctx, 8192); err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if stats := pool.GetStats(); stats.Evictions != 2 || stats.IdleBuffers != 0 {
+			t.Errorf("GetStats() = %+v, want both idle buffers evicted", stats)
+		}
+	})
+
+	t.Run("fail when exhausted", func(t *testing.T) {
+		pool := NewBufferPool[byte](&BufferConfig{MaxTotalSize: 4096, FailWhenExhausted: true})
+		if _, err := pool.Get(ctx, 4096); err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		_, err := pool.Get(ctx, 1)
+		assertBufferPoolError(t, err, pkgerrors.ErrTypeValidation)
+	})
+
+	t.Run("waits for Put", func(t *testing.T) {
+		pool := NewBufferPool[byte](&BufferConfig{MaxTotalSize: 4096})
+		held, err := pool.Get(ctx, 4096)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		done := make(chan error, 1)
+		go func() {
+			buf, err := pool.Get(ctx, 4096)
+			if err == nil {
+				pool.Put(buf)
+			}
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			t.Fatalf("Get() returned %v before memory was released", err)
+		case <-time.After(20 * time.Millisecond):
+		}
+		pool.Put(held)
+		if err := <-done; err != nil {
+			t.Fatalf("waiting Get() error = %v", err)
+		}
+		if stats := pool.GetStats(); stats.Waits != 1 {
+			t.Errorf("Waits = %d, want 1", stats.Waits)
+		}
+	})
+
+	t.Run("wait respects context", func(t *testing.T) {
+		pool := NewBufferPool[byte](&BufferConfig{MaxTotalSize: 4096})
+		if _, err := pool.Get(ctx, 4096); err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err := pool.Get(timeout, 1)
+		assertBufferPoolError(t, err, pkgerrors.ErrTypeContext)
+	})
+
+	t.Run("time policy waits for expiry", func(t *testing.T) {
+		pool := NewBufferPool[byte](&BufferConfig{MaxTotalSize: 4096, EvictionPolicy: EvictionPolicyTime, EvictionTimeout: 30 * time.Millisecond})
+		buf, _ := pool.Get(ctx, 4096)
+		pool.Put(buf)
+		start := time.Now()
+		if _, err := pool.Get(ctx, 100); err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < 25*time.Millisecond {
+			t.Errorf("Get() returned after %v, want to wait for the idle buffer to expire", elapsed)
+		}
+	})
+
+	t.Run("request larger than limit", func(t *testing.T) {
+		pool := NewBufferPool[byte](&BufferConfig{MaxTotalSize: 4096})
+		_, err := pool.Get(ctx, 4097)
+		assertBufferPoolError(t, err, pkgerrors.ErrTypeValidation)
+	})
+}
+
+// TestBufferPool_EvictionTimeout tests that idle buffers expire.
+func TestBufferPool_EvictionTimeout(t *testing.T) {
+	pool := NewBufferPool[byte](&BufferConfig{EvictionTimeout: 10 * time.Millisecond})
+	buf, _ := pool.Get(context.Background(), 100)
+	pool.Put(buf)
+	time.Sleep(20 * time.Millisecond)
+	if stats := pool.GetStats(); stats.IdleBuffers != 0 || stats.Evictions != 1 {
+		t.Errorf("GetStats() = %+v, want the idle buffer evicted", stats)
+	}
+}
+
+// TestBufferPool_SetMaxTotalSize tests changing the memory limit.
+func TestBufferPool_SetMaxTotalSize(t *testing.T) {
+	pool := NewBufferPool[byte](nil)
+	for _, size := range []int{4096, 4096, 1024} {
+		buf, _ := pool.Get(context.Background(), size)
+		defer pool.Put(buf)
+	}
+	idle, _ := pool.Get(context.Background(), 8192)
+	pool.Put(idle)
+
+	if err := pool.SetMaxTotalSize(10000); err != nil {
+		t.Fatalf("SetMaxTotalSize() error = %v", err)
+	}
+	if stats := pool.GetStats(); stats.MaxTotalSize != 10000 || stats.IdleBuffers != 0 {
+		t.Errorf("GetStats() = %+v, want limit 10000 and idle buffer evicted", stats)
+	}
+	for _, size := range []int64{0, -1} {
+		assertBufferPoolError(t, pool.SetMaxTotalSize(size), pkgerrors.ErrTypeValidation)
+	}
+}
+
+// TestBufferPool_Errors tests invalid sizes, cancelled contexts and closed pools.
+func TestBufferPool_Errors(t *testing.T) {
+	pool := NewBufferPool[byte](nil)
+	_, err := pool.Get(context.Background(), -1)
+	assertBufferPoolError(t, err, pkgerrors.ErrTypeValidation)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = pool.Get(cancelled, 10)
+	assertBufferPoolError(t, err, pkgerrors.ErrTypeContext)
+
+	held, _ := pool.Get(context.Background(), 10)
+	pool.Close()
+	pool.Close()
+	_, err = pool.Get(context.Background(), 10)
+	assertBufferPoolError(t, err, pkgerrors.ErrTypeValidation)
+	pool.Put(held)
+	if stats := pool.GetStats(); stats.ActiveBuffers != 0 || stats.IdleBuffers != 0 {
+		t.Errorf("GetStats() after Close = %+v, want no buffers", stats)
+	}
+}
+
+// TestBufferPool_Concurrent tests concurrent Get and Put within the memory limit.
+func TestBufferPool_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	pool := NewBufferPool[byte](&BufferConfig{MaxTotalSize: 64 << 10})
+	var wg sync.WaitGroup
+	for i := range 16 {
+		wg.Add(1)
+		go func(size int) {
+			defer wg.Done()
+			for range 100 {
+				buf, err := pool.Get(ctx, size)
+				if err != nil {
+					t.Errorf("Get(%d) error = %v", size, err)
+					return
+				}
+				if total := pool.TotalSize(); total > 64<<10 {
+					t.Errorf("TotalSize() = %d exceeds the limit", total)
+				}
+				pool.Put(buf)
+			}
+		}(1024 * (i + 1))
+	}
+	wg.Wait()
+	if stats := pool.GetStats(); stats.ActiveBuffers != 0 || stats.BytesInUse != 0 {
+		t.Errorf("GetStats() = %+v, want no buffers in use", stats)
+	}
+}
@@ -1,14 +1,18 @@
 module github.com/novus-engine/novuspack/api/go
 
-go 1.25
+go 1.25.0
 
 replace github.com/novus-engine/novuspack => ../../..
 
 require (
 	github.com/cucumber/godog v0.15.1
 	github.com/goccy/go-yaml v1.19.2
+	github.com/klauspost/compress v1.18.0
+	github.com/pierrec/lz4/v4 v4.1.31
 	github.com/samber/lo v1.52.0
-	golang.org/x/text v0.33.0
+	github.com/ulikunitz/xz v0.5.15
+	golang.org/x/crypto v0.55.0
+	golang.org/x/text v0.41.0
 )
 
 require (
@@ -19,4 +23,5 @@ require (
 	github.com/hashicorp/go-memdb v1.3.5 // indirect
 	github.com/hashicorp/golang-lru v1.0.2 // indirect
 	github.com/spf13/pflag v1.0.10 // indirect
+	golang.org/x/sys v0.47.0 // indirect
 )
@@ -0,0 +1,329 @@
+// This file contains internal compression helpers used by the package writer
+// and reader to encode and decode FileEntry data. It contains the per-codec
+// compress/decompress functions and compression level resolution. This file
+// should contain only codec plumbing; FileEntry state handling belongs in the
+// callers.
+//
+// Specification: package_file_format.md: 4.1.1.3 Compression and Encryption Types
+
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz/lzma"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+const (
+	// DefaultCompressionLevel is used when a FileEntry requests compression level 0 (default).
+	DefaultCompressionLevel = 6
+
+	// MinCompressionLevel is the lowest accepted per-file compression level.
+	MinCompressionLevel = 1
+
+	// MaxCompressionLevel is the highest accepted per-file compression level.
+	MaxCompressionLevel = 9
+)
+
+// IsSupportedCompressionType reports whether compressionType has a codec implementation.
+// CompressionNone is considered supported.
+func IsSupportedCompressionType(compressionType uint8) bool {
+	switch compressionType {
+	case fileformat.CompressionNone, fileformat.CompressionZstd, fileformat.CompressionLZ4, fileformat.CompressionLZMA:
+		return true
+	default:
+		return false
+	}
+}
+
+// ResolveCompressionLevel validates a per-file compression level and maps 0 to the default.
+// Returns ErrTypeValidation if level is outside 0-9.
+func ResolveCompressionLevel(level int) (int, error) {
+	if level == 0 {
+		return DefaultCompressionLevel, nil
+	}
+	if level < MinCompressionLevel || level > MaxCompressionLevel {
+		return 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "compression level out of range", nil, pkgerrors.ValidationErrorContext{
+			Field:    "CompressionLevel",
+			Value:    level,
+			Expected: fmt.Sprintf("0 (default) or %d-%d", MinCompressionLevel, MaxCompressionLevel),
+		})
+	}
+	return level, nil
+}
+
+// CompressData compresses data with the given compression type and level.
+// Level 0 selects DefaultCompressionLevel.
+// Returns data unchanged for CompressionNone.
+func CompressData(data []byte, compressionType uint8, level int) ([]byte, error) {
+	level, err := ResolveCompressionLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	switch compressionType {
+	case fileformat.CompressionNone:
+		return data, nil
+	case fileformat.CompressionZstd:
+		return compressZstd(data, level)
+	case fileformat.CompressionLZ4:
+		return compressLZ4(data, level)
+	case fileformat.CompressionLZMA:
+		return compressLZMA(data, level)
+	default:
+		return nil, unsupportedCompressionError(compressionType)
+	}
+}
+
+// DecompressData decompresses data with the given compression type.
+// originalSize is the expected decompressed size; a mismatch is reported as corruption.
+// Returns data unchanged for CompressionNone.
+func DecompressData(data []byte, compressionType uint8, originalSize uint64) ([]byte, error) {
+	out, err := decompress(data, compressionType, originalSize, originalSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if uint64(len(out)) != originalSize {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "decompressed size mismatch", nil, pkgerrors.ValidationErrorContext{
+			Field:    "OriginalSize",
+			Value:    len(out),
+			Expected: fmt.Sprintf("%d bytes", originalSize),
+		})
+	}
+	return out, nil
+}
+
+// DecompressDataLimit decompresses data whose decompressed size is not recorded.
+// Output longer than limit bytes is reported as corruption, so a damaged or hostile
+// block cannot expand without bound.
+// Returns data unchanged for CompressionNone.
+func DecompressDataLimit(data []byte, compressionType uint8, limit uint64) ([]byte, error) {
+	out, err := decompress(data, compressionType, min(limit, uint64(len(data))*4), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if uint64(len(out)) > limit {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "decompressed size exceeds limit", nil, pkgerrors.ValidationErrorContext{
+			Field:    "DecompressedSize",
+			Value:    len(out),
+			Expected: fmt.Sprintf("at most %d bytes", limit),
+		})
+	}
+	return out, nil
+}
+
+// maxDecompressSizeHint caps the output capacity allocated up front from a
+// recorded size. Recorded sizes come from untrusted package data, so larger output
+// grows as it is decoded instead of being allocated before the stream is checked.
+const maxDecompressSizeHint = 64 << 20
+
+// outputReadLimit returns the number of output bytes to decode to detect output
+// longer than limit: limit+1, saturated so that it does not overflow an int64.
+func outputReadLimit(limit uint64) uint64 {
+	if limit >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return limit + 1
+}
+
+// decompress decodes data with the given compression type, reading at most limit+1
+// bytes of output so oversized streams are detected by the caller.
+func decompress(data []byte, compressionType uint8, sizeHint, limit uint64) ([]byte, error) {
+	sizeHint = min(sizeHint, maxDecompressSizeHint)
+	switch compressionType {
+	case fileformat.CompressionNone:
+		return data, nil
+	case fileformat.CompressionZstd:
+		return decompressZstd(data, sizeHint, limit)
+	case fileformat.CompressionLZ4:
+		return decompressLZ4(data, limit)
+	case fileformat.CompressionLZMA:
+		return decompressLZMA(data, limit)
+	default:
+		return nil, unsupportedCompressionError(compressionType)
+	}
+}
+
+// compressZstd encodes data as a single Zstandard frame.
+// Levels 1-9 are mapped onto the encoder's speed presets.
+func compressZstd(data []byte, level int) ([]byte, error) {
+	enc, err := zstd.NewWriter(nil, zstdEncoderOptions(level, nil)...)
+	if err != nil {
+		return nil, compressionError(err, "failed to create zstd encoder", fileformat.CompressionZstd)
+	}
+	defer func() { _ = enc.Close() }()
+
+	return enc.EncodeAll(data, make([]byte, 0, len(data)/2+64)), nil
+}
+
+// zstdEncoderOptions returns the encoder options for level and an optional
+// dictionary, shared by whole-buffer and streaming compression.
+func zstdEncoderOptions(level int, dict []byte) []zstd.EOption {
+	opts := []zstd.EOption{
+		zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)),
+		zstd.WithEncoderConcurrency(1),
+	}
+	if dict != nil {
+		opts = append(opts, zstd.WithEncoderDict(dict))
+	}
+	return opts
+}
+
+// zstdMinDecoderMemory is the smallest decoder memory limit. The limit also caps the
+// frame window size, so it must not drop below the windows produced by the encoder.
+const zstdMinDecoderMemory = 64 << 20
+
+// decompressZstd decodes a Zstandard frame.
+// Decoder memory is bounded by limit, with a floor of zstdMinDecoderMemory.
+func decompressZstd(data []byte, sizeHint, limit uint64) ([]byte, error) {
+	dec, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(max(outputReadLimit(limit), zstdMinDecoderMemory)))
+	if err != nil {
+		return nil, compressionError(err, "failed to create zstd decoder", fileformat.CompressionZstd)
+	}
+	defer dec.Close()
+
+	out, err := dec.DecodeAll(data, make([]byte, 0, sizeHint))
+	if errors.Is(err, zstd.ErrDecoderSizeExceeded) {
+		return nil, pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeCorruption, "decompressed size exceeds limit", pkgerrors.ValidationErrorContext{
+			Field:    "DecompressedSize",
+			Value:    nil,
+			Expected: fmt.Sprintf("at most %d bytes", limit),
+		})
+	}
+	if err != nil {
+		return nil, compressionError(err, "failed to decompress zstd data", fileformat.CompressionZstd)
+	}
+	return out, nil
+}
+
+// lz4Levels maps per-file levels 1-9 onto the LZ4 frame compression levels.
+var lz4Levels = [...]lz4.CompressionLevel{
+	lz4.Level1, lz4.Level2, lz4.Level3, lz4.Level4, lz4.Level5,
+	lz4.Level6, lz4.Level7, lz4.Level8, lz4.Level9,
+}
+
+// compressLZ4 encodes data as a single LZ4 frame with content size and checksum.
+// Levels only affect encoding speed and ratio; decoding speed is the same for all levels.
+func compressLZ4(data []byte, level int) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Grow(lz4.CompressBlockBound(len(data)) + 32)
+
+	w := lz4.NewWriter(&buf)
+	if err := w.Apply(lz4WriterOptions(level, uint64(len(data)))...); err != nil {
+		return nil, compressionError(err, "failed to configure lz4 encoder", fileformat.CompressionLZ4)
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, compressionError(err, "failed to compress lz4 data", fileformat.CompressionLZ4)
+	}
+	if err := w.Close(); err != nil {
+		return nil, compressionError(err, "failed to finish lz4 frame", fileformat.CompressionLZ4)
+	}
+	return buf.Bytes(), nil
+}
+
+// lz4WriterOptions returns the frame options for level and a content size of size
+// bytes, shared by whole-buffer and streaming compression.
+func lz4WriterOptions(level int, size uint64) []lz4.Option {
+	return []lz4.Option{
+		lz4.CompressionLevelOption(lz4Levels[level-MinCompressionLevel]),
+		lz4.SizeOption(size),
+		lz4.ChecksumOption(true),
+		lz4.ConcurrencyOption(1),
+	}
+}
+
+// decompressLZ4 decodes an LZ4 frame.
+// Decoding stops at limit+1 bytes so oversized frames are detected without unbounded reads.
+func decompressLZ4(data []byte, limit uint64) ([]byte, error) {
+	r := lz4.NewReader(bytes.NewReader(data))
+	out, err := io.ReadAll(io.LimitReader(r, int64(outputReadLimit(limit))))
+	if err != nil {
+		return nil, compressionError(err, "failed to decompress lz4 data", fileformat.CompressionLZ4)
+	}
+	return out, nil
+}
+
+// lzmaDictCaps maps per-file levels 1-9 onto LZMA dictionary capacities,
+// following the xz preset dictionary sizes.
+var lzmaDictCaps = [...]int{
+	1 << 20, 2 << 20, 4 << 20, 4 << 20, 8 << 20,
+	8 << 20, 16 << 20, 32 << 20, 64 << 20,
+}
+
+// compressLZMA encodes data as a classic LZMA stream with the size stored in the header.
+// Empty input is written with an end-of-stream marker instead, because the decoder
+// rejects a zero size in the header. The dictionary is never larger than the input,
+// so small files do not pay for large levels.
+func compressLZMA(data []byte, level int) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := lzmaWriterConfig(level, int64(len(data))).NewWriter(&buf)
+	if err != nil {
+		return nil, compressionError(err, "failed to create lzma encoder", fileformat.CompressionLZMA)
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, compressionError(err, "failed to compress lzma data", fileformat.CompressionLZMA)
+	}
+	if err := w.Close(); err != nil {
+		return nil, compressionError(err, "failed to finish lzma stream", fileformat.CompressionLZMA)
+	}
+	return buf.Bytes(), nil
+}
+
+// lzmaWriterConfig returns the stream configuration for level and an input of size
+// bytes, shared by whole-buffer and streaming compression.
+func lzmaWriterConfig(level int, size int64) lzma.WriterConfig {
+	dictCap := lzmaDictCaps[level-MinCompressionLevel]
+	if size < int64(dictCap) {
+		dictCap = max(int(size), lzma.MinDictCap)
+	}
+	return lzma.WriterConfig{
+		DictCap:      dictCap,
+		SizeInHeader: size > 0,
+		Size:         size,
+	}
+}
+
+// lzmaReaderConfig bounds the dictionary a decoder allocates from an untrusted
+// stream header to the largest capacity the encoder uses, so a crafted header
+// cannot force a large allocation before the output limit is checked.
+var lzmaReaderConfig = lzma.ReaderConfig{DictCap: lzmaDictCaps[len(lzmaDictCaps)-1]}
+
+// decompressLZMA decodes a classic LZMA stream.
+func decompressLZMA(data []byte, limit uint64) ([]byte, error) {
+	r, err := lzmaReaderConfig.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, compressionError(err, "failed to read lzma header", fileformat.CompressionLZMA)
+	}
+	out, err := io.ReadAll(io.LimitReader(r, int64(outputReadLimit(limit))))
+	if err != nil {
+		return nil, compressionError(err, "failed to decompress lzma data", fileformat.CompressionLZMA)
+	}
+	return out, nil
+}
+
+func compressionError(err error, message string, compressionType uint8) error {
+	return pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeCompression, message, pkgerrors.ValidationErrorContext{
+		Field:    "CompressionType",
+		Value:    compressionType,
+		Expected: "valid compressed stream",
+	})
+}
+
+func unsupportedCompressionError(compressionType uint8) error {
+	return pkgerrors.NewPackageError(pkgerrors.ErrTypeUnsupported, "unsupported compression type", nil, pkgerrors.ValidationErrorContext{
+		Field:    "CompressionType",
+		Value:    compressionType,
+		Expected: "supported compression type",
+	})
+}
@@ -0,0 +1,157 @@
+// This file contains internal helpers for dictionary compression. Dictionaries
+// are Zstandard dictionaries trained from sample file contents; they let many
+// small, similar files compress well by sharing common content. This file
+// should contain only dictionary training and dictionary-aware codec plumbing;
+// storing dictionaries and referencing them from FileEntry belongs in the callers.
+//
+// Specification: package_file_format.md: 4.1.4.4 Optional Data
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+const (
+	// DefaultDictionarySize is the default upper bound for trained dictionary content.
+	DefaultDictionarySize = 112 << 10
+
+	// MinDictionaryID is the first dictionary ID outside the range the Zstandard
+	// format reserves for registered dictionaries.
+	MinDictionaryID = 32768
+
+	// minDictionaryContent is the smallest dictionary content the encoder accepts.
+	minDictionaryContent = 8
+)
+
+// TrainDictionary builds a Zstandard dictionary with the given ID from samples.
+// Whole samples are taken in order until maxSize bytes of content are collected;
+// the entropy tables add a small fixed overhead on top of maxSize. A maxSize of 0
+// selects DefaultDictionarySize.
+// Returns ErrTypeValidation if the samples hold too little content to train from.
+func TrainDictionary(id uint32, samples [][]byte, maxSize int) ([]byte, error) {
+	if maxSize == 0 {
+		maxSize = DefaultDictionarySize
+	}
+
+	history := make([]byte, 0, maxSize)
+	var contents [][]byte
+	for _, sample := range samples {
+		if len(sample) == 0 {
+			continue
+		}
+		contents = append(contents, sample)
+		if room := maxSize - len(history); room > 0 {
+			history = append(history, sample[:min(len(sample), room)]...)
+		}
+	}
+	if len(history) < minDictionaryContent {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "not enough sample data to train dictionary", nil, pkgerrors.ValidationErrorContext{
+			Field:    "samples",
+			Value:    len(history),
+			Expected: fmt.Sprintf("at least %d bytes of sample data", minDictionaryContent),
+		})
+	}
+
+	dict, err := buildDict(zstd.BuildDictOptions{
+		ID:       id,
+		Contents: append(contents, literalSeed()),
+		History:  history,
+		Offsets:  [3]int{1, 4, 8},
+		Level:    zstd.SpeedDefault,
+	})
+	if err != nil {
+		return nil, compressionError(err, "failed to train compression dictionary", fileformat.CompressionZstd)
+	}
+	return dict, nil
+}
+
+// buildDict wraps zstd.BuildDict, which panics instead of returning an error for
+// some degenerate inputs.
+func buildDict(opts zstd.BuildDictOptions) (dict []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			dict, err = nil, fmt.Errorf("dictionary builder failed: %v", r)
+		}
+	}()
+	return zstd.BuildDict(opts)
+}
+
+// literalSeed returns one of each byte value. It is added to the training contents
+// so the literal statistics are never empty: samples that are fully covered by the
+// history encode without literals, which the dictionary builder cannot handle.
+func literalSeed() []byte {
+	seed := make([]byte, 256)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	return seed
+}
+
+// DictionaryID returns the ID stored in a Zstandard dictionary.
+// Returns ErrTypeCorruption if dict is not a valid dictionary.
+func DictionaryID(dict []byte) (uint32, error) {
+	d, err := zstd.InspectDictionary(dict)
+	if err != nil {
+		return 0, pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeCorruption, "invalid compression dictionary", pkgerrors.ValidationErrorContext{
+			Field:    "Dictionary",
+			Value:    len(dict),
+			Expected: "zstd dictionary",
+		})
+	}
+	return d.ID(), nil
+}
+
+// CompressDataWithDictionary compresses data as a Zstandard frame using dict.
+// Level 0 selects DefaultCompressionLevel.
+func CompressDataWithDictionary(data []byte, level int, dict []byte) ([]byte, error) {
+	level, err := ResolveCompressionLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	enc, err := zstd.NewWriter(nil, zstdEncoderOptions(level, dict)...)
+	if err != nil {
+		return nil, compressionError(err, "failed to create zstd dictionary encoder", fileformat.CompressionZstd)
+	}
+	defer func() { _ = enc.Close() }()
+
+	return enc.EncodeAll(data, make([]byte, 0, len(data)/2+64)), nil
+}
+
+// DecompressDataWithDictionary decompresses a Zstandard frame that was compressed with dict.
+// originalSize is the expected decompressed size; a mismatch is reported as corruption.
+func DecompressDataWithDictionary(data []byte, dict []byte, originalSize uint64) ([]byte, error) {
+	dec, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1), zstd.WithDecoderDicts(dict),
+		zstd.WithDecoderMaxMemory(max(outputReadLimit(originalSize), zstdMinDecoderMemory)))
+	if err != nil {
+		return nil, compressionError(err, "failed to create zstd dictionary decoder", fileformat.CompressionZstd)
+	}
+	defer dec.Close()
+
+	out, err := dec.DecodeAll(data, make([]byte, 0, min(originalSize, maxDecompressSizeHint)))
+	if errors.Is(err, zstd.ErrDecoderSizeExceeded) {
+		return nil, pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeCorruption, "decompressed size exceeds limit", pkgerrors.ValidationErrorContext{
+			Field:    "OriginalSize",
+			Value:    nil,
+			Expected: fmt.Sprintf("%d bytes", originalSize),
+		})
+	}
+	if err != nil {
+		return nil, compressionError(err, "failed to decompress zstd data with dictionary", fileformat.CompressionZstd)
+	}
+	if uint64(len(out)) != originalSize {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "decompressed size mismatch", nil, pkgerrors.ValidationErrorContext{
+			Field:    "OriginalSize",
+			Value:    len(out),
+			Expected: fmt.Sprintf("%d bytes", originalSize),
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,122 @@
+// Package internal provides internal helper functions for the NovusPack implementation.
+//
+// This file contains unit tests for dictionary compression helper functions.
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// dictionarySamples returns small, similar JSON documents.
+func dictionarySamples(n int) [][]byte {
+	samples := make([][]byte, n)
+	for i := range samples {
+		samples[i] = fmt.Appendf(nil, `{"id": %d, "name": "enemy_%d", "health": %d, "speed": 1.5, "faction": "northern_raiders", "loot_table": "common_drops"}`, i, i, 100+i)
+	}
+	return samples
+}
+
+// TestTrainDictionary_RoundTrip tests that a trained dictionary round-trips and improves small-file compression.
+func TestTrainDictionary_RoundTrip(t *testing.T) {
+	samples := dictionarySamples(200)
+	dict, err := TrainDictionary(MinDictionaryID, samples, 0)
+	if err != nil {
+		t.Fatalf("TrainDictionary() error = %v", err)
+	}
+	id, err := DictionaryID(dict)
+	if err != nil || id != MinDictionaryID {
+		t.Fatalf("DictionaryID() = %d, %v; want %d, nil", id, err, MinDictionaryID)
+	}
+
+	data := []byte(`{"id": 5000, "name": "enemy_5000", "health": 250, "speed": 1.5, "faction": "northern_raiders", "loot_table": "common_drops"}`)
+	withDict, err := CompressDataWithDictionary(data, 0, dict)
+	if err != nil {
+		t.Fatalf("CompressDataWithDictionary() error = %v", err)
+	}
+	plain, err := CompressData(data, fileformat.CompressionZstd, 0)
+	if err != nil {
+		t.Fatalf("CompressData() error = %v", err)
+	}
+	if len(withDict) >= len(plain) {
+		t.Errorf("dictionary compressed size = %d, want < plain size %d", len(withDict), len(plain))
+	}
+
+	out, err := DecompressDataWithDictionary(withDict, dict, uint64(len(data)))
+	if err != nil {
+		t.Fatalf("DecompressDataWithDictionary() error = %v", err)
+	}
+	if !bytes.Equal(out, data) {
+		t.Error("DecompressDataWithDictionary() content mismatch")
+	}
+}
+
+// TestTrainDictionary_MaxSize tests that the dictionary content is bounded by maxSize.
+func TestTrainDictionary_MaxSize(t *testing.T) {
+	dict, err := TrainDictionary(MinDictionaryID+1, dictionarySamples(500), 1024)
+	if err != nil {
+		t.Fatalf("TrainDictionary() error = %v", err)
+	}
+	if len(dict) > 4096 {
+		t.Errorf("len(dict) = %d, want a small dictionary for maxSize 1024", len(dict))
+	}
+}
+
+// TestTrainDictionary_FewSamples tests training from samples the history covers entirely.
+func TestTrainDictionary_FewSamples(t *testing.T) {
+	for _, n := range []int{1, 2, 4} {
+		samples := dictionarySamples(n)
+		dict, err := TrainDictionary(MinDictionaryID, samples, 0)
+		if err != nil {
+			t.Fatalf("TrainDictionary(%d samples) error = %v", n, err)
+		}
+		compressed, err := CompressDataWithDictionary(samples[0], 0, dict)
+		if err != nil {
+			t.Fatalf("CompressDataWithDictionary() error = %v", err)
+		}
+		out, err := DecompressDataWithDictionary(compressed, dict, uint64(len(samples[0])))
+		if err != nil || !bytes.Equal(out, samples[0]) {
+			t.Errorf("round trip with %d-sample dictionary failed: %v", n, err)
+		}
+	}
+}
+
+// TestDictionaryCompression_Errors tests dictionary error conditions.
+func TestDictionaryCompression_Errors(t *testing.T) {
+	_, err := TrainDictionary(MinDictionaryID, [][]byte{nil, []byte("abc")}, 0)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation, "TrainDictionary")
+
+	_, err = DictionaryID([]byte("not a dictionary"))
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption, "DictionaryID")
+
+	dict, err := TrainDictionary(MinDictionaryID, dictionarySamples(50), 0)
+	if err != nil {
+		t.Fatalf("TrainDictionary() error = %v", err)
+	}
+	_, err = CompressDataWithDictionary([]byte("data"), 10, dict)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation, "CompressDataWithDictionary")
+
+	_, err = CompressDataWithDictionary([]byte("data"), 0, []byte("bad"))
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeCompression, "CompressDataWithDictionary")
+
+	compressed, err := CompressDataWithDictionary([]byte("hello dictionary"), 0, dict)
+	if err != nil {
+		t.Fatalf("CompressDataWithDictionary() error = %v", err)
+	}
+	_, err = DecompressDataWithDictionary(compressed, dict, 3)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption, "DecompressDataWithDictionary")
+	_, err = DecompressDataWithDictionary(compressed, dict, math.MaxUint64)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption, "DecompressDataWithDictionary")
+
+	other, err := TrainDictionary(MinDictionaryID+7, dictionarySamples(50), 0)
+	if err != nil {
+		t.Fatalf("TrainDictionary() error = %v", err)
+	}
+	_, err = DecompressDataWithDictionary(compressed, other, 16)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeCompression, "DecompressDataWithDictionary")
+}
@@ -0,0 +1,202 @@
+// This file contains internal helpers for seekable (framed) compression. Framed
+// data is split into fixed-size frames that are compressed independently and
+// stored behind a frame table, so a range of the original data can be decoded
+// without decompressing the frames before it. This file should contain only the
+// frame layout encoding and decoding; reading frames from a package belongs in
+// the callers.
+//
+// Stored layout: [FrameCount: 4 bytes][FrameEnd: 8 bytes × FrameCount][frames...]
+// FrameEnd values are little-endian offsets relative to the first frame, so frame i
+// spans [FrameEnd(i-1), FrameEnd(i)) with FrameEnd(-1) = 0. Every frame decodes to
+// frameSize bytes except the last, which holds the remainder.
+//
+// Specification: package_file_format.md: 4.1.4.4 Optional Data
+
+package internal
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+const (
+	// MinCompressionFrameSize is the smallest accepted seekable compression frame size.
+	MinCompressionFrameSize = 4 << 10
+
+	// MaxCompressionFrameSize is the largest accepted seekable compression frame size.
+	MaxCompressionFrameSize = 64 << 20
+
+	// FrameTableHeaderSize is the size of the FrameCount field of the frame table.
+	FrameTableHeaderSize = 4
+
+	// FrameTableEntrySize is the size of one FrameEnd entry of the frame table.
+	FrameTableEntrySize = 8
+)
+
+// ValidateCompressionFrameSize checks that frameSize is within the accepted range.
+// Returns ErrTypeValidation otherwise.
+func ValidateCompressionFrameSize(frameSize int) error {
+	if frameSize < MinCompressionFrameSize || frameSize > MaxCompressionFrameSize {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "compression frame size out of range", nil, pkgerrors.ValidationErrorContext{
+			Field:    "CompressionFrameSize",
+			Value:    frameSize,
+			Expected: fmt.Sprintf("%d-%d bytes", MinCompressionFrameSize, MaxCompressionFrameSize),
+		})
+	}
+	return nil
+}
+
+// FrameCount returns the number of frames holding originalSize bytes split into
+// frames of frameSize bytes.
+func FrameCount(originalSize uint64, frameSize uint32) uint64 {
+	if frameSize == 0 {
+		return 0
+	}
+	return (originalSize + uint64(frameSize) - 1) / uint64(frameSize)
+}
+
+// FrameTableSize returns the size of a frame table with frameCount entries.
+func FrameTableSize(frameCount uint64) uint64 {
+	return FrameTableHeaderSize + frameCount*FrameTableEntrySize
+}
+
+// CompressFrames compresses data as independently compressed frames of frameSize
+// bytes and returns the stored layout (frame table followed by the frames).
+// Level 0 selects DefaultCompressionLevel.
+func CompressFrames(data []byte, compressionType uint8, level int, frameSize uint32) ([]byte, error) {
+	if err := ValidateCompressionFrameSize(int(frameSize)); err != nil {
+		return nil, err
+	}
+
+	frameCount := FrameCount(uint64(len(data)), frameSize)
+	tableSize := FrameTableSize(frameCount)
+	out := make([]byte, tableSize, tableSize+uint64(len(data))/2)
+	binary.LittleEndian.PutUint32(out, uint32(frameCount))
+
+	for i := uint64(0); i < frameCount; i++ {
+		start := i * uint64(frameSize)
+		end := min(start+uint64(frameSize), uint64(len(data)))
+		frame, err := CompressData(data[start:end], compressionType, level)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, frame...)
+		binary.LittleEndian.PutUint64(out[FrameTableHeaderSize+i*FrameTableEntrySize:], uint64(len(out))-tableSize)
+	}
+	return out, nil
+}
+
+// CompressFramesTo reads originalSize bytes from r and writes them to w as the
+// stored layout of CompressFrames, compressing one frame at a time into buf, which
+// must hold at least frameSize bytes. Frames are written behind the space reserved
+// for the frame table, and each FrameEnd entry is filled in once its frame is
+// written, so memory use does not depend on originalSize. Level 0 selects
+// DefaultCompressionLevel. Returns the stored size.
+func CompressFramesTo(w io.WriterAt, r io.Reader, compressionType uint8, level int, frameSize uint32, originalSize uint64, buf []byte) (uint64, error) {
+	if err := ValidateCompressionFrameSize(int(frameSize)); err != nil {
+		return 0, err
+	}
+
+	frameCount := FrameCount(originalSize, frameSize)
+	tableSize := FrameTableSize(frameCount)
+	var field [FrameTableEntrySize]byte
+	binary.LittleEndian.PutUint32(field[:], uint32(frameCount))
+	if _, err := w.WriteAt(field[:FrameTableHeaderSize], 0); err != nil {
+		return 0, pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to write frame table")
+	}
+
+	end := uint64(0)
+	for i := uint64(0); i < frameCount; i++ {
+		raw := buf[:FrameOriginalSize(i, originalSize, frameSize)]
+		if _, err := io.ReadFull(r, raw); err != nil {
+			return 0, pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeIO, "failed to read frame data", pkgerrors.ValidationErrorContext{
+				Field:    "OriginalSize",
+				Value:    i*uint64(frameSize) + uint64(len(raw)),
+				Expected: fmt.Sprintf("%d bytes", originalSize),
+			})
+		}
+		frame, err := CompressData(raw, compressionType, level)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := w.WriteAt(frame, int64(tableSize+end)); err != nil {
+			return 0, pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to write compressed frame")
+		}
+		end += uint64(len(frame))
+		binary.LittleEndian.PutUint64(field[:], end)
+		if _, err := w.WriteAt(field[:], int64(FrameTableHeaderSize+i*FrameTableEntrySize)); err != nil {
+			return 0, pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to write frame table")
+		}
+	}
+	return tableSize + end, nil
+}
+
+// DecompressFrames decompresses stored framed data produced by CompressFrames.
+// originalSize is the expected decompressed size of all frames together.
+func DecompressFrames(stored []byte, compressionType uint8, originalSize uint64, frameSize uint32) ([]byte, error) {
+	frameCount := FrameCount(originalSize, frameSize)
+	ends, err := ParseFrameTable(stored, frameCount)
+	if err != nil {
+		return nil, err
+	}
+	frames := stored[FrameTableSize(frameCount):]
+	if frameCount > 0 && ends[frameCount-1] != uint64(len(frames)) {
+		return nil, frameTableError("frame table does not cover stored data", ends[frameCount-1], fmt.Sprintf("%d bytes of frames", len(frames)))
+	}
+
+	out := make([]byte, 0, min(originalSize, maxDecompressSizeHint))
+	start := uint64(0)
+	for i, end := range ends {
+		frame, err := DecompressData(frames[start:end], compressionType, FrameOriginalSize(uint64(i), originalSize, frameSize))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, frame...)
+		start = end
+	}
+	return out, nil
+}
+
+// FrameOriginalSize returns the decompressed size of frame i.
+func FrameOriginalSize(i, originalSize uint64, frameSize uint32) uint64 {
+	start := i * uint64(frameSize)
+	return min(uint64(frameSize), originalSize-start)
+}
+
+// ParseFrameTable decodes the frame table at the start of table and returns the
+// FrameEnd offsets. table may hold more data after the frame table. Returns
+// ErrTypeCorruption if the table is truncated, does not hold frameCount entries,
+// or its offsets are not increasing.
+func ParseFrameTable(table []byte, frameCount uint64) ([]uint64, error) {
+	if uint64(len(table)) < FrameTableHeaderSize {
+		return nil, frameTableError("frame table truncated", len(table), fmt.Sprintf("at least %d bytes", FrameTableHeaderSize))
+	}
+	if stored := binary.LittleEndian.Uint32(table); uint64(stored) != frameCount {
+		return nil, frameTableError("frame count mismatch", stored, fmt.Sprintf("%d frames", frameCount))
+	}
+	if uint64(len(table)) < FrameTableSize(frameCount) {
+		return nil, frameTableError("frame table truncated", len(table), fmt.Sprintf("at least %d bytes", FrameTableSize(frameCount)))
+	}
+
+	ends := make([]uint64, frameCount)
+	prev := uint64(0)
+	for i := range ends {
+		ends[i] = binary.LittleEndian.Uint64(table[FrameTableHeaderSize+i*FrameTableEntrySize:])
+		if ends[i] <= prev {
+			return nil, frameTableError("frame offsets not increasing", ends[i], fmt.Sprintf("offset greater than %d", prev))
+		}
+		prev = ends[i]
+	}
+	return ends, nil
+}
+
+func frameTableError(message string, value any, expected string) error {
+	return pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, message, nil, pkgerrors.ValidationErrorContext{
+		Field:    "FrameTable",
+		Value:    value,
+		Expected: expected,
+	})
+}
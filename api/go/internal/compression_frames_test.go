@@ -0,0 +1,193 @@
+// Package internal provides internal helper functions for the NovusPack implementation.
+//
+// This file contains unit tests for seekable (framed) compression helpers.
+package internal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// framedTestPayload returns compressible data that differs between frames.
+func framedTestPayload(size int) []byte {
+	payload := make([]byte, 0, size+64)
+	for i := 0; len(payload) < size; i++ {
+		payload = fmt.Appendf(payload, "record %06d: novuspack framed payload\n", i)
+	}
+	return payload[:size]
+}
+
+// TestCompressFrames_RoundTrip tests that framed data round-trips for each codec and size.
+func TestCompressFrames_RoundTrip(t *testing.T) {
+	const frameSize = MinCompressionFrameSize
+
+	sizes := []int{0, 1, frameSize - 1, frameSize, frameSize + 1, 5*frameSize + 123}
+	for _, compressionType := range []uint8{fileformat.CompressionZstd, fileformat.CompressionLZ4, fileformat.CompressionLZMA} {
+		for _, size := range sizes {
+			t.Run(fmt.Sprintf("type=%d/size=%d", compressionType, size), func(t *testing.T) {
+				payload := framedTestPayload(size)
+				stored, err := CompressFrames(payload, compressionType, 0, frameSize)
+				if err != nil {
+					t.Fatalf("CompressFrames() error = %v", err)
+				}
+
+				frameCount := FrameCount(uint64(size), frameSize)
+				ends, err := ParseFrameTable(stored, frameCount)
+				if err != nil {
+					t.Fatalf("ParseFrameTable() error = %v", err)
+				}
+				if uint64(len(ends)) != frameCount {
+					t.Errorf("frame table entries = %d, want %d", len(ends), frameCount)
+				}
+
+				got, err := DecompressFrames(stored, compressionType, uint64(size), frameSize)
+				if err != nil {
+					t.Fatalf("DecompressFrames() error = %v", err)
+				}
+				if !bytes.Equal(got, payload) {
+					t.Error("DecompressFrames() content mismatch")
+				}
+			})
+		}
+	}
+}
+
+// TestCompressFramesTo_MatchesCompressFrames tests that streamed framed compression
+// produces the same stored layout as CompressFrames.
+func TestCompressFramesTo_MatchesCompressFrames(t *testing.T) {
+	const frameSize = MinCompressionFrameSize
+
+	for _, compressionType := range []uint8{fileformat.CompressionZstd, fileformat.CompressionLZ4, fileformat.CompressionLZMA} {
+		for _, size := range []int{0, frameSize, 5*frameSize + 123} {
+			t.Run(fmt.Sprintf("type=%d/size=%d", compressionType, size), func(t *testing.T) {
+				payload := framedTestPayload(size)
+				want, err := CompressFrames(payload, compressionType, 0, frameSize)
+				if err != nil {
+					t.Fatalf("CompressFrames() error = %v", err)
+				}
+
+				out, err := os.Create(filepath.Join(t.TempDir(), "frames"))
+				if err != nil {
+					t.Fatalf("Create() error = %v", err)
+				}
+				defer func() { _ = out.Close() }()
+				storedSize, err := CompressFramesTo(out, bytes.NewReader(payload), compressionType, 0, frameSize, uint64(size), make([]byte, frameSize))
+				if err != nil {
+					t.Fatalf("CompressFramesTo() error = %v", err)
+				}
+				got, err := os.ReadFile(out.Name())
+				if err != nil {
+					t.Fatalf("ReadFile() error = %v", err)
+				}
+				if storedSize != uint64(len(want)) || !bytes.Equal(got, want) {
+					t.Errorf("CompressFramesTo() wrote %d bytes (reported %d), want %d matching CompressFrames", len(got), storedSize, len(want))
+				}
+			})
+		}
+	}
+}
+
+// TestCompressFramesTo_ShortReader tests that a reader ending early is an I/O error.
+func TestCompressFramesTo_ShortReader(t *testing.T) {
+	const frameSize = MinCompressionFrameSize
+	out, err := os.Create(filepath.Join(t.TempDir(), "frames"))
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	defer func() { _ = out.Close() }()
+
+	payload := framedTestPayload(2 * frameSize)
+	_, err = CompressFramesTo(out, bytes.NewReader(payload), fileformat.CompressionZstd, 0, frameSize, uint64(len(payload))+1, make([]byte, frameSize))
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeIO, "CompressFramesTo")
+
+	_, err = CompressFramesTo(out, bytes.NewReader(payload), fileformat.CompressionZstd, 0, 1, uint64(len(payload)), make([]byte, frameSize))
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation, "CompressFramesTo")
+}
+
+// TestCompressFrames_FramesIndependent tests that each frame decompresses on its own.
+func TestCompressFrames_FramesIndependent(t *testing.T) {
+	const frameSize = MinCompressionFrameSize
+	payload := framedTestPayload(3*frameSize + 100)
+
+	stored, err := CompressFrames(payload, fileformat.CompressionZstd, 0, frameSize)
+	if err != nil {
+		t.Fatalf("CompressFrames() error = %v", err)
+	}
+	frameCount := FrameCount(uint64(len(payload)), frameSize)
+	ends, err := ParseFrameTable(stored, frameCount)
+	if err != nil {
+		t.Fatalf("ParseFrameTable() error = %v", err)
+	}
+
+	frames := stored[FrameTableSize(frameCount):]
+	start := uint64(0)
+	for i, end := range ends {
+		size := FrameOriginalSize(uint64(i), uint64(len(payload)), frameSize)
+		got, err := DecompressData(frames[start:end], fileformat.CompressionZstd, size)
+		if err != nil {
+			t.Fatalf("frame %d: DecompressData() error = %v", i, err)
+		}
+		want := payload[uint64(i)*frameSize : uint64(i)*frameSize+size]
+		if !bytes.Equal(got, want) {
+			t.Errorf("frame %d content mismatch", i)
+		}
+		start = end
+	}
+}
+
+// TestCompressFrames_Errors tests frame size validation.
+func TestCompressFrames_Errors(t *testing.T) {
+	_, err := CompressFrames([]byte("data"), fileformat.CompressionZstd, 0, MinCompressionFrameSize-1)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation, "CompressFrames")
+
+	_, err = CompressFrames([]byte("data"), fileformat.CompressionZstd, 0, MaxCompressionFrameSize+1)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation, "CompressFrames")
+
+	_, err = CompressFrames([]byte("data"), 0xFF, 0, MinCompressionFrameSize)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeUnsupported, "CompressFrames")
+}
+
+// TestDecompressFrames_Corruption tests detection of damaged frame tables.
+func TestDecompressFrames_Corruption(t *testing.T) {
+	const frameSize = MinCompressionFrameSize
+	payload := framedTestPayload(2*frameSize + 10)
+	size := uint64(len(payload))
+
+	stored, err := CompressFrames(payload, fileformat.CompressionZstd, 0, frameSize)
+	if err != nil {
+		t.Fatalf("CompressFrames() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func([]byte) []byte
+	}{
+		{"truncated header", func(b []byte) []byte { return b[:2] }},
+		{"truncated table", func(b []byte) []byte { return b[:FrameTableHeaderSize+FrameTableEntrySize] }},
+		{"frame count", func(b []byte) []byte {
+			binary.LittleEndian.PutUint32(b, 2)
+			return b
+		}},
+		{"offsets not increasing", func(b []byte) []byte {
+			binary.LittleEndian.PutUint64(b[FrameTableHeaderSize+FrameTableEntrySize:], 1)
+			return b
+		}},
+		{"trailing data", func(b []byte) []byte { return append(b, 0) }},
+		{"truncated frames", func(b []byte) []byte { return b[:len(b)-1] }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			damaged := tt.mutate(bytes.Clone(stored))
+			_, err := DecompressFrames(damaged, fileformat.CompressionZstd, size, frameSize)
+			assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption, "DecompressFrames")
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// This file contains internal helpers for streaming compression and decompression.
+// Unlike CompressData and DecompressData, which work on a whole buffer, the writers
+// and readers returned here encode and decode incrementally so large files can be
+// added and read without holding their content in memory. Their output matches the
+// whole-buffer codecs. This file should contain only streaming encoder and decoder
+// construction.
+//
+// Specification: api_streaming.md: 1.4 Features
+
+package internal
+
+import (
+	"errors"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/pierrec/lz4/v4"
+)
+
+// NewDecompressReader returns a reader that decompresses the stream read from r.
+//
+// dict is an optional Zstandard dictionary and must be nil for other compression
+// types. limit is the expected decompressed size; it bounds Zstandard decoder
+// memory like DecompressDataLimit. Decoding errors returned by Read are
+// *PackageError with ErrTypeCompression. The caller must Close the reader to
+// release decoder resources; Close does not close r.
+func NewDecompressReader(r io.Reader, compressionType uint8, dict []byte, limit uint64) (io.ReadCloser, error) {
+	switch compressionType {
+	case fileformat.CompressionNone:
+		return io.NopCloser(r), nil
+	case fileformat.CompressionZstd:
+		opts := []zstd.DOption{zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(max(outputReadLimit(limit), zstdMinDecoderMemory))}
+		if dict != nil {
+			opts = append(opts, zstd.WithDecoderDicts(dict))
+		}
+		dec, err := zstd.NewReader(r, opts...)
+		if err != nil {
+			return nil, compressionError(err, "failed to create zstd decoder", compressionType)
+		}
+		return &decompressReader{r: dec, close: dec.Close, compressionType: compressionType}, nil
+	case fileformat.CompressionLZ4:
+		return &decompressReader{r: lz4.NewReader(r), compressionType: compressionType}, nil
+	case fileformat.CompressionLZMA:
+		dec, err := lzmaReaderConfig.NewReader(r)
+		if err != nil {
+			return nil, compressionError(err, "failed to read lzma header", compressionType)
+		}
+		return &decompressReader{r: dec, compressionType: compressionType}, nil
+	default:
+		return nil, unsupportedCompressionError(compressionType)
+	}
+}
+
+// decompressReader wraps a streaming decoder, reporting decoding errors as
+// compression errors.
+type decompressReader struct {
+	r               io.Reader
+	close           func()
+	compressionType uint8
+}
+
+// Read decompresses into p.
+func (d *decompressReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		err = compressionError(err, "failed to decompress data", d.compressionType)
+	}
+	return n, err
+}
+
+// Close releases the decoder.
+func (d *decompressReader) Close() error {
+	if d.close != nil {
+		d.close()
+		d.close = nil
+	}
+	return nil
+}
+
+// NewCompressWriter returns a writer that compresses the data written to it and
+// writes the compressed stream to w.
+//
+// size is the exact number of bytes that will be written; LZ4 and LZMA record it
+// in the stream header and Zstandard in the frame header, as CompressData does.
+// dict is an optional Zstandard dictionary and must be nil for other compression
+// types. Level 0 selects DefaultCompressionLevel. Encoding errors are *PackageError
+// with ErrTypeCompression. The caller must Close the writer to flush the end of the
+// stream; Close does not close w.
+func NewCompressWriter(w io.Writer, compressionType uint8, level int, dict []byte, size int64) (io.WriteCloser, error) {
+	level, err := ResolveCompressionLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	switch compressionType {
+	case fileformat.CompressionNone:
+		return &compressWriter{w: w, close: func() error { return nil }, compressionType: compressionType}, nil
+	case fileformat.CompressionZstd:
+		enc, err := zstd.NewWriter(nil, zstdEncoderOptions(level, dict)...)
+		if err != nil {
+			return nil, compressionError(err, "failed to create zstd encoder", compressionType)
+		}
+		enc.ResetContentSize(w, size)
+		return &compressWriter{w: enc, close: enc.Close, compressionType: compressionType}, nil
+	case fileformat.CompressionLZ4:
+		enc := lz4.NewWriter(w)
+		if err := enc.Apply(lz4WriterOptions(level, uint64(size))...); err != nil {
+			return nil, compressionError(err, "failed to configure lz4 encoder", compressionType)
+		}
+		return &compressWriter{w: enc, close: enc.Close, compressionType: compressionType}, nil
+	case fileformat.CompressionLZMA:
+		enc, err := lzmaWriterConfig(level, size).NewWriter(w)
+		if err != nil {
+			return nil, compressionError(err, "failed to create lzma encoder", compressionType)
+		}
+		return &compressWriter{w: enc, close: enc.Close, compressionType: compressionType}, nil
+	default:
+		return nil, unsupportedCompressionError(compressionType)
+	}
+}
+
+// compressWriter wraps a streaming encoder, reporting encoding errors as
+// compression errors.
+type compressWriter struct {
+	w               io.Writer
+	close           func() error
+	compressionType uint8
+}
+
+// Write compresses p.
+func (c *compressWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	if err != nil {
+		err = compressionError(err, "failed to compress data", c.compressionType)
+	}
+	return n, err
+}
+
+// Close flushes the end of the compressed stream.
+func (c *compressWriter) Close() error {
+	if err := c.close(); err != nil {
+		return compressionError(err, "failed to finish compressed stream", c.compressionType)
+	}
+	return nil
+}
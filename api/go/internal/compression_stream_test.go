@@ -0,0 +1,152 @@
+// Package internal provides internal helper functions for the NovusPack implementation.
+//
+// This file contains unit tests for streaming compression and decompression helpers.
+package internal
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// TestNewDecompressReader_RoundTrip tests that streaming decoders match CompressData.
+func TestNewDecompressReader_RoundTrip(t *testing.T) {
+	payload := bytes.Repeat([]byte("novuspack streaming payload "), 4096)
+
+	for _, compressionType := range []uint8{fileformat.CompressionNone, fileformat.CompressionZstd, fileformat.CompressionLZ4, fileformat.CompressionLZMA} {
+		compressed, err := CompressData(payload, compressionType, 0)
+		if err != nil {
+			t.Fatalf("CompressData(%d) error = %v", compressionType, err)
+		}
+		r, err := NewDecompressReader(bytes.NewReader(compressed), compressionType, nil, uint64(len(payload)))
+		if err != nil {
+			t.Fatalf("NewDecompressReader(%d) error = %v", compressionType, err)
+		}
+		got, err := io.ReadAll(r)
+		_ = r.Close()
+		if err != nil {
+			t.Fatalf("ReadAll(%d) error = %v", compressionType, err)
+		}
+		if !bytes.Equal(got, payload) {
+			t.Errorf("NewDecompressReader(%d) content mismatch", compressionType)
+		}
+	}
+}
+
+// TestNewDecompressReader_Dictionary tests streaming decoding with a Zstandard dictionary.
+func TestNewDecompressReader_Dictionary(t *testing.T) {
+	samples := dictionarySamples(64)
+	dict, err := TrainDictionary(7, samples, 4096)
+	if err != nil {
+		t.Fatalf("TrainDictionary() error = %v", err)
+	}
+	compressed, err := CompressDataWithDictionary(samples[3], 0, dict)
+	if err != nil {
+		t.Fatalf("CompressDataWithDictionary() error = %v", err)
+	}
+	r, err := NewDecompressReader(bytes.NewReader(compressed), fileformat.CompressionZstd, dict, uint64(len(samples[3])))
+	if err != nil {
+		t.Fatalf("NewDecompressReader() error = %v", err)
+	}
+	defer func() { _ = r.Close() }()
+	got, err := io.ReadAll(r)
+	if err != nil || !bytes.Equal(got, samples[3]) {
+		t.Errorf("ReadAll() = %q, %v; want sample", got, err)
+	}
+}
+
+// TestNewDecompressReader_Errors tests unsupported types and corrupt streams.
+func TestNewDecompressReader_Errors(t *testing.T) {
+	_, err := NewDecompressReader(bytes.NewReader(nil), 0x7F, nil, 0)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeUnsupported, "NewDecompressReader")
+
+	_, err = NewDecompressReader(bytes.NewReader([]byte{0x01}), fileformat.CompressionLZMA, nil, 0)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeCompression, "NewDecompressReader")
+
+	for _, compressionType := range []uint8{fileformat.CompressionZstd, fileformat.CompressionLZ4} {
+		r, err := NewDecompressReader(bytes.NewReader(bytes.Repeat([]byte{0xA5}, 64)), compressionType, nil, 64)
+		if err != nil {
+			t.Fatalf("NewDecompressReader(%d) error = %v", compressionType, err)
+		}
+		_, err = io.ReadAll(r)
+		_ = r.Close()
+		assertPackageErrorType(t, err, pkgerrors.ErrTypeCompression, "Read")
+	}
+}
+
+// TestNewCompressWriter_RoundTrip tests that streamed compression decodes like CompressData.
+func TestNewCompressWriter_RoundTrip(t *testing.T) {
+	payload := bytes.Repeat([]byte("novuspack streaming payload "), 4096)
+
+	for _, compressionType := range []uint8{fileformat.CompressionNone, fileformat.CompressionZstd, fileformat.CompressionLZ4, fileformat.CompressionLZMA} {
+		for _, data := range [][]byte{payload, {}} {
+			var buf bytes.Buffer
+			w, err := NewCompressWriter(&buf, compressionType, 0, nil, int64(len(data)))
+			if err != nil {
+				t.Fatalf("NewCompressWriter(%d) error = %v", compressionType, err)
+			}
+			// Write in pieces so encoders see a stream rather than one buffer
+			for chunk := range slices.Chunk(data, 1000) {
+				if _, err := w.Write(chunk); err != nil {
+					t.Fatalf("Write(%d) error = %v", compressionType, err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close(%d) error = %v", compressionType, err)
+			}
+			got, err := DecompressData(buf.Bytes(), compressionType, uint64(len(data)))
+			if err != nil {
+				t.Fatalf("DecompressData(%d, %d bytes) error = %v", compressionType, len(data), err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("NewCompressWriter(%d, %d bytes) content mismatch", compressionType, len(data))
+			}
+		}
+	}
+}
+
+// TestNewCompressWriter_Dictionary tests streamed compression with a Zstandard dictionary.
+func TestNewCompressWriter_Dictionary(t *testing.T) {
+	samples := dictionarySamples(64)
+	dict, err := TrainDictionary(7, samples, 4096)
+	if err != nil {
+		t.Fatalf("TrainDictionary() error = %v", err)
+	}
+	var buf bytes.Buffer
+	w, err := NewCompressWriter(&buf, fileformat.CompressionZstd, 0, dict, int64(len(samples[5])))
+	if err != nil {
+		t.Fatalf("NewCompressWriter() error = %v", err)
+	}
+	if _, err := w.Write(samples[5]); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	got, err := DecompressDataWithDictionary(buf.Bytes(), dict, uint64(len(samples[5])))
+	if err != nil || !bytes.Equal(got, samples[5]) {
+		t.Errorf("DecompressDataWithDictionary() = %q, %v; want sample", got, err)
+	}
+}
+
+// TestNewCompressWriter_Errors tests unsupported types, invalid levels and size mismatches.
+func TestNewCompressWriter_Errors(t *testing.T) {
+	_, err := NewCompressWriter(io.Discard, 0x7F, 0, nil, 0)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeUnsupported, "NewCompressWriter")
+
+	_, err = NewCompressWriter(io.Discard, fileformat.CompressionZstd, 12, nil, 0)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation, "NewCompressWriter")
+
+	w, err := NewCompressWriter(io.Discard, fileformat.CompressionZstd, 0, nil, 10)
+	if err != nil {
+		t.Fatalf("NewCompressWriter() error = %v", err)
+	}
+	if _, err := w.Write([]byte("short")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	assertPackageErrorType(t, w.Close(), pkgerrors.ErrTypeCompression, "Close")
+}
@@ -0,0 +1,193 @@
+// Package internal provides internal helper functions for the NovusPack implementation.
+//
+// This file contains unit tests for compression helper functions.
+package internal
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// TestCompressData_RoundTrip tests that each supported codec round-trips data.
+func TestCompressData_RoundTrip(t *testing.T) {
+	payload := bytes.Repeat([]byte("novuspack compression payload "), 512)
+
+	tests := []struct {
+		name            string
+		compressionType uint8
+		level           int
+	}{
+		{"none", fileformat.CompressionNone, 0},
+		{"zstd default level", fileformat.CompressionZstd, 0},
+		{"zstd fastest", fileformat.CompressionZstd, 1},
+		{"zstd best", fileformat.CompressionZstd, 9},
+		{"lz4 default level", fileformat.CompressionLZ4, 0},
+		{"lz4 fastest", fileformat.CompressionLZ4, 1},
+		{"lz4 best", fileformat.CompressionLZ4, 9},
+		{"lzma default level", fileformat.CompressionLZMA, 0},
+		{"lzma fastest", fileformat.CompressionLZMA, 1},
+		{"lzma best", fileformat.CompressionLZMA, 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compressed, err := CompressData(payload, tt.compressionType, tt.level)
+			if err != nil {
+				t.Fatalf("CompressData() error = %v", err)
+			}
+			if tt.compressionType != fileformat.CompressionNone && len(compressed) >= len(payload) {
+				t.Errorf("CompressData() size = %d, want < %d", len(compressed), len(payload))
+			}
+
+			decompressed, err := DecompressData(compressed, tt.compressionType, uint64(len(payload)))
+			if err != nil {
+				t.Fatalf("DecompressData() error = %v", err)
+			}
+			if !bytes.Equal(decompressed, payload) {
+				t.Errorf("DecompressData() content mismatch")
+			}
+		})
+	}
+}
+
+// TestCompressData_EmptyInput tests that empty data round-trips.
+func TestCompressData_EmptyInput(t *testing.T) {
+	for _, compressionType := range []uint8{fileformat.CompressionZstd, fileformat.CompressionLZ4, fileformat.CompressionLZMA} {
+		compressed, err := CompressData(nil, compressionType, 0)
+		if err != nil {
+			t.Fatalf("CompressData(type=%d) error = %v", compressionType, err)
+		}
+		decompressed, err := DecompressData(compressed, compressionType, 0)
+		if err != nil {
+			t.Fatalf("DecompressData(type=%d) error = %v", compressionType, err)
+		}
+		if len(decompressed) != 0 {
+			t.Errorf("DecompressData(type=%d) len = %d, want 0", compressionType, len(decompressed))
+		}
+	}
+}
+
+// TestCompressData_Errors tests compression error conditions.
+func TestCompressData_Errors(t *testing.T) {
+	_, err := CompressData([]byte("data"), fileformat.CompressionZstd, 10)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation, "CompressData")
+
+	_, err = CompressData([]byte("data"), fileformat.CompressionZstd, -1)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation, "CompressData")
+
+	_, err = CompressData([]byte("data"), 0xFF, 0)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeUnsupported, "CompressData")
+}
+
+// TestDecompressData_Errors tests decompression error conditions.
+func TestDecompressData_Errors(t *testing.T) {
+	_, err := DecompressData([]byte("not a zstd frame"), fileformat.CompressionZstd, 16)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeCompression, "DecompressData")
+
+	_, err = DecompressData([]byte("not an lz4 frame"), fileformat.CompressionLZ4, 16)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeCompression, "DecompressData")
+
+	_, err = DecompressData([]byte{0xFF}, fileformat.CompressionLZMA, 16)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeCompression, "DecompressData")
+
+	// A header declaring a 1 GiB dictionary is rejected before it is allocated
+	oversized := []byte{0x5D, 0x00, 0x00, 0x00, 0x40, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x00}
+	_, err = DecompressData(oversized, fileformat.CompressionLZMA, 16)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeCompression, "DecompressData")
+	_, err = NewDecompressReader(bytes.NewReader(oversized), fileformat.CompressionLZMA, nil, 16)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeCompression, "NewDecompressReader")
+
+	_, err = DecompressData([]byte("data"), 0xFF, 4)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeUnsupported, "DecompressData")
+
+	compressed, err := CompressData([]byte("hello world"), fileformat.CompressionZstd, 0)
+	if err != nil {
+		t.Fatalf("CompressData() error = %v", err)
+	}
+	_, err = DecompressData(compressed, fileformat.CompressionZstd, 5)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption, "DecompressData")
+
+	compressed, err = CompressData([]byte("hello world"), fileformat.CompressionLZ4, 0)
+	if err != nil {
+		t.Fatalf("CompressData() error = %v", err)
+	}
+	_, err = DecompressData(compressed, fileformat.CompressionLZ4, 5)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption, "DecompressData")
+
+	compressed, err = CompressData([]byte("hello world"), fileformat.CompressionLZMA, 0)
+	if err != nil {
+		t.Fatalf("CompressData() error = %v", err)
+	}
+	_, err = DecompressData(compressed, fileformat.CompressionLZMA, 5)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption, "DecompressData")
+
+	// A recorded size far beyond the real output is a mismatch, not an allocation
+	for _, compressionType := range []uint8{fileformat.CompressionZstd, fileformat.CompressionLZ4, fileformat.CompressionLZMA} {
+		compressed, err := CompressData([]byte("hello world"), compressionType, 0)
+		if err != nil {
+			t.Fatalf("CompressData(type=%d) error = %v", compressionType, err)
+		}
+		for _, size := range []uint64{1 << 62, 1 << 63, math.MaxUint64} {
+			_, err = DecompressData(compressed, compressionType, size)
+			assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption, "DecompressData")
+		}
+	}
+}
+
+// TestDecompressDataLimit tests decompression of data with an unrecorded size.
+func TestDecompressDataLimit(t *testing.T) {
+	payload := bytes.Repeat([]byte("metadata block "), 64)
+
+	for _, compressionType := range []uint8{fileformat.CompressionZstd, fileformat.CompressionLZ4, fileformat.CompressionLZMA} {
+		compressed, err := CompressData(payload, compressionType, 0)
+		if err != nil {
+			t.Fatalf("CompressData(type=%d) error = %v", compressionType, err)
+		}
+
+		decompressed, err := DecompressDataLimit(compressed, compressionType, uint64(len(payload)))
+		if err != nil {
+			t.Fatalf("DecompressDataLimit(type=%d) error = %v", compressionType, err)
+		}
+		if !bytes.Equal(decompressed, payload) {
+			t.Errorf("DecompressDataLimit(type=%d) content mismatch", compressionType)
+		}
+
+		_, err = DecompressDataLimit(compressed, compressionType, uint64(len(payload))-1)
+		assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption, "DecompressDataLimit")
+	}
+}
+
+// TestResolveCompressionLevel tests level validation and defaulting.
+func TestResolveCompressionLevel(t *testing.T) {
+	if got, err := ResolveCompressionLevel(0); err != nil || got != DefaultCompressionLevel {
+		t.Errorf("ResolveCompressionLevel(0) = %d, %v; want %d, nil", got, err, DefaultCompressionLevel)
+	}
+	if got, err := ResolveCompressionLevel(3); err != nil || got != 3 {
+		t.Errorf("ResolveCompressionLevel(3) = %d, %v; want 3, nil", got, err)
+	}
+	_, err := ResolveCompressionLevel(MaxCompressionLevel + 1)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation, "ResolveCompressionLevel")
+}
+
+// TestIsSupportedCompressionType tests codec support reporting.
+func TestIsSupportedCompressionType(t *testing.T) {
+	if !IsSupportedCompressionType(fileformat.CompressionNone) {
+		t.Error("IsSupportedCompressionType(None) = false, want true")
+	}
+	if !IsSupportedCompressionType(fileformat.CompressionZstd) {
+		t.Error("IsSupportedCompressionType(Zstd) = false, want true")
+	}
+	if !IsSupportedCompressionType(fileformat.CompressionLZ4) {
+		t.Error("IsSupportedCompressionType(LZ4) = false, want true")
+	}
+	if !IsSupportedCompressionType(fileformat.CompressionLZMA) {
+		t.Error("IsSupportedCompressionType(LZMA) = false, want true")
+	}
+	if IsSupportedCompressionType(0xFF) {
+		t.Error("IsSupportedCompressionType(0xFF) = true, want false")
+	}
+}
@@ -0,0 +1,156 @@
+// This file contains internal encryption helpers: cipher construction and the
+// single-message AEAD used for small sealed values such as encrypted path sections
+// and wrapped content keys, stored as a nonce followed by the ciphertext and tag.
+// FileEntry data is sealed in chunks by the framing in encryption_stream.go. This
+// file should contain only cipher plumbing; key management and FileEntry state
+// handling belong in the callers.
+//
+// Specification: package_file_format.md: 4.1.1.4 Encrypted File Data Framing
+// Specification: package_file_format.md: 4.1.1.7 ChaCha20-Poly1305 File Data
+
+package internal
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+const (
+	// AES256KeySize is the key size of AES-256-GCM in bytes.
+	AES256KeySize = 32
+
+	// ChaCha20Poly1305KeySize is the key size of ChaCha20-Poly1305 in bytes.
+	ChaCha20Poly1305KeySize = chacha20poly1305.KeySize
+
+	// AEADNonceSize is the size of the nonce stored before the ciphertext.
+	AEADNonceSize = 12
+
+	// AEADTagSize is the size of the authentication tag at the end of the ciphertext.
+	AEADTagSize = 16
+)
+
+// IsSupportedEncryptionType reports whether encryptionType (an on-disk value) has a
+// cipher implementation. EncryptionNone is considered supported.
+func IsSupportedEncryptionType(encryptionType uint8) bool {
+	switch encryptionType {
+	case fileformat.EncryptionNone, fileformat.EncryptionAES256GCM, fileformat.EncryptionChaCha20Poly1305,
+		fileformat.EncryptionQuantumSafe:
+		return true
+	default:
+		return false
+	}
+}
+
+// EncryptData encrypts data as a single message with the given encryption type and
+// key and returns the stored form: a fresh random nonce followed by the ciphertext
+// and tag. File data is encrypted with NewEncryptWriter instead. For
+// EncryptionQuantumSafe, key is the recipient's ML-KEM encapsulation key and the
+// stored form is prefixed with the KEM ciphertext.
+// Returns data unchanged for EncryptionNone.
+func EncryptData(data []byte, encryptionType uint8, key []byte) ([]byte, error) {
+	switch encryptionType {
+	case fileformat.EncryptionNone:
+		return data, nil
+	case fileformat.EncryptionQuantumSafe:
+		return encryptHybrid(data, key)
+	}
+	aead, err := newAEAD(encryptionType, key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, AEADNonceSize, AEADNonceSize+len(data)+AEADTagSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeEncryption, "failed to generate nonce")
+	}
+	return aead.Seal(nonce, nonce, data, nil), nil
+}
+
+// DecryptData decrypts stored data produced by EncryptData. For
+// EncryptionQuantumSafe, key is the seed of the ML-KEM decapsulation key.
+// Returns ErrTypeCorruption if the data is too short to hold a nonce and tag, and
+// ErrTypeEncryption if authentication fails (wrong key or modified data).
+// Returns data unchanged for EncryptionNone.
+func DecryptData(data []byte, encryptionType uint8, key []byte) ([]byte, error) {
+	switch encryptionType {
+	case fileformat.EncryptionNone:
+		return data, nil
+	case fileformat.EncryptionQuantumSafe:
+		return decryptHybrid(data, key)
+	}
+	aead, err := newAEAD(encryptionType, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < AEADNonceSize+AEADTagSize {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "encrypted data truncated", nil, pkgerrors.ValidationErrorContext{
+			Field:    "StoredSize",
+			Value:    len(data),
+			Expected: "at least nonce and authentication tag",
+		})
+	}
+	plain, err := aead.Open(nil, data[:AEADNonceSize], data[AEADNonceSize:], nil)
+	if err != nil {
+		return nil, pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeEncryption, "decryption failed: wrong key or modified data", pkgerrors.ValidationErrorContext{
+			Field:    "EncryptionType",
+			Value:    encryptionType,
+			Expected: "data authenticated with the supplied key",
+		})
+	}
+	return plain, nil
+}
+
+// EncryptionKeySize returns the symmetric key size in bytes required by
+// encryptionType, or 0 if the type has no symmetric cipher implementation.
+func EncryptionKeySize(encryptionType uint8) int {
+	switch encryptionType {
+	case fileformat.EncryptionAES256GCM:
+		return AES256KeySize
+	case fileformat.EncryptionChaCha20Poly1305:
+		return ChaCha20Poly1305KeySize
+	default:
+		return 0
+	}
+}
+
+// newAEAD returns the AEAD cipher for the symmetric encryptionType keyed with key.
+func newAEAD(encryptionType uint8, key []byte) (cipher.AEAD, error) {
+	if EncryptionKeySize(encryptionType) == 0 {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeUnsupported, "unsupported encryption type", nil, pkgerrors.ValidationErrorContext{
+			Field:    "EncryptionType",
+			Value:    encryptionType,
+			Expected: "supported encryption type",
+		})
+	}
+	if len(key) != EncryptionKeySize(encryptionType) {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeEncryption, "invalid encryption key size", nil, pkgerrors.ValidationErrorContext{
+			Field:    "key",
+			Value:    len(key),
+			Expected: "key size of the encryption type",
+		})
+	}
+
+	if encryptionType == fileformat.EncryptionChaCha20Poly1305 {
+		aead, err := chacha20poly1305.New(key)
+		if err != nil {
+			return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeEncryption, "failed to create cipher")
+		}
+		return aead, nil
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeEncryption, "failed to create cipher")
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeEncryption, "failed to create cipher")
+	}
+	return aead, nil
+}
@@ -0,0 +1,200 @@
+// This file contains the ML-KEM hybrid cipher used for quantum-safe file data
+// (EncryptionType 0x02). Each encryption encapsulates a fresh shared secret to the
+// recipient's ML-KEM encapsulation key, derives an AES-256-GCM key from it with
+// HKDF-SHA256, and stores the KEM ciphertext in front of the AEAD framing. This
+// file should contain only ML-KEM and hybrid framing plumbing; key management
+// belongs in the callers.
+//
+// Specification: package_file_format.md: 4.1.1.8 Quantum-Safe Hybrid File Data
+
+package internal
+
+import (
+	"crypto/hkdf"
+	"crypto/mlkem"
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+const (
+	// MLKEMSeedSize is the size of the seed form of an ML-KEM decapsulation key.
+	MLKEMSeedSize = mlkem.SeedSize
+
+	// MLKEM768Level is the security level of ML-KEM-768.
+	MLKEM768Level = 3
+
+	// MLKEM1024Level is the security level of ML-KEM-1024.
+	MLKEM1024Level = 5
+
+	// hybridKEMCiphertextLenSize is the size of the KEMCiphertextLen prefix.
+	hybridKEMCiphertextLenSize = 2
+
+	// hybridKeyInfo is the HKDF info string used to derive the file data key.
+	hybridKeyInfo = "novuspack-file-encryption-v1"
+)
+
+// MLKEMEncapsulationKeySize returns the encapsulation key size in bytes of the ML-KEM
+// parameter set with the given security level, or 0 if the level is not supported.
+func MLKEMEncapsulationKeySize(level int) int {
+	switch level {
+	case MLKEM768Level:
+		return mlkem.EncapsulationKeySize768
+	case MLKEM1024Level:
+		return mlkem.EncapsulationKeySize1024
+	default:
+		return 0
+	}
+}
+
+// MLKEMEncapsulationKey returns the encapsulation key of the ML-KEM decapsulation
+// key with the given security level and seed.
+// Returns ErrTypeUnsupported for unsupported levels and ErrTypeEncryption for an
+// invalid seed.
+func MLKEMEncapsulationKey(level int, seed []byte) ([]byte, error) {
+	switch level {
+	case MLKEM768Level:
+		dk, err := mlkem.NewDecapsulationKey768(seed)
+		if err != nil {
+			return nil, mlkemKeyError(err, "seed", len(seed))
+		}
+		return dk.EncapsulationKey().Bytes(), nil
+	case MLKEM1024Level:
+		dk, err := mlkem.NewDecapsulationKey1024(seed)
+		if err != nil {
+			return nil, mlkemKeyError(err, "seed", len(seed))
+		}
+		return dk.EncapsulationKey().Bytes(), nil
+	default:
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeUnsupported, "unsupported ML-KEM security level", nil, pkgerrors.ValidationErrorContext{
+			Field:    "Level",
+			Value:    level,
+			Expected: "3 (ML-KEM-768) or 5 (ML-KEM-1024)",
+		})
+	}
+}
+
+// encryptHybrid encrypts data to an ML-KEM encapsulation key. The parameter set is
+// selected by the size of encapsulationKey.
+func encryptHybrid(data, encapsulationKey []byte) ([]byte, error) {
+	fileKey, header, err := hybridEncapsulate(encapsulationKey)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(fileKey)
+	sealed, err := EncryptData(data, fileformat.EncryptionAES256GCM, fileKey)
+	if err != nil {
+		return nil, err
+	}
+	return append(header, sealed...), nil
+}
+
+// decryptHybrid decrypts stored data produced by encryptHybrid with the seed of the
+// ML-KEM decapsulation key.
+func decryptHybrid(data, seed []byte) ([]byte, error) {
+	if len(data) < hybridKEMCiphertextLenSize {
+		return nil, hybridTruncatedError(len(data))
+	}
+	kemCiphertextLen := int(binary.LittleEndian.Uint16(data))
+	if len(data) < hybridKEMCiphertextLenSize+kemCiphertextLen {
+		return nil, hybridTruncatedError(len(data))
+	}
+	fileKey, err := hybridDecapsulate(data[hybridKEMCiphertextLenSize:hybridKEMCiphertextLenSize+kemCiphertextLen], seed)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(fileKey)
+	return DecryptData(data[hybridKEMCiphertextLenSize+kemCiphertextLen:], fileformat.EncryptionAES256GCM, fileKey)
+}
+
+// hybridEncapsulate encapsulates a fresh shared secret to an ML-KEM encapsulation
+// key and returns the derived file key and the stored KEM header: KEMCiphertextLen
+// followed by KEMCiphertext. The parameter set is selected by the size of
+// encapsulationKey.
+func hybridEncapsulate(encapsulationKey []byte) (fileKey, header []byte, err error) {
+	var sharedKey, kemCiphertext []byte
+	switch len(encapsulationKey) {
+	case mlkem.EncapsulationKeySize768:
+		ek, err := mlkem.NewEncapsulationKey768(encapsulationKey)
+		if err != nil {
+			return nil, nil, mlkemKeyError(err, "encapsulationKey", len(encapsulationKey))
+		}
+		sharedKey, kemCiphertext = ek.Encapsulate()
+	case mlkem.EncapsulationKeySize1024:
+		ek, err := mlkem.NewEncapsulationKey1024(encapsulationKey)
+		if err != nil {
+			return nil, nil, mlkemKeyError(err, "encapsulationKey", len(encapsulationKey))
+		}
+		sharedKey, kemCiphertext = ek.Encapsulate()
+	default:
+		return nil, nil, mlkemKeyError(nil, "encapsulationKey", len(encapsulationKey))
+	}
+	defer clear(sharedKey)
+
+	if fileKey, err = hybridFileKey(sharedKey); err != nil {
+		return nil, nil, err
+	}
+	header = make([]byte, hybridKEMCiphertextLenSize, hybridKEMCiphertextLenSize+len(kemCiphertext))
+	binary.LittleEndian.PutUint16(header, uint16(len(kemCiphertext)))
+	return fileKey, append(header, kemCiphertext...), nil
+}
+
+// hybridDecapsulate derives the file key from a stored KEM ciphertext with the seed
+// of the ML-KEM decapsulation key. The parameter set is selected by the KEM
+// ciphertext size.
+func hybridDecapsulate(kemCiphertext, seed []byte) ([]byte, error) {
+	var sharedKey []byte
+	var err error
+	switch len(kemCiphertext) {
+	case mlkem.CiphertextSize768:
+		dk, keyErr := mlkem.NewDecapsulationKey768(seed)
+		if keyErr != nil {
+			return nil, mlkemKeyError(keyErr, "seed", len(seed))
+		}
+		sharedKey, err = dk.Decapsulate(kemCiphertext)
+	case mlkem.CiphertextSize1024:
+		dk, keyErr := mlkem.NewDecapsulationKey1024(seed)
+		if keyErr != nil {
+			return nil, mlkemKeyError(keyErr, "seed", len(seed))
+		}
+		sharedKey, err = dk.Decapsulate(kemCiphertext)
+	default:
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "invalid KEM ciphertext length", nil, pkgerrors.ValidationErrorContext{
+			Field:    "KEMCiphertextLen",
+			Value:    len(kemCiphertext),
+			Expected: "ML-KEM-768 or ML-KEM-1024 ciphertext size",
+		})
+	}
+	if err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeEncryption, "failed to decapsulate file key")
+	}
+	defer clear(sharedKey)
+	return hybridFileKey(sharedKey)
+}
+
+// hybridFileKey derives the AES-256-GCM file data key from an ML-KEM shared secret.
+func hybridFileKey(sharedKey []byte) ([]byte, error) {
+	key, err := hkdf.Key(sha256.New, sharedKey, nil, hybridKeyInfo, AES256KeySize)
+	if err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeEncryption, "failed to derive file key")
+	}
+	return key, nil
+}
+
+func mlkemKeyError(cause error, field string, size int) error {
+	return pkgerrors.NewPackageError(pkgerrors.ErrTypeEncryption, "invalid ML-KEM key", cause, pkgerrors.ValidationErrorContext{
+		Field:    field,
+		Value:    size,
+		Expected: "ML-KEM-768 or ML-KEM-1024 key",
+	})
+}
+
+func hybridTruncatedError(size int) error {
+	return pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "encrypted data truncated", nil, pkgerrors.ValidationErrorContext{
+		Field:    "StoredSize",
+		Value:    size,
+		Expected: "at least KEM ciphertext",
+	})
+}
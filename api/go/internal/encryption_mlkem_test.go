@@ -0,0 +1,89 @@
+// Package internal provides internal helper functions for the NovusPack implementation.
+//
+// This file contains unit tests for the ML-KEM hybrid cipher.
+package internal
+
+import (
+	"bytes"
+	"crypto/mlkem"
+	"encoding/binary"
+	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// TestEncryptData_QuantumSafe tests hybrid encryption round trips for both
+// supported ML-KEM parameter sets.
+func TestEncryptData_QuantumSafe(t *testing.T) {
+	payload := []byte("quantum-safe save game")
+	seed := bytes.Repeat([]byte{0x5A}, MLKEMSeedSize)
+
+	for _, tc := range []struct {
+		level         int
+		kemCiphertext int
+	}{
+		{MLKEM768Level, mlkem.CiphertextSize768},
+		{MLKEM1024Level, mlkem.CiphertextSize1024},
+	} {
+		ek, err := MLKEMEncapsulationKey(tc.level, seed)
+		if err != nil {
+			t.Fatalf("MLKEMEncapsulationKey(%d) error = %v", tc.level, err)
+		}
+		if len(ek) != MLKEMEncapsulationKeySize(tc.level) {
+			t.Errorf("MLKEMEncapsulationKey(%d) size = %d, want %d", tc.level, len(ek), MLKEMEncapsulationKeySize(tc.level))
+		}
+
+		stored, err := EncryptData(payload, fileformat.EncryptionQuantumSafe, ek)
+		if err != nil {
+			t.Fatalf("EncryptData(level %d) error = %v", tc.level, err)
+		}
+		if got := int(binary.LittleEndian.Uint16(stored)); got != tc.kemCiphertext {
+			t.Errorf("KEMCiphertextLen = %d, want %d", got, tc.kemCiphertext)
+		}
+		if want := 2 + tc.kemCiphertext + AEADNonceSize + len(payload) + AEADTagSize; len(stored) != want {
+			t.Errorf("EncryptData(level %d) size = %d, want %d", tc.level, len(stored), want)
+		}
+
+		got, err := DecryptData(stored, fileformat.EncryptionQuantumSafe, seed)
+		if err != nil {
+			t.Fatalf("DecryptData(level %d) error = %v", tc.level, err)
+		}
+		if !bytes.Equal(got, payload) {
+			t.Errorf("DecryptData(level %d) content mismatch", tc.level)
+		}
+	}
+}
+
+// TestDecryptData_QuantumSafeErrors tests hybrid decryption error conditions.
+func TestDecryptData_QuantumSafeErrors(t *testing.T) {
+	seed := bytes.Repeat([]byte{0x5A}, MLKEMSeedSize)
+	ek, err := MLKEMEncapsulationKey(MLKEM768Level, seed)
+	if err != nil {
+		t.Fatalf("MLKEMEncapsulationKey() error = %v", err)
+	}
+	stored, err := EncryptData([]byte("secret"), fileformat.EncryptionQuantumSafe, ek)
+	if err != nil {
+		t.Fatalf("EncryptData() error = %v", err)
+	}
+
+	_, err = DecryptData(stored, fileformat.EncryptionQuantumSafe, bytes.Repeat([]byte{0x5B}, MLKEMSeedSize))
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption, "DecryptData")
+
+	_, err = DecryptData(stored, fileformat.EncryptionQuantumSafe, seed[:32])
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption, "DecryptData")
+
+	_, err = DecryptData(stored[:100], fileformat.EncryptionQuantumSafe, seed)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption, "DecryptData")
+
+	badLen := bytes.Clone(stored)
+	binary.LittleEndian.PutUint16(badLen, 16)
+	_, err = DecryptData(badLen, fileformat.EncryptionQuantumSafe, seed)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption, "DecryptData")
+
+	_, err = EncryptData([]byte("secret"), fileformat.EncryptionQuantumSafe, ek[:100])
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption, "EncryptData")
+
+	_, err = MLKEMEncapsulationKey(1, seed)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeUnsupported, "MLKEMEncapsulationKey")
+}
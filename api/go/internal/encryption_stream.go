@@ -0,0 +1,537 @@
+// This file contains the chunked AEAD framing of encrypted file data. File data is
+// split into EncryptedChunkSize plaintext chunks that are sealed separately, so a
+// file is encrypted as it is written and any chunk can be decrypted without the
+// others. Every chunk authenticates the FileID, the KeyID, its index and whether it
+// is the last chunk as associated data, so chunks cannot be moved between files or
+// keys, reordered, or dropped from the end. This file should contain only file
+// data chunk framing; cipher construction is in encryption.go.
+//
+// Specification: package_file_format.md: 4.1.1.5 Common Conventions
+
+package internal
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+const (
+	// EncryptedChunkSize is the plaintext size of every chunk of encrypted file data
+	// except the last, which holds the remaining 0 to EncryptedChunkSize bytes.
+	EncryptedChunkSize = 64 << 10
+
+	// encryptedChunkStoredSize is the stored size of a full chunk.
+	encryptedChunkStoredSize = EncryptedChunkSize + AEADTagSize
+)
+
+// FileDataBinding identifies the file entry and key that encrypted file data belongs
+// to. It is authenticated with every chunk.
+type FileDataBinding struct {
+	FileID uint64
+	KeyID  string
+}
+
+// chunkAD returns the associated data of chunk index: FileID, KeyID length, KeyID,
+// chunk index and the final chunk flag.
+func (b FileDataBinding) chunkAD(index uint64, final bool) []byte {
+	ad := make([]byte, 0, 8+2+len(b.KeyID)+8+1)
+	ad = binary.LittleEndian.AppendUint64(ad, b.FileID)
+	ad = binary.LittleEndian.AppendUint16(ad, uint16(len(b.KeyID)))
+	ad = append(ad, b.KeyID...)
+	ad = binary.LittleEndian.AppendUint64(ad, index)
+	if final {
+		return append(ad, 1)
+	}
+	return append(ad, 0)
+}
+
+// chunkNonce writes the nonce of chunk index to dst: the stored nonce with its last
+// 8 bytes XORed with the big-endian chunk index.
+func chunkNonce(dst, nonce []byte, index uint64) []byte {
+	dst = append(dst[:0], nonce...)
+	counter := binary.BigEndian.Uint64(dst[AEADNonceSize-8:])
+	binary.BigEndian.PutUint64(dst[AEADNonceSize-8:], counter^index)
+	return dst
+}
+
+// NewEncryptWriter returns a writer that encrypts the file data written to it and
+// writes the stored form to w: the framing header, then each chunk's ciphertext
+// and tag. Chunks are sealed as they fill; Close seals the last chunk and must be
+// called. For EncryptionQuantumSafe, key is the recipient's ML-KEM encapsulation key.
+// Close does not close w.
+func NewEncryptWriter(w io.Writer, encryptionType uint8, key []byte, binding FileDataBinding) (io.WriteCloser, error) {
+	sealer, header, err := newChunkSealer(encryptionType, key, binding)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, encryptWriteError(err)
+	}
+	return &encryptWriter{w: w, sealer: sealer, buf: make([]byte, 0, encryptedChunkStoredSize)}, nil
+}
+
+// encryptWriter seals file data one chunk at a time.
+type encryptWriter struct {
+	w      io.Writer
+	sealer *chunkSealer
+	buf    []byte // Plaintext of the pending chunk
+	index  uint64
+	err    error
+}
+
+// Write buffers p, sealing each full chunk once more data follows it.
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	written := 0
+	for len(p) > 0 {
+		if len(e.buf) == EncryptedChunkSize {
+			if err := e.seal(false); err != nil {
+				return written, err
+			}
+		}
+		n := copy(e.buf[len(e.buf):EncryptedChunkSize], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+// Close seals the pending data as the last chunk. Further writes fail.
+func (e *encryptWriter) Close() error {
+	if e.err != nil {
+		return e.err
+	}
+	err := e.seal(true)
+	clear(e.buf[:cap(e.buf)])
+	if err == nil {
+		e.err = encryptWriterClosedError()
+	}
+	return err
+}
+
+// seal encrypts the pending chunk in place and writes it.
+func (e *encryptWriter) seal(final bool) error {
+	if _, err := e.w.Write(e.sealer.seal(e.buf, e.index, final)); err != nil {
+		e.err = encryptWriteError(err)
+		return e.err
+	}
+	e.index++
+	e.buf = e.buf[:0]
+	return nil
+}
+
+// EncryptWriterAt encrypts file data whose leading bytes are filled in after later
+// bytes are written, such as a frame table that records each frame as it is
+// written. The chunks overlapping the first head bytes stay in memory until Close;
+// later chunks are sealed as they fill. Data is written with WriteAt: at or past
+// the end of the data written so far, which fills any gap with zeros, or over
+// earlier data within the held chunks.
+type EncryptWriterAt struct {
+	w          io.WriterAt
+	sealer     *chunkSealer
+	chunkStart int64  // Stored offset of the first chunk in w
+	held       []byte // Plaintext of the leading chunks
+	heldSize   int64  // Size of the leading chunks, a multiple of EncryptedChunkSize
+	size       int64  // Plaintext bytes written
+	buf        []byte // Plaintext of the pending chunk past the held chunks
+	index      uint64 // Index of the pending chunk
+	err        error
+}
+
+// NewEncryptWriterAt returns an EncryptWriterAt that writes the stored form of the
+// file data to w, keeping the chunks that overlap the first head bytes in memory.
+// For EncryptionQuantumSafe, key is the recipient's ML-KEM encapsulation key. Close
+// seals the remaining chunks and must be called; it does not close w.
+func NewEncryptWriterAt(w io.WriterAt, encryptionType uint8, key []byte, binding FileDataBinding, head int64) (*EncryptWriterAt, error) {
+	sealer, header, err := newChunkSealer(encryptionType, key, binding)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.WriteAt(header, 0); err != nil {
+		return nil, encryptWriteError(err)
+	}
+	heldSize := (max(head, 0) + EncryptedChunkSize - 1) / EncryptedChunkSize * EncryptedChunkSize
+	return &EncryptWriterAt{
+		w:          w,
+		sealer:     sealer,
+		chunkStart: int64(len(header)),
+		heldSize:   heldSize,
+		buf:        make([]byte, 0, encryptedChunkStoredSize),
+		index:      uint64(heldSize / EncryptedChunkSize),
+	}, nil
+}
+
+// WriteAt writes p at plaintext offset off.
+func (e *EncryptWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	n := 0
+	if off < e.size || off < 0 {
+		end := min(off+int64(len(p)), e.size)
+		if off < 0 || end > e.heldSize {
+			return 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "rewrite outside held encrypted chunks", nil, pkgerrors.ValidationErrorContext{
+				Field:    "offset",
+				Value:    off,
+				Expected: fmt.Sprintf("rewrites within the first %d bytes or writes at or after %d", e.heldSize, e.size),
+			})
+		}
+		n = copy(e.held[off:end], p)
+	}
+	if n == len(p) {
+		return n, nil
+	}
+	var zero [1024]byte
+	for gap := off + int64(n) - e.size; gap > 0; gap -= int64(len(zero)) {
+		if err := e.append(zero[:min(gap, int64(len(zero)))]); err != nil {
+			return n, err
+		}
+	}
+	if err := e.append(p[n:]); err != nil {
+		return n, err
+	}
+	return len(p), nil
+}
+
+// append adds p at the end of the data, sealing each full chunk past the held
+// chunks once more data follows it.
+func (e *EncryptWriterAt) append(p []byte) error {
+	if e.size < e.heldSize {
+		n := min(int64(len(p)), e.heldSize-e.size)
+		e.held = append(e.held, p[:n]...)
+		e.size += n
+		p = p[n:]
+	}
+	for len(p) > 0 {
+		if len(e.buf) == EncryptedChunkSize {
+			if err := e.seal(e.buf, e.index, false); err != nil {
+				return err
+			}
+			e.index++
+			e.buf = e.buf[:0]
+		}
+		n := copy(e.buf[len(e.buf):EncryptedChunkSize], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		e.size += int64(n)
+		p = p[n:]
+	}
+	return nil
+}
+
+// Close seals the pending chunk and the held chunks. Further writes fail.
+func (e *EncryptWriterAt) Close() error {
+	if e.err != nil {
+		return e.err
+	}
+	last := max(uint64((e.size+EncryptedChunkSize-1)/EncryptedChunkSize), 1) - 1
+	if e.size > e.heldSize {
+		if err := e.seal(e.buf, e.index, true); err != nil {
+			return err
+		}
+	}
+	heldChunks := uint64((len(e.held) + EncryptedChunkSize - 1) / EncryptedChunkSize)
+	if e.size == 0 {
+		// Empty data is one empty chunk
+		heldChunks = 1
+	}
+	for index := range heldChunks {
+		start := index * EncryptedChunkSize
+		end := min(start+EncryptedChunkSize, uint64(len(e.held)))
+		chunk := make([]byte, end-start, end-start+AEADTagSize)
+		copy(chunk, e.held[start:end])
+		if err := e.seal(chunk, index, index == last); err != nil {
+			return err
+		}
+	}
+	clear(e.held)
+	clear(e.buf[:cap(e.buf)])
+	e.err = encryptWriterClosedError()
+	return nil
+}
+
+// seal encrypts chunk index in place and writes it at its stored offset.
+func (e *EncryptWriterAt) seal(plain []byte, index uint64, final bool) error {
+	offset := e.chunkStart + int64(index)*encryptedChunkStoredSize
+	if _, err := e.w.WriteAt(e.sealer.seal(plain, index, final), offset); err != nil {
+		e.err = encryptWriteError(err)
+		return e.err
+	}
+	return nil
+}
+
+// chunkSealer seals the chunks of one file.
+type chunkSealer struct {
+	aead       cipher.AEAD
+	nonce      []byte
+	chunkNonce []byte
+	binding    FileDataBinding
+}
+
+// newChunkSealer returns a sealer with a fresh nonce and the stored framing header
+// that precedes the chunks.
+func newChunkSealer(encryptionType uint8, key []byte, binding FileDataBinding) (*chunkSealer, []byte, error) {
+	var header []byte
+	var aead cipher.AEAD
+	var err error
+	if encryptionType == fileformat.EncryptionQuantumSafe {
+		var fileKey []byte
+		if fileKey, header, err = hybridEncapsulate(key); err != nil {
+			return nil, nil, err
+		}
+		aead, err = newAEAD(fileformat.EncryptionAES256GCM, fileKey)
+		clear(fileKey)
+	} else {
+		aead, err = newAEAD(encryptionType, key)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nonce := make([]byte, AEADNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeEncryption, "failed to generate nonce")
+	}
+	return &chunkSealer{aead: aead, nonce: nonce, binding: binding}, append(header, nonce...), nil
+}
+
+// seal encrypts plain as chunk index in place; plain must have capacity for the
+// tag. It returns the ciphertext and tag.
+func (s *chunkSealer) seal(plain []byte, index uint64, final bool) []byte {
+	s.chunkNonce = chunkNonce(s.chunkNonce, s.nonce, index)
+	return s.aead.Seal(plain[:0], s.chunkNonce, plain, s.binding.chunkAD(index, final))
+}
+
+// DecryptReader decrypts chunked file data. It implements io.ReaderAt over the
+// plaintext and authenticates each chunk as it is read, keeping the last chunk
+// read. A DecryptReader is safe for concurrent use.
+type DecryptReader struct {
+	mu         sync.Mutex
+	stored     io.ReaderAt
+	storedSize int64
+	chunkStart int64 // Offset of the first chunk in stored
+	chunks     uint64
+	size       int64
+	aead       cipher.AEAD
+	nonce      []byte
+	chunkNonce []byte
+	binding    FileDataBinding
+	buf        []byte
+	plain      []byte // Plaintext of chunk cached, or nil
+	cached     uint64
+}
+
+// NewDecryptReader returns a reader of the plaintext of the storedSize bytes of
+// stored file data produced by NewEncryptWriter. For EncryptionQuantumSafe, key is
+// the seed of the ML-KEM decapsulation key.
+// Returns ErrTypeCorruption if the framing is truncated and ErrTypeEncryption if the
+// content of an empty file fails authentication; other chunks are authenticated by
+// ReadAt.
+func NewDecryptReader(stored io.ReaderAt, storedSize int64, encryptionType uint8, key []byte, binding FileDataBinding) (*DecryptReader, error) {
+	var headerSize int64
+	var aead cipher.AEAD
+	var err error
+	if encryptionType == fileformat.EncryptionQuantumSafe {
+		var fileKey []byte
+		if fileKey, headerSize, err = storedHybridFileKey(stored, storedSize, key); err != nil {
+			return nil, err
+		}
+		aead, err = newAEAD(fileformat.EncryptionAES256GCM, fileKey)
+		clear(fileKey)
+	} else {
+		aead, err = newAEAD(encryptionType, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	body := storedSize - headerSize - AEADNonceSize
+	if body < AEADTagSize {
+		return nil, encryptedDataTruncatedError(storedSize)
+	}
+	chunks := (body + encryptedChunkStoredSize - 1) / encryptedChunkStoredSize
+	if body-(chunks-1)*encryptedChunkStoredSize < AEADTagSize {
+		return nil, encryptedDataTruncatedError(storedSize)
+	}
+	nonce := make([]byte, AEADNonceSize)
+	if err := readStoredAt(stored, nonce, headerSize); err != nil {
+		return nil, err
+	}
+	d := &DecryptReader{
+		stored:     stored,
+		storedSize: storedSize,
+		chunkStart: headerSize + AEADNonceSize,
+		chunks:     uint64(chunks),
+		size:       body - chunks*AEADTagSize,
+		aead:       aead,
+		nonce:      nonce,
+		binding:    binding,
+		buf:        make([]byte, encryptedChunkStoredSize),
+	}
+	if d.size == 0 {
+		// There is no content to read, so authenticate the empty chunk now
+		if _, err := d.openChunk(0); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// Size returns the plaintext size in bytes.
+func (d *DecryptReader) Size() int64 {
+	return d.size
+}
+
+// ReadAt reads len(p) bytes of plaintext starting at off, decrypting the chunks
+// that hold them. It returns io.EOF if fewer bytes are available.
+func (d *DecryptReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "negative read offset", nil, pkgerrors.ValidationErrorContext{
+			Field:    "offset",
+			Value:    off,
+			Expected: "non-negative offset",
+		})
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n := 0
+	for n < len(p) {
+		pos := off + int64(n)
+		if pos >= d.size {
+			return n, io.EOF
+		}
+		index := uint64(pos / EncryptedChunkSize)
+		plain, err := d.openChunk(index)
+		if err != nil {
+			return n, err
+		}
+		n += copy(p[n:], plain[pos-int64(index)*EncryptedChunkSize:])
+	}
+	return n, nil
+}
+
+// openChunk returns the authenticated plaintext of chunk index.
+func (d *DecryptReader) openChunk(index uint64) ([]byte, error) {
+	if d.plain != nil && d.cached == index {
+		return d.plain, nil
+	}
+	d.plain = nil
+	start := d.chunkStart + int64(index)*encryptedChunkStoredSize
+	sealed := d.buf[:min(encryptedChunkStoredSize, d.storedSize-start)]
+	if err := readStoredAt(d.stored, sealed, start); err != nil {
+		return nil, err
+	}
+	d.chunkNonce = chunkNonce(d.chunkNonce, d.nonce, index)
+	plain, err := d.aead.Open(sealed[:0], d.chunkNonce, sealed, d.binding.chunkAD(index, index == d.chunks-1))
+	if err != nil {
+		return nil, pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeEncryption, "decryption failed: wrong key or modified data", pkgerrors.ValidationErrorContext{
+			Field:    "Chunk",
+			Value:    index,
+			Expected: "data authenticated with the supplied key",
+		})
+	}
+	d.plain, d.cached = plain, index
+	return plain, nil
+}
+
+// EncryptFileData encrypts data in memory like NewEncryptWriter and returns the
+// stored form.
+func EncryptFileData(data []byte, encryptionType uint8, key []byte, binding FileDataBinding) ([]byte, error) {
+	var stored bytes.Buffer
+	stored.Grow(len(data) + (len(data)/EncryptedChunkSize+1)*AEADTagSize + AEADNonceSize)
+	w, err := NewEncryptWriter(&stored, encryptionType, key, binding)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return stored.Bytes(), nil
+}
+
+// DecryptFileData decrypts stored file data in memory like NewDecryptReader and
+// returns the plaintext, which never shares memory with stored.
+func DecryptFileData(stored []byte, encryptionType uint8, key []byte, binding FileDataBinding) ([]byte, error) {
+	r, err := NewDecryptReader(bytes.NewReader(stored), int64(len(stored)), encryptionType, key, binding)
+	if err != nil {
+		return nil, err
+	}
+	plain := make([]byte, r.Size())
+	if _, err := r.ReadAt(plain, 0); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return plain, nil
+}
+
+// storedHybridFileKey reads the KEM header of stored quantum-safe file data and
+// derives the file key with the seed. It returns the key and the header size.
+func storedHybridFileKey(stored io.ReaderAt, storedSize int64, seed []byte) ([]byte, int64, error) {
+	var prefix [hybridKEMCiphertextLenSize]byte
+	if storedSize < hybridKEMCiphertextLenSize {
+		return nil, 0, hybridTruncatedError(int(storedSize))
+	}
+	if err := readStoredAt(stored, prefix[:], 0); err != nil {
+		return nil, 0, err
+	}
+	headerSize := int64(hybridKEMCiphertextLenSize) + int64(binary.LittleEndian.Uint16(prefix[:]))
+	if storedSize < headerSize {
+		return nil, 0, hybridTruncatedError(int(storedSize))
+	}
+	kemCiphertext := make([]byte, headerSize-hybridKEMCiphertextLenSize)
+	if err := readStoredAt(stored, kemCiphertext, hybridKEMCiphertextLenSize); err != nil {
+		return nil, 0, err
+	}
+	fileKey, err := hybridDecapsulate(kemCiphertext, seed)
+	if err != nil {
+		return nil, 0, err
+	}
+	return fileKey, headerSize, nil
+}
+
+// readStoredAt fills p from stored at off. A short read is reported as truncated
+// data.
+func readStoredAt(stored io.ReaderAt, p []byte, off int64) error {
+	n, err := stored.ReadAt(p, off)
+	switch {
+	case n == len(p):
+		return nil
+	case err == nil, errors.Is(err, io.EOF):
+		return pkgerrors.WrapErrorWithContext(io.ErrUnexpectedEOF, pkgerrors.ErrTypeCorruption, "encrypted data truncated", pkgerrors.ValidationErrorContext{
+			Field:    "StoredSize",
+			Value:    off + int64(n),
+			Expected: fmt.Sprintf("at least %d bytes", off+int64(len(p))),
+		})
+	default:
+		return pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to read encrypted data")
+	}
+}
+
+func encryptedDataTruncatedError(size int64) error {
+	return pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "encrypted data truncated", nil, pkgerrors.ValidationErrorContext{
+		Field:    "StoredSize",
+		Value:    size,
+		Expected: "at least nonce and authentication tag",
+	})
+}
+
+func encryptWriterClosedError() error {
+	return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "encrypt writer is closed", nil, struct{}{})
+}
+
+func encryptWriteError(err error) error {
+	return pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to write encrypted data")
+}
@@ -0,0 +1,284 @@
+// Package internal provides internal helper functions for the NovusPack implementation.
+//
+// This file contains unit tests for the chunked file data encryption.
+package internal
+
+import (
+	"bytes"
+	"crypto/mlkem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// streamTestPayload returns size bytes of distinct content.
+func streamTestPayload(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i*7 + i/EncryptedChunkSize)
+	}
+	return data
+}
+
+// TestEncryptFileData_RoundTrip tests chunked round trips for every cipher at chunk
+// boundaries, and the stored size of the framing.
+func TestEncryptFileData_RoundTrip(t *testing.T) {
+	seed := bytes.Repeat([]byte{0x5A}, MLKEMSeedSize)
+	ek, err := MLKEMEncapsulationKey(MLKEM768Level, seed)
+	if err != nil {
+		t.Fatalf("MLKEMEncapsulationKey() error = %v", err)
+	}
+	ciphers := []struct {
+		encryptionType uint8
+		encryptKey     []byte
+		decryptKey     []byte
+		headerSize     int
+	}{
+		{fileformat.EncryptionAES256GCM, bytes.Repeat([]byte{0x42}, AES256KeySize), bytes.Repeat([]byte{0x42}, AES256KeySize), 0},
+		{fileformat.EncryptionChaCha20Poly1305, bytes.Repeat([]byte{0x43}, ChaCha20Poly1305KeySize), bytes.Repeat([]byte{0x43}, ChaCha20Poly1305KeySize), 0},
+		{fileformat.EncryptionQuantumSafe, ek, seed, 2 + mlkem.CiphertextSize768},
+	}
+	binding := FileDataBinding{FileID: 7, KeyID: "dlc-key"}
+
+	for _, c := range ciphers {
+		for _, size := range []int{0, 1, EncryptedChunkSize, EncryptedChunkSize + 1, 3*EncryptedChunkSize - 5} {
+			payload := streamTestPayload(size)
+			stored, err := EncryptFileData(payload, c.encryptionType, c.encryptKey, binding)
+			if err != nil {
+				t.Fatalf("EncryptFileData(%d, %d bytes) error = %v", c.encryptionType, size, err)
+			}
+			chunks := max((size+EncryptedChunkSize-1)/EncryptedChunkSize, 1)
+			if want := c.headerSize + AEADNonceSize + size + chunks*AEADTagSize; len(stored) != want {
+				t.Errorf("EncryptFileData(%d, %d bytes) size = %d, want %d", c.encryptionType, size, len(stored), want)
+			}
+			got, err := DecryptFileData(stored, c.encryptionType, c.decryptKey, binding)
+			if err != nil {
+				t.Fatalf("DecryptFileData(%d, %d bytes) error = %v", c.encryptionType, size, err)
+			}
+			if !bytes.Equal(got, payload) {
+				t.Errorf("DecryptFileData(%d, %d bytes) content mismatch", c.encryptionType, size)
+			}
+		}
+	}
+}
+
+// TestEncryptWriter_SmallWrites tests that data written in pieces matches data
+// written at once.
+func TestEncryptWriter_SmallWrites(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, AES256KeySize)
+	binding := FileDataBinding{FileID: 3}
+	payload := streamTestPayload(2*EncryptedChunkSize + 100)
+
+	var stored bytes.Buffer
+	w, err := NewEncryptWriter(&stored, fileformat.EncryptionAES256GCM, key, binding)
+	if err != nil {
+		t.Fatalf("NewEncryptWriter() error = %v", err)
+	}
+	for rest := payload; len(rest) > 0; {
+		n := min(len(rest), 999)
+		if _, err := w.Write(rest[:n]); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		rest = rest[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err := w.Write([]byte("late")); err == nil {
+		t.Error("Write() after Close succeeded")
+	}
+
+	got, err := DecryptFileData(stored.Bytes(), fileformat.EncryptionAES256GCM, key, binding)
+	if err != nil {
+		t.Fatalf("DecryptFileData() error = %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Error("DecryptFileData() content mismatch")
+	}
+}
+
+// TestEncryptWriterAt_HeldHead tests that data written after its leading bytes, and
+// framed compression filling in its frame table, decrypts to the final content.
+func TestEncryptWriterAt_HeldHead(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, AES256KeySize)
+	binding := FileDataBinding{FileID: 5, KeyID: "k"}
+
+	for _, size := range []int{0, 10, 3*EncryptedChunkSize + 7} {
+		for _, head := range []int{0, 100, 2*EncryptedChunkSize + 5} {
+			head = min(head, size)
+			t.Run(fmt.Sprintf("size=%d/head=%d", size, head), func(t *testing.T) {
+				payload := streamTestPayload(size)
+				out, err := os.Create(filepath.Join(t.TempDir(), "sealed"))
+				if err != nil {
+					t.Fatalf("Create() error = %v", err)
+				}
+				defer func() { _ = out.Close() }()
+				w, err := NewEncryptWriterAt(out, fileformat.EncryptionAES256GCM, key, binding, int64(head))
+				if err != nil {
+					t.Fatalf("NewEncryptWriterAt() error = %v", err)
+				}
+				for off := head; off < size; off += 1000 {
+					if _, err := w.WriteAt(payload[off:min(off+1000, size)], int64(off)); err != nil {
+						t.Fatalf("WriteAt(%d) error = %v", off, err)
+					}
+				}
+				if _, err := w.WriteAt(payload[:head], 0); err != nil {
+					t.Fatalf("WriteAt(head) error = %v", err)
+				}
+				if err := w.Close(); err != nil {
+					t.Fatalf("Close() error = %v", err)
+				}
+
+				stored, err := os.ReadFile(out.Name())
+				if err != nil {
+					t.Fatalf("ReadFile() error = %v", err)
+				}
+				got, err := DecryptFileData(stored, fileformat.EncryptionAES256GCM, key, binding)
+				if err != nil {
+					t.Fatalf("DecryptFileData() error = %v", err)
+				}
+				if !bytes.Equal(got, payload) {
+					t.Errorf("DecryptFileData() = %d bytes, want %d matching bytes", len(got), len(payload))
+				}
+			})
+		}
+	}
+
+	t.Run("framed compression", func(t *testing.T) {
+		const frameSize = MinCompressionFrameSize
+		payload := framedTestPayload(40*frameSize + 9)
+		want, err := CompressFrames(payload, fileformat.CompressionZstd, 0, frameSize)
+		if err != nil {
+			t.Fatalf("CompressFrames() error = %v", err)
+		}
+		out, err := os.Create(filepath.Join(t.TempDir(), "frames"))
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		defer func() { _ = out.Close() }()
+		tableSize := FrameTableSize(FrameCount(uint64(len(payload)), frameSize))
+		w, err := NewEncryptWriterAt(out, fileformat.EncryptionAES256GCM, key, binding, int64(tableSize))
+		if err != nil {
+			t.Fatalf("NewEncryptWriterAt() error = %v", err)
+		}
+		if _, err := CompressFramesTo(w, bytes.NewReader(payload), fileformat.CompressionZstd, 0, frameSize, uint64(len(payload)), make([]byte, frameSize)); err != nil {
+			t.Fatalf("CompressFramesTo() error = %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		stored, err := os.ReadFile(out.Name())
+		if err != nil {
+			t.Fatalf("ReadFile() error = %v", err)
+		}
+		got, err := DecryptFileData(stored, fileformat.EncryptionAES256GCM, key, binding)
+		if err != nil {
+			t.Fatalf("DecryptFileData() error = %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Error("decrypted frames do not match CompressFrames")
+		}
+	})
+
+	t.Run("rewrite outside held chunks", func(t *testing.T) {
+		out, err := os.Create(filepath.Join(t.TempDir(), "sealed"))
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		defer func() { _ = out.Close() }()
+		w, err := NewEncryptWriterAt(out, fileformat.EncryptionAES256GCM, key, binding, 10)
+		if err != nil {
+			t.Fatalf("NewEncryptWriterAt() error = %v", err)
+		}
+		if _, err := w.WriteAt(streamTestPayload(2*EncryptedChunkSize), 0); err != nil {
+			t.Fatalf("WriteAt() error = %v", err)
+		}
+		_, err = w.WriteAt([]byte("late"), EncryptedChunkSize+1)
+		assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation, "WriteAt")
+		_, err = w.WriteAt([]byte("negative"), -1)
+		assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation, "WriteAt")
+	})
+}
+
+// TestDecryptReader_ReadAt tests random access across chunk boundaries.
+func TestDecryptReader_ReadAt(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, AES256KeySize)
+	binding := FileDataBinding{FileID: 9, KeyID: "k"}
+	payload := streamTestPayload(3*EncryptedChunkSize + 17)
+	stored, err := EncryptFileData(payload, fileformat.EncryptionAES256GCM, key, binding)
+	if err != nil {
+		t.Fatalf("EncryptFileData() error = %v", err)
+	}
+	r, err := NewDecryptReader(bytes.NewReader(stored), int64(len(stored)), fileformat.EncryptionAES256GCM, key, binding)
+	if err != nil {
+		t.Fatalf("NewDecryptReader() error = %v", err)
+	}
+	if r.Size() != int64(len(payload)) {
+		t.Fatalf("Size() = %d, want %d", r.Size(), len(payload))
+	}
+
+	for _, off := range []int{2*EncryptedChunkSize - 10, 5, EncryptedChunkSize, 3 * EncryptedChunkSize} {
+		buf := make([]byte, 40)
+		n, err := r.ReadAt(buf, int64(off))
+		want := payload[off:min(off+len(buf), len(payload))]
+		if n != len(want) || !bytes.Equal(buf[:n], want) {
+			t.Errorf("ReadAt(%d) = %d bytes, want %d matching bytes", off, n, len(want))
+		}
+		if n < len(buf) && !errors.Is(err, io.EOF) {
+			t.Errorf("ReadAt(%d) short read error = %v, want io.EOF", off, err)
+		}
+		if n == len(buf) && err != nil {
+			t.Errorf("ReadAt(%d) error = %v", off, err)
+		}
+	}
+}
+
+// TestDecryptReader_Errors tests that chunks are bound to their file, key, position
+// and the end of the data.
+func TestDecryptReader_Errors(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, AES256KeySize)
+	binding := FileDataBinding{FileID: 11, KeyID: "save-key"}
+	payload := streamTestPayload(3 * EncryptedChunkSize)
+	stored, err := EncryptFileData(payload, fileformat.EncryptionAES256GCM, key, binding)
+	if err != nil {
+		t.Fatalf("EncryptFileData() error = %v", err)
+	}
+	decrypt := func(data []byte, binding FileDataBinding) error {
+		_, err := DecryptFileData(data, fileformat.EncryptionAES256GCM, key, binding)
+		return err
+	}
+
+	assertPackageErrorType(t, decrypt(stored, FileDataBinding{FileID: 12, KeyID: "save-key"}), pkgerrors.ErrTypeEncryption, "other FileID")
+	assertPackageErrorType(t, decrypt(stored, FileDataBinding{FileID: 11, KeyID: "other-key"}), pkgerrors.ErrTypeEncryption, "other KeyID")
+
+	// Dropping the last chunk leaves a chunk that was not sealed as the last one
+	truncated := stored[:AEADNonceSize+2*encryptedChunkStoredSize]
+	assertPackageErrorType(t, decrypt(truncated, binding), pkgerrors.ErrTypeEncryption, "truncated at chunk boundary")
+
+	swapped := bytes.Clone(stored)
+	first := swapped[AEADNonceSize : AEADNonceSize+encryptedChunkStoredSize]
+	second := swapped[AEADNonceSize+encryptedChunkStoredSize : AEADNonceSize+2*encryptedChunkStoredSize]
+	tmp := bytes.Clone(first)
+	copy(first, second)
+	copy(second, tmp)
+	assertPackageErrorType(t, decrypt(swapped, binding), pkgerrors.ErrTypeEncryption, "reordered chunks")
+
+	assertPackageErrorType(t, decrypt(stored[:AEADNonceSize+AEADTagSize-1], binding), pkgerrors.ErrTypeCorruption, "truncated framing")
+	assertPackageErrorType(t, decrypt(stored[:AEADNonceSize+encryptedChunkStoredSize+3], binding), pkgerrors.ErrTypeCorruption, "partial tag")
+
+	empty, err := EncryptFileData(nil, fileformat.EncryptionAES256GCM, key, binding)
+	if err != nil {
+		t.Fatalf("EncryptFileData(empty) error = %v", err)
+	}
+	_, err = NewDecryptReader(bytes.NewReader(empty), int64(len(empty)), fileformat.EncryptionAES256GCM, key, FileDataBinding{FileID: 1})
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption, "empty file with other binding")
+
+	_, err = NewEncryptWriter(io.Discard, 0xFF, key, binding)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeUnsupported, "NewEncryptWriter")
+}
@@ -0,0 +1,102 @@
+// Package internal provides internal helper functions for the NovusPack implementation.
+//
+// This file contains unit tests for encryption helper functions.
+package internal
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// TestEncryptData_RoundTrip tests that each supported cipher round-trips data with a fresh nonce.
+func TestEncryptData_RoundTrip(t *testing.T) {
+	payload := []byte("paid DLC asset payload")
+
+	for _, encryptionType := range []uint8{fileformat.EncryptionAES256GCM, fileformat.EncryptionChaCha20Poly1305} {
+		key := bytes.Repeat([]byte{0x42}, EncryptionKeySize(encryptionType))
+
+		first, err := EncryptData(payload, encryptionType, key)
+		if err != nil {
+			t.Fatalf("EncryptData(%d) error = %v", encryptionType, err)
+		}
+		if len(first) != AEADNonceSize+len(payload)+AEADTagSize {
+			t.Errorf("EncryptData(%d) size = %d, want %d", encryptionType, len(first), AEADNonceSize+len(payload)+AEADTagSize)
+		}
+		if bytes.Contains(first, payload) {
+			t.Errorf("EncryptData(%d) output contains plaintext", encryptionType)
+		}
+		second, err := EncryptData(payload, encryptionType, key)
+		if err != nil {
+			t.Fatalf("EncryptData(%d) error = %v", encryptionType, err)
+		}
+		if bytes.Equal(first[:AEADNonceSize], second[:AEADNonceSize]) {
+			t.Errorf("EncryptData(%d) reused a nonce", encryptionType)
+		}
+
+		got, err := DecryptData(first, encryptionType, key)
+		if err != nil {
+			t.Fatalf("DecryptData(%d) error = %v", encryptionType, err)
+		}
+		if !bytes.Equal(got, payload) {
+			t.Errorf("DecryptData(%d) content mismatch", encryptionType)
+		}
+	}
+
+	plain, err := EncryptData(payload, fileformat.EncryptionNone, nil)
+	if err != nil || !bytes.Equal(plain, payload) {
+		t.Errorf("EncryptData(None) = %q, %v; want data unchanged", plain, err)
+	}
+}
+
+// TestDecryptData_Errors tests decryption error conditions.
+func TestDecryptData_Errors(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, AES256KeySize)
+	stored, err := EncryptData([]byte("secret"), fileformat.EncryptionAES256GCM, key)
+	if err != nil {
+		t.Fatalf("EncryptData() error = %v", err)
+	}
+
+	wrongKey := bytes.Repeat([]byte{0x43}, AES256KeySize)
+	_, err = DecryptData(stored, fileformat.EncryptionAES256GCM, wrongKey)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption, "DecryptData")
+
+	tampered := bytes.Clone(stored)
+	tampered[AEADNonceSize] ^= 0x01
+	_, err = DecryptData(tampered, fileformat.EncryptionAES256GCM, key)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption, "DecryptData")
+
+	_, err = DecryptData(stored[:AEADNonceSize], fileformat.EncryptionAES256GCM, key)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption, "DecryptData")
+
+	_, err = DecryptData(stored, fileformat.EncryptionAES256GCM, key[:16])
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption, "DecryptData")
+
+	_, err = EncryptData([]byte("secret"), 0xFF, key)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeUnsupported, "EncryptData")
+}
+
+// TestDecryptData_ChaCha20Poly1305 tests that ChaCha20-Poly1305 data only opens with
+// its own cipher and key.
+func TestDecryptData_ChaCha20Poly1305(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, ChaCha20Poly1305KeySize)
+	stored, err := EncryptData([]byte("secret"), fileformat.EncryptionChaCha20Poly1305, key)
+	if err != nil {
+		t.Fatalf("EncryptData() error = %v", err)
+	}
+
+	_, err = DecryptData(stored, fileformat.EncryptionAES256GCM, key)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption, "DecryptData")
+
+	_, err = DecryptData(stored, fileformat.EncryptionChaCha20Poly1305, bytes.Repeat([]byte{0x43}, ChaCha20Poly1305KeySize))
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption, "DecryptData")
+
+	_, err = DecryptData(stored, fileformat.EncryptionChaCha20Poly1305, key[:16])
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption, "DecryptData")
+
+	if !IsSupportedEncryptionType(fileformat.EncryptionChaCha20Poly1305) || IsSupportedEncryptionType(0xFF) {
+		t.Error("IsSupportedEncryptionType() mismatch for ChaCha20-Poly1305 or unknown type")
+	}
+}
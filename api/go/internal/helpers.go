@@ -191,6 +191,17 @@ func validatePackageHeader(header *fileformat.PackageHeader) error {
 // LoadFileEntry loads a FileEntry from the specified offset in the file.
 // Returns the FileEntry or an error if loading fails.
 func LoadFileEntry(file *os.File, offset uint64) (*metadata.FileEntry, error) {
+	return loadFileEntry(file, offset, (*metadata.FileEntry).ReadFrom)
+}
+
+// LoadFileEntryWithEncryptedPaths loads a FileEntry of a package with a
+// confidential index from the specified offset in the file. The encrypted path
+// section is kept in EncryptedPaths.
+func LoadFileEntryWithEncryptedPaths(file *os.File, offset uint64) (*metadata.FileEntry, error) {
+	return loadFileEntry(file, offset, (*metadata.FileEntry).ReadFromEncrypted)
+}
+
+func loadFileEntry(file *os.File, offset uint64, readFrom func(*metadata.FileEntry, io.Reader) (int64, error)) (*metadata.FileEntry, error) {
 	// Seek to entry offset
 	if _, err := file.Seek(int64(offset), 0); err != nil {
 		return nil, pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeIO, "failed to seek to file entry offset", pkgerrors.ValidationErrorContext{
@@ -202,7 +213,7 @@ func LoadFileEntry(file *os.File, offset uint64) (*metadata.FileEntry, error) {
 
 	// Create new FileEntry and read from file
 	entry := metadata.NewFileEntry()
-	_, err := entry.ReadFrom(file)
+	_, err := readFrom(entry, file)
 	if err != nil {
 		return nil, pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeIO, "failed to read file entry", pkgerrors.ValidationErrorContext{
 			Field:    "FileEntry",
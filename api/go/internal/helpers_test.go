@@ -21,6 +21,21 @@ import (
 	"github.com/novus-engine/novuspack/api/go/pkgerrors"
 )
 
+// assertPackageErrorType verifies err is a *PackageError of the wanted type.
+func assertPackageErrorType(t *testing.T, err error, want pkgerrors.ErrorType, fnName string) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("%s() expected error, got nil", fnName)
+	}
+	var pkgErr *pkgerrors.PackageError
+	if !pkgerrors.As(err, &pkgErr) {
+		t.Fatalf("%s() error is not a PackageError: %v", fnName, err)
+	}
+	if pkgErr.Type != want {
+		t.Fatalf("%s() error type = %v, want %v", fnName, pkgErr.Type, want)
+	}
+}
+
 func assertPathValidationError(t *testing.T, err error, shouldError bool, errorType pkgerrors.ErrorType, fnName string) {
 	t.Helper()
 	if shouldError {
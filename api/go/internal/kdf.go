@@ -0,0 +1,50 @@
+// This file contains the passphrase key derivation function used to turn a
+// package passphrase into an AES-256 key. This file should contain only key
+// derivation plumbing; parameter storage and key management belong in the
+// callers.
+//
+// Specification: api_security.md: 7.1 Passphrase KDF File
+
+package internal
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha256"
+
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+const (
+	// KDFPBKDF2SHA256 identifies PBKDF2 with HMAC-SHA256 in the passphrase KDF file.
+	KDFPBKDF2SHA256 = 0x01
+
+	// PassphraseSaltSize is the size of the random salt generated for a passphrase.
+	PassphraseSaltSize = 16
+)
+
+// DerivePassphraseKey derives an AES-256 key from passphrase with PBKDF2-HMAC-SHA256.
+// Returns ErrTypeValidation if the passphrase or salt is empty or iterations is
+// not positive.
+func DerivePassphraseKey(passphrase string, salt []byte, iterations int) ([]byte, error) {
+	switch {
+	case passphrase == "":
+		return nil, kdfParameterError("passphrase", "", "non-empty passphrase")
+	case len(salt) == 0:
+		return nil, kdfParameterError("salt", 0, "non-empty salt")
+	case iterations <= 0:
+		return nil, kdfParameterError("iterations", iterations, "positive iteration count")
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, AES256KeySize)
+	if err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeEncryption, "passphrase key derivation failed")
+	}
+	return key, nil
+}
+
+func kdfParameterError(field string, value any, expected string) error {
+	return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "invalid passphrase key derivation parameter", nil, pkgerrors.ValidationErrorContext{
+		Field:    field,
+		Value:    value,
+		Expected: expected,
+	})
+}
@@ -0,0 +1,43 @@
+// Package internal provides internal helper functions for the NovusPack implementation.
+//
+// This file contains unit tests for passphrase key derivation.
+package internal
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// TestDerivePassphraseKey tests PBKDF2-HMAC-SHA256 against a published test vector
+// ("password", "salt", 4096 iterations).
+func TestDerivePassphraseKey(t *testing.T) {
+	want, _ := hex.DecodeString("c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a")
+	got, err := DerivePassphraseKey("password", []byte("salt"), 4096)
+	if err != nil {
+		t.Fatalf("DerivePassphraseKey() error = %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("DerivePassphraseKey() = %x, want %x", got, want)
+	}
+
+	other, err := DerivePassphraseKey("password", []byte("pepper"), 4096)
+	if err != nil {
+		t.Fatalf("DerivePassphraseKey() error = %v", err)
+	}
+	if bytes.Equal(got, other) {
+		t.Error("DerivePassphraseKey() ignored the salt")
+	}
+}
+
+// TestDerivePassphraseKey_Errors tests parameter validation.
+func TestDerivePassphraseKey_Errors(t *testing.T) {
+	_, err := DerivePassphraseKey("", []byte("salt"), 1)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation, "DerivePassphraseKey")
+	_, err = DerivePassphraseKey("password", nil, 1)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation, "DerivePassphraseKey")
+	_, err = DerivePassphraseKey("password", []byte("salt"), 0)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation, "DerivePassphraseKey")
+}
@@ -0,0 +1,107 @@
+// This file contains the AES key wrap algorithm (RFC 3394) used to wrap package
+// content keys for symmetric key envelope recipients. This file should contain
+// only key wrap plumbing; envelope encoding and key management belong in the
+// callers.
+//
+// Specification: api_security.md: 6.1 Key Envelope File
+
+package internal
+
+import (
+	"crypto/aes"
+	"crypto/subtle"
+	"encoding/binary"
+
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+const (
+	// KeyWrapBlockSize is the size of a key wrap semiblock in bytes.
+	KeyWrapBlockSize = 8
+
+	// KeyWrapOverhead is the number of bytes wrapping adds to the key.
+	KeyWrapOverhead = KeyWrapBlockSize
+)
+
+// keyWrapIV is the default initial value from RFC 3394 section 2.2.3.1.
+var keyWrapIV = [KeyWrapBlockSize]byte{0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6}
+
+// WrapKey wraps key with the AES key-encryption key kek (RFC 3394).
+// The key must be a multiple of 8 bytes and at least 16 bytes long.
+// Returns ErrTypeEncryption for invalid key or KEK sizes.
+func WrapKey(kek, key []byte) ([]byte, error) {
+	if len(key) < 2*KeyWrapBlockSize || len(key)%KeyWrapBlockSize != 0 {
+		return nil, keyWrapSizeError("key", len(key))
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeEncryption, "invalid key-encryption key")
+	}
+
+	n := len(key) / KeyWrapBlockSize
+	out := make([]byte, KeyWrapBlockSize+len(key))
+	copy(out, keyWrapIV[:])
+	copy(out[KeyWrapBlockSize:], key)
+
+	var buf [2 * KeyWrapBlockSize]byte
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(buf[:KeyWrapBlockSize], out[:KeyWrapBlockSize])
+			copy(buf[KeyWrapBlockSize:], out[i*KeyWrapBlockSize:])
+			block.Encrypt(buf[:], buf[:])
+
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(out, binary.BigEndian.Uint64(buf[:KeyWrapBlockSize])^t)
+			copy(out[i*KeyWrapBlockSize:], buf[KeyWrapBlockSize:])
+		}
+	}
+	return out, nil
+}
+
+// UnwrapKey unwraps a key produced by WrapKey with the key-encryption key kek.
+// Returns ErrTypeEncryption if the integrity check fails (wrong KEK or modified
+// data) or the sizes are invalid.
+func UnwrapKey(kek, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < 3*KeyWrapBlockSize || len(wrapped)%KeyWrapBlockSize != 0 {
+		return nil, keyWrapSizeError("wrapped", len(wrapped))
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeEncryption, "invalid key-encryption key")
+	}
+
+	n := len(wrapped)/KeyWrapBlockSize - 1
+	out := make([]byte, len(wrapped))
+	copy(out, wrapped)
+
+	var buf [2 * KeyWrapBlockSize]byte
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(buf[:KeyWrapBlockSize], binary.BigEndian.Uint64(out)^t)
+			copy(buf[KeyWrapBlockSize:], out[i*KeyWrapBlockSize:])
+			block.Decrypt(buf[:], buf[:])
+
+			copy(out, buf[:KeyWrapBlockSize])
+			copy(out[i*KeyWrapBlockSize:], buf[KeyWrapBlockSize:])
+		}
+	}
+
+	if subtle.ConstantTimeCompare(out[:KeyWrapBlockSize], keyWrapIV[:]) != 1 {
+		clear(out)
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeEncryption, "key unwrap failed: wrong key or modified data", nil, pkgerrors.ValidationErrorContext{
+			Field:    "wrapped",
+			Value:    len(wrapped),
+			Expected: "key wrapped with the supplied key-encryption key",
+		})
+	}
+	return out[KeyWrapBlockSize:], nil
+}
+
+func keyWrapSizeError(field string, size int) error {
+	return pkgerrors.NewPackageError(pkgerrors.ErrTypeEncryption, "invalid key wrap input size", nil, pkgerrors.ValidationErrorContext{
+		Field:    field,
+		Value:    size,
+		Expected: "multiple of 8 bytes, at least 16 bytes of key data",
+	})
+}
@@ -0,0 +1,65 @@
+// Package internal provides internal helper functions for the NovusPack implementation.
+//
+// This file contains unit tests for AES key wrap.
+package internal
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// TestWrapKey_RFC3394 tests key wrap against the RFC 3394 section 4.6 test vector
+// (256 bits of key data with a 256-bit KEK).
+func TestWrapKey_RFC3394(t *testing.T) {
+	kek, _ := hex.DecodeString("000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F")
+	key, _ := hex.DecodeString("00112233445566778899AABBCCDDEEFF000102030405060708090A0B0C0D0E0F")
+	want, _ := hex.DecodeString("28C9F404C4B810F4CBCCB35CFB87F8263F5786E2D80ED326CBC7F0E71A99F43BFB988B9B7A02DD21")
+
+	wrapped, err := WrapKey(kek, key)
+	if err != nil {
+		t.Fatalf("WrapKey() error = %v", err)
+	}
+	if !bytes.Equal(wrapped, want) {
+		t.Errorf("WrapKey() = %X, want %X", wrapped, want)
+	}
+	if len(wrapped) != len(key)+KeyWrapOverhead {
+		t.Errorf("WrapKey() size = %d, want %d", len(wrapped), len(key)+KeyWrapOverhead)
+	}
+
+	got, err := UnwrapKey(kek, wrapped)
+	if err != nil {
+		t.Fatalf("UnwrapKey() error = %v", err)
+	}
+	if !bytes.Equal(got, key) {
+		t.Errorf("UnwrapKey() = %X, want %X", got, key)
+	}
+}
+
+// TestUnwrapKey_Errors tests key unwrap error conditions.
+func TestUnwrapKey_Errors(t *testing.T) {
+	kek := bytes.Repeat([]byte{0x11}, AES256KeySize)
+	wrapped, err := WrapKey(kek, bytes.Repeat([]byte{0x22}, AES256KeySize))
+	if err != nil {
+		t.Fatalf("WrapKey() error = %v", err)
+	}
+
+	_, err = UnwrapKey(bytes.Repeat([]byte{0x12}, AES256KeySize), wrapped)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption, "UnwrapKey")
+
+	tampered := bytes.Clone(wrapped)
+	tampered[len(tampered)-1] ^= 0x01
+	_, err = UnwrapKey(kek, tampered)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption, "UnwrapKey")
+
+	_, err = UnwrapKey(kek, wrapped[:16])
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption, "UnwrapKey")
+
+	_, err = WrapKey(kek, make([]byte, 20))
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption, "WrapKey")
+
+	_, err = WrapKey(kek[:7], make([]byte, 32))
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption, "WrapKey")
+}
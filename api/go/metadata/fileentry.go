@@ -117,6 +117,12 @@ type FileEntry struct {
 	// Specification: package_file_format.md: 4.1.4.4 Optional Data
 	OptionalData []OptionalDataEntry
 
+	// EncryptedPaths is the encrypted path section of an entry in a package with a
+	// confidential index. Paths is empty until the section is decrypted; when set,
+	// the section is written as-is in place of Paths.
+	// Specification: package_file_format.md: 4.1.4.5 Encrypted Path Section
+	EncryptedPaths []byte
+
 	// Data management (runtime only, not stored in file)
 	// Specification: api_file_mgmt_file_entry.md: 1.1 FileEntry Structure Definition
 	EntryOffset     uint64          // Absolute offset to the FileEntry metadata start in the package file
@@ -207,7 +213,7 @@ func (f *FileEntry) Validate() error {
 		})
 	}
 
-	if f.PathCount != uint16(len(f.Paths)) {
+	if !f.pathsEncrypted() && f.PathCount != uint16(len(f.Paths)) {
 		return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "path count mismatch", nil, pkgerrors.ValidationErrorContext{
 			Field:    "PathCount",
 			Value:    f.PathCount,
@@ -270,12 +276,25 @@ func (f *FileEntry) FixedSize() int {
 //
 // Specification: package_file_format.md: 4.1.4 Variable-Length Data (follows fixed structure)
 func (f *FileEntry) VariableSize() int {
-	pathSize := lo.SumBy(f.Paths, func(p generics.PathEntry) int { return p.Size() })
+	pathSize := f.pathSectionSize()
 	hashSize := lo.SumBy(f.Hashes, func(h HashEntry) int { return h.size() })
 	optSize := lo.SumBy(f.OptionalData, func(o OptionalDataEntry) int { return o.size() })
 	return pathSize + hashSize + optSize
 }
 
+// pathsEncrypted reports whether the entry holds only the encrypted path section.
+func (f *FileEntry) pathsEncrypted() bool {
+	return f.EncryptedPaths != nil && len(f.Paths) == 0
+}
+
+// pathSectionSize returns the stored size of the path section.
+func (f *FileEntry) pathSectionSize() int {
+	if f.EncryptedPaths != nil {
+		return len(f.EncryptedPaths)
+	}
+	return lo.SumBy(f.Paths, func(p generics.PathEntry) int { return p.Size() })
+}
+
 // TotalSize returns the total size of the FileEntry (fixed + variable).
 //
 // Specification: package_file_format.md: 4.1 FileEntry Binary Format Specification
@@ -425,6 +444,35 @@ func (f *FileEntry) ReadFrom(r io.Reader) (int64, error) {
 	return readFileEntryOptionalData(r, f, totalRead, pathsSize+hashSize)
 }
 
+// ReadFromEncrypted reads a FileEntry whose path section is encrypted (confidential
+// index). The path section, HashDataOffset bytes long, is stored in EncryptedPaths
+// and Paths is left empty; hashes and optional data are read as in ReadFrom.
+//
+// Returns the number of bytes read and any error encountered.
+//
+// Specification: api_file_mgmt_file_entry.md: 6.6.6 FileEntry.ReadFromEncrypted Method
+func (f *FileEntry) ReadFromEncrypted(r io.Reader) (int64, error) {
+	totalRead, err := readFileEntryFixed(r, f)
+	if err != nil {
+		return totalRead, err
+	}
+	f.EncryptedPaths = make([]byte, f.HashDataOffset)
+	n, err := io.ReadFull(r, f.EncryptedPaths)
+	totalRead += int64(n)
+	if err != nil {
+		return totalRead, pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeCorruption, "failed to read encrypted path section", pkgerrors.ValidationErrorContext{
+			Field: "HashDataOffset", Value: f.HashDataOffset, Expected: "complete encrypted path section",
+		})
+	}
+	pathsSize := int64(len(f.EncryptedPaths))
+	totalRead, err = readFileEntryHashes(r, f, totalRead, pathsSize)
+	if err != nil {
+		return totalRead, err
+	}
+	hashSize := int64(lo.SumBy(f.Hashes, func(h HashEntry) int { return h.size() }))
+	return readFileEntryOptionalData(r, f, totalRead, pathsSize+hashSize)
+}
+
 // WriteTo writes both metadata and data to a writer.
 //
 // Writes both metadata and data to a writer.
@@ -137,15 +137,15 @@ func (f *FileEntry) Marshal() (metadata, data []byte, err error) {
 func (f *FileEntry) WriteMetaTo(w io.Writer) (int64, error) {
 	var totalWritten int64
 
-	// Update counts to match actual data
-	f.PathCount = uint16(len(f.Paths))
+	// Update counts to match actual data; PathCount of an entry whose paths are
+	// still encrypted is kept as read
+	if !f.pathsEncrypted() {
+		f.PathCount = uint16(len(f.Paths))
+	}
 	f.HashCount = uint8(len(f.Hashes))
 
 	// Calculate sizes and offsets
-	pathsSize := 0
-	for _, p := range f.Paths {
-		pathsSize += p.Size()
-	}
+	pathsSize := f.pathSectionSize()
 
 	hashSize := 0
 	for _, h := range f.Hashes {
@@ -192,11 +192,23 @@ func (f *FileEntry) WriteMetaTo(w io.Writer) (int64, error) {
 	}
 	totalWritten += FileEntryFixedSize
 
-	// Write path entries (starting at offset 0)
+	// Write path entries (starting at offset 0), or the encrypted path section
 	var err error
-	totalWritten, err = writeSliceToWriter(w, totalWritten, len(f.Paths), "Paths", func(i int) (int64, error) { return f.Paths[i].WriteTo(w) })
-	if err != nil {
-		return totalWritten, err
+	if f.EncryptedPaths != nil {
+		n, err := w.Write(f.EncryptedPaths)
+		totalWritten += int64(n)
+		if err != nil {
+			return totalWritten, pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeIO, "failed to write encrypted path section", pkgerrors.ValidationErrorContext{
+				Field:    "EncryptedPaths",
+				Value:    len(f.EncryptedPaths),
+				Expected: "written successfully",
+			})
+		}
+	} else {
+		totalWritten, err = writeSliceToWriter(w, totalWritten, len(f.Paths), "Paths", func(i int) (int64, error) { return f.Paths[i].WriteTo(w) })
+		if err != nil {
+			return totalWritten, err
+		}
 	}
 
 	// Write hash entries (starting at HashDataOffset)
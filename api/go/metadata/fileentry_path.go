@@ -9,6 +9,8 @@
 package metadata
 
 import (
+	"bytes"
+	"fmt"
 	"path/filepath"
 	"strings"
 
@@ -188,3 +190,52 @@ func (f *FileEntry) GetPaths() []generics.PathEntry {
 func (f *FileEntry) GetFileID() uint64 {
 	return f.FileID
 }
+
+// MarshalPaths serializes Paths in the path section encoding.
+//
+// Returns:
+//   - []byte: Path entries as stored at the start of the variable-length data
+//   - error: *PackageError if a path entry cannot be written
+//
+// Specification: api_file_mgmt_file_entry.md: 6.1.4 FileEntry.MarshalPaths Method
+func (f *FileEntry) MarshalPaths() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := writeSliceToWriter(&buf, 0, len(f.Paths), "Paths", func(i int) (int64, error) { return f.Paths[i].WriteTo(&buf) }); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalPaths parses a plaintext path section of PathCount entries and
+// replaces Paths with it. Paths is left unchanged on error.
+//
+// Returns:
+//   - error: *PackageError with ErrTypeCorruption if section does not hold
+//     exactly PathCount valid path entries
+//
+// Specification: api_file_mgmt_file_entry.md: 6.6.7 FileEntry.UnmarshalPaths Method
+func (f *FileEntry) UnmarshalPaths(section []byte) error {
+	r := bytes.NewReader(section)
+	paths := make([]generics.PathEntry, 0, f.PathCount)
+	for i := uint16(0); i < f.PathCount; i++ {
+		var path generics.PathEntry
+		if _, err := path.ReadFrom(r); err != nil {
+			return pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeCorruption, "failed to read path entry from path section", pkgerrors.ValidationErrorContext{
+				Field: "Paths", Value: i, Expected: "valid path entry",
+			})
+		}
+		if err := path.Validate(); err != nil {
+			return pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeCorruption, "invalid path entry in path section", pkgerrors.ValidationErrorContext{
+				Field: "Paths", Value: i, Expected: "valid path entry",
+			})
+		}
+		paths = append(paths, path)
+	}
+	if r.Len() != 0 {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "trailing data in path section", nil, pkgerrors.ValidationErrorContext{
+			Field: "Paths", Value: r.Len(), Expected: fmt.Sprintf("exactly %d path entries", f.PathCount),
+		})
+	}
+	f.Paths = paths
+	return nil
+}
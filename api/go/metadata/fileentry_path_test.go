@@ -1,6 +1,7 @@
 package metadata
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/novus-engine/novuspack/api/go/generics"
@@ -247,3 +248,69 @@ func TestGetFileID(t *testing.T) {
 		t.Errorf("GetFileID() = %d, want %d", got, 12345)
 	}
 }
+
+// TestMarshalPathsRoundTrip tests MarshalPaths and UnmarshalPaths
+func TestMarshalPathsRoundTrip(t *testing.T) {
+	fe := fileEntryWithTwoPathsFirstSymlink("/link.bin", "/data.bin", "/data.bin")
+	for i := range fe.Paths {
+		fe.Paths[i].PathLength = uint16(len(fe.Paths[i].Path))
+	}
+	fe.PathCount = 2
+	section, err := fe.MarshalPaths()
+	if err != nil {
+		t.Fatalf("MarshalPaths() error = %v", err)
+	}
+
+	got := NewFileEntry()
+	got.PathCount = 2
+	if err := got.UnmarshalPaths(section); err != nil {
+		t.Fatalf("UnmarshalPaths() error = %v", err)
+	}
+	if len(got.Paths) != 2 || got.Paths[0].Path != "/link.bin" || got.Paths[1].Path != "/data.bin" {
+		t.Errorf("UnmarshalPaths() paths = %+v", got.Paths)
+	}
+
+	truncated := NewFileEntry()
+	truncated.PathCount = 2
+	if err := truncated.UnmarshalPaths(section[:len(section)-1]); err == nil || truncated.Paths != nil {
+		t.Errorf("UnmarshalPaths(truncated) error = %v, paths = %+v; want error and no paths", err, truncated.Paths)
+	}
+	if err := got.UnmarshalPaths(append(section, 0)); err == nil {
+		t.Error("UnmarshalPaths(trailing data) expected error")
+	}
+}
+
+// TestReadFromEncrypted tests reading an entry with an encrypted path section
+func TestReadFromEncrypted(t *testing.T) {
+	fe := NewFileEntry()
+	fe.FileID = 7
+	fe.Paths = []generics.PathEntry{{PathLength: 9, Path: "/data.bin"}}
+	fe.PathCount = 1
+	fe.EncryptedPaths = []byte("opaque encrypted path section")
+	var buf bytes.Buffer
+	if _, err := fe.WriteMetaTo(&buf); err != nil {
+		t.Fatalf("WriteMetaTo() error = %v", err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("/data.bin")) {
+		t.Error("WriteMetaTo() wrote the plaintext path")
+	}
+
+	got := NewFileEntry()
+	n, err := got.ReadFromEncrypted(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadFromEncrypted() error = %v", err)
+	}
+	if n != int64(buf.Len()) || got.FileID != 7 || got.PathCount != 1 || len(got.Paths) != 0 {
+		t.Errorf("ReadFromEncrypted() = %d, entry = %+v", n, got)
+	}
+	if !bytes.Equal(got.EncryptedPaths, fe.EncryptedPaths) || got.TotalSize() != buf.Len() {
+		t.Errorf("EncryptedPaths = %q, TotalSize() = %d", got.EncryptedPaths, got.TotalSize())
+	}
+	if err := got.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	if _, err := NewFileEntry().ReadFromEncrypted(bytes.NewReader(buf.Bytes()[:buf.Len()-4])); err == nil {
+		t.Error("ReadFromEncrypted(truncated) expected error")
+	}
+}
@@ -18,7 +18,11 @@ import (
 // Optional data type constants
 // Specification: package_file_format.md: 4.1.4.4 Optional Data
 const (
-	OptionalDataTagsData = 0x00 // Per-file tags data
+	OptionalDataTagsData                = 0x00 // Per-file tags data
+	OptionalDataCompressionDictionaryID = 0x03 // Compression dictionary identifier (4 bytes)
+	OptionalDataSolidGroupID            = 0x04 // Solid compression group identifier and offset (8 bytes)
+	OptionalDataCompressionFrameSize    = 0x09 // Seekable compression frame size (4 bytes)
+	OptionalDataEncryptionKeyID         = 0x0A // Identifier of the key the file data is encrypted with (variable)
 )
 
 // OptionalDataEntry represents rarely-used file attributes.
@@ -148,3 +152,156 @@ func (o *OptionalDataEntry) writeTo(w io.Writer) (int64, error) {
 
 	return totalWritten, nil
 }
+
+// GetCompressionDictionaryID returns the compression dictionary identifier referenced by
+// the FileEntry. Returns false if the FileEntry has no well-formed CompressionDictionaryID
+// optional data entry.
+//
+// Specification: api_file_mgmt_file_entry.md: 17.1 FileEntry.GetCompressionDictionaryID Method
+func (f *FileEntry) GetCompressionDictionaryID() (uint32, bool) {
+	return f.getOptionalDataUint32(OptionalDataCompressionDictionaryID)
+}
+
+// SetCompressionDictionaryID sets the compression dictionary identifier referenced by
+// the FileEntry, replacing any existing CompressionDictionaryID optional data entry.
+//
+// Specification: api_file_mgmt_file_entry.md: 17.2 FileEntry.SetCompressionDictionaryID Method
+func (f *FileEntry) SetCompressionDictionaryID(id uint32) {
+	f.setOptionalDataUint32(OptionalDataCompressionDictionaryID, id)
+}
+
+// ClearCompressionDictionaryID removes the CompressionDictionaryID optional data entry.
+//
+// Specification: api_file_mgmt_file_entry.md: 17.3 FileEntry.ClearCompressionDictionaryID Method
+func (f *FileEntry) ClearCompressionDictionaryID() {
+	f.removeOptionalDataType(OptionalDataCompressionDictionaryID)
+}
+
+// GetSolidGroup returns the solid compression group the FileEntry belongs to and the
+// offset of its content inside the group's decompressed block. Returns false if the
+// FileEntry has no well-formed SolidGroupID optional data entry.
+//
+// The SolidGroupID data is [GroupID: 4 bytes][Offset: 4 bytes], little-endian.
+//
+// Specification: api_file_mgmt_file_entry.md: 17.4 FileEntry.GetSolidGroup Method
+func (f *FileEntry) GetSolidGroup() (groupID uint32, offset uint32, ok bool) {
+	data, ok := f.getOptionalData(OptionalDataSolidGroupID)
+	if !ok || len(data) != 8 {
+		return 0, 0, false
+	}
+	return binary.LittleEndian.Uint32(data), binary.LittleEndian.Uint32(data[4:]), true
+}
+
+// SetSolidGroup sets the solid compression group and block offset of the FileEntry,
+// replacing any existing SolidGroupID optional data entry.
+//
+// Specification: api_file_mgmt_file_entry.md: 17.5 FileEntry.SetSolidGroup Method
+func (f *FileEntry) SetSolidGroup(groupID uint32, offset uint32) {
+	data := binary.LittleEndian.AppendUint32(nil, groupID)
+	data = binary.LittleEndian.AppendUint32(data, offset)
+	f.setOptionalData(OptionalDataSolidGroupID, data)
+}
+
+// ClearSolidGroup removes the SolidGroupID optional data entry.
+//
+// Specification: api_file_mgmt_file_entry.md: 17.6 FileEntry.ClearSolidGroup Method
+func (f *FileEntry) ClearSolidGroup() {
+	f.removeOptionalDataType(OptionalDataSolidGroupID)
+}
+
+// GetCompressionFrameSize returns the frame size of a FileEntry stored with seekable
+// (framed) compression. Returns false if the FileEntry has no well-formed
+// CompressionFrameSize optional data entry.
+//
+// Specification: api_file_mgmt_file_entry.md: 17.7 FileEntry.GetCompressionFrameSize Method
+func (f *FileEntry) GetCompressionFrameSize() (uint32, bool) {
+	return f.getOptionalDataUint32(OptionalDataCompressionFrameSize)
+}
+
+// SetCompressionFrameSize sets the seekable compression frame size of the FileEntry,
+// replacing any existing CompressionFrameSize optional data entry.
+//
+// Specification: api_file_mgmt_file_entry.md: 17.8 FileEntry.SetCompressionFrameSize Method
+func (f *FileEntry) SetCompressionFrameSize(frameSize uint32) {
+	f.setOptionalDataUint32(OptionalDataCompressionFrameSize, frameSize)
+}
+
+// ClearCompressionFrameSize removes the CompressionFrameSize optional data entry.
+//
+// Specification: api_file_mgmt_file_entry.md: 17.9 FileEntry.ClearCompressionFrameSize Method
+func (f *FileEntry) ClearCompressionFrameSize() {
+	f.removeOptionalDataType(OptionalDataCompressionFrameSize)
+}
+
+// GetEncryptionKeyID returns the identifier of the key the FileEntry's data is
+// encrypted with. Returns false if the FileEntry has no EncryptionKeyID optional
+// data entry.
+//
+// Specification: api_file_mgmt_file_entry.md: 17.10 FileEntry.GetEncryptionKeyID Method
+func (f *FileEntry) GetEncryptionKeyID() (string, bool) {
+	data, ok := f.getOptionalData(OptionalDataEncryptionKeyID)
+	if !ok || len(data) == 0 {
+		return "", false
+	}
+	return string(data), true
+}
+
+// SetEncryptionKeyID sets the identifier of the key the FileEntry's data is
+// encrypted with, replacing any existing EncryptionKeyID optional data entry.
+//
+// Specification: api_file_mgmt_file_entry.md: 17.11 FileEntry.SetEncryptionKeyID Method
+func (f *FileEntry) SetEncryptionKeyID(keyID string) {
+	f.setOptionalData(OptionalDataEncryptionKeyID, []byte(keyID))
+}
+
+// ClearEncryptionKeyID removes the EncryptionKeyID optional data entry.
+//
+// Specification: api_file_mgmt_file_entry.md: 17.12 FileEntry.ClearEncryptionKeyID Method
+func (f *FileEntry) ClearEncryptionKeyID() {
+	f.removeOptionalDataType(OptionalDataEncryptionKeyID)
+}
+
+// getOptionalData returns the data of the first optional data entry of dataType.
+func (f *FileEntry) getOptionalData(dataType uint8) ([]byte, bool) {
+	for _, opt := range f.OptionalData {
+		if opt.DataType == dataType {
+			return opt.Data, true
+		}
+	}
+	return nil, false
+}
+
+// getOptionalDataUint32 returns the little-endian uint32 value of the first optional
+// data entry of dataType. Returns false if there is no entry or it is not 4 bytes.
+func (f *FileEntry) getOptionalDataUint32(dataType uint8) (uint32, bool) {
+	data, ok := f.getOptionalData(dataType)
+	if !ok || len(data) != 4 {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint32(data), true
+}
+
+// setOptionalDataUint32 replaces all optional data entries of dataType with a single
+// 4-byte little-endian entry holding value.
+func (f *FileEntry) setOptionalDataUint32(dataType uint8, value uint32) {
+	f.setOptionalData(dataType, binary.LittleEndian.AppendUint32(nil, value))
+}
+
+// setOptionalData replaces all optional data entries of dataType with a single entry holding data.
+func (f *FileEntry) setOptionalData(dataType uint8, data []byte) {
+	f.removeOptionalDataType(dataType)
+	f.OptionalData = append(f.OptionalData, OptionalDataEntry{DataType: dataType, DataLength: uint16(len(data)), Data: data})
+	f.updateOptionalDataLen()
+}
+
+// removeOptionalDataType removes all optional data entries of dataType.
+func (f *FileEntry) removeOptionalDataType(dataType uint8) {
+	kept := f.OptionalData[:0]
+	for _, opt := range f.OptionalData {
+		if opt.DataType != dataType {
+			kept = append(kept, opt)
+		}
+	}
+	f.OptionalData = kept
+	f.updateOptionalDataLen()
+}
@@ -88,3 +88,116 @@ func TestOptionalDataEntry_ReadFrom_IncompleteData(t *testing.T) {
 	}
 	runReadFromIncompleteTable(t, tests, func() readFromEntry { return &OptionalDataEntry{} })
 }
+
+// TestFileEntry_CompressionDictionaryID tests setting, replacing and clearing the dictionary reference.
+func TestFileEntry_CompressionDictionaryID(t *testing.T) {
+	fe := NewFileEntry()
+	if _, ok := fe.GetCompressionDictionaryID(); ok {
+		t.Fatal("GetCompressionDictionaryID() on new entry: want false")
+	}
+
+	fe.OptionalData = append(fe.OptionalData, OptionalDataEntry{DataType: OptionalDataTagsData, DataLength: 2, Data: []byte("[]")})
+	fe.SetCompressionDictionaryID(32768)
+	fe.SetCompressionDictionaryID(40000)
+	if id, ok := fe.GetCompressionDictionaryID(); !ok || id != 40000 {
+		t.Errorf("GetCompressionDictionaryID() = %d, %v; want 40000, true", id, ok)
+	}
+	if len(fe.OptionalData) != 2 {
+		t.Errorf("len(OptionalData) = %d, want 2", len(fe.OptionalData))
+	}
+	if fe.OptionalDataLen != 5+7 {
+		t.Errorf("OptionalDataLen = %d, want 12", fe.OptionalDataLen)
+	}
+
+	fe.ClearCompressionDictionaryID()
+	if _, ok := fe.GetCompressionDictionaryID(); ok {
+		t.Error("GetCompressionDictionaryID() after clear: want false")
+	}
+	if len(fe.OptionalData) != 1 || fe.OptionalData[0].DataType != OptionalDataTagsData {
+		t.Errorf("OptionalData after clear = %+v, want only tags entry", fe.OptionalData)
+	}
+
+	fe.OptionalData = append(fe.OptionalData, OptionalDataEntry{DataType: OptionalDataCompressionDictionaryID, DataLength: 2, Data: []byte{1, 2}})
+	if _, ok := fe.GetCompressionDictionaryID(); ok {
+		t.Error("GetCompressionDictionaryID() with malformed entry: want false")
+	}
+}
+
+// TestFileEntry_SolidGroup tests setting, reading and clearing the SolidGroupID optional data.
+func TestFileEntry_SolidGroup(t *testing.T) {
+	fe := NewFileEntry()
+	if _, _, ok := fe.GetSolidGroup(); ok {
+		t.Fatal("GetSolidGroup() on new entry: want false")
+	}
+
+	fe.SetCompressionDictionaryID(32768)
+	fe.SetSolidGroup(3, 100)
+	fe.SetSolidGroup(7, 4096)
+	groupID, offset, ok := fe.GetSolidGroup()
+	if !ok || groupID != 7 || offset != 4096 {
+		t.Errorf("GetSolidGroup() = %d, %d, %v; want 7, 4096, true", groupID, offset, ok)
+	}
+	if fe.OptionalDataLen != 7+11 {
+		t.Errorf("OptionalDataLen = %d, want 18", fe.OptionalDataLen)
+	}
+
+	fe.ClearSolidGroup()
+	if _, _, ok := fe.GetSolidGroup(); ok {
+		t.Error("GetSolidGroup() after clear: want false")
+	}
+	if id, ok := fe.GetCompressionDictionaryID(); !ok || id != 32768 {
+		t.Errorf("GetCompressionDictionaryID() after ClearSolidGroup = %d, %v; want 32768, true", id, ok)
+	}
+
+	fe.OptionalData = append(fe.OptionalData, OptionalDataEntry{DataType: OptionalDataSolidGroupID, DataLength: 4, Data: []byte{1, 0, 0, 0}})
+	if _, _, ok := fe.GetSolidGroup(); ok {
+		t.Error("GetSolidGroup() with malformed entry: want false")
+	}
+}
+
+// TestFileEntry_CompressionFrameSize tests setting, reading and clearing the CompressionFrameSize optional data.
+func TestFileEntry_CompressionFrameSize(t *testing.T) {
+	fe := NewFileEntry()
+	if _, ok := fe.GetCompressionFrameSize(); ok {
+		t.Fatal("GetCompressionFrameSize() on new entry: want false")
+	}
+
+	fe.SetCompressionFrameSize(64 << 10)
+	fe.SetCompressionFrameSize(1 << 20)
+	if size, ok := fe.GetCompressionFrameSize(); !ok || size != 1<<20 {
+		t.Errorf("GetCompressionFrameSize() = %d, %v; want %d, true", size, ok, 1<<20)
+	}
+	if fe.OptionalDataLen != 7 {
+		t.Errorf("OptionalDataLen = %d, want 7", fe.OptionalDataLen)
+	}
+
+	fe.ClearCompressionFrameSize()
+	if _, ok := fe.GetCompressionFrameSize(); ok {
+		t.Error("GetCompressionFrameSize() after clear: want false")
+	}
+	if len(fe.OptionalData) != 0 {
+		t.Errorf("OptionalData after clear = %+v, want empty", fe.OptionalData)
+	}
+}
+
+// TestFileEntry_EncryptionKeyID tests setting, reading and clearing the EncryptionKeyID optional data.
+func TestFileEntry_EncryptionKeyID(t *testing.T) {
+	fe := NewFileEntry()
+	if _, ok := fe.GetEncryptionKeyID(); ok {
+		t.Fatal("GetEncryptionKeyID() on new entry: want false")
+	}
+
+	fe.SetEncryptionKeyID("dlc-2024")
+	fe.SetEncryptionKeyID("dlc-2025")
+	if id, ok := fe.GetEncryptionKeyID(); !ok || id != "dlc-2025" {
+		t.Errorf("GetEncryptionKeyID() = %q, %v; want \"dlc-2025\", true", id, ok)
+	}
+	if fe.OptionalDataLen != 3+8 {
+		t.Errorf("OptionalDataLen = %d, want 11", fe.OptionalDataLen)
+	}
+
+	fe.ClearEncryptionKeyID()
+	if _, ok := fe.GetEncryptionKeyID(); ok {
+		t.Error("GetEncryptionKeyID() after clear: want false")
+	}
+}
@@ -1,7 +1,7 @@
 // This file defines the SecurityLevel type and constants representing security
-// levels for packages and signatures. It contains the SecurityLevel type definition
-// and security level constants (None, Low, Medium, High, Maximum). This file
-// should contain only the SecurityLevel type definition and constants.
+// levels for packages and signatures. It contains the SecurityLevel type definition,
+// security level constants (None, Low, Medium, High, Maximum) and their names. This
+// file should contain only the SecurityLevel type definition and constants.
 //
 // Specification: api_metadata.md: 0 Overview
 
@@ -13,7 +13,9 @@ package metadata
 
 // SecurityLevel represents the security level of a package.
 //
-// TODO: Full implementation with all security levels per specification.
+// The level is derived from the package's verified signatures and file encryption;
+// see SecurityStatus for the inputs and the reasons a level was assigned. Higher
+// levels compare greater, so callers can gate on a minimum level.
 //
 // Specification: api_security.md: 2 SecurityStatus Structure
 type SecurityLevel int
@@ -34,3 +36,23 @@ const (
 	// SecurityLevelMaximum indicates maximum security features
 	SecurityLevelMaximum
 )
+
+// String returns the name of the security level.
+//
+// Specification: api_security.md: 2.4.1 SecurityLevel.String Method
+func (l SecurityLevel) String() string {
+	switch l {
+	case SecurityLevelNone:
+		return "None"
+	case SecurityLevelLow:
+		return "Low"
+	case SecurityLevelMedium:
+		return "Medium"
+	case SecurityLevelHigh:
+		return "High"
+	case SecurityLevelMaximum:
+		return "Maximum"
+	default:
+		return "Unknown"
+	}
+}
@@ -60,3 +60,20 @@ func TestSecurityLevel_TypeConversion(t *testing.T) {
 		t.Errorf("SecurityLevel(2) = %v, want SecurityLevelMedium", levelFromInt)
 	}
 }
+
+// TestSecurityLevel_String tests SecurityLevel names.
+func TestSecurityLevel_String(t *testing.T) {
+	names := map[SecurityLevel]string{
+		SecurityLevelNone:    "None",
+		SecurityLevelLow:     "Low",
+		SecurityLevelMedium:  "Medium",
+		SecurityLevelHigh:    "High",
+		SecurityLevelMaximum: "Maximum",
+		SecurityLevel(9):     "Unknown",
+	}
+	for level, want := range names {
+		if got := level.String(); got != want {
+			t.Errorf("SecurityLevel(%d).String() = %q, want %q", int(level), got, want)
+		}
+	}
+}
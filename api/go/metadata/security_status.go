@@ -0,0 +1,54 @@
+// This file defines the SecurityStatus structure describing the security state of a
+// package: its signatures and their validation results, file encryption coverage,
+// checksum state, the resulting SecurityLevel and the reasons it was assigned. This
+// file should contain only the SecurityStatus type definitions.
+//
+// Specification: api_metadata.md: 7.3 SecurityStatus Structure
+
+package metadata
+
+import "github.com/novus-engine/novuspack/api/go/signatures"
+
+// SecurityStatus contains the security status of a package.
+//
+// Specification: api_metadata.md: 7.3 SecurityStatus Structure
+type SecurityStatus struct {
+	// SecurityLevel is the overall security level derived from the fields below
+	SecurityLevel SecurityLevel
+
+	// Signatures
+	SignatureCount        int                                    // Number of signatures
+	ValidSignatures       int                                    // Number of valid signatures
+	TrustedSignatures     int                                    // Number of trusted signatures
+	TrustedSigners        int                                    // Number of distinct signers among trusted signatures
+	QuantumSafeSignatures int                                    // Number of trusted quantum-safe signatures
+	SignatureResults      []signatures.SignatureValidationResult // Individual results
+
+	// Encryption (regular files only; special metadata files are not counted)
+	FileCount            int      // Number of regular files
+	EncryptedFiles       int      // Number of encrypted regular files
+	QuantumSafeFiles     int      // Number of regular files with quantum-safe encryption
+	EncryptionAlgorithms []string // Encryption algorithms in use, sorted by name
+
+	// Checksums
+	HasChecksums   bool // Checksums present
+	ChecksumsValid bool // Checksums valid
+
+	// Reasons explains why SecurityLevel was assigned
+	Reasons []string
+
+	// ValidationErrors lists signature, checksum and structure validation errors
+	ValidationErrors []string
+}
+
+// SecurityValidationResult is the result of package security validation.
+//
+// Specification: api_security.md: 2.1 SecurityValidationResult Struct
+type SecurityValidationResult = SecurityStatus
+
+// AllFilesEncrypted reports whether the package has regular files and all of them are encrypted.
+//
+// Specification: api_security.md: 2.4.2 SecurityStatus.AllFilesEncrypted Method
+func (s *SecurityStatus) AllFilesEncrypted() bool {
+	return s.FileCount > 0 && s.EncryptedFiles == s.FileCount
+}
@@ -14,9 +14,17 @@
 // from NovusPack package files.
 //
 // Thread Safety:
-//   - Package instances are not thread-safe and should not be shared
-//     across goroutines without external synchronization.
-//   - Use separate Package instances for concurrent operations.
+//   - Package instances provide the ThreadSafetyReadOnly guarantee: read methods
+//     (ReadFile, ReadFileRange, OpenFile, ListFiles, GetMetadata, GetInfo,
+//     Validate, ValidateWithOptions without required signatures, and the
+//     Get/Has/Is accessors) may be called concurrently from many goroutines on
+//     one open package. They read the package file with positional reads, and
+//     the key ring and runtime caches they fill on first use are guarded by an
+//     internal read-write lock.
+//   - Verify and GetSecurityStatus update PackageInfo and therefore require
+//     exclusive access.
+//   - Methods that modify the package, and Close, must not run concurrently with
+//     any other method without external synchronization.
 //
 // Context Integration:
 //   - All I/O methods accept context.Context for cancellation and timeout.
@@ -33,10 +41,15 @@ package novus_package
 
 import (
 	"context"
+	"crypto"
+	"crypto/x509"
+	"io"
 	"os"
+	"sync"
 
 	"github.com/novus-engine/novuspack/api/go/fileformat"
 	"github.com/novus-engine/novuspack/api/go/metadata"
+	"github.com/novus-engine/novuspack/api/go/signatures"
 )
 
 // =============================================================================
@@ -52,9 +65,12 @@ import (
 type Package interface {
 	// Read operations
 	ReadFile(ctx context.Context, path string) ([]byte, error)
+	ReadFileRange(ctx context.Context, path string, offset, length int64) ([]byte, error)
+	OpenFile(ctx context.Context, path string) (*FileStream, error)
 	ListFiles() ([]FileInfo, error)
 	GetMetadata() (*metadata.PackageMetadata, error)
 	Validate(ctx context.Context) error
+	ValidateWithOptions(ctx context.Context, options *ValidateOptions) error
 	GetInfo() (*metadata.PackageInfo, error)
 
 	// Write operations
@@ -87,9 +103,46 @@ type Package interface {
 	// Specification: api_basic_operations.md: 3.1 Package Implementation Structure
 	AddFile(ctx context.Context, filesystemPath string, options *AddFileOptions) (*metadata.FileEntry, error)
 	AddFileFromMemory(ctx context.Context, path string, data []byte, options *AddFileOptions) (*metadata.FileEntry, error)
+	AddFileFromReader(ctx context.Context, path string, r io.Reader, size int64, options *AddFileOptions) (*metadata.FileEntry, error)
 	AddFilePattern(ctx context.Context, pattern string, options *AddFileOptions) ([]*metadata.FileEntry, error)
 	AddDirectory(ctx context.Context, dirPath string, options *AddFileOptions) ([]*metadata.FileEntry, error)
 
+	// Encryption key operations
+	// Specification: api_security.md: 4.1.4.4 Operation Requirements
+	AddEncryptionKey(key *EncryptionKey) error
+	RemoveEncryptionKey(keyID string) error
+
+	// Key rotation operations
+	// Specification: api_security.md: 9. Key Rotation
+	RotateEncryptionKey(ctx context.Context, oldKey, newKey *EncryptionKey) (int, error)
+	RotateContentKey(ctx context.Context) (int, error)
+
+	// Key envelope operations
+	// Specification: api_security.md: 6. Key Envelopes
+	AddKeyRecipient(ctx context.Context, recipient *EncryptionKey) error
+	RevokeKeyRecipient(ctx context.Context, recipientID string) error
+
+	// Passphrase operations
+	// Specification: api_security.md: 7. Passphrase Protection
+	SetPassphrase(ctx context.Context, passphrase string, options *PassphraseOptions) error
+	UnlockPassphrase(ctx context.Context, passphrase string) error
+
+	// Signing operations
+	// Specification: api_signatures.md: 2.8 Existing Package Signing
+	Sign(ctx context.Context, signer crypto.Signer, comment string) error
+	SignWithCertificate(ctx context.Context, signer crypto.Signer, chain []*x509.Certificate, comment string) error
+	ClearAllSignatures(ctx context.Context) error
+	Verify(ctx context.Context, trust TrustStore) ([]signatures.SignatureInfo, error)
+
+	// Security status operations
+	// Specification: api_security.md: 1.1.2 Package.GetSecurityStatus Method
+	GetSecurityStatus(ctx context.Context, trust TrustStore) (*metadata.SecurityStatus, error)
+
+	// Confidential index operations
+	// Specification: api_security.md: 8. Confidential Index
+	EnableConfidentialIndex(ctx context.Context, key *EncryptionKey) error
+	UnlockIndex(ctx context.Context) error
+
 	// File removal operations
 	// Specification: api_file_mgmt_removal.md: 2. RemoveFile Package Method
 	RemoveFile(ctx context.Context, path string) error
@@ -116,6 +169,23 @@ type Package interface {
 	SetPackageIdentity(vendorID uint32, appID uint64) error
 	GetPackageIdentity() (uint32, uint64)
 	ClearPackageIdentity() error
+
+	// Compression dictionary operations
+	// Specification: package_file_format.md: 4.1.4.4 Optional Data
+	TrainCompressionDictionary(ctx context.Context, samplePaths []string, maxSize int) (uint32, error)
+	SetFileCompressionDictionary(ctx context.Context, path string, dictID uint32) error
+
+	// Solid compression group operations
+	// Specification: package_file_format.md: 4.1.4.4 Optional Data
+	CreateSolidGroup(ctx context.Context, paths []string) (uint32, error)
+
+	// Package compression operations
+	// Specification: api_package_compression.md: 4. In-Memory Compression Methods
+	// Specification: api_package_compression.md: 6. File-Based Compression Methods
+	CompressPackage(ctx context.Context, compressionType uint8) error
+	DecompressPackage(ctx context.Context) error
+	CompressPackageFile(ctx context.Context, path string, compressionType uint8, overwrite bool) error
+	DecompressPackageFile(ctx context.Context, path string, overwrite bool) error
 }
 
 // =============================================================================
@@ -167,6 +237,18 @@ type filePackage struct {
 	fileHandle  *os.File                  // Open file handle (nil when closed)
 	isOpen      bool                      // True when file is open for reading, false when closed
 	sessionBase string                    // Package-level session base path for automatic path derivation (runtime only)
+
+	mu                      sync.RWMutex              // Guards the key ring and runtime caches, which read methods may fill
+	compressionDictionaries map[uint32][]byte         // Parsed compression dictionary special file, keyed by ID (runtime cache)
+	solidGroupBlocks        solidGroupCache           // Decompressed solid group blocks, bounded (runtime cache)
+	mapped                  []byte                    // Read-only memory mapping of fileHandle, released on Close (runtime only)
+	spoolPath               string                    // Uncompressed copy of an opened compressed package, removed on Close (runtime only)
+	encryptionKeys          map[string]*EncryptionKey // Keys supplied for encrypting and decrypting file data, keyed by KeyID (runtime only)
+	encryptionType          EncryptionType            // Encryption algorithm required for added files; EncryptionNone allows any (set by PackageBuilder)
+	keyEnvelope             *keyEnvelope              // Parsed key envelope special file (runtime cache)
+	confidentialIndex       *confidentialIndex        // Confidential index key reference and lock state (runtime only)
+	signedPath              string                    // Cleaned path of the package file the in-memory signatures belong to (runtime only)
+	signedFile              *os.File                  // Open handle of that file, so Verify reads the bytes opened or signed (runtime only)
 }
 
 // =============================================================================
@@ -0,0 +1,85 @@
+// This file contains the shared buffer pool used for transient buffers in package
+// reads and writes: stored file data that is decrypted or decompressed, stored
+// frame ranges, copy buffers, and package sections rewritten by package
+// compression. Buffers returned to callers are never pooled. This file should
+// contain only buffer pool plumbing.
+//
+// Specification: api_streaming.md: 2.7 Package Buffer Pool
+
+package novus_package
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+
+	"github.com/novus-engine/novuspack/api/go/generics"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// copyBufferSize is the size of pooled buffers used to copy file data.
+const copyBufferSize = 64 << 10
+
+// defaultBufferPool is the shared pool used until SetSharedBufferPool is called.
+var defaultBufferPool = generics.NewBufferPool[byte](generics.DefaultBufferConfig())
+
+// sharedBufferPool holds the pool installed with SetSharedBufferPool.
+var sharedBufferPool atomic.Pointer[generics.BufferPool[byte]]
+
+// SharedBufferPool returns the buffer pool used by all packages for transient
+// buffers.
+//
+// Specification: api_streaming.md: 2.7.1 SharedBufferPool Function
+func SharedBufferPool() *generics.BufferPool[byte] {
+	if pool := sharedBufferPool.Load(); pool != nil {
+		return pool
+	}
+	return defaultBufferPool
+}
+
+// SetSharedBufferPool replaces the buffer pool used by all packages; nil restores
+// the default pool. Buffers taken before the call are returned to the pool they
+// came from.
+//
+// Specification: api_streaming.md: 2.7.2 SetSharedBufferPool Function
+func SetSharedBufferPool(pool *generics.BufferPool[byte]) {
+	sharedBufferPool.Store(pool)
+}
+
+// acquireBuffer returns a buffer of size bytes from the shared pool and a function
+// that returns it. Requests larger than the pool's memory limit fail, and requests
+// the pool cannot currently satisfy wait or fail as the pool is configured, so
+// callers read large data in chunks. Callers must not request another buffer while
+// holding one, so concurrent readers cannot wait on each other.
+func acquireBuffer(ctx context.Context, size int64) ([]byte, func(), error) {
+	pool := SharedBufferPool()
+	buf, err := pool.Get(ctx, int(size))
+	if err != nil {
+		return nil, nil, err
+	}
+	return buf, func() { pool.Put(buf) }, nil
+}
+
+// copyWithPooledBuffer copies up to n bytes from src to dst through a pooled buffer
+// and returns the number of bytes copied.
+// Returns *PackageError on failure; PackageErrors from src or dst are returned
+// unchanged
+func copyWithPooledBuffer(ctx context.Context, dst io.Writer, src io.Reader, n int64) (int64, error) {
+	buf, release, err := acquireBuffer(ctx, min(n, copyBufferSize, SharedBufferPool().GetStats().MaxTotalSize))
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	if len(buf) == 0 {
+		return 0, nil
+	}
+	copied, err := io.CopyBuffer(dst, io.LimitReader(src, n), buf)
+	var pkgErr *pkgerrors.PackageError
+	if pkgerrors.As(err, &pkgErr) {
+		return copied, err
+	}
+	if err != nil {
+		return copied, pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to copy file data from source")
+	}
+	return copied, nil
+}
@@ -0,0 +1,144 @@
+// This file contains tests for the shared buffer pool: package reads and writes
+// take and return pooled buffers, large reads are chunked to fit the pool, and
+// buffers larger than the pool fail instead of bypassing it.
+//
+// Specification: api_streaming.md: 2.7 Package Buffer Pool
+
+package novus_package
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/generics"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// useTestBufferPool installs a buffer pool for the duration of the test.
+func useTestBufferPool(t *testing.T, config *generics.BufferConfig) *generics.BufferPool[byte] {
+	t.Helper()
+	pool := generics.NewBufferPool[byte](config)
+	SetSharedBufferPool(pool)
+	t.Cleanup(func() {
+		SetSharedBufferPool(nil)
+		pool.Close()
+	})
+	return pool
+}
+
+func TestSharedBufferPool_SetAndRestore(t *testing.T) {
+	pool := useTestBufferPool(t, nil)
+	if SharedBufferPool() != pool {
+		t.Fatal("SharedBufferPool() did not return the installed pool")
+	}
+	SetSharedBufferPool(nil)
+	if SharedBufferPool() != defaultBufferPool {
+		t.Error("SetSharedBufferPool(nil) did not restore the default pool")
+	}
+}
+
+func TestPackage_ReadWrite_UsesSharedBufferPool(t *testing.T) {
+	ctx := context.Background()
+	pool := useTestBufferPool(t, &generics.BufferConfig{MaxTotalSize: 4 << 20})
+	content := rangeTestPayload(12*rangeTestFrameSize + 77)
+	key := testEncryptionKey("pool", 0x3C)
+
+	compressed := &AddFileOptions{}
+	compressed.CompressionType.Set(fileformat.CompressionZstd)
+	encrypted := encryptedOptions(key)
+	encrypted.CompressionType.Set(fileformat.CompressionLZ4)
+
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	if err := pkg.AddEncryptionKey(key); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	files := map[string]*AddFileOptions{
+		"/data/compressed.bin": compressed,
+		"/data/encrypted.bin":  encrypted,
+		"/data/framed.bin":     framedRangeOptions(fileformat.CompressionZstd),
+	}
+	for path, opts := range files {
+		if _, err := pkg.AddFileFromMemory(ctx, path, content, opts); err != nil {
+			t.Fatalf("AddFileFromMemory(%q) failed: %v", path, err)
+		}
+	}
+	reopened := writeAndReopen(t, ctx, pkg)
+	if err := reopened.AddEncryptionKey(key); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+
+	for range 2 {
+		for path := range files {
+			got, err := reopened.ReadFile(ctx, path)
+			if err != nil {
+				t.Fatalf("ReadFile(%q) failed: %v", path, err)
+			}
+			if !bytes.Equal(got, content) {
+				t.Errorf("ReadFile(%q) returned %d bytes, want %d matching bytes", path, len(got), len(content))
+			}
+		}
+		assertRanges(t, ctx, reopened, "/data/framed.bin", content)
+	}
+
+	stats := pool.GetStats()
+	if stats.Misses == 0 || stats.Hits == 0 {
+		t.Errorf("GetStats() = %+v, want pooled buffers to be allocated and reused", stats)
+	}
+	if stats.ActiveBuffers != 0 || stats.BytesInUse != 0 {
+		t.Errorf("GetStats() = %+v, want every buffer returned to the pool", stats)
+	}
+}
+
+func TestPackage_ReadFile_LargerThanBufferPool(t *testing.T) {
+	ctx := context.Background()
+	pool := useTestBufferPool(t, &generics.BufferConfig{MaxTotalSize: 1024, FailWhenExhausted: true})
+	content := rangeTestPayload(64 << 10)
+
+	opts := &AddFileOptions{}
+	opts.CompressionType.Set(fileformat.CompressionZstd)
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/data/large.bin", content, opts); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	reopened := writeAndReopen(t, ctx, pkg)
+
+	got, err := reopened.ReadFile(ctx, "/data/large.bin")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("ReadFile returned %d bytes, want %d matching bytes", len(got), len(content))
+	}
+	if stats := pool.GetStats(); stats.BytesInUse != 0 {
+		t.Errorf("GetStats() = %+v, want no buffers in use", stats)
+	}
+}
+
+func TestPackage_ReadFileRange_FrameLargerThanBufferPool(t *testing.T) {
+	ctx := context.Background()
+	content := rangeTestPayload(4*rangeTestFrameSize + 9)
+
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/data/framed.bin", content, framedRangeOptions(fileformat.CompressionLZ4)); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	reopened := writeAndReopen(t, ctx, pkg)
+
+	pool := useTestBufferPool(t, &generics.BufferConfig{MaxTotalSize: 64, FailWhenExhausted: true})
+	_, err = reopened.ReadFileRange(ctx, "/data/framed.bin", 0, 16)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+	if stats := pool.GetStats(); stats.BytesInUse != 0 || stats.BytesIdle > stats.MaxTotalSize {
+		t.Errorf("GetStats() = %+v, want buffers within the pool limit and returned", stats)
+	}
+}
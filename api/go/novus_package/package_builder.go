@@ -70,6 +70,8 @@ func (b *packageBuilder) WithCompression(comp CompressionType) PackageBuilder {
 }
 
 // WithEncryption sets the encryption type for the package.
+// When set to a type other than EncryptionNone, every file added to the built
+// package must be encrypted with an EncryptionKey of that type.
 func (b *packageBuilder) WithEncryption(enc EncryptionType) PackageBuilder {
 	b.encryption = enc
 	return b
@@ -161,7 +163,15 @@ func (b *packageBuilder) Build(ctx context.Context) (Package, error) {
 		}
 	}
 
-	// TODO: Apply compression and encryption settings when Write is implemented
+	// Set the package encryption algorithm (must have a file data implementation)
+	if b.encryption != EncryptionNone {
+		if _, err := onDiskEncryptionType(b.encryption); err != nil {
+			return nil, err
+		}
+		filePkg.encryptionType = b.encryption
+	}
+
+	// TODO: Apply compression settings when Write is implemented
 	// TODO: Apply metadata when metadata system is implemented
 
 	return pkg, nil
@@ -5,6 +5,7 @@
 package novus_package
 
 import (
+	"bytes"
 	"context"
 	"strings"
 	"testing"
@@ -216,3 +217,32 @@ func TestPackageBuilder_Build_SetVendorIDError(t *testing.T) {
 func TestPackageBuilder_Build_SetAppIDError(t *testing.T) {
 	runBuildAndAssertID(t, NewBuilder().WithAppID(67890), func(p Package) uint64 { return p.GetAppID() }, uint64(67890), "AppID")
 }
+
+// TestPackageBuilder_Build_WithEncryption tests that the built package requires
+// files to be encrypted with the configured algorithm.
+func TestPackageBuilder_Build_WithEncryption(t *testing.T) {
+	ctx := context.Background()
+	pkg, err := NewBuilder().WithEncryption(EncryptionChaCha20Poly1305).Build(ctx)
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+
+	_, err = pkg.AddFileFromMemory(ctx, "/plain.txt", []byte("plain"), nil)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	aes := encryptedOptions(testEncryptionKey("aes", 0x01))
+	_, err = pkg.AddFileFromMemory(ctx, "/aes.bin", []byte("aes"), aes)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	chacha := encryptedOptions(NewEncryptionKey(EncryptionChaCha20Poly1305, "chacha", bytes.Repeat([]byte{0x02}, 32)))
+	fe, err := pkg.AddFileFromMemory(ctx, "/chacha.bin", []byte("chacha"), chacha)
+	if err != nil {
+		t.Fatalf("AddFileFromMemory() failed: %v", err)
+	}
+	if fe.EncryptionType != fileformat.EncryptionChaCha20Poly1305 {
+		t.Errorf("EncryptionType = %d, want ChaCha20-Poly1305", fe.EncryptionType)
+	}
+
+	_, err = NewBuilder().WithEncryption(EncryptionMLKEM512).Build(ctx)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeUnsupported)
+}
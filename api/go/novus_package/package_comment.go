@@ -30,7 +30,9 @@ import (
 //
 // Specification: api_metadata.md: 1. Comment Management
 func (p *filePackage) SetComment(comment string) error {
-
+	if err := p.checkNotSigned("SetComment"); err != nil {
+		return err
+	}
 	pc, err := buildPackageComment(comment)
 	if err != nil {
 		return err
@@ -88,7 +90,9 @@ func (p *filePackage) GetComment() string {
 //
 // Specification: api_metadata.md: 1.1 Package-Level Comment Methods
 func (p *filePackage) ClearComment() error {
-
+	if err := p.checkNotSigned("ClearComment"); err != nil {
+		return err
+	}
 	// Clear header fields
 	p.header.CommentSize = 0
 	p.header.CommentStart = 0
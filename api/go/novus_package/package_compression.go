@@ -0,0 +1,744 @@
+// This file implements whole-package compression. A compressed package keeps the
+// header, package comment and signatures uncompressed. Each FileEntry's metadata is
+// compressed with LZ4, each file's data with the package compression type (LZ4 for
+// special metadata files), and the file index as a single LZ4 block. A metadata
+// index at offset 112 locates every block. Opened compressed packages are decoded
+// into an uncompressed spool file, so reads and rewrites use the regular layout.
+// This file should contain only package compression operations and the conversion
+// between the compressed and uncompressed package layouts.
+//
+// Specification: api_package_compression.md: 1. Package Compression Overview
+// Specification: package_file_format.md: 5 Metadata Index Section
+
+package novus_package
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/internal"
+	"github.com/novus-engine/novuspack/api/go/metadata"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// maxMetadataBlockSize bounds the decompressed size of one FileEntry metadata block.
+const maxMetadataBlockSize = 16 << 20
+
+// CompressPackage enables compression of the whole package with the given type.
+//
+// The package is written in the compressed layout by the next Write: FileEntry
+// metadata and the file index are compressed with LZ4, and file data with
+// compressionType. The header, package comment and signatures stay uncompressed.
+// Calling CompressPackage with the type the package already uses is a no-op.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - compressionType: Compression type for file data (1=Zstd, 2=LZ4, 3=LZMA)
+//
+// Returns:
+//   - error: *PackageError on failure
+//
+// Error Conditions:
+//   - ErrTypeValidation: Invalid compression type, or the package is already
+//     compressed with a different type
+//   - ErrTypeSecurity: The package is signed
+//
+// Specification: api_package_compression.md: 4.1 Package.CompressPackage Method
+func (p *filePackage) CompressPackage(ctx context.Context, compressionType uint8) error {
+	if err := internal.CheckContext(ctx, "CompressPackage"); err != nil {
+		return err
+	}
+	if compressionType < fileformat.CompressionZstd || compressionType > fileformat.CompressionLZMA {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "invalid package compression type", nil, pkgerrors.ValidationErrorContext{
+			Field:    "compressionType",
+			Value:    compressionType,
+			Expected: "1 (Zstd), 2 (LZ4) or 3 (LZMA)",
+		})
+	}
+	if err := p.checkNotSigned("CompressPackage"); err != nil {
+		return err
+	}
+
+	current := extractCompressionType(p.header)
+	if current == compressionType {
+		return nil
+	}
+	if current != fileformat.CompressionNone {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "package is already compressed with a different type", nil, pkgerrors.ValidationErrorContext{
+			Field:    "compressionType",
+			Value:    compressionType,
+			Expected: fmt.Sprintf("%d (current package compression)", current),
+		})
+	}
+
+	p.setPackageCompression(compressionType)
+	return nil
+}
+
+// DecompressPackage disables whole-package compression.
+//
+// The package is written in the regular uncompressed layout by the next Write.
+// Per-file compression is not affected.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//
+// Returns:
+//   - error: *PackageError on failure
+//
+// Error Conditions:
+//   - ErrTypeValidation: The package is not compressed
+//   - ErrTypeSecurity: The package is signed
+//
+// Specification: api_package_compression.md: 4.2 Package.DecompressPackage Method
+func (p *filePackage) DecompressPackage(ctx context.Context) error {
+	if err := internal.CheckContext(ctx, "DecompressPackage"); err != nil {
+		return err
+	}
+	if err := p.checkNotSigned("DecompressPackage"); err != nil {
+		return err
+	}
+	if extractCompressionType(p.header) == fileformat.CompressionNone {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "package is not compressed", nil, pkgerrors.ValidationErrorContext{
+			Field:    "PackageCompression",
+			Value:    fileformat.CompressionNone,
+			Expected: "compressed package",
+		})
+	}
+
+	p.setPackageCompression(fileformat.CompressionNone)
+	return nil
+}
+
+// CompressPackageFile compresses the package and writes it to path.
+//
+// The package's configured path is not changed; the package stays compressed in
+// memory, so later writes also use the compressed layout.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - path: Target file path for the compressed package
+//   - compressionType: Compression type for file data (1=Zstd, 2=LZ4, 3=LZMA)
+//   - overwrite: Whether to overwrite an existing file
+//
+// Returns:
+//   - error: *PackageError on failure
+//
+// Error Conditions:
+//   - ErrTypeValidation: Invalid path or compression type, the package is already
+//     compressed with a different type, or the file exists and overwrite is false
+//   - ErrTypeSecurity: The package is signed
+//   - ErrTypeIO: Writing the file failed
+//
+// Specification: api_package_compression.md: 6.1 Package.CompressPackageFile Method
+func (p *filePackage) CompressPackageFile(ctx context.Context, path string, compressionType uint8, overwrite bool) error {
+	if err := internal.CheckContext(ctx, "CompressPackageFile"); err != nil {
+		return err
+	}
+	if err := internal.ValidatePath(ctx, path); err != nil {
+		return err
+	}
+	if err := p.CompressPackage(ctx, compressionType); err != nil {
+		return err
+	}
+	if err := p.SavePathMetadataFile(ctx); err != nil {
+		return pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to save path metadata before CompressPackageFile")
+	}
+	return p.safeWriteTo(ctx, strings.TrimSpace(path), overwrite)
+}
+
+// DecompressPackageFile decompresses the package and writes it to path.
+//
+// The package's configured path is not changed; the package stays uncompressed in
+// memory, so later writes also use the regular layout.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - path: Target file path for the uncompressed package
+//   - overwrite: Whether to overwrite an existing file
+//
+// Returns:
+//   - error: *PackageError on failure
+//
+// Error Conditions:
+//   - ErrTypeValidation: Invalid path, the package is not compressed, or the file
+//     exists and overwrite is false
+//   - ErrTypeIO: Writing the file failed
+//
+// Specification: api_package_compression.md: 6.2 Package.DecompressPackageFile Method
+func (p *filePackage) DecompressPackageFile(ctx context.Context, path string, overwrite bool) error {
+	if err := internal.CheckContext(ctx, "DecompressPackageFile"); err != nil {
+		return err
+	}
+	if err := internal.ValidatePath(ctx, path); err != nil {
+		return err
+	}
+	if err := p.DecompressPackage(ctx); err != nil {
+		return err
+	}
+	if err := p.SavePathMetadataFile(ctx); err != nil {
+		return pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to save path metadata before DecompressPackageFile")
+	}
+	return p.safeWriteTo(ctx, strings.TrimSpace(path), overwrite)
+}
+
+// setPackageCompression records the package compression type in the header flags
+// (bits 8-15) and in PackageInfo.
+func (p *filePackage) setPackageCompression(compressionType uint8) {
+	if p.header == nil {
+		p.header = fileformat.NewPackageHeader()
+	}
+	p.header.Flags = (p.header.Flags &^ fileformat.FlagsMaskCompressionType) | uint32(compressionType)<<fileformat.FlagsShiftCompressionType
+
+	if p.Info == nil {
+		p.Info = metadata.NewPackageInfo()
+	}
+	p.Info.PackageCompression = compressionType
+	p.Info.IsPackageCompressed = compressionType != fileformat.CompressionNone
+	if !p.Info.IsPackageCompressed {
+		p.setPackageCompressionSizes(0, 0)
+	}
+}
+
+// setPackageCompressionSizes records the uncompressed and compressed package file
+// sizes in PackageInfo. The ratio is compressed size over original size.
+func (p *filePackage) setPackageCompressionSizes(originalSize, compressedSize int64) {
+	p.Info.PackageOriginalSize = originalSize
+	p.Info.PackageCompressedSize = compressedSize
+	p.Info.PackageCompressionRatio = 0
+	if originalSize > 0 {
+		p.Info.PackageCompressionRatio = float64(compressedSize) / float64(originalSize)
+	}
+}
+
+// writePackageContent writes the package to file, in the compressed layout when
+// package compression is enabled.
+//
+// Compressed packages are first written in the regular layout to a staging file
+// next to file, which is then converted block by block.
+func (p *filePackage) writePackageContent(ctx context.Context, file *os.File) error {
+	compressionType := extractCompressionType(p.header)
+	if compressionType == fileformat.CompressionNone {
+		return p.writePackageToFile(ctx, file)
+	}
+
+	staging, err := os.CreateTemp(filepath.Dir(file.Name()), ".nvpk-stage-*")
+	if err != nil {
+		return pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeIO, "failed to create staging file", pkgerrors.ValidationErrorContext{
+			Field: "FilePath",
+			Value: file.Name(),
+		})
+	}
+	defer func() {
+		_ = staging.Close()
+		_ = os.Remove(staging.Name())
+	}()
+
+	if err := p.writePackageToFile(ctx, staging); err != nil {
+		return err
+	}
+	header, err := writeCompressedPackage(ctx, staging, file, compressionType)
+	if err != nil {
+		return err
+	}
+	p.header.IndexStart = header.IndexStart
+	p.header.IndexSize = header.IndexSize
+	p.header.CommentStart = header.CommentStart
+
+	originalSize, err := fileSize(staging)
+	if err != nil {
+		return err
+	}
+	compressedSize, err := fileSize(file)
+	if err != nil {
+		return err
+	}
+	p.setPackageCompressionSizes(originalSize, compressedSize)
+	return nil
+}
+
+// writeCompressedPackage converts the uncompressed package in src into the compressed
+// layout in dst and returns the header written to dst.
+//
+// Layout: header, metadata index, then for each entry its LZ4 metadata block followed
+// by its data block, then the LZ4 file index block and the uncompressed comment.
+//
+// Specification: package_file_format.md: 3.1 Compression Scope
+func writeCompressedPackage(ctx context.Context, src, dst *os.File, compressionType uint8) (*fileformat.PackageHeader, error) {
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to seek to staged package header")
+	}
+	header, err := internal.ReadAndValidateHeader(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	index := fileformat.NewFileIndex()
+	if header.IndexSize > 0 {
+		if _, err := src.Seek(int64(header.IndexStart), io.SeekStart); err != nil {
+			return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to seek to staged file index")
+		}
+		if _, err := readFileIndexFrom(src, index); err != nil {
+			return nil, err
+		}
+	}
+
+	metaIndex := fileformat.NewMetadataIndex()
+	metaIndex.Entries = make([]fileformat.MetadataIndexEntry, 0, len(index.Entries))
+	metaIndex.EntryCount = uint32(len(index.Entries))
+	blockIndex := fileformat.NewFileIndex()
+	blockIndex.Entries = make([]fileformat.IndexEntry, 0, len(index.Entries))
+
+	offset := uint64(fileformat.PackageHeaderSize + fileformat.MetadataIndexHeaderSize + fileformat.MetadataIndexEntrySize*len(index.Entries))
+	blockIndex.FirstEntryOffset = offset
+	if _, err := dst.Seek(int64(offset), io.SeekStart); err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to seek past metadata index")
+	}
+
+	for _, indexEntry := range index.Entries {
+		if err := internal.CheckContext(ctx, "CompressPackage"); err != nil {
+			return nil, err
+		}
+
+		entry, err := internal.LoadFileEntry(src, indexEntry.Offset)
+		if err != nil {
+			return nil, err
+		}
+		metaSize := uint64(entry.TotalSize())
+		meta, err := readPackageSection(src, indexEntry.Offset, metaSize)
+		if err != nil {
+			return nil, err
+		}
+		metaBlock, err := internal.CompressData(meta, fileformat.CompressionLZ4, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := dst.Write(metaBlock); err != nil {
+			return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to write metadata block")
+		}
+		dataBlockSize, err := compressPackageDataBlock(ctx, src, dst, indexEntry.Offset+metaSize, entry.StoredSize, packageDataCompressionType(entry, compressionType))
+		if err != nil {
+			return nil, err
+		}
+		if len(metaBlock) > math.MaxUint32 || dataBlockSize > math.MaxUint32 {
+			return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "compressed block too large", nil, pkgerrors.ValidationErrorContext{
+				Field:    "FileID",
+				Value:    indexEntry.FileID,
+				Expected: fmt.Sprintf("blocks of at most %d bytes", uint64(math.MaxUint32)),
+			})
+		}
+
+		metaIndex.Entries = append(metaIndex.Entries, fileformat.MetadataIndexEntry{
+			FileID:              indexEntry.FileID,
+			MetadataBlockOffset: offset,
+			MetadataBlockSize:   uint32(len(metaBlock)),
+			DataBlockOffset:     offset + uint64(len(metaBlock)),
+			DataBlockSize:       uint32(dataBlockSize),
+		})
+		blockIndex.Entries = append(blockIndex.Entries, fileformat.IndexEntry{
+			FileID: indexEntry.FileID,
+			Offset: offset,
+		})
+		offset += uint64(len(metaBlock)) + dataBlockSize
+	}
+
+	// File index as a single LZ4 block, with offsets of the metadata blocks
+	var indexBuf bytes.Buffer
+	if _, err := writeFileIndexTo(&indexBuf, blockIndex); err != nil {
+		return nil, err
+	}
+	indexBlock, err := internal.CompressData(indexBuf.Bytes(), fileformat.CompressionLZ4, 0)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dst.Write(indexBlock); err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to write file index block")
+	}
+
+	out := *header
+	out.Flags = (out.Flags &^ fileformat.FlagsMaskCompressionType) | uint32(compressionType)<<fileformat.FlagsShiftCompressionType
+	out.IndexStart = offset
+	out.IndexSize = uint64(len(indexBlock))
+	offset += out.IndexSize
+
+	commentStart, err := copyPackageSection(ctx, src, dst, header.CommentStart, uint64(header.CommentSize), offset)
+	if err != nil {
+		return nil, err
+	}
+	out.CommentStart = commentStart
+	out.SignatureOffset = 0
+
+	if _, err := dst.Seek(fileformat.PackageHeaderSize, io.SeekStart); err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to seek to metadata index")
+	}
+	if err := writeMetadataIndex(dst, metaIndex); err != nil {
+		return nil, err
+	}
+	if _, err := dst.Seek(0, io.SeekStart); err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to seek to beginning for header update")
+	}
+	if _, err := writePackageHeader(dst, &out); err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to write compressed package header")
+	}
+	return &out, nil
+}
+
+// openPackageSpool decodes the compressed package in file into a new temporary
+// spool file holding the regular uncompressed layout. The returned spool is
+// positioned at its start; the caller owns it and removes it when done.
+func openPackageSpool(ctx context.Context, file *os.File, header *fileformat.PackageHeader) (*os.File, error) {
+	spool, err := os.CreateTemp("", "nvpk-spool-*")
+	if err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to create package spool file")
+	}
+	if err := decodeCompressedPackage(ctx, file, header, spool); err != nil {
+		_ = spool.Close()
+		_ = os.Remove(spool.Name())
+		return nil, err
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		_ = spool.Close()
+		_ = os.Remove(spool.Name())
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to seek to spool start")
+	}
+	return spool, nil
+}
+
+// removeSpool deletes the spool file of an opened compressed package, if any.
+func (p *filePackage) removeSpool() {
+	if p.spoolPath != "" {
+		_ = os.Remove(p.spoolPath)
+		p.spoolPath = ""
+	}
+}
+
+// decodeCompressedPackage writes the regular uncompressed layout of the compressed
+// package in src to dst. The file index is cross-checked against the metadata index.
+//
+// Specification: package_file_format.md: 5.2 Compressed Package Metadata Index Detection
+func decodeCompressedPackage(ctx context.Context, src *os.File, header *fileformat.PackageHeader, dst *os.File) error {
+	compressionType := extractCompressionType(header)
+	if compressionType > fileformat.CompressionLZMA {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeUnsupported, "unsupported package compression type", nil, pkgerrors.ValidationErrorContext{
+			Field:    "Flags",
+			Value:    compressionType,
+			Expected: "1 (Zstd), 2 (LZ4) or 3 (LZMA)",
+		})
+	}
+
+	if _, err := src.Seek(fileformat.PackageHeaderSize, io.SeekStart); err != nil {
+		return pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to seek to metadata index")
+	}
+	metaIndex, err := readMetadataIndex(src)
+	if err != nil {
+		return err
+	}
+
+	indexBlock, err := readPackageSection(src, header.IndexStart, header.IndexSize)
+	if err != nil {
+		return err
+	}
+	indexData, err := internal.DecompressData(indexBlock, fileformat.CompressionLZ4, uint64(16+len(metaIndex.Entries)*fileformat.IndexEntrySize))
+	if err != nil {
+		return pkgerrors.WrapError(err, pkgerrors.ErrTypeCorruption, "failed to decompress file index")
+	}
+	blockIndex := fileformat.NewFileIndex()
+	if _, err := readFileIndexFrom(bytes.NewReader(indexData), blockIndex); err != nil {
+		return err
+	}
+	if err := validateFileIndex(blockIndex); err != nil {
+		return pkgerrors.WrapError(err, pkgerrors.ErrTypeCorruption, "invalid compressed file index")
+	}
+	if blockIndex.EntryCount != metaIndex.EntryCount {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "file index does not match metadata index", nil, pkgerrors.ValidationErrorContext{
+			Field:    "EntryCount",
+			Value:    blockIndex.EntryCount,
+			Expected: fmt.Sprintf("%d entries", metaIndex.EntryCount),
+		})
+	}
+
+	offset := uint64(fileformat.PackageHeaderSize)
+	if _, err := dst.Seek(int64(offset), io.SeekStart); err != nil {
+		return pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to seek past spool header")
+	}
+	index := fileformat.NewFileIndex()
+	index.FirstEntryOffset = offset
+	index.Entries = make([]fileformat.IndexEntry, 0, len(metaIndex.Entries))
+
+	for i, metaEntry := range metaIndex.Entries {
+		if err := internal.CheckContext(ctx, "OpenPackage"); err != nil {
+			return err
+		}
+		if blockIndex.Entries[i].FileID != metaEntry.FileID || blockIndex.Entries[i].Offset != metaEntry.MetadataBlockOffset {
+			return pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "file index does not match metadata index", nil, pkgerrors.ValidationErrorContext{
+				Field:    "FileID",
+				Value:    blockIndex.Entries[i].FileID,
+				Expected: fmt.Sprintf("FileID %d at offset %d", metaEntry.FileID, metaEntry.MetadataBlockOffset),
+			})
+		}
+
+		metaBlock, err := readPackageSection(src, metaEntry.MetadataBlockOffset, uint64(metaEntry.MetadataBlockSize))
+		if err != nil {
+			return err
+		}
+		meta, err := internal.DecompressDataLimit(metaBlock, fileformat.CompressionLZ4, maxMetadataBlockSize)
+		if err != nil {
+			return pkgerrors.WrapError(err, pkgerrors.ErrTypeCorruption, "failed to decompress file entry metadata")
+		}
+		entry, err := metadata.UnmarshalFileEntry(meta)
+		if err != nil {
+			return pkgerrors.WrapError(err, pkgerrors.ErrTypeCorruption, "failed to parse file entry metadata")
+		}
+		if entry.TotalSize() != len(meta) || entry.FileID != metaEntry.FileID {
+			return pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "metadata block does not match its index entry", nil, pkgerrors.ValidationErrorContext{
+				Field:    "FileID",
+				Value:    entry.FileID,
+				Expected: fmt.Sprintf("FileID %d with %d bytes of metadata", metaEntry.FileID, len(meta)),
+			})
+		}
+
+		if metaEntry.DataBlockSize == 0 && entry.StoredSize != 0 {
+			return pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "file data block missing", nil, pkgerrors.ValidationErrorContext{
+				Field:    "DataBlockSize",
+				Value:    metaEntry.DataBlockSize,
+				Expected: fmt.Sprintf("block holding %d stored bytes", entry.StoredSize),
+			})
+		}
+
+		if _, err := dst.Write(meta); err != nil {
+			return pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to write spool file entry")
+		}
+		if metaEntry.DataBlockSize > 0 {
+			if err := decompressPackageDataBlock(ctx, src, dst, metaEntry.DataBlockOffset, uint64(metaEntry.DataBlockSize), entry.StoredSize, packageDataCompressionType(entry, compressionType)); err != nil {
+				return err
+			}
+		}
+		index.Entries = append(index.Entries, fileformat.IndexEntry{FileID: metaEntry.FileID, Offset: offset})
+		offset += uint64(len(meta)) + entry.StoredSize
+	}
+
+	out := *header
+	out.Flags &^= fileformat.FlagsMaskCompressionType
+	out.IndexStart = offset
+	indexWritten, err := writeFileIndexTo(dst, index)
+	if err != nil {
+		return err
+	}
+	out.IndexSize = uint64(indexWritten)
+	offset += out.IndexSize
+
+	if out.CommentStart, err = copyPackageSection(ctx, src, dst, header.CommentStart, uint64(header.CommentSize), offset); err != nil {
+		return err
+	}
+	offset += uint64(header.CommentSize)
+
+	if header.SignatureOffset > 0 {
+		srcSize, err := fileSize(src)
+		if err != nil {
+			return err
+		}
+		if uint64(srcSize) < header.SignatureOffset {
+			return pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "signature offset beyond end of package", nil, pkgerrors.ValidationErrorContext{
+				Field:    "SignatureOffset",
+				Value:    header.SignatureOffset,
+				Expected: fmt.Sprintf("offset within %d-byte package", srcSize),
+			})
+		}
+		if out.SignatureOffset, err = copyPackageSection(ctx, src, dst, header.SignatureOffset, uint64(srcSize)-header.SignatureOffset, offset); err != nil {
+			return err
+		}
+	}
+
+	if _, err := dst.Seek(0, io.SeekStart); err != nil {
+		return pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to seek to spool header")
+	}
+	if _, err := writePackageHeader(dst, &out); err != nil {
+		return pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to write spool header")
+	}
+	return nil
+}
+
+// packageDataCompressionType returns the compression type of an entry's data block:
+// LZ4 for special metadata files, the package compression type otherwise.
+func packageDataCompressionType(entry *metadata.FileEntry, compressionType uint8) uint8 {
+	if entry.Type >= 65000 {
+		return fileformat.CompressionLZ4
+	}
+	return compressionType
+}
+
+// readMetadataIndex reads a metadata index from r.
+func readMetadataIndex(r io.Reader) (*fileformat.MetadataIndex, error) {
+	index := fileformat.NewMetadataIndex()
+	if err := binary.Read(r, binary.LittleEndian, &index.EntryCount); err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeCorruption, "failed to read metadata index entry count")
+	}
+	if err := binary.Read(r, binary.LittleEndian, &index.Reserved); err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeCorruption, "failed to read metadata index reserved field")
+	}
+	if index.Reserved != 0 {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "reserved field must be zero", nil, pkgerrors.ValidationErrorContext{
+			Field:    "Reserved",
+			Value:    index.Reserved,
+			Expected: "0",
+		})
+	}
+	if err := validateEntryCountAllocation(index.EntryCount); err != nil {
+		return nil, err
+	}
+
+	index.Entries = make([]fileformat.MetadataIndexEntry, 0, min(index.EntryCount, 1<<16))
+	for i := uint32(0); i < index.EntryCount; i++ {
+		var entry fileformat.MetadataIndexEntry
+		if err := binary.Read(r, binary.LittleEndian, &entry); err != nil {
+			return nil, pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeCorruption, fmt.Sprintf("failed to read metadata index entry %d", i), pkgerrors.ValidationErrorContext{
+				Field:    "Entries",
+				Value:    i,
+				Expected: "valid metadata index entry",
+			})
+		}
+		index.Entries = append(index.Entries, entry)
+	}
+	return index, nil
+}
+
+// writeMetadataIndex writes a metadata index to w.
+func writeMetadataIndex(w io.Writer, index *fileformat.MetadataIndex) error {
+	buf := make([]byte, 0, index.Size())
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(index.Entries)))
+	buf = binary.LittleEndian.AppendUint32(buf, index.Reserved)
+	for _, entry := range index.Entries {
+		var err error
+		if buf, err = binary.Append(buf, binary.LittleEndian, entry); err != nil {
+			return pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to encode metadata index entry")
+		}
+	}
+	if _, err := w.Write(buf); err != nil {
+		return pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to write metadata index")
+	}
+	return nil
+}
+
+// readPackageSection reads size bytes at offset from file, reporting sections that
+// extend past the end of the file as corruption.
+func readPackageSection(file *os.File, offset, size uint64) ([]byte, error) {
+	if err := checkPackageSection(file, offset, size); err != nil {
+		return nil, err
+	}
+	data := make([]byte, size)
+	if _, err := file.ReadAt(data, int64(offset)); err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to read package section")
+	}
+	return data, nil
+}
+
+// checkPackageSection reports sections that extend past the end of file as corruption.
+func checkPackageSection(file *os.File, offset, size uint64) error {
+	total, err := fileSize(file)
+	if err != nil {
+		return err
+	}
+	if offset > uint64(total) || size > uint64(total)-offset {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "package section extends past end of file", nil, pkgerrors.ValidationErrorContext{
+			Field:    "Offset",
+			Value:    offset,
+			Expected: fmt.Sprintf("%d bytes within %d-byte file", size, total),
+		})
+	}
+	return nil
+}
+
+// copyPackageSection copies size bytes at srcOffset in src to dstOffset in dst and
+// returns dstOffset, or 0 when the section is empty.
+func copyPackageSection(ctx context.Context, src, dst *os.File, srcOffset, size, dstOffset uint64) (uint64, error) {
+	if size == 0 {
+		return 0, nil
+	}
+	if err := checkPackageSection(src, srcOffset, size); err != nil {
+		return 0, err
+	}
+	if _, err := copyWithPooledBuffer(ctx, io.NewOffsetWriter(dst, int64(dstOffset)), io.NewSectionReader(src, int64(srcOffset), int64(size)), int64(size)); err != nil {
+		return 0, err
+	}
+	return dstOffset, nil
+}
+
+// compressPackageDataBlock streams size stored bytes at offset in src through a
+// compressor into dst at its current position and returns the size of the block.
+// An empty section produces an empty block.
+func compressPackageDataBlock(ctx context.Context, src, dst *os.File, offset, size uint64, compressionType uint8) (uint64, error) {
+	if size == 0 {
+		return 0, nil
+	}
+	if err := checkPackageSection(src, offset, size); err != nil {
+		return 0, err
+	}
+	start, err := dst.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to locate data block")
+	}
+	encoder, err := internal.NewCompressWriter(dst, compressionType, 0, nil, int64(size))
+	if err != nil {
+		return 0, err
+	}
+	_, err = copyWithPooledBuffer(ctx, encoder, io.NewSectionReader(src, int64(offset), int64(size)), int64(size))
+	if closeErr := encoder.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	end, err := dst.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to locate data block")
+	}
+	return uint64(end - start), nil
+}
+
+// decompressPackageDataBlock streams the blockSize-byte data block at offset in src
+// through a decompressor into dst at its current position, reporting blocks that
+// do not decode to exactly storedSize bytes as corruption.
+func decompressPackageDataBlock(ctx context.Context, src, dst *os.File, offset, blockSize, storedSize uint64, compressionType uint8) error {
+	if err := checkPackageSection(src, offset, blockSize); err != nil {
+		return err
+	}
+	decoder, err := internal.NewDecompressReader(io.NewSectionReader(src, int64(offset), int64(blockSize)), compressionType, nil, storedSize)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = decoder.Close() }()
+
+	written, err := copyWithPooledBuffer(ctx, dst, decoder, int64(storedSize))
+	if err != nil {
+		if errType, _ := pkgerrors.GetErrorType(err); errType != pkgerrors.ErrTypeCompression {
+			return err
+		}
+	} else if uint64(written) != storedSize {
+		err = io.ErrUnexpectedEOF
+	} else {
+		var probe [1]byte
+		if n, probeErr := decoder.Read(probe[:]); n == 0 && errors.Is(probeErr, io.EOF) {
+			return nil
+		}
+		err = errors.New("data block decodes past its stored size")
+	}
+	return pkgerrors.WrapError(err, pkgerrors.ErrTypeCorruption, "failed to decompress file data block")
+}
+
+// fileSize returns the current size of file.
+func fileSize(file *os.File) (int64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to stat package file")
+	}
+	return info.Size(), nil
+}
@@ -0,0 +1,348 @@
+// This file implements shared compression dictionaries. Dictionaries are trained
+// from package files, stored together in a special file (type 65004), and
+// referenced from each FileEntry through the CompressionDictionaryID optional
+// data entry (0x03). This file should contain only dictionary training, storage
+// and lookup; applying a dictionary during Write and ReadFile belongs in the
+// writer and reader.
+//
+// Specification: package_file_format.md: 4.1.4.4 Optional Data
+
+package novus_package
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"slices"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/generics"
+	"github.com/novus-engine/novuspack/api/go/internal"
+	"github.com/novus-engine/novuspack/api/go/metadata"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+const (
+	// compressionDictionaryFileType is the special file type holding compression dictionaries.
+	compressionDictionaryFileType = uint16(fileformat.FileTypeCompressionDictionary)
+
+	// compressionDictionaryFilePath is the stored path of the compression dictionary special file.
+	compressionDictionaryFilePath = "/__NVPK_DICT_65004__.nvpkdict"
+
+	// dictionaryRecordHeaderSize is the size of the ID and length fields preceding each dictionary.
+	dictionaryRecordHeaderSize = 8
+)
+
+// TrainCompressionDictionary trains a compression dictionary from package files and
+// stores it in the compression dictionary special file.
+//
+// The dictionary is built from the contents of samplePaths, which should be small,
+// similar files (for example, JSON or YAML configs). maxSize bounds the dictionary
+// content in bytes; 0 selects the default size. Files are not changed; use
+// SetFileCompressionDictionary or AddFileOptions.CompressionDictionaryID to compress
+// files with the returned dictionary.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - samplePaths: Package paths of files to train from
+//   - maxSize: Maximum dictionary content size in bytes (0 = default)
+//
+// Returns:
+//   - uint32: ID of the new dictionary
+//   - error: *PackageError on failure
+//
+// Specification: package_file_format.md: 4.1.4.4 Optional Data
+func (p *filePackage) TrainCompressionDictionary(ctx context.Context, samplePaths []string, maxSize int) (uint32, error) {
+	if err := internal.CheckContext(ctx, "TrainCompressionDictionary"); err != nil {
+		return 0, err
+	}
+	if err := p.checkNotSigned("TrainCompressionDictionary"); err != nil {
+		return 0, err
+	}
+	if len(samplePaths) == 0 {
+		return 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "no sample files to train dictionary from", nil, pkgerrors.ValidationErrorContext{
+			Field:    "samplePaths",
+			Value:    0,
+			Expected: "at least one package path",
+		})
+	}
+	if maxSize < 0 {
+		return 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "dictionary size must not be negative", nil, pkgerrors.ValidationErrorContext{
+			Field:    "maxSize",
+			Value:    maxSize,
+			Expected: "0 (default) or a positive size",
+		})
+	}
+
+	samples := make([][]byte, 0, len(samplePaths))
+	for _, path := range samplePaths {
+		fe, err := p.findRegularFileEntry(path)
+		if err != nil {
+			return 0, err
+		}
+		data, err := p.fileEntryContent(ctx, fe)
+		if err != nil {
+			return 0, err
+		}
+		samples = append(samples, data)
+	}
+
+	dicts, err := p.loadCompressionDictionaries(ctx)
+	if err != nil {
+		return 0, err
+	}
+	id := uint32(internal.MinDictionaryID)
+	for existing := range dicts {
+		if existing >= id {
+			id = existing + 1
+		}
+	}
+
+	dict, err := internal.TrainDictionary(id, samples, maxSize)
+	if err != nil {
+		return 0, err
+	}
+	dicts[id] = dict
+	p.storeCompressionDictionaries(dicts)
+	return id, nil
+}
+
+// SetFileCompressionDictionary compresses a file with a stored compression dictionary.
+//
+// The file is switched to Zstd compression and references dictID through its
+// CompressionDictionaryID optional data entry. The data is recompressed on the next Write.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - path: Package path of the file
+//   - dictID: ID returned by TrainCompressionDictionary
+//
+// Returns:
+//   - error: *PackageError on failure
+//
+// Specification: package_file_format.md: 4.1.4.4 Optional Data
+func (p *filePackage) SetFileCompressionDictionary(ctx context.Context, path string, dictID uint32) error {
+	if err := internal.CheckContext(ctx, "SetFileCompressionDictionary"); err != nil {
+		return err
+	}
+	if err := p.checkNotSigned("SetFileCompressionDictionary"); err != nil {
+		return err
+	}
+	if _, err := p.compressionDictionary(ctx, dictID); err != nil {
+		return err
+	}
+	fe, err := p.findRegularFileEntry(path)
+	if err != nil {
+		return err
+	}
+	if groupID, _, ok := fe.GetSolidGroup(); ok {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "file belongs to a solid group", nil, pkgerrors.ValidationErrorContext{
+			Field:    "SolidGroupID",
+			Value:    groupID,
+			Expected: "file outside any solid group",
+		})
+	}
+	if fe.EncryptionType != fileformat.EncryptionNone {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeUnsupported, "cannot recompress encrypted file", nil, pkgerrors.ValidationErrorContext{
+			Field:    "EncryptionType",
+			Value:    fe.EncryptionType,
+			Expected: "unencrypted file",
+		})
+	}
+
+	data, err := p.fileEntryContent(ctx, fe)
+	if err != nil {
+		return err
+	}
+	fe.SetData(data)
+	fe.CompressionType = fileformat.CompressionZstd
+	fe.ClearCompressionFrameSize()
+	fe.SetCompressionDictionaryID(dictID)
+	return nil
+}
+
+// resolveCompressionDictionary returns the dictionary ID requested by options after
+// verifying the dictionary is stored in the package. Returns false if no dictionary
+// is requested.
+func (p *filePackage) resolveCompressionDictionary(ctx context.Context, options *AddFileOptions) (uint32, bool, error) {
+	if options == nil || !options.CompressionDictionaryID.IsSet() {
+		return 0, false, nil
+	}
+	dictID := options.CompressionDictionaryID.GetOrDefault(0)
+	if _, err := p.compressionDictionary(ctx, dictID); err != nil {
+		return 0, false, err
+	}
+	return dictID, true, nil
+}
+
+// findRegularFileEntry resolves a package path to a regular (non-special) file entry.
+func (p *filePackage) findRegularFileEntry(path string) (*metadata.FileEntry, error) {
+	normalizedPath, err := internal.NormalizePackagePath(path)
+	if err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeValidation, "invalid package path")
+	}
+	fe, err := p.findFileEntryByPath(normalizedPath)
+	if err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeValidation, "file not found")
+	}
+	if _, special := p.SpecialFiles[fe.Type]; special {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "path refers to a special file", nil, pkgerrors.ValidationErrorContext{
+			Field:    "path",
+			Value:    normalizedPath,
+			Expected: "regular file path",
+		})
+	}
+	return fe, nil
+}
+
+// fileEntryContent returns the uncompressed content of a file entry, whether it is
+// held in memory, staged in a source file, or stored in the package file.
+func (p *filePackage) fileEntryContent(ctx context.Context, fe *metadata.FileEntry) ([]byte, error) {
+	data, ok, err := rawFileEntryData(fe)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return data, nil
+	}
+	return p.readFileDataFromSource(ctx, fe)
+}
+
+// compressionDictionary returns the stored dictionary with the given ID.
+// Returns ErrTypeValidation if the package has no such dictionary.
+func (p *filePackage) compressionDictionary(ctx context.Context, dictID uint32) ([]byte, error) {
+	dicts, err := p.loadCompressionDictionaries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dict, ok := dicts[dictID]
+	if !ok {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "compression dictionary not found", nil, pkgerrors.ValidationErrorContext{
+			Field:    "CompressionDictionaryID",
+			Value:    dictID,
+			Expected: "ID of a dictionary stored in the package",
+		})
+	}
+	return dict, nil
+}
+
+// loadCompressionDictionaries returns the dictionaries stored in the compression
+// dictionary special file, keyed by ID. The parsed set is cached on the package;
+// concurrent first loads keep the set cached first.
+func (p *filePackage) loadCompressionDictionaries(ctx context.Context) (map[uint32][]byte, error) {
+	p.mu.RLock()
+	cached := p.compressionDictionaries
+	p.mu.RUnlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	dicts := make(map[uint32][]byte)
+	specialFile, exists := p.SpecialFiles[compressionDictionaryFileType]
+	if exists {
+		data, err := p.fileEntryContent(ctx, specialFile)
+		if err != nil {
+			return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to read compression dictionary file")
+		}
+		if dicts, err = decodeCompressionDictionaries(data); err != nil {
+			return nil, err
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.compressionDictionaries != nil {
+		return p.compressionDictionaries, nil
+	}
+	p.compressionDictionaries = dicts
+	return dicts, nil
+}
+
+// storeCompressionDictionaries writes dicts to the compression dictionary special file,
+// creating the special file if needed, and updates the cache.
+func (p *filePackage) storeCompressionDictionaries(dicts map[uint32][]byte) {
+	data := encodeCompressionDictionaries(dicts)
+
+	specialFile, exists := p.SpecialFiles[compressionDictionaryFileType]
+	if !exists {
+		specialFile = metadata.NewFileEntry()
+		specialFile.FileID = p.allocateNextFileID()
+		specialFile.Type = compressionDictionaryFileType
+		specialFile.Paths = []generics.PathEntry{
+			{PathLength: uint16(len(compressionDictionaryFilePath)), Path: compressionDictionaryFilePath},
+		}
+		specialFile.PathCount = 1
+		specialFile.CompressionType = fileformat.CompressionNone // Dictionaries do not compress further
+		specialFile.EncryptionType = fileformat.EncryptionNone
+
+		if p.SpecialFiles == nil {
+			p.SpecialFiles = make(map[uint16]*metadata.FileEntry)
+		}
+		p.SpecialFiles[compressionDictionaryFileType] = specialFile
+		p.FileEntries = append(p.FileEntries, specialFile)
+	}
+	specialFile.OriginalSize = uint64(len(data))
+	specialFile.StoredSize = uint64(len(data))
+	specialFile.SetData(data)
+
+	p.mu.Lock()
+	p.compressionDictionaries = dicts
+	p.mu.Unlock()
+}
+
+// encodeCompressionDictionaries serializes dictionaries in ascending ID order.
+// Each record is [ID: 4 bytes][Length: 4 bytes][Dictionary: Length bytes], little-endian.
+func encodeCompressionDictionaries(dicts map[uint32][]byte) []byte {
+	ids := make([]uint32, 0, len(dicts))
+	size := 0
+	for id, dict := range dicts {
+		ids = append(ids, id)
+		size += dictionaryRecordHeaderSize + len(dict)
+	}
+	slices.Sort(ids)
+
+	out := make([]byte, 0, size)
+	for _, id := range ids {
+		out = binary.LittleEndian.AppendUint32(out, id)
+		out = binary.LittleEndian.AppendUint32(out, uint32(len(dicts[id])))
+		out = append(out, dicts[id]...)
+	}
+	return out
+}
+
+// decodeCompressionDictionaries parses the compression dictionary special file.
+// Returns ErrTypeCorruption if a record is truncated or its ID does not match the dictionary.
+func decodeCompressionDictionaries(data []byte) (map[uint32][]byte, error) {
+	dicts := make(map[uint32][]byte)
+	for offset := 0; offset < len(data); {
+		if len(data)-offset < dictionaryRecordHeaderSize {
+			return nil, dictionaryFileCorruption("truncated dictionary record header", offset)
+		}
+		id := binary.LittleEndian.Uint32(data[offset:])
+		length := int(binary.LittleEndian.Uint32(data[offset+4:]))
+		offset += dictionaryRecordHeaderSize
+		if length > len(data)-offset {
+			return nil, dictionaryFileCorruption("truncated dictionary record", offset)
+		}
+		dict := data[offset : offset+length]
+		offset += length
+
+		embeddedID, err := internal.DictionaryID(dict)
+		if err != nil {
+			return nil, err
+		}
+		if embeddedID != id {
+			return nil, dictionaryFileCorruption(fmt.Sprintf("dictionary ID %d does not match record ID %d", embeddedID, id), offset)
+		}
+		dicts[id] = dict
+	}
+	return dicts, nil
+}
+
+func dictionaryFileCorruption(message string, offset int) error {
+	return pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, message, nil, pkgerrors.ValidationErrorContext{
+		Field:    "CompressionDictionaryFile",
+		Value:    offset,
+		Expected: "well-formed dictionary records",
+	})
+}
@@ -0,0 +1,267 @@
+// This file contains tests for shared compression dictionaries: training,
+// referencing dictionaries from file entries, and reading dictionary-compressed
+// files back after a write and reopen.
+//
+// Specification: package_file_format.md: 4.1.4.4 Optional Data
+
+package novus_package
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/internal"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// dictionaryTestConfigs returns small, similar JSON documents that compress poorly
+// on their own but share most of their content.
+func dictionaryTestConfigs(n int) map[string][]byte {
+	configs := make(map[string][]byte, n)
+	for i := 0; i < n; i++ {
+		path := fmt.Sprintf("/config/unit%03d.json", i)
+		configs[path] = fmt.Appendf(nil, `{"unit":{"id":%d,"name":"unit-%d","faction":"northern-alliance","stats":{"health":%d,"armor":%d,"speed":%d},"abilities":["charge","shield-wall","rally"],"model":"models/units/infantry.mesh"}}`,
+			i, i, 100+i, 10+i%7, 5+i%3)
+	}
+	return configs
+}
+
+// newDictionaryTestPackage creates a package holding the given files uncompressed.
+func newDictionaryTestPackage(t *testing.T, ctx context.Context, files map[string][]byte) Package {
+	t.Helper()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	for path, data := range files {
+		if _, err := pkg.AddFileFromMemory(ctx, path, data, nil); err != nil {
+			t.Fatalf("AddFileFromMemory(%q) failed: %v", path, err)
+		}
+	}
+	return pkg
+}
+
+// trainTestDictionary trains a dictionary from every file in files.
+func trainTestDictionary(t *testing.T, ctx context.Context, pkg Package, files map[string][]byte) uint32 {
+	t.Helper()
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	dictID, err := pkg.TrainCompressionDictionary(ctx, paths, 0)
+	if err != nil {
+		t.Fatalf("TrainCompressionDictionary failed: %v", err)
+	}
+	return dictID
+}
+
+func TestPackage_CompressionDictionary_TrainSetAndReadBack(t *testing.T) {
+	ctx := context.Background()
+	files := dictionaryTestConfigs(64)
+	pkg := newDictionaryTestPackage(t, ctx, files)
+
+	dictID := trainTestDictionary(t, ctx, pkg, files)
+	if dictID != internal.MinDictionaryID {
+		t.Errorf("first dictionary ID = %d, want %d", dictID, internal.MinDictionaryID)
+	}
+	for path := range files {
+		if err := pkg.SetFileCompressionDictionary(ctx, path, dictID); err != nil {
+			t.Fatalf("SetFileCompressionDictionary(%q) failed: %v", path, err)
+		}
+	}
+
+	reopened := writeAndReopen(t, ctx, pkg)
+	fp := reopened.(*filePackage)
+	if _, ok := fp.SpecialFiles[compressionDictionaryFileType]; !ok {
+		t.Fatal("compression dictionary special file not found after reopen")
+	}
+
+	var storedTotal, originalTotal uint64
+	for path, want := range files {
+		fe, err := fp.findFileEntryByPath(path)
+		if err != nil {
+			t.Fatalf("findFileEntryByPath(%q) failed: %v", path, err)
+		}
+		if got, ok := fe.GetCompressionDictionaryID(); !ok || got != dictID {
+			t.Errorf("%s: CompressionDictionaryID = (%d, %v), want (%d, true)", path, got, ok, dictID)
+		}
+		if fe.CompressionType != fileformat.CompressionZstd {
+			t.Errorf("%s: CompressionType = %d, want Zstd", path, fe.CompressionType)
+		}
+		storedTotal += fe.StoredSize
+		originalTotal += fe.OriginalSize
+
+		got, err := reopened.ReadFile(ctx, path)
+		if err != nil {
+			t.Fatalf("ReadFile(%q) failed: %v", path, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("ReadFile(%q) content mismatch", path)
+		}
+	}
+	if storedTotal*3 > originalTotal {
+		t.Errorf("stored %d of %d bytes, want dictionary compression to save at least two thirds", storedTotal, originalTotal)
+	}
+
+	files2, err := reopened.ListFiles()
+	if err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	if len(files2) != len(files) {
+		t.Errorf("ListFiles returned %d files, want %d (dictionary file must be hidden)", len(files2), len(files))
+	}
+}
+
+func TestPackage_CompressionDictionary_AddFileOption(t *testing.T) {
+	ctx := context.Background()
+	files := dictionaryTestConfigs(32)
+	pkg := newDictionaryTestPackage(t, ctx, files)
+	dictID := trainTestDictionary(t, ctx, pkg, files)
+
+	opts := &AddFileOptions{}
+	opts.CompressionDictionaryID.Set(dictID)
+	memData := []byte(`{"unit":{"id":900,"name":"unit-900","faction":"northern-alliance","stats":{"health":1000}}}`)
+	if _, err := pkg.AddFileFromMemory(ctx, "/config/extra.json", memData, opts); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+
+	diskData := []byte(`{"unit":{"id":901,"name":"unit-901","faction":"northern-alliance","stats":{"health":1001}}}`)
+	diskPath := filepath.Join(t.TempDir(), "disk.json")
+	if err := os.WriteFile(diskPath, diskData, 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	diskOpts := &AddFileOptions{}
+	diskOpts.CompressionDictionaryID.Set(dictID)
+	diskOpts.StoredPath.Set("/config/disk.json")
+	if _, err := pkg.AddFile(ctx, diskPath, diskOpts); err != nil {
+		t.Fatalf("AddFile failed: %v", err)
+	}
+
+	reopened := writeAndReopen(t, ctx, pkg)
+	for path, want := range map[string][]byte{"/config/extra.json": memData, "/config/disk.json": diskData} {
+		fe, err := reopened.(*filePackage).findFileEntryByPath(path)
+		if err != nil {
+			t.Fatalf("findFileEntryByPath(%q) failed: %v", path, err)
+		}
+		if got, ok := fe.GetCompressionDictionaryID(); !ok || got != dictID {
+			t.Errorf("%s: CompressionDictionaryID = (%d, %v), want (%d, true)", path, got, ok, dictID)
+		}
+		got, err := reopened.ReadFile(ctx, path)
+		if err != nil {
+			t.Fatalf("ReadFile(%q) failed: %v", path, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("ReadFile(%q) content mismatch", path)
+		}
+	}
+}
+
+func TestPackage_CompressionDictionary_MultipleDictionariesPersist(t *testing.T) {
+	ctx := context.Background()
+	files := dictionaryTestConfigs(16)
+	pkg := newDictionaryTestPackage(t, ctx, files)
+
+	first := trainTestDictionary(t, ctx, pkg, files)
+	second := trainTestDictionary(t, ctx, pkg, files)
+	if second != first+1 {
+		t.Errorf("second dictionary ID = %d, want %d", second, first+1)
+	}
+	if err := pkg.SetFileCompressionDictionary(ctx, "/config/unit000.json", first); err != nil {
+		t.Fatalf("SetFileCompressionDictionary failed: %v", err)
+	}
+	if err := pkg.SetFileCompressionDictionary(ctx, "/config/unit001.json", second); err != nil {
+		t.Fatalf("SetFileCompressionDictionary failed: %v", err)
+	}
+
+	reopened := writeAndReopen(t, ctx, pkg)
+	for _, path := range []string{"/config/unit000.json", "/config/unit001.json"} {
+		got, err := reopened.ReadFile(ctx, path)
+		if err != nil {
+			t.Fatalf("ReadFile(%q) failed: %v", path, err)
+		}
+		if !bytes.Equal(got, files[path]) {
+			t.Errorf("ReadFile(%q) content mismatch", path)
+		}
+	}
+
+	third, err := reopened.TrainCompressionDictionary(ctx, []string{"/config/unit002.json"}, 0)
+	if err != nil {
+		t.Fatalf("TrainCompressionDictionary on reopened package failed: %v", err)
+	}
+	if third != second+1 {
+		t.Errorf("dictionary ID after reopen = %d, want %d", third, second+1)
+	}
+}
+
+func TestPackage_CompressionDictionary_Errors(t *testing.T) {
+	ctx := context.Background()
+	files := dictionaryTestConfigs(4)
+	pkg := newDictionaryTestPackage(t, ctx, files)
+
+	_, err := pkg.TrainCompressionDictionary(ctx, nil, 0)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	_, err = pkg.TrainCompressionDictionary(ctx, []string{"/config/unit000.json"}, -1)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	_, err = pkg.TrainCompressionDictionary(ctx, []string{"/missing.json"}, 0)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	err = pkg.SetFileCompressionDictionary(ctx, "/config/unit000.json", internal.MinDictionaryID)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	dictID := trainTestDictionary(t, ctx, pkg, files)
+	err = pkg.SetFileCompressionDictionary(ctx, compressionDictionaryFilePath, dictID)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	opts := &AddFileOptions{}
+	opts.CompressionDictionaryID.Set(dictID + 1)
+	_, err = pkg.AddFileFromMemory(ctx, "/unknown-dict.json", []byte("{}"), opts)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	opts = compressionOptions(fileformat.CompressionLZ4, 0)
+	opts.CompressionDictionaryID.Set(dictID)
+	_, err = pkg.AddFileFromMemory(ctx, "/lz4-dict.json", []byte("{}"), opts)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	opts = autoCompressOptions()
+	opts.CompressionDictionaryID.Set(dictID)
+	_, err = pkg.AddFileFromMemory(ctx, "/auto-dict.json", []byte("{}"), opts)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+}
+
+func TestDecodeCompressionDictionaries_Corruption(t *testing.T) {
+	dict, err := internal.TrainDictionary(internal.MinDictionaryID, [][]byte{compressibleTestData()}, 0)
+	if err != nil {
+		t.Fatalf("TrainDictionary failed: %v", err)
+	}
+	encoded := encodeCompressionDictionaries(map[uint32][]byte{internal.MinDictionaryID: dict})
+
+	decoded, err := decodeCompressionDictionaries(encoded)
+	if err != nil {
+		t.Fatalf("decodeCompressionDictionaries failed: %v", err)
+	}
+	if !bytes.Equal(decoded[internal.MinDictionaryID], dict) {
+		t.Error("decoded dictionary does not match encoded dictionary")
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"truncated header", encoded[:4]},
+		{"truncated dictionary", encoded[:len(encoded)-1]},
+		{"mismatched ID", append([]byte{0x01, 0x00, 0x00, 0x00}, encoded[4:]...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeCompressionDictionaries(tt.data)
+			assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption)
+		})
+	}
+}
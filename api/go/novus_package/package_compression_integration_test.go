@@ -0,0 +1,642 @@
+// This file contains integration tests for per-file compression.
+// It verifies that compressed file entries are written, reopened, and read back correctly.
+//
+// Specification: api_file_mgmt_compression.md: 1. FileEntry.Compress Method
+
+package novus_package
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/internal"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// compressibleTestData returns repetitive content that compresses well.
+func compressibleTestData() []byte {
+	return bytes.Repeat([]byte("level-geometry vertex buffer block "), 256)
+}
+
+// compressionOptions builds AddFileOptions requesting the given compression type and level.
+func compressionOptions(compressionType uint8, level int) *AddFileOptions {
+	opts := &AddFileOptions{}
+	opts.CompressionType.Set(compressionType)
+	if level != 0 {
+		opts.CompressionLevel.Set(level)
+	}
+	return opts
+}
+
+// writeAndReopen writes pkg to a temp path and reopens it.
+func writeAndReopen(t *testing.T, ctx context.Context, pkg Package) Package {
+	t.Helper()
+	tmpPkg := filepath.Join(t.TempDir(), "test.pkg")
+	if err := pkg.SetTargetPath(ctx, tmpPkg); err != nil {
+		t.Fatalf("SetTargetPath failed: %v", err)
+	}
+	if err := pkg.Write(ctx); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	reopened, err := OpenPackage(ctx, tmpPkg)
+	if err != nil {
+		t.Fatalf("OpenPackage failed: %v", err)
+	}
+	t.Cleanup(func() { _ = reopened.Close() })
+	return reopened
+}
+
+// assertStoredCompressed verifies the stored metadata of a compressed entry.
+func assertStoredCompressed(t *testing.T, pkg Package, path string, compressionType uint8, level uint8, original []byte) {
+	t.Helper()
+	fe, err := pkg.(*filePackage).findFileEntryByPath(path)
+	if err != nil {
+		t.Fatalf("findFileEntryByPath(%q) failed: %v", path, err)
+	}
+	if fe.CompressionType != compressionType {
+		t.Errorf("CompressionType = %d, want %d", fe.CompressionType, compressionType)
+	}
+	if fe.CompressionLevel != level {
+		t.Errorf("CompressionLevel = %d, want %d", fe.CompressionLevel, level)
+	}
+	if fe.OriginalSize != uint64(len(original)) {
+		t.Errorf("OriginalSize = %d, want %d", fe.OriginalSize, len(original))
+	}
+	if fe.StoredSize == 0 || fe.StoredSize >= fe.OriginalSize {
+		t.Errorf("StoredSize = %d, want 0 < StoredSize < %d", fe.StoredSize, fe.OriginalSize)
+	}
+	if fe.RawChecksum != internal.CalculateCRC32(original) {
+		t.Errorf("RawChecksum = 0x%08X, want checksum of original data", fe.RawChecksum)
+	}
+	if fe.StoredChecksum == fe.RawChecksum {
+		t.Error("StoredChecksum equals RawChecksum, want checksum of compressed data")
+	}
+}
+
+func TestPackage_Compression_Zstd_AddFileFromMemory_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+
+	data := compressibleTestData()
+	if _, err := pkg.AddFileFromMemory(ctx, "/levels/level1.bin", data, compressionOptions(fileformat.CompressionZstd, 0)); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+
+	reopened := writeAndReopen(t, ctx, pkg)
+	assertStoredCompressed(t, reopened, "/levels/level1.bin", fileformat.CompressionZstd, internal.DefaultCompressionLevel, data)
+
+	got, err := reopened.ReadFile(ctx, "/levels/level1.bin")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("ReadFile content mismatch: got %d bytes, want %d", len(got), len(data))
+	}
+
+	if reopened.(*filePackage).header.Flags&fileformat.FlagHasCompressedFiles == 0 {
+		t.Error("FlagHasCompressedFiles not set in header")
+	}
+
+	files, err := reopened.ListFiles()
+	if err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	if len(files) != 1 || !files[0].IsCompressed || files[0].CompressionType != fileformat.CompressionZstd {
+		t.Errorf("ListFiles = %+v, want one Zstd-compressed file", files)
+	}
+}
+
+func TestPackage_Compression_Zstd_AddFile_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+
+	data := compressibleTestData()
+	srcPath := filepath.Join(t.TempDir(), "texture.dds")
+	if err := os.WriteFile(srcPath, data, 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	opts := &AddFileOptions{}
+	opts.Compress.Set(true)
+	opts.CompressionLevel.Set(3)
+	opts.StoredPath.Set("/textures/texture.dds")
+	if _, err := pkg.AddFile(ctx, srcPath, opts); err != nil {
+		t.Fatalf("AddFile failed: %v", err)
+	}
+
+	reopened := writeAndReopen(t, ctx, pkg)
+	assertStoredCompressed(t, reopened, "/textures/texture.dds", fileformat.CompressionZstd, 3, data)
+
+	got, err := reopened.ReadFile(ctx, "/textures/texture.dds")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("ReadFile content mismatch")
+	}
+}
+
+func TestPackage_Compression_LZ4_RoundTripAndListFiles(t *testing.T) {
+	ctx := context.Background()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+
+	hot := compressibleTestData()
+	if _, err := pkg.AddFileFromMemory(ctx, "/hot/sprite.bin", hot, compressionOptions(fileformat.CompressionLZ4, 1)); err != nil {
+		t.Fatalf("AddFileFromMemory(lz4) failed: %v", err)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/cold/raw.txt", []byte("uncompressed"), nil); err != nil {
+		t.Fatalf("AddFileFromMemory(raw) failed: %v", err)
+	}
+
+	reopened := writeAndReopen(t, ctx, pkg)
+	assertStoredCompressed(t, reopened, "/hot/sprite.bin", fileformat.CompressionLZ4, 1, hot)
+
+	got, err := reopened.ReadFile(ctx, "/hot/sprite.bin")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if !bytes.Equal(got, hot) {
+		t.Error("ReadFile content mismatch")
+	}
+
+	files, err := reopened.ListFiles()
+	if err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	byPath := make(map[string]FileInfo, len(files))
+	for _, f := range files {
+		byPath[f.PrimaryPath] = f
+	}
+	if f := byPath["hot/sprite.bin"]; !f.IsCompressed || f.CompressionType != fileformat.CompressionLZ4 || f.StoredSize >= f.Size {
+		t.Errorf("ListFiles hot/sprite.bin = %+v, want LZ4-compressed", f)
+	}
+	if f := byPath["cold/raw.txt"]; f.IsCompressed || f.CompressionType != fileformat.CompressionNone {
+		t.Errorf("ListFiles cold/raw.txt = %+v, want uncompressed", f)
+	}
+}
+
+func TestPackage_Compression_Zstd_RewritePreservesCompressedEntries(t *testing.T) {
+	ctx := context.Background()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+
+	data := compressibleTestData()
+	if _, err := pkg.AddFileFromMemory(ctx, "/a.bin", data, compressionOptions(fileformat.CompressionZstd, 9)); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+
+	reopened := writeAndReopen(t, ctx, pkg)
+	if _, err := reopened.AddFileFromMemory(ctx, "/b.txt", []byte("plain"), nil); err != nil {
+		t.Fatalf("AddFileFromMemory on reopened package failed: %v", err)
+	}
+
+	second := writeAndReopen(t, ctx, reopened)
+	assertStoredCompressed(t, second, "/a.bin", fileformat.CompressionZstd, 9, data)
+
+	got, err := second.ReadFile(ctx, "/a.bin")
+	if err != nil {
+		t.Fatalf("ReadFile(/a.bin) failed: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("ReadFile(/a.bin) content mismatch after rewrite")
+	}
+	plain, err := second.ReadFile(ctx, "/b.txt")
+	if err != nil {
+		t.Fatalf("ReadFile(/b.txt) failed: %v", err)
+	}
+	if string(plain) != "plain" {
+		t.Errorf("ReadFile(/b.txt) = %q, want %q", plain, "plain")
+	}
+}
+
+func TestPackage_Compression_CorruptedStoredData(t *testing.T) {
+	ctx := context.Background()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+
+	data := compressibleTestData()
+	if _, err := pkg.AddFileFromMemory(ctx, "/a.bin", data, compressionOptions(fileformat.CompressionZstd, 0)); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	reopened := writeAndReopen(t, ctx, pkg)
+
+	fe, err := reopened.(*filePackage).findFileEntryByPath("/a.bin")
+	if err != nil {
+		t.Fatalf("findFileEntryByPath failed: %v", err)
+	}
+	fe.StoredChecksum ^= 0xFFFFFFFF
+
+	_, err = reopened.ReadFile(ctx, "/a.bin")
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption)
+}
+
+func TestPackage_Compression_OptionErrors(t *testing.T) {
+	ctx := context.Background()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+
+	_, err = pkg.AddFileFromMemory(ctx, "/bad-level.bin", []byte("x"), compressionOptions(fileformat.CompressionZstd, 12))
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	_, err = pkg.AddFileFromMemory(ctx, "/bad-type.bin", []byte("x"), compressionOptions(0x7F, 0))
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeUnsupported)
+}
+
+func TestResolveCompressionOptions(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   func() *AddFileOptions
+		wantType  uint8
+		wantLevel uint8
+	}{
+		{"nil options", func() *AddFileOptions { return nil }, fileformat.CompressionNone, 0},
+		{"no compression", func() *AddFileOptions { return &AddFileOptions{} }, fileformat.CompressionNone, 0},
+		{"compress defaults to zstd", func() *AddFileOptions {
+			opts := &AddFileOptions{}
+			opts.Compress.Set(true)
+			return opts
+		}, fileformat.CompressionZstd, internal.DefaultCompressionLevel},
+		{"type implies compress", func() *AddFileOptions {
+			return compressionOptions(fileformat.CompressionZstd, 2)
+		}, fileformat.CompressionZstd, 2},
+		{"compress false overrides type", func() *AddFileOptions {
+			opts := compressionOptions(fileformat.CompressionZstd, 0)
+			opts.Compress.Set(false)
+			return opts
+		}, fileformat.CompressionNone, 0},
+		{"dictionary implies zstd", func() *AddFileOptions {
+			opts := &AddFileOptions{}
+			opts.CompressionDictionaryID.Set(internal.MinDictionaryID)
+			return opts
+		}, fileformat.CompressionZstd, internal.DefaultCompressionLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotType, gotLevel, err := resolveCompressionOptions(tt.options(), fileformat.FileTypeBinary, nil)
+			if err != nil {
+				t.Fatalf("resolveCompressionOptions() error = %v", err)
+			}
+			if gotType != tt.wantType || gotLevel != tt.wantLevel {
+				t.Errorf("resolveCompressionOptions() = (%d, %d), want (%d, %d)", gotType, gotLevel, tt.wantType, tt.wantLevel)
+			}
+		})
+	}
+}
+
+func TestPackage_Compression_LZMA_LevelsRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	data := compressibleTestData()
+
+	for level := 1; level <= 9; level++ {
+		t.Run("level"+string(rune('0'+level)), func(t *testing.T) {
+			pkg, err := NewPackage()
+			if err != nil {
+				t.Fatalf("NewPackage failed: %v", err)
+			}
+			if _, err := pkg.AddFileFromMemory(ctx, "/loc/strings.bin", data, compressionOptions(fileformat.CompressionLZMA, level)); err != nil {
+				t.Fatalf("AddFileFromMemory failed: %v", err)
+			}
+
+			reopened := writeAndReopen(t, ctx, pkg)
+			assertStoredCompressed(t, reopened, "/loc/strings.bin", fileformat.CompressionLZMA, uint8(level), data)
+
+			got, err := reopened.ReadFile(ctx, "/loc/strings.bin")
+			if err != nil {
+				t.Fatalf("ReadFile failed: %v", err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Error("ReadFile content mismatch")
+			}
+		})
+	}
+}
+
+func TestPackage_Compression_LZMA_SafeWrite_InSubdirectory(t *testing.T) {
+	ctx := context.Background()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	data := compressibleTestData()
+	if _, err := pkg.AddFileFromMemory(ctx, "/subtitles/en.srt", data, compressionOptions(fileformat.CompressionLZMA, 0)); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+
+	subDir := filepath.Join(t.TempDir(), "subdir")
+	if err := os.MkdirAll(subDir, 0o755); err != nil {
+		t.Fatalf("Failed to create subdirectory: %v", err)
+	}
+	tmpPkg := filepath.Join(subDir, "test.pkg")
+	if err := pkg.SetTargetPath(ctx, tmpPkg); err != nil {
+		t.Fatalf("SetTargetPath failed: %v", err)
+	}
+	if err := pkg.SafeWrite(ctx, true); err != nil {
+		t.Fatalf("SafeWrite failed: %v", err)
+	}
+
+	reopened, err := OpenPackage(ctx, tmpPkg)
+	if err != nil {
+		t.Fatalf("OpenPackage failed: %v", err)
+	}
+	defer func() { _ = reopened.Close() }()
+	got, err := reopened.ReadFile(ctx, "/subtitles/en.srt")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("ReadFile content mismatch")
+	}
+}
+
+func TestPackage_Compression_LZMA_Write_ReopenAndModify(t *testing.T) {
+	ctx := context.Background()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	v1 := compressibleTestData()
+	if _, err := pkg.AddFileFromMemory(ctx, "/loc/en.bin", v1, compressionOptions(fileformat.CompressionLZMA, 9)); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+
+	reopened := writeAndReopen(t, ctx, pkg)
+	v2 := bytes.Repeat([]byte("localized string table "), 128)
+	if _, err := reopened.AddFileFromMemory(ctx, "/loc/fr.bin", v2, compressionOptions(fileformat.CompressionLZMA, 1)); err != nil {
+		t.Fatalf("AddFileFromMemory on reopened package failed: %v", err)
+	}
+
+	second := writeAndReopen(t, ctx, reopened)
+	for path, want := range map[string][]byte{"/loc/en.bin": v1, "/loc/fr.bin": v2} {
+		got, err := second.ReadFile(ctx, path)
+		if err != nil {
+			t.Fatalf("ReadFile(%s) failed: %v", path, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("ReadFile(%s) content mismatch", path)
+		}
+	}
+	assertStoredCompressed(t, second, "/loc/en.bin", fileformat.CompressionLZMA, 9, v1)
+	assertStoredCompressed(t, second, "/loc/fr.bin", fileformat.CompressionLZMA, 1, v2)
+}
+
+func TestPackage_Compression_LZMA_WritePackageToFile_IndexBuilding(t *testing.T) {
+	ctx := context.Background()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+
+	contents := make(map[string][]byte)
+	for i := 0; i < 5; i++ {
+		path := "/file" + string(rune('0'+i)) + ".txt"
+		data := bytes.Repeat([]byte(path), 100+i)
+		contents[path] = data
+		if _, err := pkg.AddFileFromMemory(ctx, path, data, compressionOptions(fileformat.CompressionLZMA, 0)); err != nil {
+			t.Fatalf("AddFileFromMemory(%s) failed: %v", path, err)
+		}
+	}
+
+	reopened := writeAndReopen(t, ctx, pkg)
+	for path, want := range contents {
+		got, err := reopened.ReadFile(ctx, path)
+		if err != nil {
+			t.Fatalf("ReadFile(%s) failed: %v", path, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("ReadFile(%s) content mismatch", path)
+		}
+	}
+}
+
+func TestPackage_Compression_LZMA_AddFile_EmptyFile(t *testing.T) {
+	ctx := context.Background()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+
+	srcPath := filepath.Join(t.TempDir(), "empty.txt")
+	if err := os.WriteFile(srcPath, nil, 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	opts := compressionOptions(fileformat.CompressionLZMA, 0)
+	opts.StoredPath.Set("/empty.txt")
+	if _, err := pkg.AddFile(ctx, srcPath, opts); err != nil {
+		t.Fatalf("AddFile failed: %v", err)
+	}
+
+	reopened := writeAndReopen(t, ctx, pkg)
+	got, err := reopened.ReadFile(ctx, "/empty.txt")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("ReadFile len = %d, want 0", len(got))
+	}
+}
+
+// autoCompressOptions builds AddFileOptions requesting automatic compression.
+func autoCompressOptions() *AddFileOptions {
+	opts := &AddFileOptions{}
+	opts.AutoCompress.Set(true)
+	return opts
+}
+
+// incompressibleTestData returns pseudo-random content that does not compress.
+func incompressibleTestData() []byte {
+	data := make([]byte, 8192)
+	state := uint32(2463534242)
+	for i := range data {
+		state ^= state << 13
+		state ^= state >> 17
+		state ^= state << 5
+		data[i] = byte(state)
+	}
+	return data
+}
+
+func TestPackage_AutoCompress_SelectsByFileType(t *testing.T) {
+	ctx := context.Background()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+
+	// Each file gets distinct content so entries are not deduplicated.
+	text := compressibleTestData()
+	bmp := bytes.Repeat([]byte("pixel row "), 512)
+	png := append([]byte("\x89PNG\r\n\x1a\n"), text...)
+	ogg := bytes.Repeat([]byte("audio page "), 512)
+	zip := bytes.Repeat([]byte("archive member "), 512)
+	tests := []struct {
+		path     string
+		data     []byte
+		wantType uint8
+		fileType fileformat.FileType
+	}{
+		{"/scripts/init.lua", text, fileformat.CompressionZstd, fileformat.FileTypeLua},
+		{"/textures/albedo.bmp", bmp, fileformat.CompressionLZ4, fileformat.FileTypeBMP},
+		{"/textures/icon.png", png, fileformat.CompressionNone, fileformat.FileTypePNG},
+		{"/music/theme.ogg", ogg, fileformat.CompressionNone, fileformat.FileTypeOGG},
+		{"/bundles/dlc.zip", zip, fileformat.CompressionNone, fileformat.FileTypeArchive},
+		{"/data/noise.bin", incompressibleTestData(), fileformat.CompressionNone, fileformat.FileTypeBinary},
+	}
+
+	for _, tt := range tests {
+		fe, err := pkg.AddFileFromMemory(ctx, tt.path, tt.data, autoCompressOptions())
+		if err != nil {
+			t.Fatalf("AddFileFromMemory(%s) failed: %v", tt.path, err)
+		}
+		if fe.Type != uint16(tt.fileType) {
+			t.Errorf("%s: Type = %d, want %d", tt.path, fe.Type, tt.fileType)
+		}
+		if fe.CompressionType != tt.wantType {
+			t.Errorf("%s: CompressionType = %d, want %d", tt.path, fe.CompressionType, tt.wantType)
+		}
+	}
+
+	reopened := writeAndReopen(t, ctx, pkg)
+	for _, tt := range tests {
+		got, err := reopened.ReadFile(ctx, tt.path)
+		if err != nil {
+			t.Fatalf("ReadFile(%s) failed: %v", tt.path, err)
+		}
+		if !bytes.Equal(got, tt.data) {
+			t.Errorf("ReadFile(%s) content mismatch", tt.path)
+		}
+	}
+	assertStoredCompressed(t, reopened, "/scripts/init.lua", fileformat.CompressionZstd, internal.DefaultCompressionLevel, text)
+	assertStoredCompressed(t, reopened, "/textures/albedo.bmp", fileformat.CompressionLZ4, internal.DefaultCompressionLevel, bmp)
+}
+
+func TestPackage_AutoCompress_AddFile(t *testing.T) {
+	ctx := context.Background()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+
+	data := compressibleTestData()
+	srcPath := filepath.Join(t.TempDir(), "settings.yaml")
+	if err := os.WriteFile(srcPath, data, 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	opts := autoCompressOptions()
+	opts.CompressionLevel.Set(3)
+	opts.StoredPath.Set("/config/settings.yaml")
+	fe, err := pkg.AddFile(ctx, srcPath, opts)
+	if err != nil {
+		t.Fatalf("AddFile failed: %v", err)
+	}
+	if fe.Type != uint16(fileformat.FileTypeYAML) {
+		t.Errorf("Type = %d, want %d", fe.Type, fileformat.FileTypeYAML)
+	}
+
+	reopened := writeAndReopen(t, ctx, pkg)
+	assertStoredCompressed(t, reopened, "/config/settings.yaml", fileformat.CompressionZstd, 3, data)
+	got, err := reopened.ReadFile(ctx, "/config/settings.yaml")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("ReadFile content mismatch")
+	}
+}
+
+func TestPackage_AutoCompress_MinSavingsThreshold(t *testing.T) {
+	ctx := context.Background()
+
+	// Half compressible, half random: saves well under 90% but more than 10%.
+	data := append(bytes.Repeat([]byte{'a'}, 8192), incompressibleTestData()...)
+	for _, tt := range []struct {
+		minSavings int
+		wantType   uint8
+	}{
+		{0, fileformat.CompressionZstd},
+		{10, fileformat.CompressionZstd},
+		{90, fileformat.CompressionNone},
+	} {
+		pkg, err := NewPackage()
+		if err != nil {
+			t.Fatalf("NewPackage failed: %v", err)
+		}
+		opts := autoCompressOptions()
+		opts.AutoCompressMinSavings.Set(tt.minSavings)
+		fe, err := pkg.AddFileFromMemory(ctx, "/data/mixed.bin", data, opts)
+		if err != nil {
+			t.Fatalf("AddFileFromMemory(minSavings=%d) failed: %v", tt.minSavings, err)
+		}
+		if fe.CompressionType != tt.wantType {
+			t.Errorf("minSavings=%d: CompressionType = %d, want %d", tt.minSavings, fe.CompressionType, tt.wantType)
+		}
+	}
+}
+
+func TestPackage_AutoCompress_FileTypeOverride(t *testing.T) {
+	ctx := context.Background()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+
+	opts := autoCompressOptions()
+	opts.FileType.Set(uint16(fileformat.FileTypeJPEG))
+	fe, err := pkg.AddFileFromMemory(ctx, "/textures/baked.txt", compressibleTestData(), opts)
+	if err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	if fe.Type != uint16(fileformat.FileTypeJPEG) {
+		t.Errorf("Type = %d, want %d", fe.Type, fileformat.FileTypeJPEG)
+	}
+	if fe.CompressionType != fileformat.CompressionNone {
+		t.Errorf("CompressionType = %d, want %d", fe.CompressionType, fileformat.CompressionNone)
+	}
+}
+
+func TestPackage_AutoCompress_OptionErrors(t *testing.T) {
+	ctx := context.Background()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+
+	opts := autoCompressOptions()
+	opts.CompressionType.Set(fileformat.CompressionLZ4)
+	_, err = pkg.AddFileFromMemory(ctx, "/a.txt", []byte("x"), opts)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	opts = autoCompressOptions()
+	opts.Compress.Set(true)
+	_, err = pkg.AddFileFromMemory(ctx, "/b.txt", []byte("x"), opts)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	opts = autoCompressOptions()
+	opts.AutoCompressMinSavings.Set(100)
+	_, err = pkg.AddFileFromMemory(ctx, "/c.txt", []byte("x"), opts)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	opts = autoCompressOptions()
+	opts.CompressionLevel.Set(10)
+	_, err = pkg.AddFileFromMemory(ctx, "/d.txt", []byte("x"), opts)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+}
@@ -0,0 +1,313 @@
+// This file contains tests for whole-package compression: compressing and
+// decompressing packages, the compressed on-disk layout, and transparent opening
+// of compressed packages.
+//
+// Specification: api_package_compression.md: 4. In-Memory Compression Methods
+// Specification: package_file_format.md: 5 Metadata Index Section
+
+package novus_package
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// packageCompressionTestFiles returns compressible files for package compression tests.
+func packageCompressionTestFiles() map[string][]byte {
+	return map[string][]byte{
+		"/maps/level1.map":    compressibleTestData(),
+		"/scripts/intro.lua":  bytes.Repeat([]byte("print(\"welcome to the intro\")\n"), 64),
+		"/textures/empty.dds": {},
+	}
+}
+
+// newPackageCompressionTestPackage creates a package holding files and a comment.
+func newPackageCompressionTestPackage(t *testing.T, ctx context.Context, files map[string][]byte) Package {
+	t.Helper()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	for _, path := range sortedPaths(files) {
+		if _, err := pkg.AddFileFromMemory(ctx, path, files[path], nil); err != nil {
+			t.Fatalf("AddFileFromMemory(%q) failed: %v", path, err)
+		}
+	}
+	if err := pkg.SetComment("compressed test package"); err != nil {
+		t.Fatalf("SetComment failed: %v", err)
+	}
+	return pkg
+}
+
+// assertPackageCompression verifies the package compression state reported by GetInfo.
+func assertPackageCompression(t *testing.T, pkg Package, want uint8) {
+	t.Helper()
+	info, err := pkg.GetInfo()
+	if err != nil {
+		t.Fatalf("GetInfo failed: %v", err)
+	}
+	if info.PackageCompression != want || info.IsPackageCompressed != (want != fileformat.CompressionNone) {
+		t.Errorf("PackageCompression = %d (compressed %v), want %d", info.PackageCompression, info.IsPackageCompressed, want)
+	}
+}
+
+func TestPackage_CompressPackage_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	files := packageCompressionTestFiles()
+
+	tests := []struct {
+		name            string
+		compressionType uint8
+	}{
+		{"zstd", fileformat.CompressionZstd},
+		{"lz4", fileformat.CompressionLZ4},
+		{"lzma", fileformat.CompressionLZMA},
+	}
+	for _, tt := range tests {
+		compressionType := tt.compressionType
+		t.Run(tt.name, func(t *testing.T) {
+			pkg := newPackageCompressionTestPackage(t, ctx, files)
+			if err := pkg.CompressPackage(ctx, compressionType); err != nil {
+				t.Fatalf("CompressPackage failed: %v", err)
+			}
+			assertPackageCompression(t, pkg, compressionType)
+
+			reopened := writeAndReopen(t, ctx, pkg)
+			assertPackageCompression(t, reopened, compressionType)
+			assertReadsBack(t, ctx, reopened, files)
+			if got := reopened.GetComment(); got != "compressed test package" {
+				t.Errorf("GetComment() = %q, want %q", got, "compressed test package")
+			}
+			if err := reopened.Validate(ctx); err != nil {
+				t.Errorf("Validate failed: %v", err)
+			}
+
+			info, err := reopened.GetInfo()
+			if err != nil {
+				t.Fatalf("GetInfo failed: %v", err)
+			}
+			if info.PackageCompressedSize <= 0 || info.PackageCompressedSize >= info.PackageOriginalSize {
+				t.Errorf("PackageCompressedSize = %d, want between 0 and PackageOriginalSize %d", info.PackageCompressedSize, info.PackageOriginalSize)
+			}
+			if info.PackageCompressionRatio <= 0 || info.PackageCompressionRatio >= 1 {
+				t.Errorf("PackageCompressionRatio = %f, want between 0 and 1", info.PackageCompressionRatio)
+			}
+		})
+	}
+}
+
+func TestPackage_CompressPackage_Layout(t *testing.T) {
+	ctx := context.Background()
+	pkg := newPackageCompressionTestPackage(t, ctx, packageCompressionTestFiles())
+	if err := pkg.CompressPackage(ctx, fileformat.CompressionZstd); err != nil {
+		t.Fatalf("CompressPackage failed: %v", err)
+	}
+	reopened := writeAndReopen(t, ctx, pkg)
+	fp := reopened.(*filePackage)
+
+	raw, err := os.ReadFile(fp.FilePath)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	flags := binary.LittleEndian.Uint32(raw[8:12])
+	if got := uint8((flags & fileformat.FlagsMaskCompressionType) >> fileformat.FlagsShiftCompressionType); got != fileformat.CompressionZstd {
+		t.Errorf("header compression type = %d, want %d", got, fileformat.CompressionZstd)
+	}
+
+	// The metadata index at offset 112 lists every entry, special files included
+	entryCount := binary.LittleEndian.Uint32(raw[fileformat.PackageHeaderSize:])
+	if int(entryCount) != len(fp.FileEntries) {
+		t.Errorf("metadata index EntryCount = %d, want %d", entryCount, len(fp.FileEntries))
+	}
+
+	// The comment stays readable in place
+	if !bytes.Contains(raw, []byte("compressed test package")) {
+		t.Error("package comment not stored uncompressed")
+	}
+	if bytes.Contains(raw, []byte("level-geometry vertex buffer block level-geometry")) {
+		t.Error("file data stored uncompressed")
+	}
+}
+
+func TestPackage_CompressPackage_RewriteAndDecompress(t *testing.T) {
+	ctx := context.Background()
+	files := packageCompressionTestFiles()
+	pkg := newPackageCompressionTestPackage(t, ctx, files)
+	if err := pkg.CompressPackage(ctx, fileformat.CompressionLZ4); err != nil {
+		t.Fatalf("CompressPackage failed: %v", err)
+	}
+	reopened := writeAndReopen(t, ctx, pkg)
+
+	files["/scripts/outro.lua"] = []byte("print(\"goodbye\")\n")
+	if _, err := reopened.AddFileFromMemory(ctx, "/scripts/outro.lua", files["/scripts/outro.lua"], nil); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	second := writeAndReopen(t, ctx, reopened)
+	assertPackageCompression(t, second, fileformat.CompressionLZ4)
+	assertReadsBack(t, ctx, second, files)
+
+	if err := second.DecompressPackage(ctx); err != nil {
+		t.Fatalf("DecompressPackage failed: %v", err)
+	}
+	third := writeAndReopen(t, ctx, second)
+	assertPackageCompression(t, third, fileformat.CompressionNone)
+	assertReadsBack(t, ctx, third, files)
+	if third.(*filePackage).spoolPath != "" {
+		t.Error("uncompressed package opened through a spool file")
+	}
+}
+
+func TestPackage_CompressPackageFile(t *testing.T) {
+	ctx := context.Background()
+	files := packageCompressionTestFiles()
+	pkg := newPackageCompressionTestPackage(t, ctx, files)
+	dir := t.TempDir()
+
+	compressedPath := filepath.Join(dir, "compressed.nvpk")
+	if err := pkg.CompressPackageFile(ctx, compressedPath, fileformat.CompressionZstd, false); err != nil {
+		t.Fatalf("CompressPackageFile failed: %v", err)
+	}
+	err := pkg.CompressPackageFile(ctx, compressedPath, fileformat.CompressionZstd, false)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	compressed, err := OpenPackage(ctx, compressedPath)
+	if err != nil {
+		t.Fatalf("OpenPackage failed: %v", err)
+	}
+	defer func() { _ = compressed.Close() }()
+	assertPackageCompression(t, compressed, fileformat.CompressionZstd)
+	assertReadsBack(t, ctx, compressed, files)
+
+	plainPath := filepath.Join(dir, "plain.nvpk")
+	if err := compressed.DecompressPackageFile(ctx, plainPath, false); err != nil {
+		t.Fatalf("DecompressPackageFile failed: %v", err)
+	}
+	err = compressed.DecompressPackageFile(ctx, plainPath, true)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	plain, err := OpenPackage(ctx, plainPath)
+	if err != nil {
+		t.Fatalf("OpenPackage failed: %v", err)
+	}
+	defer func() { _ = plain.Close() }()
+	assertPackageCompression(t, plain, fileformat.CompressionNone)
+	assertReadsBack(t, ctx, plain, files)
+}
+
+func TestPackage_CompressPackage_Errors(t *testing.T) {
+	ctx := context.Background()
+	pkg := newPackageCompressionTestPackage(t, ctx, packageCompressionTestFiles())
+
+	err := pkg.DecompressPackage(ctx)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	for _, compressionType := range []uint8{fileformat.CompressionNone, fileformat.CompressionLZMA + 1} {
+		err = pkg.CompressPackage(ctx, compressionType)
+		assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+	}
+
+	if err := pkg.CompressPackage(ctx, fileformat.CompressionZstd); err != nil {
+		t.Fatalf("CompressPackage failed: %v", err)
+	}
+	if err := pkg.CompressPackage(ctx, fileformat.CompressionZstd); err != nil {
+		t.Errorf("CompressPackage with the current type failed: %v", err)
+	}
+	err = pkg.CompressPackage(ctx, fileformat.CompressionLZ4)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	signed := newPackageCompressionTestPackage(t, ctx, packageCompressionTestFiles())
+	signed.(*filePackage).header.SignatureOffset = 4096
+	err = signed.CompressPackage(ctx, fileformat.CompressionZstd)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeSecurity)
+}
+
+func TestOpenPackage_CompressedSpool(t *testing.T) {
+	ctx := context.Background()
+	spoolDir := t.TempDir()
+	pkgDir := t.TempDir()
+	t.Setenv("TMPDIR", spoolDir)
+
+	pkg := newPackageCompressionTestPackage(t, ctx, packageCompressionTestFiles())
+	if err := pkg.CompressPackage(ctx, fileformat.CompressionZstd); err != nil {
+		t.Fatalf("CompressPackage failed: %v", err)
+	}
+	pkgPath := filepath.Join(pkgDir, "compressed.nvpk")
+	if err := pkg.SetTargetPath(ctx, pkgPath); err != nil {
+		t.Fatalf("SetTargetPath failed: %v", err)
+	}
+	if err := pkg.Write(ctx); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	spoolFiles := func() int {
+		entries, err := os.ReadDir(spoolDir)
+		if err != nil {
+			t.Fatalf("ReadDir failed: %v", err)
+		}
+		return len(entries)
+	}
+
+	opened, err := OpenPackage(ctx, pkgPath)
+	if err != nil {
+		t.Fatalf("OpenPackage failed: %v", err)
+	}
+	if opened.GetPath() != pkgPath {
+		t.Errorf("GetPath() = %q, want %q", opened.GetPath(), pkgPath)
+	}
+	if spoolFiles() != 1 {
+		t.Errorf("spool files while open = %d, want 1", spoolFiles())
+	}
+	if err := opened.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if spoolFiles() != 0 {
+		t.Errorf("spool files after Close = %d, want 0", spoolFiles())
+	}
+
+	raw, err := os.ReadFile(pkgPath)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	tests := []struct {
+		name   string
+		mutate func(raw []byte) []byte
+	}{
+		{"metadata index reserved", func(raw []byte) []byte {
+			binary.LittleEndian.PutUint32(raw[fileformat.PackageHeaderSize+4:], 1)
+			return raw
+		}},
+		{"metadata index count", func(raw []byte) []byte {
+			binary.LittleEndian.PutUint32(raw[fileformat.PackageHeaderSize:], 1)
+			return raw
+		}},
+		{"metadata block", func(raw []byte) []byte {
+			offset := binary.LittleEndian.Uint64(raw[fileformat.PackageHeaderSize+16:])
+			raw[offset+8] ^= 0xFF
+			return raw
+		}},
+		{"truncated", func(raw []byte) []byte {
+			return raw[:len(raw)/2]
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			corruptPath := filepath.Join(pkgDir, "corrupt.nvpk")
+			if err := os.WriteFile(corruptPath, tt.mutate(bytes.Clone(raw)), 0o644); err != nil {
+				t.Fatalf("WriteFile failed: %v", err)
+			}
+			if _, err := OpenPackage(ctx, corruptPath); err == nil {
+				t.Fatal("OpenPackage of corrupted compressed package succeeded")
+			}
+			if spoolFiles() != 0 {
+				t.Errorf("spool files after failed open = %d, want 0", spoolFiles())
+			}
+		})
+	}
+}
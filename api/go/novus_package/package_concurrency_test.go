@@ -0,0 +1,124 @@
+// This file contains tests for the ThreadSafetyReadOnly guarantee of Package: many
+// goroutines reading every stored form from one open package. Run with -race.
+//
+// Specification: api_basic_operations.md: 3.3.6 Thread Safety and Concurrency
+
+package novus_package
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"maps"
+	"sync"
+	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+)
+
+// newConcurrencyTestPackage writes a package holding plain, compressed, framed,
+// dictionary-compressed, solid-grouped and envelope-encrypted files and returns a
+// fresh session on it with the recipient key registered, along with the expected
+// content of every file.
+func newConcurrencyTestPackage(t *testing.T, ctx context.Context) (Package, map[string][]byte) {
+	t.Helper()
+	recipient := testEncryptionKey("reader", 0x7E)
+	content := rangeTestPayload(6*rangeTestFrameSize + 55)
+
+	configs := dictionaryTestConfigs(12)
+	pkg := newDictionaryTestPackage(t, ctx, configs)
+	dictID := trainTestDictionary(t, ctx, pkg, configs)
+	for path := range configs {
+		if err := pkg.SetFileCompressionDictionary(ctx, path, dictID); err != nil {
+			t.Fatalf("SetFileCompressionDictionary failed: %v", err)
+		}
+	}
+	scripts := solidGroupTestScripts(4)
+	for _, path := range sortedPaths(scripts) {
+		if _, err := pkg.AddFileFromMemory(ctx, path, scripts[path], nil); err != nil {
+			t.Fatalf("AddFileFromMemory failed: %v", err)
+		}
+	}
+	if _, err := pkg.CreateSolidGroup(ctx, sortedPaths(scripts)); err != nil {
+		t.Fatalf("CreateSolidGroup failed: %v", err)
+	}
+	if err := pkg.AddKeyRecipient(ctx, recipient); err != nil {
+		t.Fatalf("AddKeyRecipient failed: %v", err)
+	}
+
+	compressed := &AddFileOptions{}
+	compressed.CompressionType.Set(fileformat.CompressionZstd)
+	encrypted := envelopeOptions()
+	encrypted.CompressionType.Set(fileformat.CompressionLZ4)
+	files := map[string]*AddFileOptions{
+		"/media/plain.raw":      nil,
+		"/media/compressed.raw": compressed,
+		"/media/framed.raw":     framedRangeOptions(fileformat.CompressionZstd),
+		"/media/encrypted.raw":  encrypted,
+	}
+	expected := make(map[string][]byte)
+	for path, opts := range files {
+		if _, err := pkg.AddFileFromMemory(ctx, path, content, opts); err != nil {
+			t.Fatalf("AddFileFromMemory(%q) failed: %v", path, err)
+		}
+		expected[path] = content
+	}
+	maps.Copy(expected, configs)
+	maps.Copy(expected, scripts)
+
+	// A new session starts with empty caches and only the recipient key, so the
+	// concurrent reads race to load dictionaries, group blocks and the envelope
+	written := writeAndReopen(t, ctx, pkg)
+	reopened := reopenPackage(t, ctx, written)
+	if err := reopened.AddEncryptionKey(recipient); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	return reopened, expected
+}
+
+func TestPackage_ConcurrentReads(t *testing.T) {
+	ctx := context.Background()
+	pkg, expected := newConcurrencyTestPackage(t, ctx)
+
+	var wg sync.WaitGroup
+	for range 16 {
+		wg.Go(func() {
+			for path, content := range expected {
+				got, err := pkg.ReadFile(ctx, path)
+				if err != nil || !bytes.Equal(got, content) {
+					t.Errorf("ReadFile(%q) = %d bytes, %v; want %d matching bytes", path, len(got), err, len(content))
+					return
+				}
+				size := int64(len(content))
+				got, err = pkg.ReadFileRange(ctx, path, size/3, size/4)
+				if err != nil || !bytes.Equal(got, content[size/3:size/3+size/4]) {
+					t.Errorf("ReadFileRange(%q) = %d bytes, %v; want %d matching bytes", path, len(got), err, size/4)
+					return
+				}
+			}
+			stream, err := pkg.OpenFile(ctx, "/media/framed.raw")
+			if err != nil {
+				t.Errorf("OpenFile failed: %v", err)
+				return
+			}
+			got, err := io.ReadAll(stream)
+			_ = stream.Close()
+			if err != nil || !bytes.Equal(got, expected["/media/framed.raw"]) {
+				t.Errorf("stream ReadAll = %d bytes, %v", len(got), err)
+			}
+			if files, err := pkg.ListFiles(); err != nil || len(files) == 0 {
+				t.Errorf("ListFiles() = %d files, %v", len(files), err)
+			}
+			if _, err := pkg.GetInfo(); err != nil {
+				t.Errorf("GetInfo() failed: %v", err)
+			}
+			if _, err := pkg.GetMetadata(); err != nil {
+				t.Errorf("GetMetadata() failed: %v", err)
+			}
+			if err := pkg.Validate(ctx); err != nil {
+				t.Errorf("Validate() failed: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+}
@@ -0,0 +1,336 @@
+// This file implements the confidential index mode. When enabled, the path section
+// of every FileEntry is encrypted on write and the path metadata special file is
+// stored encrypted, both with the index key; the fixed FileEntry section, hashes,
+// optional data and the file index stay readable so the package structure can be
+// validated without the key. The index key's type and ID are stored in the
+// confidential index special file (type 65007). Packages opened from disk start
+// locked: path lookups fail until UnlockIndex decrypts the index. This file should
+// contain only index key handling and path section encryption.
+//
+// Specification: api_security.md: 8. Confidential Index
+
+package novus_package
+
+import (
+	"context"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/generics"
+	"github.com/novus-engine/novuspack/api/go/internal"
+	"github.com/novus-engine/novuspack/api/go/metadata"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+const (
+	// confidentialIndexFileType is the special file type holding the index key reference.
+	confidentialIndexFileType = uint16(fileformat.FileTypeConfidentialIndex)
+
+	// confidentialIndexFilePath is the stored path of the confidential index special file.
+	confidentialIndexFilePath = "/__NVPK_INDEX_65007__.nvpkidx"
+
+	// confidentialIndexVersion is the confidential index file encoding version
+	// written by this implementation.
+	confidentialIndexVersion = 1
+)
+
+// confidentialIndex is the parsed confidential index special file and its lock state.
+type confidentialIndex struct {
+	encryptionType uint8  // On-disk encryption type of the index key
+	keyID          string // KeyID of the index key
+	locked         bool   // Path sections and path metadata are still encrypted
+}
+
+// EnableConfidentialIndex encrypts the package index with key from the next write
+// on: the path section of every FileEntry and the path metadata special file.
+//
+// A nil key selects the key envelope content key, so any envelope recipient (or the
+// package passphrase) unlocks the index. Only symmetric keys (AES-256-GCM,
+// ChaCha20-Poly1305) can encrypt the index.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - key: Index key, or nil for the key envelope content key
+//
+// Returns:
+//   - error: *PackageError with ErrTypeValidation if the index is already
+//     confidential, ErrTypeUnsupported for asymmetric key types
+//
+// Specification: api_security.md: 8.2 Package.EnableConfidentialIndex Method
+func (p *filePackage) EnableConfidentialIndex(ctx context.Context, key *EncryptionKey) error {
+	if err := internal.CheckContext(ctx, "EnableConfidentialIndex"); err != nil {
+		return err
+	}
+	if err := p.checkNotSigned("EnableConfidentialIndex"); err != nil {
+		return err
+	}
+	if p.confidentialIndex != nil {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "confidential index already enabled", nil, pkgerrors.ValidationErrorContext{
+			Field:    "ConfidentialIndex",
+			Value:    p.confidentialIndex.keyID,
+			Expected: "package without a confidential index",
+		})
+	}
+	if key == nil {
+		var err error
+		if key, err = p.keyEnvelopeContentKey(ctx); err != nil {
+			return err
+		}
+	}
+	if err := key.validate("EnableConfidentialIndex"); err != nil {
+		return err
+	}
+	encryptionType, err := onDiskEncryptionType(key.KeyType)
+	if err != nil {
+		return err
+	}
+	if encryptionType == fileformat.EncryptionQuantumSafe {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeUnsupported, "index key must be symmetric", nil, EncryptionErrorContext{
+			Operation:      "EnableConfidentialIndex",
+			EncryptionType: key.KeyType,
+			KeyID:          key.KeyID,
+			ErrorStage:     "validation",
+		})
+	}
+	if err := p.AddEncryptionKey(key); err != nil {
+		return err
+	}
+
+	p.confidentialIndex = &confidentialIndex{encryptionType: encryptionType, keyID: key.KeyID}
+	p.storeConfidentialIndex()
+	return nil
+}
+
+// UnlockIndex decrypts the confidential index of a package opened from disk with
+// the index key registered through AddEncryptionKey, or unlocked from the key
+// envelope, then loads path metadata. Listing and path lookups work afterwards.
+// Does nothing if the package has no confidential index or it is already unlocked.
+// Only the session state changes; the package is not modified.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//
+// Returns:
+//   - error: *PackageError with ErrTypeEncryption if the index key is not
+//     available or does not decrypt the index
+//
+// Specification: api_security.md: 8.3 Package.UnlockIndex Method
+func (p *filePackage) UnlockIndex(ctx context.Context) error {
+	if err := internal.CheckContext(ctx, "UnlockIndex"); err != nil {
+		return err
+	}
+	if !p.indexLocked() {
+		return nil
+	}
+	key, err := p.confidentialIndexKey(ctx, "UnlockIndex")
+	if err != nil {
+		return err
+	}
+	material, err := key.GetKey()
+	if err != nil {
+		return err
+	}
+	defer clear(material)
+
+	// Decrypt every section before changing any entry, so a wrong key leaves the
+	// index locked
+	sections := make(map[*metadata.FileEntry][]byte, len(p.FileEntries))
+	for _, fe := range p.FileEntries {
+		if fe == nil || fe.EncryptedPaths == nil {
+			continue
+		}
+		section, err := internal.DecryptData(fe.EncryptedPaths, p.confidentialIndex.encryptionType, material)
+		if err != nil {
+			return pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeEncryption, "failed to decrypt confidential index", EncryptionErrorContext{
+				Operation:  "UnlockIndex",
+				KeyID:      key.KeyID,
+				ErrorStage: "decryption",
+			})
+		}
+		sections[fe] = section
+	}
+	for fe, section := range sections {
+		if err := fe.UnmarshalPaths(section); err != nil {
+			return err
+		}
+		fe.EncryptedPaths = nil
+	}
+	p.confidentialIndex.locked = false
+
+	if err := p.LoadPathMetadataFile(ctx); err != nil {
+		return err
+	}
+	return p.UpdateFilePathAssociations(ctx)
+}
+
+// indexLocked reports whether the package has a confidential index that has not
+// been unlocked.
+func (p *filePackage) indexLocked() bool {
+	return p.confidentialIndex != nil && p.confidentialIndex.locked
+}
+
+// checkIndexUnlocked returns ErrTypeEncryption if the confidential index is locked.
+func (p *filePackage) checkIndexUnlocked(operation string) error {
+	if !p.indexLocked() {
+		return nil
+	}
+	return pkgerrors.NewPackageError(pkgerrors.ErrTypeEncryption, "confidential index locked: call UnlockIndex with the index key", nil, EncryptionErrorContext{
+		Operation:  operation,
+		KeyID:      p.confidentialIndex.keyID,
+		ErrorStage: "key_lookup",
+	})
+}
+
+// confidentialIndexKey returns the registered index key, unlocking the key envelope
+// if the index key is its content key. Returns ErrTypeEncryption if the key is not
+// available.
+func (p *filePackage) confidentialIndexKey(ctx context.Context, operation string) (*EncryptionKey, error) {
+	keyID := p.confidentialIndex.keyID
+	if key, ok := p.registeredEncryptionKey(keyID); ok {
+		return key, nil
+	}
+	key, err := p.unlockKeyEnvelopeFor(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeEncryption, "index key not available", nil, EncryptionErrorContext{
+			Operation:  operation,
+			KeyID:      keyID,
+			ErrorStage: "key_lookup",
+		})
+	}
+	return key, nil
+}
+
+// sealPathSections encrypts the path section of every file entry with the index
+// key for writing. Entries keep their plaintext Paths. Sections of a locked index
+// are still encrypted and are written as loaded.
+func (p *filePackage) sealPathSections(ctx context.Context) error {
+	if p.confidentialIndex == nil || p.confidentialIndex.locked {
+		return nil
+	}
+	key, err := p.confidentialIndexKey(ctx, "Write")
+	if err != nil {
+		return err
+	}
+	material, err := key.GetKey()
+	if err != nil {
+		return err
+	}
+	defer clear(material)
+
+	for _, fe := range p.FileEntries {
+		if fe == nil {
+			continue
+		}
+		section, err := fe.MarshalPaths()
+		if err != nil {
+			return err
+		}
+		if fe.EncryptedPaths, err = internal.EncryptData(section, p.confidentialIndex.encryptionType, material); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encryptPathMetadataFile marks the path metadata special file for encryption with
+// the index key when the index is confidential.
+func (p *filePackage) encryptPathMetadataFile(specialFile *metadata.FileEntry) {
+	if p.confidentialIndex == nil {
+		return
+	}
+	specialFile.EncryptionType = p.confidentialIndex.encryptionType
+	specialFile.SetEncryptionKeyID(p.confidentialIndex.keyID)
+	// Data is re-encoded on write; drop checksums of the previous stored form
+	specialFile.RawChecksum = 0
+	specialFile.StoredChecksum = 0
+}
+
+// loadConfidentialIndex parses the confidential index special file of a package
+// opened from disk; the index starts locked. Returns ErrTypeCorruption if the
+// package is flagged confidential but has no index file.
+func (p *filePackage) loadConfidentialIndex(ctx context.Context) error {
+	specialFile, exists := p.SpecialFiles[confidentialIndexFileType]
+	if !exists {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "confidential index file missing", nil, pkgerrors.ValidationErrorContext{
+			Field:    "Flags",
+			Value:    fileformat.FlagConfidentialIndex,
+			Expected: "confidential index special file",
+		})
+	}
+	data, err := p.fileEntryContent(ctx, specialFile)
+	if err != nil {
+		return pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to read confidential index file")
+	}
+	index, err := decodeConfidentialIndex(data)
+	if err != nil {
+		return err
+	}
+	index.locked = true
+	p.confidentialIndex = index
+	return nil
+}
+
+// storeConfidentialIndex writes the confidential index special file, creating it if
+// needed.
+func (p *filePackage) storeConfidentialIndex() {
+	data := encodeConfidentialIndex(p.confidentialIndex)
+
+	specialFile, exists := p.SpecialFiles[confidentialIndexFileType]
+	if !exists {
+		specialFile = metadata.NewFileEntry()
+		specialFile.FileID = p.allocateNextFileID()
+		specialFile.Type = confidentialIndexFileType
+		specialFile.Paths = []generics.PathEntry{
+			{PathLength: uint16(len(confidentialIndexFilePath)), Path: confidentialIndexFilePath},
+		}
+		specialFile.PathCount = 1
+		specialFile.CompressionType = fileformat.CompressionNone
+		specialFile.EncryptionType = fileformat.EncryptionNone // Needed to unlock the index
+
+		if p.SpecialFiles == nil {
+			p.SpecialFiles = make(map[uint16]*metadata.FileEntry)
+		}
+		p.SpecialFiles[confidentialIndexFileType] = specialFile
+		p.FileEntries = append(p.FileEntries, specialFile)
+	}
+	specialFile.OriginalSize = uint64(len(data))
+	specialFile.StoredSize = uint64(len(data))
+	specialFile.SetData(data)
+}
+
+// encodeConfidentialIndex serializes index:
+// [Version: 1][EncryptionType: 1][KeyIDLen: 1][KeyID].
+func encodeConfidentialIndex(index *confidentialIndex) []byte {
+	out := []byte{confidentialIndexVersion, index.encryptionType, byte(len(index.keyID))}
+	return append(out, index.keyID...)
+}
+
+// decodeConfidentialIndex parses the confidential index special file.
+// Returns ErrTypeUnsupported for unknown versions or encryption types and
+// ErrTypeCorruption if the data is truncated.
+func decodeConfidentialIndex(data []byte) (*confidentialIndex, error) {
+	r := envelopeReader{data: data}
+	version := r.byte()
+	if r.err == nil && version != confidentialIndexVersion {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeUnsupported, "unsupported confidential index version", nil, pkgerrors.ValidationErrorContext{
+			Field:    "ConfidentialIndexVersion",
+			Value:    version,
+			Expected: "1",
+		})
+	}
+	index := &confidentialIndex{encryptionType: r.byte()}
+	index.keyID = string(r.bytes(int(r.byte())))
+	if r.err != nil {
+		return nil, r.err
+	}
+	if !internal.IsSupportedEncryptionType(index.encryptionType) || index.encryptionType == fileformat.EncryptionNone {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeUnsupported, "unsupported confidential index encryption type", nil, pkgerrors.ValidationErrorContext{
+			Field:    "EncryptionType",
+			Value:    index.encryptionType,
+			Expected: "supported file data encryption type",
+		})
+	}
+	return index, nil
+}
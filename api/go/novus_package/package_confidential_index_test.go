@@ -0,0 +1,175 @@
+// This file contains tests for the confidential index mode: encrypting path sections
+// and path metadata on write, keeping the structure readable while locked, and
+// unlocking with the index key, the key envelope or the package passphrase.
+//
+// Specification: api_security.md: 8. Confidential Index
+
+package novus_package
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+func TestPackage_ConfidentialIndex_EnableAndUnlock(t *testing.T) {
+	ctx := context.Background()
+	secret := []byte("unreleased level layout")
+	indexKey := testEncryptionKey("index", 0x1D)
+
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	if err := pkg.EnableConfidentialIndex(ctx, indexKey); err != nil {
+		t.Fatalf("EnableConfidentialIndex failed: %v", err)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/levels/secret_boss.map", secret, nil); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	written := writeAndReopen(t, ctx, pkg)
+
+	raw, err := os.ReadFile(written.(*filePackage).FilePath)
+	if err != nil {
+		t.Fatalf("ReadFile(package) failed: %v", err)
+	}
+	if bytes.Contains(raw, []byte("secret_boss")) {
+		t.Error("package file contains a plaintext path")
+	}
+	if info, err := written.GetInfo(); err != nil || info.FileCount == 0 {
+		t.Errorf("GetInfo = %+v, err = %v; want file entries readable while locked", info, err)
+	}
+
+	_, err = written.ListFiles()
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+	_, err = written.(*filePackage).ListPaths()
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+	_, err = written.ReadFile(ctx, "/levels/secret_boss.map")
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+	assertPackageErrorType(t, written.UnlockIndex(ctx), pkgerrors.ErrTypeEncryption)
+
+	if err := written.AddEncryptionKey(testEncryptionKey("index", 0x2E)); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	assertPackageErrorType(t, written.UnlockIndex(ctx), pkgerrors.ErrTypeEncryption)
+	if _, err := written.ListFiles(); err == nil {
+		t.Error("ListFiles succeeded after UnlockIndex with the wrong key")
+	}
+
+	if err := written.AddEncryptionKey(indexKey); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	if err := written.UnlockIndex(ctx); err != nil {
+		t.Fatalf("UnlockIndex failed: %v", err)
+	}
+	if err := written.UnlockIndex(ctx); err != nil {
+		t.Errorf("UnlockIndex(unlocked) = %v, want nil", err)
+	}
+	if got, err := written.ReadFile(ctx, "/levels/secret_boss.map"); err != nil || !bytes.Equal(got, secret) {
+		t.Errorf("ReadFile mismatch, err = %v", err)
+	}
+	files, err := written.ListFiles()
+	if err != nil || len(files) != 1 || files[0].PrimaryPath != "levels/secret_boss.map" {
+		t.Errorf("ListFiles = %+v, err = %v", files, err)
+	}
+	paths, err := written.(*filePackage).ListPaths()
+	if err != nil || len(paths) == 0 {
+		t.Errorf("ListPaths = %+v, err = %v", paths, err)
+	}
+
+	// The index stays confidential when the package is written again
+	if _, err := written.AddFileFromMemory(ctx, "/levels/credits.txt", []byte("credits"), nil); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	rewritten := writeAndReopen(t, ctx, written)
+	if rewritten.(*filePackage).header.Flags&fileformat.FlagConfidentialIndex == 0 {
+		t.Error("FlagConfidentialIndex not set after rewrite")
+	}
+	if err := rewritten.AddEncryptionKey(indexKey); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	if err := rewritten.UnlockIndex(ctx); err != nil {
+		t.Fatalf("UnlockIndex failed: %v", err)
+	}
+	if got, err := rewritten.ReadFile(ctx, "/levels/credits.txt"); err != nil || string(got) != "credits" {
+		t.Errorf("ReadFile(credits) = %q, err = %v", got, err)
+	}
+}
+
+func TestPackage_ConfidentialIndex_Passphrase(t *testing.T) {
+	ctx := context.Background()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	if err := pkg.SetPassphrase(ctx, "qa", fastPassphraseOptions); err != nil {
+		t.Fatalf("SetPassphrase failed: %v", err)
+	}
+	if err := pkg.EnableConfidentialIndex(ctx, nil); err != nil {
+		t.Fatalf("EnableConfidentialIndex(envelope) failed: %v", err)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/docs/roadmap.md", []byte("roadmap"), nil); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	written := writeAndReopen(t, ctx, pkg)
+
+	_, err = written.ListFiles()
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+	if err := written.UnlockPassphrase(ctx, "qa"); err != nil {
+		t.Fatalf("UnlockPassphrase failed: %v", err)
+	}
+	if got, err := written.ReadFile(ctx, "/docs/roadmap.md"); err != nil || string(got) != "roadmap" {
+		t.Errorf("ReadFile = %q, err = %v", got, err)
+	}
+}
+
+func TestPackage_ConfidentialIndex_Errors(t *testing.T) {
+	ctx := context.Background()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+
+	if err := pkg.UnlockIndex(ctx); err != nil {
+		t.Errorf("UnlockIndex(no index) = %v, want nil", err)
+	}
+	assertPackageErrorType(t, pkg.EnableConfidentialIndex(ctx, nil), pkgerrors.ErrTypeValidation)
+	mlkem, err := GenerateMLKEMKey(3)
+	if err != nil {
+		t.Fatalf("GenerateMLKEMKey failed: %v", err)
+	}
+	assertPackageErrorType(t, pkg.EnableConfidentialIndex(ctx, mlkem.EncryptionKey("mlkem")), pkgerrors.ErrTypeUnsupported)
+
+	if err := pkg.EnableConfidentialIndex(ctx, testEncryptionKey("index", 0x1D)); err != nil {
+		t.Fatalf("EnableConfidentialIndex failed: %v", err)
+	}
+	assertPackageErrorType(t, pkg.EnableConfidentialIndex(ctx, testEncryptionKey("other", 0x2E)), pkgerrors.ErrTypeValidation)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assertPackageErrorType(t, pkg.EnableConfidentialIndex(cancelled, nil), pkgerrors.ErrTypeContext)
+	assertPackageErrorType(t, pkg.UnlockIndex(cancelled), pkgerrors.ErrTypeContext)
+}
+
+func TestConfidentialIndex_EncodeDecode(t *testing.T) {
+	index := &confidentialIndex{encryptionType: fileformat.EncryptionChaCha20Poly1305, keyID: "index"}
+	data := encodeConfidentialIndex(index)
+	got, err := decodeConfidentialIndex(data)
+	if err != nil {
+		t.Fatalf("decodeConfidentialIndex failed: %v", err)
+	}
+	if got.encryptionType != index.encryptionType || got.keyID != index.keyID || got.locked {
+		t.Errorf("decodeConfidentialIndex = %+v, want %+v", got, index)
+	}
+
+	_, err = decodeConfidentialIndex(data[:len(data)-1])
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption)
+	_, err = decodeConfidentialIndex(append([]byte{2}, data[1:]...))
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeUnsupported)
+	_, err = decodeConfidentialIndex([]byte{confidentialIndexVersion, fileformat.EncryptionNone, 0})
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeUnsupported)
+}
@@ -0,0 +1,483 @@
+// This file implements per-file encryption support: EncryptionKey operations,
+// the package key ring used by ReadFile and Write, and the mapping between API
+// encryption types and on-disk EncryptionType values. Encrypted FileEntries record
+// the ID of their key through the EncryptionKeyID optional data entry (0x0A).
+// This file should contain only key handling and encryption option resolution;
+// cipher implementations belong in internal.
+//
+// Specification: api_security.md: 4.1.3 EncryptionKey Struct
+
+package novus_package
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/internal"
+	"github.com/novus-engine/novuspack/api/go/metadata"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// maxEncryptionKeyIDLength is the longest KeyID accepted by EncryptionKey.IsValid.
+const maxEncryptionKeyIDLength = 255
+
+// EncryptionErrorContext provides type-safe error context for encryption operations.
+//
+// Specification: api_security.md: 4.7.1 EncryptionErrorContext Struct
+type EncryptionErrorContext struct {
+	Path           string         // File path that caused the error
+	Operation      string         // Operation name (e.g., "AddFile", "ReadFile")
+	EncryptionType EncryptionType // Encryption algorithm type
+	KeyID          string         // Encryption key ID (if applicable)
+	KeySize        int            // Key size in bits (if applicable)
+	ErrorStage     string         // Stage where error occurred (e.g., "key_validation", "encryption", "decryption")
+}
+
+// IsValidEncryptionType checks if the encryption type is a defined algorithm.
+//
+// Specification: api_security.md: 3.2.1 IsValidEncryptionType Function
+func IsValidEncryptionType(encType EncryptionType) bool {
+	return encType >= EncryptionNone && encType <= EncryptionMLKEM1024
+}
+
+// GetEncryptionTypeName returns the human-readable name of the encryption type.
+//
+// Specification: api_security.md: 3.2.2 GetEncryptionTypeName Function
+func GetEncryptionTypeName(encType EncryptionType) string {
+	switch encType {
+	case EncryptionNone:
+		return "None"
+	case EncryptionAES256GCM:
+		return "AES-256-GCM"
+	case EncryptionChaCha20Poly1305:
+		return "ChaCha20-Poly1305"
+	case EncryptionMLKEM512:
+		return "ML-KEM-512"
+	case EncryptionMLKEM768:
+		return "ML-KEM-768"
+	case EncryptionMLKEM1024:
+		return "ML-KEM-1024"
+	default:
+		return "Unknown"
+	}
+}
+
+// NewEncryptionKey creates a new encryption key with the specified type, ID, and key material.
+// The key material is copied and CreatedAt is set to the current time.
+//
+// Specification: api_security.md: 4.1.3.2 NewEncryptionKey Function
+func NewEncryptionKey(keyType EncryptionType, keyID string, key []byte) *EncryptionKey {
+	k := &EncryptionKey{KeyType: keyType, KeyID: keyID, CreatedAt: time.Now()}
+	k.key.Set(bytes.Clone(key))
+	return k
+}
+
+// GenerateEncryptionKey creates a new encryption key of keyType with random key material.
+// ML-KEM key types get a new key pair; see MLKEMKey.EncryptionKey.
+// Returns ErrTypeUnsupported if keyType has no key generation.
+func GenerateEncryptionKey(keyType EncryptionType, keyID string) (*EncryptionKey, error) {
+	if level := mlkemLevel(keyType); level != 0 {
+		pair, err := GenerateMLKEMKey(level)
+		if err != nil {
+			return nil, err
+		}
+		defer pair.Clear()
+		return pair.EncryptionKey(keyID), nil
+	}
+	size := encryptionKeySize(keyType)
+	if size == 0 {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeUnsupported, "cannot generate key for encryption type", nil, EncryptionErrorContext{
+			Operation:      "GenerateEncryptionKey",
+			EncryptionType: keyType,
+			KeyID:          keyID,
+			ErrorStage:     "key_generation",
+		})
+	}
+	material := make([]byte, size)
+	if _, err := rand.Read(material); err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeEncryption, "failed to generate key material")
+	}
+	return NewEncryptionKey(keyType, keyID, material), nil
+}
+
+// GetKey returns a copy of the key material.
+// Returns ErrTypeEncryption if the key is not set, invalid, or expired.
+//
+// Specification: api_security.md: 4.1.3.3 EncryptionKey.GetKey Method
+func (k *EncryptionKey) GetKey() ([]byte, error) {
+	if err := k.validate("GetKey"); err != nil {
+		return nil, err
+	}
+	key, _ := k.key.Get()
+	return bytes.Clone(key), nil
+}
+
+// SetKey replaces the key material with a copy of key. CreatedAt is not changed.
+// Returns ErrTypeEncryption if key does not fit KeyType or the key has expired.
+//
+// Specification: api_security.md: 4.1.3.4 EncryptionKey.SetKey Method
+func (k *EncryptionKey) SetKey(key []byte) error {
+	if !validEncryptionKeySize(k.KeyType, len(key)) {
+		return k.error("SetKey", "key type mismatch", "key_validation")
+	}
+	if k.IsExpired() {
+		return k.error("SetKey", "key expired", "key_validation")
+	}
+	k.Clear()
+	k.key.Set(bytes.Clone(key))
+	return nil
+}
+
+// IsValid returns true if the key material is set and fits KeyType, KeyID is 1-255
+// bytes, CreatedAt is set, KeyType is a valid algorithm, and ExpiresAt (if set) is
+// after CreatedAt.
+//
+// Specification: api_security.md: 4.2.1 IsValid() Requirements
+func (k *EncryptionKey) IsValid() bool {
+	if k == nil {
+		return false
+	}
+	key, ok := k.key.Get()
+	switch {
+	case !ok || len(key) == 0:
+		return false
+	case k.KeyID == "" || len(k.KeyID) > maxEncryptionKeyIDLength:
+		return false
+	case k.CreatedAt.IsZero():
+		return false
+	case !IsValidEncryptionType(k.KeyType) || k.KeyType == EncryptionNone:
+		return false
+	case k.ExpiresAt != nil && !k.ExpiresAt.After(k.CreatedAt):
+		return false
+	}
+	return validEncryptionKeySize(k.KeyType, len(key))
+}
+
+// IsExpired returns true if ExpiresAt is set and the current time is at or after it.
+//
+// Specification: api_security.md: 4.2.2 IsExpired() Requirements
+func (k *EncryptionKey) IsExpired() bool {
+	return k != nil && k.ExpiresAt != nil && !time.Now().Before(*k.ExpiresAt)
+}
+
+// Clear overwrites and removes the key material.
+//
+// Specification: api_security.md: 4.1.3.7 EncryptionKey.Clear Method
+func (k *EncryptionKey) Clear() {
+	if key, ok := k.key.Get(); ok {
+		clear(key)
+	}
+	k.key.Clear()
+}
+
+// validate checks that the key is set, valid and not expired.
+//
+// Specification: api_security.md: 4.2.4 Validation Order
+func (k *EncryptionKey) validate(operation string) error {
+	if k == nil {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeEncryption, "key not set", nil, EncryptionErrorContext{Operation: operation, ErrorStage: "key_validation"})
+	}
+	if !k.key.IsSet() {
+		return k.error(operation, "key not set", "key_validation")
+	}
+	if !k.IsValid() {
+		return k.error(operation, "key invalid", "key_validation")
+	}
+	if k.IsExpired() {
+		return k.error(operation, "key expired", "key_validation")
+	}
+	return nil
+}
+
+func (k *EncryptionKey) error(operation, message, stage string) error {
+	key, _ := k.key.Get()
+	return pkgerrors.NewPackageError(pkgerrors.ErrTypeEncryption, message, nil, EncryptionErrorContext{
+		Operation:      operation,
+		EncryptionType: k.KeyType,
+		KeyID:          k.KeyID,
+		KeySize:        len(key) * 8,
+		ErrorStage:     stage,
+	})
+}
+
+// encryptionKeySize returns the key size in bytes required by the symmetric
+// encryption type, or 0 if the type has no fixed symmetric key size.
+func encryptionKeySize(encType EncryptionType) int {
+	switch encType {
+	case EncryptionAES256GCM:
+		return internal.AES256KeySize
+	case EncryptionChaCha20Poly1305:
+		return internal.ChaCha20Poly1305KeySize
+	default:
+		return 0
+	}
+}
+
+// validEncryptionKeySize reports whether size bytes of key material fit encType.
+// ML-KEM keys hold either the decapsulation key seed or the encapsulation key.
+func validEncryptionKeySize(encType EncryptionType, size int) bool {
+	if level := mlkemLevel(encType); level != 0 {
+		return size == internal.MLKEMSeedSize || size == internal.MLKEMEncapsulationKeySize(level)
+	}
+	keySize := encryptionKeySize(encType)
+	return keySize == 0 || size == keySize
+}
+
+// onDiskEncryptionType maps an API encryption type to its on-disk EncryptionType value.
+// Returns ErrTypeUnsupported for algorithms without a file data implementation.
+//
+// Specification: api_security.md: 3.3 On-Disk Mapping
+func onDiskEncryptionType(encType EncryptionType) (uint8, error) {
+	switch encType {
+	case EncryptionNone:
+		return fileformat.EncryptionNone, nil
+	case EncryptionAES256GCM:
+		return fileformat.EncryptionAES256GCM, nil
+	case EncryptionChaCha20Poly1305:
+		return fileformat.EncryptionChaCha20Poly1305, nil
+	case EncryptionMLKEM768, EncryptionMLKEM1024:
+		return fileformat.EncryptionQuantumSafe, nil
+	default:
+		return 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeUnsupported, "encryption algorithm not supported for file data", nil, EncryptionErrorContext{
+			Operation:      "AddFile",
+			EncryptionType: encType,
+			ErrorStage:     "encryption",
+		})
+	}
+}
+
+// AddEncryptionKey registers key with the package. Files added with the key are
+// encrypted with it on Write, and ReadFile decrypts files whose recorded key ID
+// matches key.KeyID. A registered key with the same ID is replaced.
+//
+// Parameters:
+//   - key: Valid, unexpired encryption key
+//
+// Returns:
+//   - error: *PackageError with ErrTypeEncryption if the key is invalid or expired
+//
+// Specification: api_security.md: 4.1.4.4 Operation Requirements
+func (p *filePackage) AddEncryptionKey(key *EncryptionKey) error {
+	if err := key.validate("AddEncryptionKey"); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.encryptionKeys == nil {
+		p.encryptionKeys = make(map[string]*EncryptionKey)
+	}
+	p.encryptionKeys[key.KeyID] = key
+	return nil
+}
+
+// RemoveEncryptionKey unregisters the key with keyID from the package.
+// Returns ErrTypeValidation if no such key is registered.
+//
+// Specification: api_security.md: 4.1.4.4 Operation Requirements
+func (p *filePackage) RemoveEncryptionKey(keyID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.encryptionKeys[keyID]; !ok {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "encryption key not registered", nil, pkgerrors.ValidationErrorContext{
+			Field:    "KeyID",
+			Value:    keyID,
+			Expected: "ID of a registered key",
+		})
+	}
+	delete(p.encryptionKeys, keyID)
+	return nil
+}
+
+// resolveEncryptionKey returns the key requested by options and its on-disk
+// encryption type, after registering the key with the package. Returns a nil key
+// and EncryptionNone if options request no encryption. With UseKeyEnvelope the key
+// is the unlocked content key of the package key envelope; passphrase-protected
+// packages use it unless options set EncryptionKey or UseKeyEnvelope. Returns
+// ErrTypeValidation if the package was built with an encryption type and options
+// request no encryption or a key of another type.
+//
+// Specification: api_file_mgmt_addition.md: 2.8.5 File Processing Options
+func (p *filePackage) resolveEncryptionKey(ctx context.Context, options *AddFileOptions) (*EncryptionKey, uint8, error) {
+	useEnvelope := p.isPassphraseProtected()
+	if options != nil {
+		useEnvelope = options.UseKeyEnvelope.GetOrDefault(useEnvelope && !options.EncryptionKey.IsSet())
+	}
+	if useEnvelope && options != nil && options.EncryptionKey.IsSet() {
+		return nil, 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "UseKeyEnvelope cannot be combined with EncryptionKey", nil, pkgerrors.ValidationErrorContext{
+			Field:    "UseKeyEnvelope",
+			Value:    true,
+			Expected: "either UseKeyEnvelope or EncryptionKey",
+		})
+	}
+	if !useEnvelope && (options == nil || !options.EncryptionKey.IsSet()) {
+		if p.encryptionType != EncryptionNone {
+			return nil, 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "package requires encrypted files", nil, EncryptionErrorContext{
+				Operation:      "AddFile",
+				EncryptionType: p.encryptionType,
+				ErrorStage:     "validation",
+			})
+		}
+		return nil, fileformat.EncryptionNone, nil
+	}
+	var key *EncryptionKey
+	if useEnvelope {
+		var err error
+		if key, err = p.keyEnvelopeContentKey(ctx); err != nil {
+			return nil, 0, err
+		}
+	} else {
+		key = options.EncryptionKey.GetOrDefault(nil)
+	}
+	if err := key.validate("AddFile"); err != nil {
+		return nil, 0, err
+	}
+	if p.encryptionType != EncryptionNone && key.KeyType != p.encryptionType {
+		return nil, 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "encryption key type does not match package encryption type", nil, EncryptionErrorContext{
+			Operation:      "AddFile",
+			EncryptionType: key.KeyType,
+			KeyID:          key.KeyID,
+			ErrorStage:     "validation",
+		})
+	}
+	encryptionType, err := onDiskEncryptionType(key.KeyType)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := p.AddEncryptionKey(key); err != nil {
+		return nil, 0, err
+	}
+	return key, encryptionType, nil
+}
+
+// fileEntryEncryptionKey returns the registered key the file entry's data is
+// encrypted with. Returns ErrTypeEncryption if the key has not been
+// supplied through AddEncryptionKey or does not match the entry's encryption type.
+// Keys of the package key envelope are unlocked on first use.
+func (p *filePackage) fileEntryEncryptionKey(ctx context.Context, fe *metadata.FileEntry, operation string) (*EncryptionKey, error) {
+	keyID, _ := fe.GetEncryptionKeyID()
+	key, ok := p.registeredEncryptionKey(keyID)
+	if !ok {
+		var err error
+		if key, err = p.unlockKeyEnvelopeFor(ctx, keyID); err != nil {
+			return nil, err
+		}
+		ok = key != nil
+	}
+	if !ok {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeEncryption, "encryption key not available", nil, EncryptionErrorContext{
+			Path:       fe.GetPrimaryPath(),
+			Operation:  operation,
+			KeyID:      keyID,
+			ErrorStage: "key_lookup",
+		})
+	}
+	if encryptionType, err := onDiskEncryptionType(key.KeyType); err != nil || encryptionType != fe.EncryptionType {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeEncryption, "key type mismatch", err, EncryptionErrorContext{
+			Path:           fe.GetPrimaryPath(),
+			Operation:      operation,
+			EncryptionType: key.KeyType,
+			KeyID:          keyID,
+			ErrorStage:     "key_lookup",
+		})
+	}
+	return key, nil
+}
+
+// registeredEncryptionKey returns the key registered with keyID.
+func (p *filePackage) registeredEncryptionKey(keyID string) (*EncryptionKey, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	key, ok := p.encryptionKeys[keyID]
+	return key, ok
+}
+
+// newFileEntryEncryptWriter returns a writer that encrypts the (possibly
+// compressed) data of a file entry with its registered key, chunk by chunk, and
+// writes the stored form to w. The caller must Close it to seal the last chunk.
+func (p *filePackage) newFileEntryEncryptWriter(ctx context.Context, fe *metadata.FileEntry, w io.Writer) (io.WriteCloser, error) {
+	material, err := p.fileEntryEncryptionMaterial(ctx, fe)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(material)
+	return internal.NewEncryptWriter(w, fe.EncryptionType, material, fileDataBinding(fe))
+}
+
+// newFileEntryEncryptWriterAt is newFileEntryEncryptWriter for stored data whose
+// first head bytes are filled in after later bytes are written; see
+// internal.EncryptWriterAt.
+func (p *filePackage) newFileEntryEncryptWriterAt(ctx context.Context, fe *metadata.FileEntry, w io.WriterAt, head int64) (*internal.EncryptWriterAt, error) {
+	material, err := p.fileEntryEncryptionMaterial(ctx, fe)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(material)
+	return internal.NewEncryptWriterAt(w, fe.EncryptionType, material, fileDataBinding(fe), head)
+}
+
+// fileEntryEncryptionMaterial returns the key material that encrypts the data of a
+// file entry. ML-KEM keys encrypt to their encapsulation key, which is derived from
+// the seed if the key holds one. The caller must clear the returned material.
+func (p *filePackage) fileEntryEncryptionMaterial(ctx context.Context, fe *metadata.FileEntry) ([]byte, error) {
+	key, err := p.fileEntryEncryptionKey(ctx, fe, "Write")
+	if err != nil {
+		return nil, err
+	}
+	material, err := key.GetKey()
+	if err != nil {
+		return nil, err
+	}
+	if level := mlkemLevel(key.KeyType); level != 0 && len(material) == internal.MLKEMSeedSize {
+		defer clear(material)
+		return internal.MLKEMEncapsulationKey(level, material)
+	}
+	return material, nil
+}
+
+// decryptWithFileEntryKey decrypts the stored data of a file entry in memory with
+// its resolved key. The returned data never shares memory with stored.
+func decryptWithFileEntryKey(fe *metadata.FileEntry, key *EncryptionKey, stored []byte) ([]byte, error) {
+	r, err := newFileEntryDecryptReader(fe, key, bytes.NewReader(stored), int64(len(stored)))
+	if err != nil {
+		return nil, err
+	}
+	plain := make([]byte, r.Size())
+	if _, err := r.ReadAt(plain, 0); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return plain, nil
+}
+
+// newFileEntryDecryptReader returns a reader of the decrypted data of a file entry
+// whose storedSize stored bytes are read from stored. Chunks are decrypted and
+// authenticated as they are read.
+// Returns ErrTypeEncryption if an ML-KEM key holds only the encapsulation key.
+func newFileEntryDecryptReader(fe *metadata.FileEntry, key *EncryptionKey, stored io.ReaderAt, storedSize int64) (*internal.DecryptReader, error) {
+	material, err := key.GetKey()
+	if err != nil {
+		return nil, err
+	}
+	defer clear(material)
+	if mlkemLevel(key.KeyType) != 0 && len(material) != internal.MLKEMSeedSize {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeEncryption, "decapsulation key not available", nil, EncryptionErrorContext{
+			Path:           fe.GetPrimaryPath(),
+			Operation:      "ReadFile",
+			EncryptionType: key.KeyType,
+			KeyID:          key.KeyID,
+			ErrorStage:     "decryption",
+		})
+	}
+	return internal.NewDecryptReader(stored, storedSize, fe.EncryptionType, material, fileDataBinding(fe))
+}
+
+// fileDataBinding returns the FileID and KeyID that the encrypted data of a file
+// entry is bound to.
+func fileDataBinding(fe *metadata.FileEntry) internal.FileDataBinding {
+	keyID, _ := fe.GetEncryptionKeyID()
+	return internal.FileDataBinding{FileID: fe.FileID, KeyID: keyID}
+}
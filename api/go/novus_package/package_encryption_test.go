@@ -0,0 +1,404 @@
+// This file contains tests for per-file encryption: EncryptionKey validation,
+// AES-256-GCM and ChaCha20-Poly1305 encryption on Write, and decryption in ReadFile with keys supplied
+// through AddEncryptionKey.
+//
+// Specification: api_security.md: 4.1.3 EncryptionKey Struct
+
+package novus_package
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/internal"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// testEncryptionKey returns an AES-256-GCM key with fixed key material.
+func testEncryptionKey(keyID string, fill byte) *EncryptionKey {
+	return NewEncryptionKey(EncryptionAES256GCM, keyID, bytes.Repeat([]byte{fill}, 32))
+}
+
+// encryptedOptions returns AddFileOptions encrypting with key.
+func encryptedOptions(key *EncryptionKey) *AddFileOptions {
+	opts := &AddFileOptions{}
+	opts.EncryptionKey.Set(key)
+	return opts
+}
+
+func TestEncryptionKey_Validation(t *testing.T) {
+	key := testEncryptionKey("dlc", 0x11)
+	if !key.IsValid() || key.IsExpired() {
+		t.Fatalf("IsValid() = %v, IsExpired() = %v; want true, false", key.IsValid(), key.IsExpired())
+	}
+
+	material, err := key.GetKey()
+	if err != nil {
+		t.Fatalf("GetKey failed: %v", err)
+	}
+	material[0] = 0xFF
+	if again, _ := key.GetKey(); again[0] != 0x11 {
+		t.Error("GetKey returned the stored key material instead of a copy")
+	}
+
+	if err := key.SetKey([]byte("short")); err == nil {
+		t.Error("SetKey with wrong key size: expected error")
+	}
+	if err := key.SetKey(bytes.Repeat([]byte{0x22}, 32)); err != nil {
+		t.Errorf("SetKey failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*EncryptionKey)
+	}{
+		{"empty key ID", func(k *EncryptionKey) { k.KeyID = "" }},
+		{"zero CreatedAt", func(k *EncryptionKey) { k.CreatedAt = time.Time{} }},
+		{"no algorithm", func(k *EncryptionKey) { k.KeyType = EncryptionNone }},
+		{"expires before creation", func(k *EncryptionKey) {
+			expires := k.CreatedAt.Add(-time.Hour)
+			k.ExpiresAt = &expires
+		}},
+		{"cleared", func(k *EncryptionKey) { k.Clear() }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := testEncryptionKey("dlc", 0x11)
+			tt.mutate(k)
+			if k.IsValid() {
+				t.Error("IsValid() = true, want false")
+			}
+			_, err := k.GetKey()
+			assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+		})
+	}
+
+	expired := testEncryptionKey("old", 0x11)
+	expired.CreatedAt = time.Now().Add(-2 * time.Hour)
+	expiresAt := time.Now().Add(-time.Hour)
+	expired.ExpiresAt = &expiresAt
+	if !expired.IsValid() || !expired.IsExpired() {
+		t.Errorf("IsValid() = %v, IsExpired() = %v; want true, true", expired.IsValid(), expired.IsExpired())
+	}
+	_, err = expired.GetKey()
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+
+	generated, err := GenerateEncryptionKey(EncryptionAES256GCM, "generated")
+	if err != nil || !generated.IsValid() {
+		t.Errorf("GenerateEncryptionKey() = %v, %v; want valid key", generated, err)
+	}
+	_, err = GenerateEncryptionKey(EncryptionMLKEM512, "kem")
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeUnsupported)
+}
+
+func TestPackage_Encryption_WriteAndReadBack(t *testing.T) {
+	ctx := context.Background()
+	secret := bytes.Repeat([]byte("paid DLC level geometry "), 200)
+	key := testEncryptionKey("dlc-2025", 0x42)
+
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/dlc/level.bin", secret, encryptedOptions(key)); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	compressed := encryptedOptions(key)
+	compressed.CompressionType.Set(fileformat.CompressionZstd)
+	if _, err := pkg.AddFileFromMemory(ctx, "/dlc/level.lua", append([]byte("-- script\n"), secret...), compressed); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/readme.txt", []byte("free content"), nil); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+
+	tmpPkg := filepath.Join(t.TempDir(), "dlc.nvpk")
+	if err := pkg.SetTargetPath(ctx, tmpPkg); err != nil {
+		t.Fatalf("SetTargetPath failed: %v", err)
+	}
+	if err := pkg.Write(ctx); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	raw, err := os.ReadFile(tmpPkg)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if bytes.Contains(raw, []byte("paid DLC level geometry")) {
+		t.Error("package file contains plaintext of an encrypted file")
+	}
+
+	reopened, err := OpenPackage(ctx, tmpPkg)
+	if err != nil {
+		t.Fatalf("OpenPackage failed: %v", err)
+	}
+	defer func() { _ = reopened.Close() }()
+
+	fe, err := reopened.(*filePackage).findFileEntryByPath("/dlc/level.bin")
+	if err != nil {
+		t.Fatalf("findFileEntryByPath failed: %v", err)
+	}
+	if fe.EncryptionType != fileformat.EncryptionAES256GCM {
+		t.Errorf("EncryptionType = %d, want AES-256-GCM", fe.EncryptionType)
+	}
+	if keyID, ok := fe.GetEncryptionKeyID(); !ok || keyID != "dlc-2025" {
+		t.Errorf("GetEncryptionKeyID() = %q, %v; want \"dlc-2025\", true", keyID, ok)
+	}
+	if fe.StoredSize != uint64(len(secret))+28 {
+		t.Errorf("StoredSize = %d, want nonce + ciphertext + tag = %d", fe.StoredSize, len(secret)+28)
+	}
+	if reopened.(*filePackage).header.Flags&fileformat.FlagHasEncryptedFiles == 0 {
+		t.Error("FlagHasEncryptedFiles not set")
+	}
+
+	_, err = reopened.ReadFile(ctx, "/dlc/level.bin")
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+	if got, err := reopened.ReadFile(ctx, "/readme.txt"); err != nil || string(got) != "free content" {
+		t.Errorf("ReadFile(unencrypted) = %q, %v", got, err)
+	}
+
+	if err := reopened.AddEncryptionKey(testEncryptionKey("dlc-2025", 0x42)); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	got, err := reopened.ReadFile(ctx, "/dlc/level.bin")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if !bytes.Equal(got, secret) {
+		t.Error("ReadFile content mismatch")
+	}
+	got, err = reopened.ReadFile(ctx, "/dlc/level.lua")
+	if err != nil {
+		t.Fatalf("ReadFile(compressed) failed: %v", err)
+	}
+	if !bytes.Equal(got, append([]byte("-- script\n"), secret...)) {
+		t.Error("ReadFile(compressed) content mismatch")
+	}
+	if got, err := reopened.ReadFileRange(ctx, "/dlc/level.bin", 24, 24); err != nil || !bytes.Equal(got, secret[24:48]) {
+		t.Errorf("ReadFileRange = %q, %v", got, err)
+	}
+
+	if err := reopened.RemoveEncryptionKey("dlc-2025"); err != nil {
+		t.Fatalf("RemoveEncryptionKey failed: %v", err)
+	}
+	_, err = reopened.ReadFile(ctx, "/dlc/level.bin")
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+	assertPackageErrorType(t, reopened.RemoveEncryptionKey("dlc-2025"), pkgerrors.ErrTypeValidation)
+}
+
+func TestPackage_Encryption_WrongKey(t *testing.T) {
+	ctx := context.Background()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/secret.bin", []byte("secret"), encryptedOptions(testEncryptionKey("k1", 0x01))); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	reopened := writeAndReopen(t, ctx, pkg)
+
+	if err := reopened.AddEncryptionKey(testEncryptionKey("k1", 0x02)); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	_, err = reopened.ReadFile(ctx, "/secret.bin")
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+
+	fe, err := reopened.(*filePackage).findFileEntryByPath("/secret.bin")
+	if err != nil {
+		t.Fatalf("findFileEntryByPath failed: %v", err)
+	}
+	fe.StoredChecksum ^= 0xFFFFFFFF
+	_, err = reopened.ReadFile(ctx, "/secret.bin")
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption)
+}
+
+func TestPackage_Encryption_DataBoundToEntry(t *testing.T) {
+	ctx := context.Background()
+	key := testEncryptionKey("k1", 0x01)
+	large := bytes.Repeat([]byte("multi-chunk asset "), 3*internal.EncryptedChunkSize/16)
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	for path, data := range map[string][]byte{"/a.bin": large, "/b.bin": []byte("second secret")} {
+		if _, err := pkg.AddFileFromMemory(ctx, path, data, encryptedOptions(key)); err != nil {
+			t.Fatalf("AddFileFromMemory(%s) failed: %v", path, err)
+		}
+	}
+	reopened := writeAndReopen(t, ctx, pkg)
+	if err := reopened.AddEncryptionKey(testEncryptionKey("k1", 0x01)); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	if got, err := reopened.ReadFile(ctx, "/a.bin"); err != nil || !bytes.Equal(got, large) {
+		t.Fatalf("ReadFile(multi-chunk) = %d bytes, %v; want %d matching bytes", len(got), err, len(large))
+	}
+
+	// Stored data moved to another entry with the same key fails authentication
+	a, _ := reopened.(*filePackage).findFileEntryByPath("/a.bin")
+	b, _ := reopened.(*filePackage).findFileEntryByPath("/b.bin")
+	a.SourceOffset, b.SourceOffset = b.SourceOffset, a.SourceOffset
+	a.StoredSize, b.StoredSize = b.StoredSize, a.StoredSize
+	a.StoredChecksum, b.StoredChecksum = b.StoredChecksum, a.StoredChecksum
+	a.OriginalSize, b.OriginalSize = b.OriginalSize, a.OriginalSize
+	_, err = reopened.ReadFile(ctx, "/a.bin")
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+	_, err = reopened.ReadFile(ctx, "/b.bin")
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+}
+
+func TestPackage_Encryption_AddFile(t *testing.T) {
+	ctx := context.Background()
+	tmpDir := t.TempDir()
+	srcPath := filepath.Join(tmpDir, "asset.dat")
+	content := bytes.Repeat([]byte("texture atlas "), 100)
+	if err := os.WriteFile(srcPath, content, 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	key := testEncryptionKey("assets", 0x33)
+	opts := encryptedOptions(key)
+	opts.Compress.Set(true)
+	added, err := pkg.AddFile(ctx, srcPath, opts)
+	if err != nil {
+		t.Fatalf("AddFile failed: %v", err)
+	}
+	// Identical plain content must not be deduplicated into the encrypted entry
+	if _, err := pkg.AddFileFromMemory(ctx, "/plain.dat", content, nil); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	if n := len(pkg.(*filePackage).FileEntries); n != 2 {
+		t.Errorf("FileEntries = %d, want 2", n)
+	}
+
+	reopened := writeAndReopen(t, ctx, pkg)
+	if err := reopened.AddEncryptionKey(key); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	got, err := reopened.ReadFile(ctx, added.GetPrimaryPath())
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Error("ReadFile content mismatch")
+	}
+}
+
+func TestPackage_Encryption_ChaCha20Poly1305(t *testing.T) {
+	ctx := context.Background()
+	srcPath := filepath.Join(t.TempDir(), "music.ogg")
+	streamed := bytes.Repeat([]byte("soundtrack frame "), 300)
+	if err := os.WriteFile(srcPath, streamed, 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	inMemory := bytes.Repeat([]byte("dialogue line "), 150)
+	key := NewEncryptionKey(EncryptionChaCha20Poly1305, "arm-devices", bytes.Repeat([]byte{0x24}, 32))
+
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	added, err := pkg.AddFile(ctx, srcPath, encryptedOptions(key))
+	if err != nil {
+		t.Fatalf("AddFile failed: %v", err)
+	}
+	compressed := encryptedOptions(key)
+	compressed.CompressionType.Set(fileformat.CompressionLZ4)
+	if _, err := pkg.AddFileFromMemory(ctx, "/dialogue.txt", inMemory, compressed); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+
+	reopened := writeAndReopen(t, ctx, pkg)
+	fe, err := reopened.(*filePackage).findFileEntryByPath(added.Paths[0].Path)
+	if err != nil {
+		t.Fatalf("findFileEntryByPath failed: %v", err)
+	}
+	if fe.EncryptionType != fileformat.EncryptionChaCha20Poly1305 {
+		t.Errorf("EncryptionType = %d, want ChaCha20-Poly1305", fe.EncryptionType)
+	}
+	if fe.StoredSize != uint64(len(streamed))+28 {
+		t.Errorf("StoredSize = %d, want nonce + ciphertext + tag = %d", fe.StoredSize, len(streamed)+28)
+	}
+
+	if err := reopened.AddEncryptionKey(key); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	if got, err := reopened.ReadFile(ctx, added.GetPrimaryPath()); err != nil || !bytes.Equal(got, streamed) {
+		t.Errorf("ReadFile(streamed) mismatch, err = %v", err)
+	}
+	if got, err := reopened.ReadFile(ctx, "/dialogue.txt"); err != nil || !bytes.Equal(got, inMemory) {
+		t.Errorf("ReadFile(in-memory) mismatch, err = %v", err)
+	}
+	if got, err := reopened.ReadFileRange(ctx, added.GetPrimaryPath(), 17, 34); err != nil || !bytes.Equal(got, streamed[17:51]) {
+		t.Errorf("ReadFileRange = %q, %v", got, err)
+	}
+
+	// A key of another algorithm with the same ID must not open the data
+	if err := reopened.AddEncryptionKey(NewEncryptionKey(EncryptionAES256GCM, "arm-devices", bytes.Repeat([]byte{0x24}, 32))); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	_, err = reopened.ReadFile(ctx, "/dialogue.txt")
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+}
+
+func TestPackage_Encryption_Errors(t *testing.T) {
+	ctx := context.Background()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+
+	invalid := testEncryptionKey("", 0x01)
+	_, err = pkg.AddFileFromMemory(ctx, "/a.bin", []byte("a"), encryptedOptions(invalid))
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+
+	opts := &AddFileOptions{}
+	opts.EncryptionKey.Set(nil)
+	_, err = pkg.AddFileFromMemory(ctx, "/a.bin", []byte("a"), opts)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+
+	kem := NewEncryptionKey(EncryptionMLKEM512, "kem", bytes.Repeat([]byte{1}, 32))
+	_, err = pkg.AddFileFromMemory(ctx, "/a.bin", []byte("a"), encryptedOptions(kem))
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeUnsupported)
+
+	assertPackageErrorType(t, pkg.AddEncryptionKey(nil), pkgerrors.ErrTypeEncryption)
+}
+
+func TestReadOnlyPackage_EncryptedRead(t *testing.T) {
+	ctx := context.Background()
+	key := testEncryptionKey("ro", 0x55)
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/secret.bin", []byte("read-only secret"), encryptedOptions(key)); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	tmpPkg := filepath.Join(t.TempDir(), "ro.nvpk")
+	if err := pkg.SetTargetPath(ctx, tmpPkg); err != nil {
+		t.Fatalf("SetTargetPath failed: %v", err)
+	}
+	if err := pkg.Write(ctx); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	ro, err := OpenPackageReadOnly(ctx, tmpPkg)
+	if err != nil {
+		t.Fatalf("OpenPackageReadOnly failed: %v", err)
+	}
+	defer func() { _ = ro.Close() }()
+	if err := ro.AddEncryptionKey(key); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	got, err := ro.ReadFile(ctx, "/secret.bin")
+	if err != nil || string(got) != "read-only secret" {
+		t.Errorf("ReadFile = %q, %v", got, err)
+	}
+}
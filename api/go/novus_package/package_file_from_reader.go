@@ -0,0 +1,283 @@
+// This file implements AddFileFromReader, which adds a file from an io.Reader of
+// known size without holding its content in memory. The reader is streamed through
+// hashing, compression (seekable frames one frame at a time) and chunked
+// encryption into a temporary file owned by the file entry, which Write then copies
+// into the package. This file should contain only streamed file addition and its
+// staging helpers.
+//
+// Specification: api_file_mgmt_addition.md: 2.12 Package.AddFileFromReader Method
+
+package novus_package
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/generics"
+	"github.com/novus-engine/novuspack/api/go/internal"
+	"github.com/novus-engine/novuspack/api/go/metadata"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// AddFileFromReader adds a file to the package from exactly size bytes of raw
+// content read from r.
+//
+// The content is streamed through CRC32 hashing and the compression and encryption
+// requested by options into a temporary file as it is read; encrypted data is
+// sealed one chunk at a time, so memory use does not depend on size. The
+// temporary file is the entry's source for Write and is removed by Close or when
+// the file is removed. Content identical to an existing entry is deduplicated like
+// AddFileFromMemory, unless AllowDuplicate is set.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout handling; checked between reads
+//   - path: Package-relative path for the file
+//   - r: Reader supplying the raw (uncompressed, unencrypted) file content
+//   - size: Exact number of bytes to read from r
+//   - options: Optional configuration for file processing (can be nil for defaults)
+//
+// Returns:
+//   - *metadata.FileEntry: The created file entry, or the existing entry for duplicate content
+//   - error: *PackageError on failure; ErrTypeIO if r ends before size bytes
+//
+// Specification: api_file_mgmt_addition.md: 2.12 Package.AddFileFromReader Method
+//
+//nolint:gocognit,gocyclo // validation, option resolution and deduplication branches
+func (p *filePackage) AddFileFromReader(ctx context.Context, path string, r io.Reader, size int64, options *AddFileOptions) (*metadata.FileEntry, error) {
+	if err := internal.CheckContext(ctx, "AddFileFromReader"); err != nil {
+		return nil, err
+	}
+	if err := p.checkNotSigned("AddFileFromReader"); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(path) == "" {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "path cannot be empty or whitespace-only", nil, pkgerrors.ValidationErrorContext{
+			Field:    "path",
+			Value:    path,
+			Expected: "non-empty package path",
+		})
+	}
+	if r == nil {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "reader cannot be nil", nil, pkgerrors.ValidationErrorContext{
+			Field:    "r",
+			Value:    nil,
+			Expected: "non-nil io.Reader",
+		})
+	}
+	if size < 0 {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "size cannot be negative", nil, pkgerrors.ValidationErrorContext{
+			Field:    "size",
+			Value:    size,
+			Expected: "size >= 0",
+		})
+	}
+
+	normalizedPath, err := internal.NormalizePackagePath(path)
+	if err != nil {
+		return nil, pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeValidation, "failed to normalize path for AddFileFromReader", pkgerrors.ValidationErrorContext{
+			Field:    "path",
+			Value:    path,
+			Expected: "valid normalizable path",
+		})
+	}
+
+	// Peek at the leading bytes for type detection without consuming them
+	sampleSize := int(min(uint64(size), fileSampleSize(options)))
+	source := bufio.NewReaderSize(&contextReader{ctx: ctx, r: r}, max(sampleSize, copyBufferSize))
+	sample, err := source.Peek(sampleSize)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "AddFileFromReader: failed to read file for type detection")
+	}
+
+	fileType := resolveFileType(options, normalizedPath, sample)
+	compressionType, compressionLevel, err := resolveCompressionOptions(options, fileType, sample)
+	if err != nil {
+		return nil, err
+	}
+	dictID, useDict, err := p.resolveCompressionDictionary(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	frameSize, useFrames, err := resolveCompressionFrameSize(options, compressionType)
+	if err != nil {
+		return nil, err
+	}
+	encryptionKey, encryptionType, err := p.resolveEncryptionKey(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	// Encrypted data is bound to its FileID, so the ID is allocated before staging
+	staged := metadata.NewFileEntry()
+	staged.FileID = p.allocateNextFileID()
+	staged.Type = uint16(fileType)
+	staged.OriginalSize = uint64(size)
+	staged.CompressionType = compressionType
+	staged.CompressionLevel = compressionLevel
+	staged.EncryptionType = encryptionType
+	if useDict {
+		staged.SetCompressionDictionaryID(dictID)
+	}
+	if useFrames {
+		staged.SetCompressionFrameSize(frameSize)
+	}
+	if encryptionKey != nil {
+		staged.SetEncryptionKeyID(encryptionKey.KeyID)
+	}
+	if err := p.stageReaderData(ctx, staged, source); err != nil {
+		removeFileEntryTempFile(staged)
+		// Report cancellation rather than the read error it caused
+		if ctxErr := internal.CheckContext(ctx, "AddFileFromReader"); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+
+	// Deduplicate on the checksum computed while staging
+	if options == nil || !options.AllowDuplicate.GetOrDefault(false) {
+		for _, entry := range p.FileEntries {
+			if entry.OriginalSize != staged.OriginalSize || entry.RawChecksum != staged.RawChecksum || !sameEncryption(entry, encryptionType, encryptionKey) {
+				continue
+			}
+			removeFileEntryTempFile(staged)
+			if err := addDuplicatePath(entry, normalizedPath, options); err != nil {
+				return nil, err
+			}
+			if err := p.ensurePathMetadata(normalizedPath, entry); err != nil {
+				return nil, err
+			}
+			return entry, nil
+		}
+	}
+
+	staged.Paths = []generics.PathEntry{{PathLength: uint16(len(normalizedPath)), Path: normalizedPath}}
+	staged.PathCount = 1
+	staged.FileVersion = 1
+	staged.MetadataVersion = 1
+	p.FileEntries = append(p.FileEntries, staged)
+	if p.Info == nil {
+		p.Info = metadata.NewPackageInfo()
+	}
+	p.Info.FileCount++
+
+	if err := p.ensurePathMetadata(normalizedPath, staged); err != nil {
+		return nil, err
+	}
+	return staged, nil
+}
+
+// stageReaderData streams fe.OriginalSize bytes from r into a new temporary file
+// for fe, compressing and then encrypting them as fe requests, and points fe's
+// source at the stored data. fe.RawChecksum is computed from the content read.
+// On failure the caller removes the temporary file with removeFileEntryTempFile.
+func (p *filePackage) stageReaderData(ctx context.Context, fe *metadata.FileEntry, r io.Reader) error {
+	// Resolve the dictionary before taking a pooled buffer for frames or copying
+	var dict []byte
+	if dictID, ok := fe.GetCompressionDictionaryID(); ok && fe.CompressionType == fileformat.CompressionZstd {
+		var err error
+		if dict, err = p.compressionDictionary(ctx, dictID); err != nil {
+			return err
+		}
+	}
+
+	if err := fe.CreateTempFile(ctx); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(fe.TempFilePath, os.O_RDWR, 0)
+	if err != nil {
+		return pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeIO, "failed to open temporary file for writing", pkgerrors.ValidationErrorContext{
+			Field:    "TempFilePath",
+			Value:    fe.TempFilePath,
+			Expected: "file opened successfully",
+		})
+	}
+	fe.SourceFile = file
+	fe.SourceOffset = 0
+
+	size := int64(fe.OriginalSize)
+	rawHash := crc32.NewIEEE()
+	raw := io.TeeReader(r, rawHash)
+	if fe.CompressionType != fileformat.CompressionNone && fe.CompressionLevel == 0 {
+		fe.CompressionLevel = internal.DefaultCompressionLevel
+	}
+
+	var read int64
+	if frameSize, framed := fe.GetCompressionFrameSize(); framed {
+		if err := p.encodeFramedData(ctx, fe, file, raw, frameSize); err != nil {
+			return err
+		}
+		read = size
+	} else if read, err = p.encodeStreamData(ctx, fe, file, raw, dict); err != nil {
+		return err
+	}
+	if read != size {
+		return pkgerrors.WrapErrorWithContext(io.ErrUnexpectedEOF, pkgerrors.ErrTypeIO, "reader ended before size bytes", pkgerrors.ValidationErrorContext{
+			Field:    "size",
+			Value:    read,
+			Expected: "reader supplying size bytes",
+		})
+	}
+	fe.RawChecksum = rawHash.Sum32()
+
+	info, err := file.Stat()
+	if err != nil {
+		return pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to stat temporary file")
+	}
+	fe.StoredSize = uint64(info.Size())
+	fe.SourceSize = info.Size()
+	if fe.CompressionType == fileformat.CompressionNone && fe.EncryptionType == fileformat.EncryptionNone {
+		fe.StoredChecksum = fe.RawChecksum
+	} else {
+		storedHash := crc32.NewIEEE()
+		if _, err := copyWithPooledBuffer(ctx, storedHash, io.NewSectionReader(file, 0, fe.SourceSize), fe.SourceSize); err != nil {
+			return err
+		}
+		fe.StoredChecksum = storedHash.Sum32()
+	}
+	fe.IsDataLoaded = false
+	fe.ProcessingState = storedProcessingState(fe)
+	return nil
+}
+
+// addDuplicatePath adds path to entry, which already holds the same content.
+// Returns ErrTypeValidation if entry already has path and AllowOverwrite is not set.
+func addDuplicatePath(entry *metadata.FileEntry, path string, options *AddFileOptions) error {
+	for _, existing := range entry.Paths {
+		if existing.Path != path {
+			continue
+		}
+		if options == nil || !options.AllowOverwrite.GetOrDefault(false) {
+			return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "file already exists at specified path", nil, pkgerrors.ValidationErrorContext{
+				Field:    "path",
+				Value:    path,
+				Expected: "non-existing path or AllowOverwrite=true",
+			})
+		}
+		return nil
+	}
+	entry.Paths = append(entry.Paths, generics.PathEntry{PathLength: uint16(len(path)), Path: path})
+	entry.PathCount++
+	entry.MetadataVersion++
+	return nil
+}
+
+// contextReader is an io.Reader that stops with a context error once ctx is done,
+// so long streamed additions can be cancelled between reads.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// Read reads from the underlying reader unless the context is done.
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeContext, "context cancelled", err, struct{}{})
+	}
+	return c.r.Read(p)
+}
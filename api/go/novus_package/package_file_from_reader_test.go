@@ -0,0 +1,268 @@
+// This file contains tests for AddFileFromReader: streamed content in every stored
+// form read back after Write, deduplication, temporary file cleanup, short
+// readers, cancellation and bounded memory use.
+//
+// Specification: api_file_mgmt_addition.md: 2.12 Package.AddFileFromReader Method
+
+package novus_package
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/metadata"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// patternReader supplies size bytes of a repeating pattern without allocating.
+type patternReader struct {
+	remaining int64
+	offset    int
+}
+
+func (r *patternReader) Read(p []byte) (int, error) {
+	if r.remaining == 0 {
+		return 0, io.EOF
+	}
+	n := int(min(int64(len(p)), r.remaining))
+	for i := range p[:n] {
+		p[i] = byte((r.offset + i) % 251)
+	}
+	r.offset += n
+	r.remaining -= int64(n)
+	return n, nil
+}
+
+// cancellingReader cancels its context after the first read.
+type cancellingReader struct {
+	r      io.Reader
+	cancel context.CancelFunc
+}
+
+func (r *cancellingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.cancel()
+	return n, err
+}
+
+// assertTempFileRemoved verifies that the temporary file at path no longer exists.
+func assertTempFileRemoved(t *testing.T, path string) {
+	t.Helper()
+	if path == "" {
+		t.Fatal("entry had no temporary file")
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("temporary file %s still exists: %v", path, err)
+	}
+}
+
+func TestPackage_AddFileFromReader_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	key := testEncryptionKey("reader", 0x52)
+	size := 7*rangeTestFrameSize + 123
+
+	configs := dictionaryTestConfigs(12)
+	pkg := newDictionaryTestPackage(t, ctx, configs)
+	dictID := trainTestDictionary(t, ctx, pkg, configs)
+	if err := pkg.AddEncryptionKey(key); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	dictionary := &AddFileOptions{}
+	dictionary.CompressionDictionaryID.Set(dictID)
+	encrypted := encryptedOptions(key)
+	encrypted.CompressionType.Set(fileformat.CompressionLZ4)
+	framedEncrypted := framedRangeOptions(fileformat.CompressionZstd)
+	framedEncrypted.EncryptionKey.Set(key)
+
+	files := map[string]*AddFileOptions{
+		"/stream/plain.bin":            nil,
+		"/stream/zstd.bin":             compressionOptions(fileformat.CompressionZstd, 3),
+		"/stream/lz4.bin":              compressionOptions(fileformat.CompressionLZ4, 0),
+		"/stream/lzma.bin":             compressionOptions(fileformat.CompressionLZMA, 0),
+		"/stream/framed.bin":           framedRangeOptions(fileformat.CompressionZstd),
+		"/stream/dictionary.bin":       dictionary,
+		"/stream/encrypted.bin":        encrypted,
+		"/stream/framed-encrypted.bin": framedEncrypted,
+		"/stream/empty.bin":            compressionOptions(fileformat.CompressionLZMA, 0),
+	}
+	contents := make(map[string][]byte)
+	tempPaths := make(map[string]string)
+	for path, opts := range files {
+		content := append([]byte(path), rangeTestPayload(size)...)[:size]
+		if path == "/stream/empty.bin" {
+			content = []byte{}
+		}
+		fe, err := pkg.AddFileFromReader(ctx, path, bytes.NewReader(content), int64(len(content)), opts)
+		if err != nil {
+			t.Fatalf("AddFileFromReader(%q) failed: %v", path, err)
+		}
+		if !fe.IsTempFile || fe.IsDataLoaded || fe.SourceFile == nil {
+			t.Errorf("AddFileFromReader(%q) entry is not sourced from a temporary file", path)
+		}
+		if fe.OriginalSize != uint64(len(content)) || fe.SourceSize != int64(fe.StoredSize) {
+			t.Errorf("AddFileFromReader(%q) sizes = %d/%d/%d", path, fe.OriginalSize, fe.StoredSize, fe.SourceSize)
+		}
+		contents[path] = content
+		tempPaths[path] = fe.TempFilePath
+	}
+
+	reopened := writeAndReopen(t, ctx, pkg)
+	if err := reopened.AddEncryptionKey(key); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	for path, content := range contents {
+		got, err := reopened.ReadFile(ctx, path)
+		if err != nil || !bytes.Equal(got, content) {
+			t.Errorf("ReadFile(%q) after Write = %d bytes, %v; want %d matching bytes", path, len(got), err, len(content))
+		}
+	}
+	assertRanges(t, ctx, reopened, "/stream/framed.bin", contents["/stream/framed.bin"])
+
+	if err := pkg.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	for _, tempPath := range tempPaths {
+		assertTempFileRemoved(t, tempPath)
+	}
+}
+
+func TestPackage_AddFileFromReader_Deduplication(t *testing.T) {
+	ctx := context.Background()
+	content := rangeTestPayload(10000)
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	defer func() { _ = pkg.Close() }()
+
+	original, err := pkg.AddFileFromMemory(ctx, "/data/original.txt", content, nil)
+	if err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	fe, err := pkg.AddFileFromReader(ctx, "/data/copy.txt", bytes.NewReader(content), int64(len(content)), nil)
+	if err != nil {
+		t.Fatalf("AddFileFromReader failed: %v", err)
+	}
+	if fe != original || fe.PathCount != 2 {
+		t.Errorf("AddFileFromReader did not add a path to the duplicate entry (PathCount = %d)", fe.PathCount)
+	}
+
+	_, err = pkg.AddFileFromReader(ctx, "/data/copy.txt", bytes.NewReader(content), int64(len(content)), nil)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	duplicate := &AddFileOptions{}
+	duplicate.AllowDuplicate.Set(true)
+	separate, err := pkg.AddFileFromReader(ctx, "/data/separate.txt", bytes.NewReader(content), int64(len(content)), duplicate)
+	if err != nil {
+		t.Fatalf("AddFileFromReader(AllowDuplicate) failed: %v", err)
+	}
+	if separate == original {
+		t.Error("AddFileFromReader(AllowDuplicate) reused the existing entry")
+	}
+
+	// Removing the last path of a streamed entry deletes its temporary file
+	tempPath := separate.TempFilePath
+	if err := pkg.RemoveFile(ctx, "/data/separate.txt"); err != nil {
+		t.Fatalf("RemoveFile failed: %v", err)
+	}
+	assertTempFileRemoved(t, tempPath)
+}
+
+func TestPackage_AddFileFromReader_Errors(t *testing.T) {
+	ctx := context.Background()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	defer func() { _ = pkg.Close() }()
+	content := rangeTestPayload(3 * rangeTestFrameSize)
+
+	tests := []struct {
+		name string
+		path string
+		r    io.Reader
+		size int64
+		opts *AddFileOptions
+		want pkgerrors.ErrorType
+	}{
+		{"empty path", " ", bytes.NewReader(content), 10, nil, pkgerrors.ErrTypeValidation},
+		{"nil reader", "/a.bin", nil, 10, nil, pkgerrors.ErrTypeValidation},
+		{"negative size", "/a.bin", bytes.NewReader(content), -1, nil, pkgerrors.ErrTypeValidation},
+		{"short reader", "/a.bin", bytes.NewReader(content), int64(len(content)) + 1, nil, pkgerrors.ErrTypeIO},
+		{"short compressed reader", "/a.bin", bytes.NewReader(content), int64(len(content)) + 1, compressionOptions(fileformat.CompressionZstd, 0), pkgerrors.ErrTypeIO},
+		{"short framed reader", "/a.bin", bytes.NewReader(content), int64(len(content)) + 1, framedRangeOptions(fileformat.CompressionLZ4), pkgerrors.ErrTypeIO},
+		{"short encrypted reader", "/a.bin", bytes.NewReader(content), int64(len(content)) + 1, encryptedOptions(testEncryptionKey("k", 0x01)), pkgerrors.ErrTypeIO},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := pkg.AddFileFromReader(ctx, tt.path, tt.r, tt.size, tt.opts)
+			assertPackageErrorType(t, err, tt.want)
+		})
+	}
+	if entries := pkg.(*filePackage).FileEntries; len(entries) != 0 {
+		t.Errorf("failed additions left %d file entries in the package", len(entries))
+	}
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	r := &cancellingReader{r: &patternReader{remaining: 1 << 20}, cancel: cancel}
+	_, err = pkg.AddFileFromReader(cancelCtx, "/cancelled.bin", r, 1<<20, nil)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeContext)
+}
+
+func TestPackage_AddFileFromReader_ReadOnly(t *testing.T) {
+	ctx := context.Background()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	readOnly := &readOnlyPackage{inner: pkg}
+	_, err = readOnly.AddFileFromReader(ctx, "/a.bin", bytes.NewReader([]byte("data")), 4, nil)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeSecurity)
+}
+
+func TestPackage_AddFileFromReader_BoundedMemory(t *testing.T) {
+	ctx := context.Background()
+	const size = 64 << 20
+
+	tests := []struct {
+		name  string
+		opts  *AddFileOptions
+		state metadata.ProcessingState
+	}{
+		{"raw", nil, metadata.ProcessingStateRaw},
+		{"encrypted", encryptedOptions(testEncryptionKey("media", 0x4d)), metadata.ProcessingStateEncrypted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg, err := NewPackage()
+			if err != nil {
+				t.Fatalf("NewPackage failed: %v", err)
+			}
+			defer func() { _ = pkg.Close() }()
+
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			fe, err := pkg.AddFileFromReader(ctx, "/media/video.raw", &patternReader{remaining: size}, size, tt.opts)
+			if err != nil {
+				t.Fatalf("AddFileFromReader failed: %v", err)
+			}
+			writeAndReopen(t, ctx, pkg)
+			runtime.ReadMemStats(&after)
+
+			if allocated := after.TotalAlloc - before.TotalAlloc; allocated > size/8 {
+				t.Errorf("adding and writing a %d byte file allocated %d bytes", size, allocated)
+			}
+			// Encrypted data is stored with per-chunk authentication tags
+			if (tt.opts == nil) != (fe.StoredSize == size) || fe.ProcessingState != tt.state {
+				t.Errorf("entry StoredSize = %d, ProcessingState = %v", fe.StoredSize, fe.ProcessingState)
+			}
+		})
+	}
+}
@@ -6,12 +6,15 @@ package novus_package
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"syscall"
 
+	"github.com/novus-engine/novuspack/api/go/fileformat"
 	"github.com/novus-engine/novuspack/api/go/generics"
 	"github.com/novus-engine/novuspack/api/go/internal"
 	"github.com/novus-engine/novuspack/api/go/metadata"
@@ -41,6 +44,9 @@ func (p *filePackage) AddFile(ctx context.Context, path string, options *AddFile
 	if err := internal.CheckContext(ctx, "AddFile"); err != nil {
 		return nil, err
 	}
+	if err := p.checkNotSigned("AddFile"); err != nil {
+		return nil, err
+	}
 
 	// Trim and validate path is not empty
 	path = strings.TrimSpace(path)
@@ -168,10 +174,42 @@ func (p *filePackage) AddFile(ctx context.Context, path string, options *AddFile
 		)
 	}
 
-	// Determine effective compression/encryption from options
-	compressionType := uint8(0)
-	encryptionType := uint8(0)
-	// TODO: Extract from options when compression/encryption are implemented (Priority 5-6)
+	// Determine file type and effective compression/encryption from options
+	sample, err := readFileSample(sourceFile, originalSize, options)
+	if err != nil {
+		_ = sourceFile.Close()
+		return nil, pkgerrors.WrapErrorWithContext(
+			err,
+			pkgerrors.ErrTypeIO,
+			"AddFile: failed to read file for type detection",
+			pkgerrors.ValidationErrorContext{
+				Field:    "path",
+				Value:    path,
+				Expected: "readable file",
+			},
+		)
+	}
+	fileType := resolveFileType(options, storedPath, sample)
+	compressionType, compressionLevel, err := resolveCompressionOptions(options, fileType, sample)
+	if err != nil {
+		_ = sourceFile.Close()
+		return nil, err
+	}
+	dictID, useDict, err := p.resolveCompressionDictionary(ctx, options)
+	if err != nil {
+		_ = sourceFile.Close()
+		return nil, err
+	}
+	frameSize, useFrames, err := resolveCompressionFrameSize(options, compressionType)
+	if err != nil {
+		_ = sourceFile.Close()
+		return nil, err
+	}
+	encryptionKey, encryptionType, err := p.resolveEncryptionKey(ctx, options)
+	if err != nil {
+		_ = sourceFile.Close()
+		return nil, err
+	}
 
 	// =========================================================================
 	// STEP 2: Deduplication Check
@@ -191,7 +229,7 @@ func (p *filePackage) AddFile(ctx context.Context, path string, options *AddFile
 		// Fast filter by OriginalSize
 		var potentialMatches []*metadata.FileEntry
 		for _, entry := range p.FileEntries {
-			if entry.OriginalSize == originalSize {
+			if entry.OriginalSize == originalSize && sameEncryption(entry, encryptionType, encryptionKey) {
 				potentialMatches = append(potentialMatches, entry)
 			}
 		}
@@ -284,14 +322,8 @@ func (p *filePackage) AddFile(ctx context.Context, path string, options *AddFile
 	// STEP 3: Conditional Encryption Processing
 	// =========================================================================
 
-	// TODO: Priority 6 - When encryption is implemented, this step will:
-	// - Read file data
-	// - Apply compression (if both compression + encryption)
-	// - Apply encryption
-	// - Write to temp file
-	// - Update sourceFile to point to temp file
-	// - Update sourceOffset to 0
-	// - Calculate StoredSize and StoredChecksum
+	// Encryption is applied during Write, after compression, with the key
+	// registered above; SourceFile keeps the raw data until then.
 
 	// =========================================================================
 	// STEP 4: FileEntry Allocation (for unique files only)
@@ -302,7 +334,7 @@ func (p *filePackage) AddFile(ctx context.Context, path string, options *AddFile
 		newFileID := p.allocateNextFileID()
 		targetEntry = metadata.NewFileEntry()
 		targetEntry.FileID = newFileID
-		targetEntry.Type = 0 // TODO: Determine file type from extension/content
+		targetEntry.Type = uint16(fileType)
 		targetEntry.Paths = []generics.PathEntry{{PathLength: uint16(len(storedPath)), Path: storedPath}}
 		targetEntry.PathCount = 1
 		targetEntry.OriginalSize = originalSize
@@ -312,14 +344,22 @@ func (p *filePackage) AddFile(ctx context.Context, path string, options *AddFile
 
 		// Set compression/encryption
 		targetEntry.CompressionType = compressionType
+		targetEntry.CompressionLevel = compressionLevel
 		targetEntry.EncryptionType = encryptionType
+		if useDict {
+			targetEntry.SetCompressionDictionaryID(dictID)
+		}
+		if useFrames {
+			targetEntry.SetCompressionFrameSize(frameSize)
+		}
+		if encryptionKey != nil {
+			targetEntry.SetEncryptionKeyID(encryptionKey.KeyID)
+		}
 
-		// For non-encryption cases, StoredSize/StoredChecksum are placeholders
+		// StoredSize/StoredChecksum are placeholders
 		// They'll be calculated during Write operations
-		if encryptionType == 0 {
-			targetEntry.StoredSize = 0     // Placeholder
-			targetEntry.StoredChecksum = 0 // Placeholder
-		}
+		targetEntry.StoredSize = 0     // Placeholder
+		targetEntry.StoredChecksum = 0 // Placeholder
 
 		// Add to package
 		if p.FileEntries == nil {
@@ -353,7 +393,7 @@ func (p *filePackage) AddFile(ctx context.Context, path string, options *AddFile
 	targetEntry.IsTempFile = false // TODO: Set in step 3 for encryption
 
 	// Set ProcessingState to track what processing has been done
-	// For v1 with no compression/encryption: StateRaw
+	// SourceFile holds raw data; compression is applied during Write
 	targetEntry.ProcessingState = metadata.ProcessingStateRaw
 
 	// Data MUST NOT be loaded (per spec)
@@ -412,6 +452,9 @@ func (p *filePackage) AddFileFromMemory(ctx context.Context, path string, data [
 	if err := internal.CheckContext(ctx, "AddFileFromMemory"); err != nil {
 		return nil, err
 	}
+	if err := p.checkNotSigned("AddFileFromMemory"); err != nil {
+		return nil, err
+	}
 
 	// Validate path is not empty or whitespace-only
 	if strings.TrimSpace(path) == "" {
@@ -445,6 +488,25 @@ func (p *filePackage) AddFileFromMemory(ctx context.Context, path string, data [
 		)
 	}
 
+	// Determine file type and effective compression from options
+	fileType := resolveFileType(options, normalizedPath, actualData)
+	compressionType, compressionLevel, err := resolveCompressionOptions(options, fileType, actualData)
+	if err != nil {
+		return nil, err
+	}
+	dictID, useDict, err := p.resolveCompressionDictionary(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	frameSize, useFrames, err := resolveCompressionFrameSize(options, compressionType)
+	if err != nil {
+		return nil, err
+	}
+	encryptionKey, encryptionType, err := p.resolveEncryptionKey(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
 	// Calculate file metadata
 	originalSize := uint64(len(actualData))
 	rawChecksum := internal.CalculateCRC32(actualData)
@@ -459,7 +521,7 @@ func (p *filePackage) AddFileFromMemory(ctx context.Context, path string, data [
 	// Search for duplicate content
 	if p.FileEntries != nil {
 		for _, entry := range p.FileEntries {
-			if entry.OriginalSize == originalSize && entry.RawChecksum == rawChecksum {
+			if entry.OriginalSize == originalSize && entry.RawChecksum == rawChecksum && sameEncryption(entry, encryptionType, encryptionKey) {
 				// Found duplicate content - check if path already exists
 				pathExists := false
 				for _, existingPath := range entry.Paths {
@@ -503,15 +565,25 @@ func (p *filePackage) AddFileFromMemory(ctx context.Context, path string, data [
 		// Create new FileEntry
 		targetEntry = metadata.NewFileEntry()
 		targetEntry.FileID = newFileID
-		targetEntry.Type = 0 // Default type (could be enhanced with content detection)
+		targetEntry.Type = uint16(fileType)
 		targetEntry.Paths = []generics.PathEntry{{PathLength: uint16(len(normalizedPath)), Path: normalizedPath}}
 		targetEntry.PathCount = 1
 		targetEntry.OriginalSize = originalSize
 		targetEntry.RawChecksum = rawChecksum
-		targetEntry.StoredSize = originalSize    // No compression yet (Priority 5)
-		targetEntry.StoredChecksum = rawChecksum // No compression yet
-		targetEntry.CompressionType = 0          // No compression
-		targetEntry.EncryptionType = 0           // No encryption (Priority 6)
+		targetEntry.StoredSize = originalSize    // Recalculated during Write when compressed
+		targetEntry.StoredChecksum = rawChecksum // Recalculated during Write when compressed
+		targetEntry.CompressionType = compressionType
+		targetEntry.CompressionLevel = compressionLevel
+		targetEntry.EncryptionType = encryptionType
+		if useDict {
+			targetEntry.SetCompressionDictionaryID(dictID)
+		}
+		if useFrames {
+			targetEntry.SetCompressionFrameSize(frameSize)
+		}
+		if encryptionKey != nil {
+			targetEntry.SetEncryptionKeyID(encryptionKey.KeyID)
+		}
 
 		// Store data in memory for later write
 		targetEntry.SetData(actualData)
@@ -612,6 +684,9 @@ func (p *filePackage) RemoveFile(ctx context.Context, path string) error {
 	if err := internal.CheckContext(ctx, "RemoveFile"); err != nil {
 		return err
 	}
+	if err := p.checkNotSigned("RemoveFile"); err != nil {
+		return err
+	}
 
 	// Validate path is not empty or whitespace-only
 	if strings.TrimSpace(path) == "" {
@@ -675,6 +750,13 @@ func (p *filePackage) RemoveFile(ctx context.Context, path string) error {
 		)
 	}
 
+	// Removing the last path of a solid group member changes the group block
+	if targetEntry.PathCount == 1 {
+		if err := p.releaseSolidGroupMember(ctx, targetEntry); err != nil {
+			return err
+		}
+	}
+
 	// Remove the path from the Paths array
 	targetEntry.Paths = append(targetEntry.Paths[:pathIndex], targetEntry.Paths[pathIndex+1:]...)
 	targetEntry.PathCount--
@@ -688,6 +770,7 @@ func (p *filePackage) RemoveFile(ctx context.Context, path string) error {
 			}
 		}
 		p.FileEntries = newFileEntries
+		removeFileEntryTempFile(targetEntry)
 	}
 
 	// Update path metadata associations
@@ -763,6 +846,202 @@ func (p *filePackage) ensurePathMetadata(path string, fileEntry *metadata.FileEn
 	return nil
 }
 
+const (
+	// fileTypeSampleSize is the number of leading bytes read for file type detection.
+	fileTypeSampleSize = 8192
+
+	// autoCompressSampleSize is the number of leading bytes trial-compressed in auto mode.
+	autoCompressSampleSize = 1 << 20
+
+	// defaultAutoCompressMinSavings is the default minimum size reduction, in percent,
+	// that trial compression must achieve for auto mode to keep compression.
+	defaultAutoCompressMinSavings = 10
+)
+
+// readFileSample reads the leading bytes of a source file used for file type
+// detection and, in auto compression mode, trial compression.
+func readFileSample(sourceFile *os.File, size uint64, options *AddFileOptions) ([]byte, error) {
+	sample := make([]byte, min(size, fileSampleSize(options)))
+	n, err := sourceFile.ReadAt(sample, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return sample[:n], nil
+}
+
+// fileSampleSize returns the number of leading bytes sampled for file type
+// detection and, in auto compression mode, trial compression.
+func fileSampleSize(options *AddFileOptions) uint64 {
+	if options != nil && options.AutoCompress.GetOrDefault(false) {
+		return autoCompressSampleSize
+	}
+	return fileTypeSampleSize
+}
+
+// resolveFileType returns the FileType option when set, otherwise detects the
+// file type from the stored path and a leading sample of the file data.
+//
+// Specification: file_type_system.md: 4.1.1 DetermineFileType Function (Detection Process)
+func resolveFileType(options *AddFileOptions, storedPath string, sample []byte) fileformat.FileType {
+	if options != nil && options.FileType.IsSet() {
+		return fileformat.FileType(options.FileType.GetOrDefault(0))
+	}
+	return fileformat.DetermineFileType(storedPath, sample)
+}
+
+// sameEncryption reports whether entry is encrypted the way a new file with
+// encryptionType and key would be, so deduplication never shares content between
+// encrypted and unencrypted entries or between entries encrypted with different keys.
+func sameEncryption(entry *metadata.FileEntry, encryptionType uint8, key *EncryptionKey) bool {
+	if entry.EncryptionType != encryptionType {
+		return false
+	}
+	if key == nil {
+		return true
+	}
+	keyID, _ := entry.GetEncryptionKeyID()
+	return keyID == key.KeyID
+}
+
+// resolveCompressionOptions determines the effective compression type and level from options.
+// Setting CompressionType implies Compress unless Compress is explicitly false; Compress without
+// a CompressionType selects Zstd. CompressionDictionaryID implies Zstd and rejects other types.
+// AutoCompress selects the type from fileType and sample instead (see selectAutoCompression). The returned level is the resolved level (0 maps to the default).
+//
+// Specification: api_file_mgmt_addition.md: 2.8.5 File Processing Options
+func resolveCompressionOptions(options *AddFileOptions, fileType fileformat.FileType, sample []byte) (uint8, uint8, error) {
+	if options == nil {
+		return fileformat.CompressionNone, 0, nil
+	}
+
+	if options.AutoCompress.GetOrDefault(false) {
+		if options.CompressionDictionaryID.IsSet() {
+			return 0, 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "AutoCompress cannot be combined with a compression dictionary", nil, pkgerrors.ValidationErrorContext{
+				Field:    "CompressionDictionaryID",
+				Value:    options.CompressionDictionaryID.GetOrDefault(0),
+				Expected: "CompressionDictionaryID unset when AutoCompress is set",
+			})
+		}
+		return selectAutoCompression(options, fileType, sample)
+	}
+
+	compressionType := options.CompressionType.GetOrDefault(fileformat.CompressionNone)
+	if options.CompressionDictionaryID.IsSet() {
+		if (compressionType != fileformat.CompressionNone && compressionType != fileformat.CompressionZstd) || !options.Compress.GetOrDefault(true) {
+			return 0, 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "compression dictionaries require Zstd compression", nil, pkgerrors.ValidationErrorContext{
+				Field:    "CompressionType",
+				Value:    compressionType,
+				Expected: "Zstd or unset when CompressionDictionaryID is set",
+			})
+		}
+		compressionType = fileformat.CompressionZstd
+	}
+	if !options.Compress.GetOrDefault(compressionType != fileformat.CompressionNone) {
+		return fileformat.CompressionNone, 0, nil
+	}
+	if compressionType == fileformat.CompressionNone {
+		compressionType = fileformat.CompressionZstd
+	}
+
+	if !internal.IsSupportedCompressionType(compressionType) {
+		return 0, 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeUnsupported, "unsupported compression type", nil, pkgerrors.ValidationErrorContext{
+			Field:    "CompressionType",
+			Value:    compressionType,
+			Expected: "supported compression type",
+		})
+	}
+
+	level, err := internal.ResolveCompressionLevel(options.CompressionLevel.GetOrDefault(0))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return compressionType, uint8(level), nil
+}
+
+// resolveCompressionFrameSize returns the seekable compression frame size requested by
+// options. Returns false if no frame size is requested, or if AutoCompress chose to store
+// the file raw. Framed compression requires compression and cannot be combined with a
+// compression dictionary.
+//
+// Specification: api_file_mgmt_addition.md: 2.8.5 File Processing Options
+func resolveCompressionFrameSize(options *AddFileOptions, compressionType uint8) (uint32, bool, error) {
+	if options == nil || !options.CompressionFrameSize.IsSet() {
+		return 0, false, nil
+	}
+	frameSize := options.CompressionFrameSize.GetOrDefault(0)
+	if err := internal.ValidateCompressionFrameSize(frameSize); err != nil {
+		return 0, false, err
+	}
+	if options.CompressionDictionaryID.IsSet() {
+		return 0, false, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "seekable compression cannot be combined with a compression dictionary", nil, pkgerrors.ValidationErrorContext{
+			Field:    "CompressionFrameSize",
+			Value:    frameSize,
+			Expected: "CompressionFrameSize unset when CompressionDictionaryID is set",
+		})
+	}
+	if compressionType == fileformat.CompressionNone {
+		if options.AutoCompress.GetOrDefault(false) {
+			return 0, false, nil
+		}
+		return 0, false, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "seekable compression requires compression", nil, pkgerrors.ValidationErrorContext{
+			Field:    "CompressionFrameSize",
+			Value:    frameSize,
+			Expected: "compression enabled when CompressionFrameSize is set",
+		})
+	}
+	return uint32(frameSize), true, nil
+}
+
+// selectAutoCompression selects a compression type for fileType and keeps it only if
+// trial compression of sample saves at least AutoCompressMinSavings percent.
+// Already compressed formats, empty files and files that do not compress well are stored raw.
+// AutoCompress cannot be combined with CompressionType or Compress.
+//
+// Specification: file_type_system.md: 2.2.1 SelectCompressionType Function
+func selectAutoCompression(options *AddFileOptions, fileType fileformat.FileType, sample []byte) (uint8, uint8, error) {
+	if options.CompressionType.IsSet() || options.Compress.IsSet() {
+		return 0, 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "AutoCompress cannot be combined with explicit compression options", nil, pkgerrors.ValidationErrorContext{
+			Field:    "AutoCompress",
+			Value:    true,
+			Expected: "CompressionType and Compress unset",
+		})
+	}
+
+	minSavings := options.AutoCompressMinSavings.GetOrDefault(defaultAutoCompressMinSavings)
+	if minSavings < 0 || minSavings > 99 {
+		return 0, 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "auto compression savings threshold out of range", nil, pkgerrors.ValidationErrorContext{
+			Field:    "AutoCompressMinSavings",
+			Value:    minSavings,
+			Expected: "0-99 percent",
+		})
+	}
+
+	level, err := internal.ResolveCompressionLevel(options.CompressionLevel.GetOrDefault(0))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	compressionType := fileformat.SelectCompressionType(sample, fileType)
+	if compressionType == fileformat.CompressionNone || len(sample) == 0 {
+		return fileformat.CompressionNone, 0, nil
+	}
+
+	if len(sample) > autoCompressSampleSize {
+		sample = sample[:autoCompressSampleSize]
+	}
+	compressed, err := internal.CompressData(sample, compressionType, level)
+	if err != nil {
+		return 0, 0, err
+	}
+	saved := len(sample) - len(compressed)
+	if saved <= 0 || saved*100 < minSavings*len(sample) {
+		return fileformat.CompressionNone, 0, nil
+	}
+
+	return compressionType, uint8(level), nil
+}
+
 // determineStoredPath determines the stored package path from the filesystem path.
 // Implements the complete path determination logic per api_file_mgmt_addition.md Section 2.6 (Path Determination Rules).
 //
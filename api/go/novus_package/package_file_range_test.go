@@ -0,0 +1,296 @@
+// This file contains tests for range reads: plain reads at an offset, seekable
+// (framed) compression that decodes only the touched frames, and the fallback
+// for other stored forms.
+//
+// Specification: package_file_format.md: 4.1.4.4 Optional Data
+
+package novus_package
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/internal"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+const rangeTestFrameSize = 4 << 10
+
+// rangeTestPayload returns compressible content that differs between frames.
+func rangeTestPayload(size int) []byte {
+	payload := make([]byte, 0, size+64)
+	for i := 0; len(payload) < size; i++ {
+		payload = fmt.Appendf(payload, "sample %07d: streamed audio frame payload\n", i)
+	}
+	return payload[:size]
+}
+
+// framedRangeOptions returns AddFileOptions requesting seekable compression.
+func framedRangeOptions(compressionType uint8) *AddFileOptions {
+	opts := &AddFileOptions{}
+	opts.CompressionType.Set(compressionType)
+	opts.CompressionFrameSize.Set(rangeTestFrameSize)
+	return opts
+}
+
+// assertRanges verifies ReadFileRange against content for reads at the start, in the
+// middle, across frame boundaries and at the end of the file.
+func assertRanges(t *testing.T, ctx context.Context, pkg Package, path string, content []byte) {
+	t.Helper()
+	size := int64(len(content))
+	ranges := []struct{ offset, length int64 }{
+		{0, 100},
+		{rangeTestFrameSize - 10, 20},
+		{3*rangeTestFrameSize + 7, 2*rangeTestFrameSize + 5},
+		{size - 4096, 4096},
+		{size - 10, 100},
+		{size, 10},
+		{0, size},
+		{5, 0},
+	}
+	for _, r := range ranges {
+		got, err := pkg.ReadFileRange(ctx, path, r.offset, r.length)
+		if err != nil {
+			t.Fatalf("ReadFileRange(%d, %d) failed: %v", r.offset, r.length, err)
+		}
+		want := content[r.offset:min(r.offset+r.length, size)]
+		if !bytes.Equal(got, want) {
+			t.Errorf("ReadFileRange(%d, %d) returned %d bytes, want %d matching bytes", r.offset, r.length, len(got), len(want))
+		}
+	}
+}
+
+func TestPackage_ReadFileRange_Framed(t *testing.T) {
+	ctx := context.Background()
+	content := rangeTestPayload(20*rangeTestFrameSize + 321)
+
+	for _, compressionType := range []uint8{fileformat.CompressionZstd, fileformat.CompressionLZ4, fileformat.CompressionLZMA} {
+		t.Run(fmt.Sprintf("type=%d", compressionType), func(t *testing.T) {
+			pkg, err := NewPackage()
+			if err != nil {
+				t.Fatalf("NewPackage failed: %v", err)
+			}
+			if _, err := pkg.AddFileFromMemory(ctx, "/media/track.raw", content, framedRangeOptions(compressionType)); err != nil {
+				t.Fatalf("AddFileFromMemory failed: %v", err)
+			}
+			reopened := writeAndReopen(t, ctx, pkg)
+			fe, err := reopened.(*filePackage).findFileEntryByPath("/media/track.raw")
+			if err != nil {
+				t.Fatalf("findFileEntryByPath failed: %v", err)
+			}
+			if size, ok := fe.GetCompressionFrameSize(); !ok || size != rangeTestFrameSize {
+				t.Errorf("GetCompressionFrameSize() = %d, %v; want %d, true", size, ok, rangeTestFrameSize)
+			}
+			if fe.StoredSize >= fe.OriginalSize/2 {
+				t.Errorf("StoredSize = %d, want well below OriginalSize %d", fe.StoredSize, fe.OriginalSize)
+			}
+
+			assertRanges(t, ctx, reopened, "/media/track.raw", content)
+			full, err := reopened.ReadFile(ctx, "/media/track.raw")
+			if err != nil {
+				t.Fatalf("ReadFile failed: %v", err)
+			}
+			if !bytes.Equal(full, content) {
+				t.Error("ReadFile content mismatch")
+			}
+		})
+	}
+}
+
+func TestPackage_ReadFileRange_DecodesOnlyTouchedFrames(t *testing.T) {
+	ctx := context.Background()
+	content := rangeTestPayload(8 * rangeTestFrameSize)
+
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/media/clip.raw", content, framedRangeOptions(fileformat.CompressionZstd)); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	reopened := writeAndReopen(t, ctx, pkg)
+	fe, err := reopened.(*filePackage).findFileEntryByPath("/media/clip.raw")
+	if err != nil {
+		t.Fatalf("findFileEntryByPath failed: %v", err)
+	}
+
+	// Damage the middle of the last frame in the package file
+	frameCount := internal.FrameCount(fe.OriginalSize, rangeTestFrameSize)
+	table, err := readSourceRange(ctx, fe, fe.SourceOffset, int64(internal.FrameTableSize(frameCount)))
+	if err != nil {
+		t.Fatalf("readSourceRange failed: %v", err)
+	}
+	ends, err := internal.ParseFrameTable(table, frameCount)
+	if err != nil {
+		t.Fatalf("ParseFrameTable failed: %v", err)
+	}
+	lastStart := ends[frameCount-2]
+	damageAt := fe.SourceOffset + int64(internal.FrameTableSize(frameCount)+(lastStart+ends[frameCount-1])/2)
+	file, err := os.OpenFile(reopened.GetPath(), os.O_RDWR, 0)
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	if _, err := file.WriteAt([]byte{0xFF, 0x00, 0xFF, 0x00}, damageAt); err != nil {
+		t.Fatalf("WriteAt failed: %v", err)
+	}
+	_ = file.Close()
+
+	got, err := reopened.ReadFileRange(ctx, "/media/clip.raw", rangeTestFrameSize, 2*rangeTestFrameSize)
+	if err != nil {
+		t.Fatalf("ReadFileRange of undamaged frames failed: %v", err)
+	}
+	if !bytes.Equal(got, content[rangeTestFrameSize:3*rangeTestFrameSize]) {
+		t.Error("ReadFileRange content mismatch")
+	}
+
+	_, err = reopened.ReadFile(ctx, "/media/clip.raw")
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption)
+	_, err = reopened.ReadFileRange(ctx, "/media/clip.raw", int64(len(content))-10, 10)
+	if err == nil {
+		t.Error("ReadFileRange of damaged frame: expected error")
+	}
+}
+
+func TestPackage_ReadFileRange_OtherStoredForms(t *testing.T) {
+	ctx := context.Background()
+	content := rangeTestPayload(6*rangeTestFrameSize + 99)
+
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/plain.bin", content, nil); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	opts := &AddFileOptions{}
+	opts.CompressionType.Set(fileformat.CompressionZstd)
+	if _, err := pkg.AddFileFromMemory(ctx, "/whole.bin", append([]byte("whole "), content...), opts); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+
+	reopened := writeAndReopen(t, ctx, pkg)
+	assertRanges(t, ctx, reopened, "/plain.bin", content)
+	assertRanges(t, ctx, reopened, "/whole.bin", append([]byte("whole "), content...))
+}
+
+func TestPackage_ReadFileRange_Errors(t *testing.T) {
+	ctx := context.Background()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/data.bin", []byte("0123456789"), nil); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	reopened := writeAndReopen(t, ctx, pkg)
+
+	_, err = reopened.ReadFileRange(ctx, "/data.bin", -1, 5)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+	_, err = reopened.ReadFileRange(ctx, "/data.bin", 0, -1)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+	_, err = reopened.ReadFileRange(ctx, "/data.bin", 11, 1)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+	_, err = reopened.ReadFileRange(ctx, "/missing.bin", 0, 1)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = reopened.ReadFileRange(cancelled, "/data.bin", 0, 1)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeContext)
+}
+
+func TestAddFileOptions_CompressionFrameSize(t *testing.T) {
+	ctx := context.Background()
+	content := rangeTestPayload(3 * rangeTestFrameSize)
+
+	tests := []struct {
+		name      string
+		configure func(*AddFileOptions)
+		wantErr   bool
+		wantFrame bool
+	}{
+		{"too small", func(o *AddFileOptions) {
+			o.Compress.Set(true)
+			o.CompressionFrameSize.Set(internal.MinCompressionFrameSize - 1)
+		}, true, false},
+		{"too large", func(o *AddFileOptions) {
+			o.Compress.Set(true)
+			o.CompressionFrameSize.Set(internal.MaxCompressionFrameSize + 1)
+		}, true, false},
+		{"no compression", func(o *AddFileOptions) {
+			o.CompressionFrameSize.Set(rangeTestFrameSize)
+		}, true, false},
+		{"with dictionary", func(o *AddFileOptions) {
+			o.CompressionDictionaryID.Set(32768)
+			o.CompressionFrameSize.Set(rangeTestFrameSize)
+		}, true, false},
+		{"compress defaults to zstd", func(o *AddFileOptions) {
+			o.Compress.Set(true)
+			o.CompressionFrameSize.Set(rangeTestFrameSize)
+		}, false, true},
+		{"auto compress stores raw", func(o *AddFileOptions) {
+			o.AutoCompress.Set(true)
+			o.CompressionFrameSize.Set(rangeTestFrameSize)
+		}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg, err := NewPackage()
+			if err != nil {
+				t.Fatalf("NewPackage failed: %v", err)
+			}
+			opts := &AddFileOptions{}
+			tt.configure(opts)
+			path := "/media/clip.mp4"
+			if tt.wantFrame {
+				path = "/media/clip.raw"
+			}
+			_, err = pkg.AddFileFromMemory(ctx, path, content, opts)
+			if tt.wantErr {
+				assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+				return
+			}
+			if err != nil {
+				t.Fatalf("AddFileFromMemory failed: %v", err)
+			}
+			fe, err := pkg.(*filePackage).findFileEntryByPath(path)
+			if err != nil {
+				t.Fatalf("findFileEntryByPath failed: %v", err)
+			}
+			if _, ok := fe.GetCompressionFrameSize(); ok != tt.wantFrame {
+				t.Errorf("GetCompressionFrameSize() ok = %v, want %v", ok, tt.wantFrame)
+			}
+		})
+	}
+}
+
+func TestPackage_CompressionFrameSize_ClearedByRegrouping(t *testing.T) {
+	ctx := context.Background()
+	content := rangeTestPayload(6 * rangeTestFrameSize)
+
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/a.raw", content, framedRangeOptions(fileformat.CompressionZstd)); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	if _, err := pkg.CreateSolidGroup(ctx, []string{"/a.raw"}); err != nil {
+		t.Fatalf("CreateSolidGroup failed: %v", err)
+	}
+
+	reopened := writeAndReopen(t, ctx, pkg)
+	fe, err := reopened.(*filePackage).findFileEntryByPath("/a.raw")
+	if err != nil {
+		t.Fatalf("findFileEntryByPath failed: %v", err)
+	}
+	if _, ok := fe.GetCompressionFrameSize(); ok {
+		t.Error("solid group member kept its compression frame size")
+	}
+	assertRanges(t, ctx, reopened, "/a.raw", content)
+}
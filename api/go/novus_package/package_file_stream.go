@@ -0,0 +1,746 @@
+// This file implements FileStream, streaming access to file content. OpenFile
+// returns a FileStream that decodes a file entry incrementally and implements
+// io.Reader, io.Seeker, io.ReaderAt and io.Closer. Stored data is read with
+// positioned reads, decrypted one chunk at a time, decompressed on the fly
+// (seekable frames are decoded individually), and its checksums are verified when
+// the content is read through to the end. This file should contain only FileStream and its stored-data sources.
+//
+// Specification: api_streaming.md: 1. File Streaming Interface
+
+package novus_package
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/internal"
+	"github.com/novus-engine/novuspack/api/go/metadata"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+const (
+	// DefaultStreamChunkSize is the number of bytes returned by FileStream.ReadChunk.
+	DefaultStreamChunkSize = 64 << 10
+
+	// streamSkipLimit is the largest forward seek served by decoding and discarding
+	// from the current position when the source supports cheaper random access.
+	streamSkipLimit = 64 << 10
+)
+
+// FileStream provides streaming access to the content of a file in a package.
+//
+// FileStream implements io.Reader, io.Seeker, io.ReaderAt and io.Closer over the
+// uncompressed, decrypted content. Read and Seek share a position; ReadAt does not
+// use or change it. Compressed content is decoded as it is read: files stored with
+// seekable compression frames decode only the frames that are read, while other
+// compressed files decode from the start on a backward seek. Encrypted files are
+// decrypted and authenticated one chunk at a time as they are read. Members of
+// solid compression groups are served from the group's cached block, or decoded
+// from the start of the group's block when it is not cached.
+//
+// When the content is read from the start through to the end, RawChecksum and
+// StoredChecksum are verified incrementally and a mismatch is returned by the read
+// that reaches the end instead of io.EOF.
+//
+// A FileStream is safe for concurrent use. It must be closed before the package.
+//
+// Specification: api_streaming.md: 1.2.1 FileStream Struct
+type FileStream struct {
+	mu        sync.Mutex
+	ctx       context.Context
+	source    *streamSource
+	cursor    *streamCursor // Read position cursor
+	atCursor  *streamCursor // ReadAt cursor
+	position  int64
+	bytesRead int64
+	started   time.Time
+	closed    bool
+}
+
+// OpenFile opens a file in the package for streaming.
+//
+// The stream reads stored data from the package file as it is consumed. ctx
+// applies to the stream's reads as well as to opening it.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - path: Package-internal path to the file
+//
+// Returns:
+//   - *FileStream: Stream over the file content; the caller must Close it
+//   - error: *PackageError on failure
+//
+// Error Conditions:
+//   - ErrTypeContext: Context is cancelled or has deadline exceeded
+//   - ErrTypeValidation: Path is invalid or file not found
+//   - ErrTypeIO: Failed to read file data
+//   - ErrTypeCorruption: Stored frame table or checksum is damaged
+//   - ErrTypeEncryption: File is encrypted and its key was not supplied through AddEncryptionKey, or decryption failed
+//
+// Specification: api_streaming.md: 1.3.1 Package.OpenFile Method
+func (p *filePackage) OpenFile(ctx context.Context, path string) (*FileStream, error) {
+	_, fe, err := p.readFileValidateAndResolve(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	source, err := p.newStreamSource(ctx, fe)
+	if err != nil {
+		return nil, err
+	}
+	return &FileStream{ctx: ctx, source: source, started: time.Now()}, nil
+}
+
+// Read reads up to len(p) bytes of content at the current position.
+// It returns io.EOF at the end of the content.
+//
+// Specification: api_streaming.md: 1.3.4.1 FileStream.Read Method
+func (s *FileStream) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkReadable("Read"); err != nil {
+		return 0, err
+	}
+	if s.position >= s.source.size && (s.cursor == nil || s.cursor.pos != s.source.size) {
+		return 0, io.EOF
+	}
+	cursor, err := s.seekCursor(s.cursor, s.position)
+	if err != nil {
+		return 0, err
+	}
+	s.cursor = cursor
+	n, err := cursor.read(p)
+	s.position += int64(n)
+	s.bytesRead += int64(n)
+	return n, err
+}
+
+// ReadAt reads len(p) bytes of content starting at off. It returns io.EOF if
+// fewer bytes are available. ReadAt does not change the stream position.
+//
+// Specification: api_streaming.md: 1.3.4.2 FileStream.ReadAt Method
+func (s *FileStream) ReadAt(p []byte, off int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkReadable("ReadAt"); err != nil {
+		return 0, err
+	}
+	if off < 0 {
+		return 0, s.offsetError(off)
+	}
+	if off >= s.source.size {
+		if len(p) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+	cursor, err := s.seekCursor(s.atCursor, off)
+	if err != nil {
+		return 0, err
+	}
+	s.atCursor = cursor
+	n, err := io.ReadFull(readerFunc(cursor.read), p)
+	s.bytesRead += int64(n)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Seek sets the position for the next Read relative to the start, the current
+// position or the end of the content, as io.Seeker. Seeking past the end is
+// allowed; reads there return io.EOF.
+//
+// Specification: api_streaming.md: 1.3.2.2 FileStream.Seek Method
+func (s *FileStream) Seek(offset int64, whence int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, streamClosedError("Seek")
+	}
+	var target int64
+	switch whence {
+	case io.SeekStart:
+		target = offset
+	case io.SeekCurrent:
+		target = s.position + offset
+	case io.SeekEnd:
+		target = s.source.size + offset
+	default:
+		return 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "invalid seek whence", nil, pkgerrors.ValidationErrorContext{
+			Field:    "whence",
+			Value:    whence,
+			Expected: "io.SeekStart, io.SeekCurrent or io.SeekEnd",
+		})
+	}
+	if target < 0 {
+		return 0, s.offsetError(target)
+	}
+	s.position = target
+	return target, nil
+}
+
+// ReadChunk reads the next chunk of up to DefaultStreamChunkSize bytes at the
+// current position. It returns io.EOF at the end of the content.
+//
+// Specification: api_streaming.md: 1.3.2.1 FileStream.ReadChunk Method
+func (s *FileStream) ReadChunk(ctx context.Context) ([]byte, error) {
+	if err := internal.CheckContext(ctx, "ReadChunk"); err != nil {
+		return nil, err
+	}
+	chunk := make([]byte, DefaultStreamChunkSize)
+	n, err := io.ReadFull(s, chunk)
+	switch {
+	case err == nil, errors.Is(err, io.ErrUnexpectedEOF):
+		return chunk[:n], nil
+	default:
+		return nil, err
+	}
+}
+
+// Close releases the stream's decoders. Closing a closed stream has no effect.
+//
+// Specification: api_streaming.md: 1.3.2.3 FileStream.Close Method
+func (s *FileStream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	s.cursor.close()
+	s.atCursor.close()
+	s.cursor, s.atCursor = nil, nil
+	return nil
+}
+
+// Size returns the size of the uncompressed content in bytes.
+//
+// Specification: api_streaming.md: 1.3.3.2 FileStream.Size Method
+func (s *FileStream) Size() int64 {
+	return s.source.size
+}
+
+// Position returns the current read position.
+//
+// Specification: api_streaming.md: 1.3.3.3 FileStream.Position Method
+func (s *FileStream) Position() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.position
+}
+
+// IsClosed reports whether the stream has been closed.
+//
+// Specification: api_streaming.md: 1.3.3.4 FileStream.IsClosed Method
+func (s *FileStream) IsClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// Progress returns the bytes returned by Read and ReadAt so far, the content
+// size, the average read speed in bytes per second and the time since the stream
+// was opened.
+//
+// Specification: api_streaming.md: 1.3.3.5 FileStream.Progress Method
+func (s *FileStream) Progress() (bytesRead int64, totalBytes int64, readSpeed int64, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elapsed = time.Since(s.started)
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		readSpeed = int64(float64(s.bytesRead) / seconds)
+	}
+	return s.bytesRead, s.source.size, readSpeed, elapsed
+}
+
+// EstimatedTimeRemaining estimates the time to read the rest of the content at
+// the average read speed so far. It returns 0 before any data has been read.
+//
+// Specification: api_streaming.md: 1.3.3.6 FileStream.EstimatedTimeRemaining Method
+func (s *FileStream) EstimatedTimeRemaining() time.Duration {
+	bytesRead, total, speed, _ := s.Progress()
+	if speed <= 0 || bytesRead >= total {
+		return 0
+	}
+	return time.Duration(float64(total-bytesRead) / float64(speed) * float64(time.Second))
+}
+
+// checkReadable returns an error if the stream is closed or its context is done.
+func (s *FileStream) checkReadable(operation string) error {
+	if s.closed {
+		return streamClosedError(operation)
+	}
+	return internal.CheckContext(s.ctx, operation)
+}
+
+// seekCursor returns a cursor positioned at offset, advancing cursor when that is
+// cheaper than opening a new one.
+func (s *FileStream) seekCursor(cursor *streamCursor, offset int64) (*streamCursor, error) {
+	if offset >= s.source.size && cursor != nil && cursor.pos >= s.source.size {
+		return cursor, nil
+	}
+	if cursor != nil && cursor.pos <= offset && (!s.source.randomAccess() || offset-cursor.pos <= streamSkipLimit) {
+		if err := cursor.skip(offset - cursor.pos); err != nil {
+			return nil, err
+		}
+		return cursor, nil
+	}
+	cursor.close()
+	return s.source.open(min(offset, s.source.size))
+}
+
+// offsetError reports a negative stream offset.
+func (s *FileStream) offsetError(offset int64) error {
+	return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "negative stream offset", nil, pkgerrors.ValidationErrorContext{
+		Field:    "offset",
+		Value:    offset,
+		Expected: fmt.Sprintf("offset within 0-%d", s.source.size),
+	})
+}
+
+func streamClosedError(operation string) error {
+	return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "file stream is closed", nil, pkgerrors.ValidationErrorContext{
+		Field:    "FileStream",
+		Value:    operation,
+		Expected: "open stream",
+	})
+}
+
+// readerFunc adapts a read function to io.Reader.
+type readerFunc func([]byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) { return f(p) }
+
+// streamSource describes where and how the content of a file entry is stored.
+// stored holds the stored bytes after decryption.
+type streamSource struct {
+	path            string
+	size            int64
+	stored          *io.SectionReader
+	sealed          *io.SectionReader                            // Encrypted stored data, or nil
+	decrypt         func(io.ReaderAt) (*io.SectionReader, error) // Decrypts sealed data read through the reader
+	blockOffset     int64                                        // Content offset in a solid group block
+	blockSize       uint64                                       // Decompressed solid group block size
+	solid           bool                                         // Content is part of a solid group block
+	compressionType uint8
+	dict            []byte
+	frameSize       uint32
+	frameEnds       []uint64
+	tableSize       int64
+	rawChecksum     uint32
+	storedChecksum  uint32 // 0 when checked on open or absent
+}
+
+// newStreamSource resolves the stored form of fe.
+func (p *filePackage) newStreamSource(ctx context.Context, fe *metadata.FileEntry) (*streamSource, error) {
+	source := &streamSource{path: fe.GetPrimaryPath(), size: int64(fe.OriginalSize), rawChecksum: fe.RawChecksum}
+	_, _, solid := fe.GetSolidGroup()
+	switch {
+	case fe.IsDataLoaded:
+		source.setContent(fe.Data)
+		source.rawChecksum = 0
+		return source, nil
+	case fe.SourceFile != nil && fe.ProcessingState == metadata.ProcessingStateRaw:
+		size := fe.SourceSize
+		if size == 0 {
+			size = int64(fe.OriginalSize)
+		}
+		source.size = size
+		source.stored = io.NewSectionReader(fe.SourceFile, fe.SourceOffset, size)
+		return source, nil
+	case solid:
+		if err := p.setSolidGroupMemberSource(ctx, source, fe); err != nil {
+			return nil, err
+		}
+		return source, nil
+	case fe.EncryptionType != fileformat.EncryptionNone:
+		key, err := p.fileEntryEncryptionKey(ctx, fe, "ReadFile")
+		if err != nil {
+			return nil, err
+		}
+		if err := checkStoredFileSource(ctx, fe); err != nil {
+			return nil, err
+		}
+		source.sealed = p.storedSection(fe)
+		source.decrypt = func(sealed io.ReaderAt) (*io.SectionReader, error) {
+			plain, err := newFileEntryDecryptReader(fe, key, sealed, source.sealed.Size())
+			if err != nil {
+				return nil, err
+			}
+			return io.NewSectionReader(plain, 0, plain.Size()), nil
+		}
+		if source.stored, err = source.decrypt(source.sealed); err != nil {
+			return nil, err
+		}
+		source.storedChecksum = fe.StoredChecksum
+	default:
+		if fe.SourceFile == nil {
+			return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "file source is not available", nil, pkgerrors.ValidationErrorContext{
+				Field: "SourceFile", Value: "nil", Expected: "valid file handle",
+			})
+		}
+		source.stored = p.storedSection(fe)
+		source.storedChecksum = fe.StoredChecksum
+	}
+
+	source.compressionType = fe.CompressionType
+	if fe.CompressionType == fileformat.CompressionNone {
+		return source, nil
+	}
+	if dictID, ok := fe.GetCompressionDictionaryID(); ok && fe.CompressionType == fileformat.CompressionZstd {
+		dict, err := p.compressionDictionary(ctx, dictID)
+		if err != nil {
+			return nil, err
+		}
+		source.dict = dict
+	}
+	if frameSize, ok := fe.GetCompressionFrameSize(); ok {
+		if err := source.loadFrameTable(frameSize); err != nil {
+			return nil, err
+		}
+	}
+	return source, nil
+}
+
+// setSolidGroupMemberSource makes the content of solid group member fe the
+// content of source: a slice of the group's block when it is cached, or else the
+// member's range of the block decoded from the leader's stored data.
+func (p *filePackage) setSolidGroupMemberSource(ctx context.Context, source *streamSource, fe *metadata.FileEntry) error {
+	groupID, offset, _ := fe.GetSolidGroup()
+	if block, ok := p.cachedSolidGroupBlock(groupID); ok {
+		end := uint64(offset) + fe.OriginalSize
+		if end > uint64(len(block)) {
+			return pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "solid group member outside group block", nil, pkgerrors.ValidationErrorContext{
+				Field:    "SolidGroupID",
+				Value:    end,
+				Expected: fmt.Sprintf("member end within %d-byte block", len(block)),
+			})
+		}
+		source.setContent(block[offset:end])
+		return nil
+	}
+
+	leader, blockSize, err := p.solidGroupLeader(groupID)
+	if err != nil {
+		return err
+	}
+	if err := checkStoredFileSource(ctx, leader); err != nil {
+		return err
+	}
+	source.stored = p.storedSection(leader)
+	source.storedChecksum = leader.StoredChecksum
+	source.compressionType = leader.CompressionType
+	source.blockOffset = int64(offset)
+	source.blockSize = blockSize
+	source.solid = true
+	return nil
+}
+
+// setContent makes data the uncompressed content of the source.
+func (src *streamSource) setContent(data []byte) {
+	src.size = int64(len(data))
+	src.stored = io.NewSectionReader(bytes.NewReader(data), 0, src.size)
+	src.compressionType = fileformat.CompressionNone
+}
+
+// loadFrameTable reads and checks the frame table of framed stored data.
+func (src *streamSource) loadFrameTable(frameSize uint32) error {
+	frameCount := internal.FrameCount(uint64(src.size), frameSize)
+	tableSize := internal.FrameTableSize(frameCount)
+	table := make([]byte, tableSize)
+	if _, err := src.stored.ReadAt(table, 0); err != nil {
+		return streamReadError(err, 0)
+	}
+	ends, err := internal.ParseFrameTable(table, frameCount)
+	if err != nil {
+		return err
+	}
+	if frameCount > 0 && int64(tableSize+ends[frameCount-1]) != src.stored.Size() {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "frame table does not cover stored data", nil, pkgerrors.ValidationErrorContext{
+			Field:    "StoredSize",
+			Value:    src.stored.Size(),
+			Expected: fmt.Sprintf("%d bytes", tableSize+ends[frameCount-1]),
+		})
+	}
+	src.frameSize = frameSize
+	src.frameEnds = ends
+	src.tableSize = int64(tableSize)
+	return nil
+}
+
+// randomAccess reports whether the source can open a cursor at any offset
+// without decoding the content before it.
+func (src *streamSource) randomAccess() bool {
+	return src.compressionType == fileformat.CompressionNone || src.frameSize != 0
+}
+
+// open returns a cursor positioned at offset. Cursors opened at offset 0 verify
+// the checksums when they reach the end.
+func (src *streamSource) open(offset int64) (*streamCursor, error) {
+	cursor := &streamCursor{source: src}
+	var storedStart int64
+	skip := offset
+	switch {
+	case src.compressionType == fileformat.CompressionNone:
+		storedStart, skip = offset, 0
+	case src.frameSize != 0:
+		frame := uint64(offset) / uint64(src.frameSize)
+		cursor.frame = frame
+		storedStart = src.tableSize
+		if frame > 0 {
+			storedStart += int64(src.frameEnds[frame-1])
+		}
+		skip = offset - int64(frame)*int64(src.frameSize)
+		if offset == 0 {
+			storedStart = 0
+		}
+	}
+	cursor.pos = offset - skip
+
+	cursor.input = io.NewSectionReader(src.stored, storedStart, src.stored.Size()-storedStart)
+	if offset == 0 {
+		cursor.raw = crc32.NewIEEE()
+		switch {
+		case src.storedChecksum == 0:
+		case src.sealed != nil:
+			// StoredChecksum covers the encrypted data, which the cursor's own
+			// decryption reads in order as the content is read
+			cursor.storedHash = crc32.NewIEEE()
+			plain, err := src.decrypt(&orderedHashReaderAt{r: src.sealed, hash: cursor.storedHash})
+			if err != nil {
+				return nil, err
+			}
+			cursor.input = plain
+		default:
+			cursor.storedHash = crc32.NewIEEE()
+			cursor.input = io.TeeReader(cursor.input, cursor.storedHash)
+		}
+		if src.frameSize != 0 {
+			// The frame table is part of the stored data covered by StoredChecksum
+			if _, err := io.CopyN(io.Discard, cursor.input, src.tableSize); err != nil {
+				return nil, streamReadError(err, 0)
+			}
+		}
+	}
+
+	switch {
+	case src.compressionType == fileformat.CompressionNone:
+		cursor.decoded = cursor.input
+	case src.frameSize != 0:
+		cursor.decoded = readerFunc(cursor.readFrames)
+	case src.solid:
+		decoder, err := internal.NewDecompressReader(cursor.input, src.compressionType, nil, src.blockSize)
+		if err != nil {
+			return nil, err
+		}
+		cursor.decoded, cursor.decoder = decoder, decoder
+		// Members before this one in the block are decoded and discarded
+		if _, err := io.CopyN(io.Discard, decoder, src.blockOffset); err != nil {
+			cursor.close()
+			return nil, streamReadError(err, 0)
+		}
+	default:
+		decoder, err := internal.NewDecompressReader(cursor.input, src.compressionType, src.dict, uint64(src.size))
+		if err != nil {
+			return nil, err
+		}
+		cursor.decoded, cursor.decoder = decoder, decoder
+	}
+	if err := cursor.skip(skip); err != nil {
+		cursor.close()
+		return nil, err
+	}
+	return cursor, nil
+}
+
+// streamCursor decodes content sequentially from pos.
+type streamCursor struct {
+	source     *streamSource
+	input      io.Reader // Stored bytes from the cursor's stored start
+	decoded    io.Reader // Content from pos
+	decoder    io.Closer // Whole-stream decoder, if any
+	pos        int64
+	raw        hash.Hash32 // Content checksum, when verifying
+	storedHash hash.Hash32 // Stored data checksum, when verifying
+	frame      uint64      // Next frame for framed sources
+	frameData  io.ReadCloser
+	frameInput *io.LimitedReader
+	end        error // Result of reaching the end: io.EOF or a verification error
+}
+
+// read reads decoded content into p. At the end of the content it checks that the
+// decoder is exhausted and verifies the checksums.
+func (c *streamCursor) read(p []byte) (int, error) {
+	if c.end != nil {
+		return 0, c.end
+	}
+	if c.pos >= c.source.size {
+		c.end = c.finish()
+		return 0, c.end
+	}
+	if remaining := c.source.size - c.pos; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := c.decoded.Read(p)
+	if c.raw != nil {
+		c.raw.Write(p[:n])
+	}
+	c.pos += int64(n)
+	switch {
+	case errors.Is(err, io.EOF) && c.pos < c.source.size:
+		return n, pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "file content shorter than recorded size", nil, pkgerrors.ValidationErrorContext{
+			Field:    "OriginalSize",
+			Value:    c.pos,
+			Expected: fmt.Sprintf("%d bytes", c.source.size),
+		})
+	case errors.Is(err, io.EOF):
+		return n, nil
+	case err != nil:
+		return n, streamReadError(err, c.pos)
+	}
+	return n, nil
+}
+
+// finish checks that the stored data ends with the content and verifies the
+// checksums of a cursor that read the content from the start. Solid group members
+// may be followed by other members in the block, so only their checksums are
+// verified.
+func (c *streamCursor) finish() error {
+	if !c.source.solid {
+		var probe [1]byte
+		if n, err := io.ReadFull(c.decoded, probe[:]); n > 0 {
+			return pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "file content longer than recorded size", nil, pkgerrors.ValidationErrorContext{
+				Field:    "OriginalSize",
+				Value:    c.source.size,
+				Expected: "size of decoded content",
+			})
+		} else if err != nil && !errors.Is(err, io.EOF) {
+			return streamReadError(err, c.pos)
+		}
+	}
+	if c.raw == nil {
+		return io.EOF
+	}
+	if c.source.rawChecksum != 0 && c.raw.Sum32() != c.source.rawChecksum {
+		return streamChecksumError("RawChecksum", c.source.rawChecksum)
+	}
+	if c.storedHash != nil {
+		if _, err := io.Copy(io.Discard, c.input); err != nil {
+			return streamReadError(err, c.pos)
+		}
+		if c.storedHash.Sum32() != c.source.storedChecksum {
+			return streamChecksumError("StoredChecksum", c.source.storedChecksum)
+		}
+	}
+	return io.EOF
+}
+
+// skip discards n bytes of content.
+func (c *streamCursor) skip(n int64) error {
+	if n <= 0 {
+		return nil
+	}
+	if _, err := io.CopyN(io.Discard, readerFunc(c.read), n); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// readFrames decodes framed content, one frame at a time.
+func (c *streamCursor) readFrames(p []byte) (int, error) {
+	for {
+		if c.frameData == nil {
+			if c.frame >= uint64(len(c.source.frameEnds)) {
+				return 0, io.EOF
+			}
+			start := uint64(0)
+			if c.frame > 0 {
+				start = c.source.frameEnds[c.frame-1]
+			}
+			c.frameInput = &io.LimitedReader{R: c.input, N: int64(c.source.frameEnds[c.frame] - start)}
+			decoder, err := internal.NewDecompressReader(c.frameInput, c.source.compressionType, nil,
+				internal.FrameOriginalSize(c.frame, uint64(c.source.size), c.source.frameSize))
+			if err != nil {
+				return 0, err
+			}
+			c.frameData = decoder
+		}
+		n, err := c.frameData.Read(p)
+		if errors.Is(err, io.EOF) {
+			// Consume what the decoder left of the frame so the next frame starts aligned
+			if _, err := io.Copy(io.Discard, c.frameInput); err != nil {
+				return n, err
+			}
+			_ = c.frameData.Close()
+			c.frameData = nil
+			c.frame++
+			if n == 0 {
+				continue
+			}
+			return n, nil
+		}
+		return n, err
+	}
+}
+
+// close releases the cursor's decoders. A nil cursor is ignored.
+func (c *streamCursor) close() {
+	if c == nil {
+		return
+	}
+	if c.decoder != nil {
+		_ = c.decoder.Close()
+	}
+	if c.frameData != nil {
+		_ = c.frameData.Close()
+	}
+}
+
+// orderedHashReaderAt hashes the bytes of r the first time they are read in order
+// from offset 0. Bytes read again, or past bytes not yet read, are not hashed.
+type orderedHashReaderAt struct {
+	r    io.ReaderAt
+	hash hash.Hash32
+	end  int64 // Number of leading bytes hashed
+}
+
+func (h *orderedHashReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := h.r.ReadAt(p, off)
+	if off <= h.end && off+int64(n) > h.end {
+		h.hash.Write(p[h.end-off : n])
+		h.end = off + int64(n)
+	}
+	return n, err
+}
+
+// streamReadError wraps an error reading stored data. Truncated stored data is
+// reported as corruption; PackageErrors from decoders are returned unchanged.
+func streamReadError(err error, offset int64) error {
+	var pkgErr *pkgerrors.PackageError
+	if pkgerrors.As(err, &pkgErr) {
+		return err
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeCorruption, "stored file data truncated", pkgerrors.ValidationErrorContext{
+			Field: "StoredSize", Value: offset, Expected: "complete stored data",
+		})
+	}
+	return pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeIO, "failed to read file data", pkgerrors.ValidationErrorContext{
+		Field: "SourceOffset", Value: offset, Expected: "read successful",
+	})
+}
+
+// streamChecksumError reports a checksum mismatch.
+func streamChecksumError(field string, expected uint32) error {
+	return pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "file data checksum mismatch", nil, pkgerrors.ValidationErrorContext{
+		Field: field, Value: expected, Expected: "checksum of data read",
+	})
+}
@@ -0,0 +1,442 @@
+// This file contains tests for FileStream: streaming reads of every stored form,
+// seeking, ReadAt, incremental checksum verification and stream errors.
+//
+// Specification: api_streaming.md: 1. File Streaming Interface
+
+package novus_package
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/metadata"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// assertStreamContent reads the stream sequentially, with seeks and with ReadAt and
+// compares every read with content.
+func assertStreamContent(t *testing.T, stream *FileStream, content []byte) {
+	t.Helper()
+	size := int64(len(content))
+	if stream.Size() != size {
+		t.Fatalf("Size() = %d, want %d", stream.Size(), size)
+	}
+	got, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatalf("ReadAll returned %d bytes, want %d matching bytes", len(got), len(content))
+	}
+	if stream.Position() != size {
+		t.Errorf("Position() after ReadAll = %d, want %d", stream.Position(), size)
+	}
+
+	seeks := []struct {
+		offset int64
+		whence int
+		want   int64
+	}{
+		{size / 2, io.SeekStart, size / 2},
+		{-size / 3, io.SeekCurrent, size/2 + 100 - size/3},
+		{-10, io.SeekEnd, size - 10},
+		{rangeTestFrameSize + 3, io.SeekStart, rangeTestFrameSize + 3},
+		{0, io.SeekStart, 0},
+	}
+	for _, sk := range seeks {
+		pos, err := stream.Seek(sk.offset, sk.whence)
+		if err != nil || pos != sk.want {
+			t.Fatalf("Seek(%d, %d) = %d, %v; want %d", sk.offset, sk.whence, pos, err, sk.want)
+		}
+		buf := make([]byte, 100)
+		n, err := io.ReadFull(stream, buf)
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("Read after Seek(%d, %d) failed: %v", sk.offset, sk.whence, err)
+		}
+		if want := content[pos:min(pos+100, size)]; !bytes.Equal(buf[:n], want) {
+			t.Errorf("Read after Seek(%d, %d) = %d bytes, want %d matching bytes", sk.offset, sk.whence, n, len(want))
+		}
+	}
+
+	for _, off := range []int64{size - 50, 0, 7, size / 2} {
+		buf := make([]byte, 50)
+		n, err := stream.ReadAt(buf, off)
+		if err != nil || n != 50 || !bytes.Equal(buf, content[off:off+50]) {
+			t.Errorf("ReadAt(%d) = %d, %v; want 50 matching bytes", off, n, err)
+		}
+	}
+	buf := make([]byte, 100)
+	if n, err := stream.ReadAt(buf, size-20); n != 20 || !errors.Is(err, io.EOF) || !bytes.Equal(buf[:20], content[size-20:]) {
+		t.Errorf("ReadAt past end = %d, %v; want 20, io.EOF", n, err)
+	}
+	if n, err := stream.ReadAt(buf, size); n != 0 || !errors.Is(err, io.EOF) {
+		t.Errorf("ReadAt(size) = %d, %v; want 0, io.EOF", n, err)
+	}
+}
+
+func TestPackage_OpenFile_StoredForms(t *testing.T) {
+	ctx := context.Background()
+	content := rangeTestPayload(12*rangeTestFrameSize + 123)
+	key := testEncryptionKey("stream", 0x5A)
+
+	compressed := func(compressionType uint8) *AddFileOptions {
+		opts := &AddFileOptions{}
+		opts.CompressionType.Set(compressionType)
+		return opts
+	}
+	encryptedCompressed := encryptedOptions(key)
+	encryptedCompressed.CompressionType.Set(fileformat.CompressionZstd)
+
+	tests := []struct {
+		name string
+		opts *AddFileOptions
+	}{
+		{"uncompressed", nil},
+		{"zstd", compressed(fileformat.CompressionZstd)},
+		{"lz4", compressed(fileformat.CompressionLZ4)},
+		{"lzma", compressed(fileformat.CompressionLZMA)},
+		{"framed zstd", framedRangeOptions(fileformat.CompressionZstd)},
+		{"framed lz4", framedRangeOptions(fileformat.CompressionLZ4)},
+		{"encrypted", encryptedOptions(key)},
+		{"encrypted zstd", encryptedCompressed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg, err := NewPackage()
+			if err != nil {
+				t.Fatalf("NewPackage failed: %v", err)
+			}
+			if _, err := pkg.AddFileFromMemory(ctx, "/media/track.raw", content, tt.opts); err != nil {
+				t.Fatalf("AddFileFromMemory failed: %v", err)
+			}
+
+			reopened := writeAndReopen(t, ctx, pkg)
+			if err := reopened.AddEncryptionKey(key); err != nil {
+				t.Fatalf("AddEncryptionKey failed: %v", err)
+			}
+			// Files added since the last write stream from memory
+			if _, err := reopened.AddFileFromMemory(ctx, "/media/pending.raw", content, tt.opts); err != nil {
+				t.Fatalf("AddFileFromMemory(pending) failed: %v", err)
+			}
+			for _, path := range []string{"/media/track.raw", "/media/pending.raw"} {
+				stream, err := reopened.OpenFile(ctx, path)
+				if err != nil {
+					t.Fatalf("OpenFile(%q) failed: %v", path, err)
+				}
+				assertStreamContent(t, stream, content)
+				_ = stream.Close()
+			}
+		})
+	}
+}
+
+func TestPackage_OpenFile_DictionaryAndSolidGroup(t *testing.T) {
+	ctx := context.Background()
+
+	configs := dictionaryTestConfigs(24)
+	pkg := newDictionaryTestPackage(t, ctx, configs)
+	dictID := trainTestDictionary(t, ctx, pkg, configs)
+	for path := range configs {
+		if err := pkg.SetFileCompressionDictionary(ctx, path, dictID); err != nil {
+			t.Fatalf("SetFileCompressionDictionary failed: %v", err)
+		}
+	}
+	scripts := solidGroupTestScripts(6)
+	for _, path := range sortedPaths(scripts) {
+		if _, err := pkg.AddFileFromMemory(ctx, path, scripts[path], nil); err != nil {
+			t.Fatalf("AddFileFromMemory failed: %v", err)
+		}
+	}
+	if _, err := pkg.CreateSolidGroup(ctx, sortedPaths(scripts)); err != nil {
+		t.Fatalf("CreateSolidGroup failed: %v", err)
+	}
+	reopened := writeAndReopen(t, ctx, pkg)
+
+	for _, files := range []map[string][]byte{configs, scripts} {
+		for path, content := range files {
+			stream, err := reopened.OpenFile(ctx, path)
+			if err != nil {
+				t.Fatalf("OpenFile(%q) failed: %v", path, err)
+			}
+			got, err := io.ReadAll(stream)
+			_ = stream.Close()
+			if err != nil || !bytes.Equal(got, content) {
+				t.Errorf("ReadAll(%q) = %d bytes, %v; want %d matching bytes", path, len(got), err, len(content))
+			}
+		}
+	}
+}
+
+func TestPackage_OpenFile_SolidGroupMemberStreamed(t *testing.T) {
+	ctx := context.Background()
+	files := map[string][]byte{
+		"/levels/a.dat": rangeTestPayload(2*rangeTestFrameSize + 17),
+		"/levels/b.dat": append([]byte("b"), rangeTestPayload(3*rangeTestFrameSize)...),
+		"/levels/c.dat": append([]byte("c"), rangeTestPayload(rangeTestFrameSize+200)...),
+	}
+	pkg, groupID := newSolidGroupTestPackage(t, ctx, files)
+	reopened := writeAndReopen(t, ctx, pkg)
+
+	for _, path := range sortedPaths(files) {
+		stream, err := reopened.OpenFile(ctx, path)
+		if err != nil {
+			t.Fatalf("OpenFile(%q) failed: %v", path, err)
+		}
+		assertStreamContent(t, stream, files[path])
+		_ = stream.Close()
+	}
+	// Streams decode the member's range of the block instead of caching the block
+	if _, cached := reopened.(*filePackage).cachedSolidGroupBlock(groupID); cached {
+		t.Errorf("OpenFile cached the block of solid group %d", groupID)
+	}
+}
+
+func TestFileStream_EncryptedVerification(t *testing.T) {
+	ctx := context.Background()
+	content := rangeTestPayload(200 << 10)
+	key := testEncryptionKey("verify", 0x6B)
+
+	tests := []struct {
+		name   string
+		damage func(t *testing.T, fe *metadata.FileEntry, path string)
+		want   pkgerrors.ErrorType
+	}{
+		{"stored checksum", func(_ *testing.T, fe *metadata.FileEntry, _ string) {
+			fe.StoredChecksum ^= 1
+		}, pkgerrors.ErrTypeCorruption},
+		{"modified chunk", func(t *testing.T, fe *metadata.FileEntry, path string) {
+			file, err := os.OpenFile(path, os.O_RDWR, 0)
+			if err != nil {
+				t.Fatalf("OpenFile failed: %v", err)
+			}
+			defer func() { _ = file.Close() }()
+			offset := fe.SourceOffset + int64(fe.StoredSize) - 1
+			original := make([]byte, 1)
+			if _, err := file.ReadAt(original, offset); err != nil {
+				t.Fatalf("ReadAt failed: %v", err)
+			}
+			if _, err := file.WriteAt([]byte{original[0] ^ 0xFF}, offset); err != nil {
+				t.Fatalf("WriteAt failed: %v", err)
+			}
+		}, pkgerrors.ErrTypeEncryption},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg, err := NewPackage()
+			if err != nil {
+				t.Fatalf("NewPackage failed: %v", err)
+			}
+			if _, err := pkg.AddFileFromMemory(ctx, "/data.bin", content, encryptedOptions(key)); err != nil {
+				t.Fatalf("AddFileFromMemory failed: %v", err)
+			}
+			reopened := writeAndReopen(t, ctx, pkg)
+			if err := reopened.AddEncryptionKey(key); err != nil {
+				t.Fatalf("AddEncryptionKey failed: %v", err)
+			}
+			tt.damage(t, reopened.(*filePackage).FileEntries[0], reopened.GetPath())
+
+			// Damage is found as the content is read, not when the stream is opened
+			stream, err := reopened.OpenFile(ctx, "/data.bin")
+			if err != nil {
+				t.Fatalf("OpenFile failed: %v", err)
+			}
+			defer func() { _ = stream.Close() }()
+			got, err := io.ReadAll(stream)
+			assertPackageErrorType(t, err, tt.want)
+			if len(got) == 0 {
+				t.Errorf("ReadAll returned no content before the error")
+			}
+		})
+	}
+}
+
+func TestFileStream_ChecksumVerification(t *testing.T) {
+	ctx := context.Background()
+	content := rangeTestPayload(3*rangeTestFrameSize + 11)
+
+	for _, tt := range []struct {
+		name string
+		opts *AddFileOptions
+	}{
+		{"uncompressed", nil},
+		{"framed", framedRangeOptions(fileformat.CompressionLZ4)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg, err := NewPackage()
+			if err != nil {
+				t.Fatalf("NewPackage failed: %v", err)
+			}
+			if _, err := pkg.AddFileFromMemory(ctx, "/data.bin", content, tt.opts); err != nil {
+				t.Fatalf("AddFileFromMemory failed: %v", err)
+			}
+			reopened := writeAndReopen(t, ctx, pkg)
+			fe := reopened.(*filePackage).FileEntries[0]
+
+			// Flip a byte in the frame table area or content without breaking the layout
+			offset := fe.SourceOffset + int64(fe.StoredSize) - 1
+			if tt.opts == nil {
+				offset = fe.SourceOffset + 5
+			}
+			file, err := os.OpenFile(reopened.GetPath(), os.O_RDWR, 0)
+			if err != nil {
+				t.Fatalf("OpenFile failed: %v", err)
+			}
+			original := make([]byte, 1)
+			if _, err := file.ReadAt(original, offset); err != nil {
+				t.Fatalf("ReadAt failed: %v", err)
+			}
+			if _, err := file.WriteAt([]byte{original[0] ^ 0xFF}, offset); err != nil {
+				t.Fatalf("WriteAt failed: %v", err)
+			}
+			_ = file.Close()
+
+			stream, err := reopened.OpenFile(ctx, "/data.bin")
+			if err != nil {
+				t.Fatalf("OpenFile failed: %v", err)
+			}
+			defer func() { _ = stream.Close() }()
+			_, err = io.ReadAll(stream)
+			var pkgErr *pkgerrors.PackageError
+			if !pkgerrors.As(err, &pkgErr) || (pkgErr.Type != pkgerrors.ErrTypeCorruption && pkgErr.Type != pkgerrors.ErrTypeCompression) {
+				t.Fatalf("ReadAll of corrupted data error = %v, want corruption or compression error", err)
+			}
+			if _, again := stream.Read(make([]byte, 1)); again == nil || errors.Is(again, io.EOF) {
+				t.Errorf("Read after failed verification = %v, want the verification error again", again)
+			}
+		})
+	}
+}
+
+func TestFileStream_ReadChunkAndProgress(t *testing.T) {
+	ctx := context.Background()
+	content := rangeTestPayload(2*DefaultStreamChunkSize + 17)
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/big.bin", content, framedRangeOptions(fileformat.CompressionZstd)); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	stream, err := writeAndReopen(t, ctx, pkg).OpenFile(ctx, "/big.bin")
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	defer func() { _ = stream.Close() }()
+
+	var got []byte
+	chunks := 0
+	for {
+		chunk, err := stream.ReadChunk(ctx)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ReadChunk failed: %v", err)
+		}
+		got = append(got, chunk...)
+		chunks++
+	}
+	if chunks != 3 || !bytes.Equal(got, content) {
+		t.Errorf("ReadChunk returned %d chunks, %d bytes; want 3 chunks of matching content", chunks, len(got))
+	}
+	if read, total, _, elapsed := stream.Progress(); read != int64(len(content)) || total != int64(len(content)) || elapsed <= 0 {
+		t.Errorf("Progress() = %d/%d in %v, want all bytes read", read, total, elapsed)
+	}
+	if remaining := stream.EstimatedTimeRemaining(); remaining != 0 {
+		t.Errorf("EstimatedTimeRemaining() = %v after reading everything, want 0", remaining)
+	}
+}
+
+func TestFileStream_ConcurrentReadAt(t *testing.T) {
+	ctx := context.Background()
+	content := rangeTestPayload(16 * rangeTestFrameSize)
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/track.raw", content, framedRangeOptions(fileformat.CompressionZstd)); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	stream, err := writeAndReopen(t, ctx, pkg).OpenFile(ctx, "/track.raw")
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	defer func() { _ = stream.Close() }()
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func(off int64) {
+			defer wg.Done()
+			buf := make([]byte, 1000)
+			if _, err := stream.ReadAt(buf, off); err != nil || !bytes.Equal(buf, content[off:off+1000]) {
+				t.Errorf("ReadAt(%d) = %v or content mismatch", off, err)
+			}
+		}(int64(i) * 2 * rangeTestFrameSize)
+	}
+	wg.Wait()
+}
+
+func TestFileStream_Errors(t *testing.T) {
+	ctx := context.Background()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/a.txt", []byte("hello stream"), nil); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	reopened := writeAndReopen(t, ctx, pkg)
+
+	_, err = reopened.OpenFile(ctx, "/missing.txt")
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = reopened.OpenFile(cancelled, "/a.txt")
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeContext)
+
+	streamCtx, cancelStream := context.WithCancel(ctx)
+	stream, err := reopened.OpenFile(streamCtx, "/a.txt")
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	_, err = stream.Seek(-1, io.SeekStart)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+	_, err = stream.Seek(0, 42)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+	_, err = stream.ReadAt(make([]byte, 1), -1)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+	if pos, err := stream.Seek(100, io.SeekStart); err != nil || pos != 100 {
+		t.Fatalf("Seek past end = %d, %v; want 100", pos, err)
+	}
+	if n, err := stream.Read(make([]byte, 4)); n != 0 || !errors.Is(err, io.EOF) {
+		t.Errorf("Read past end = %d, %v; want 0, io.EOF", n, err)
+	}
+
+	cancelStream()
+	_, err = stream.Read(make([]byte, 4))
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeContext)
+
+	if err := stream.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if !stream.IsClosed() {
+		t.Error("IsClosed() = false after Close")
+	}
+	if err := stream.Close(); err != nil {
+		t.Errorf("second Close = %v, want nil", err)
+	}
+	_, err = stream.Read(make([]byte, 4))
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+	_, err = stream.ReadAt(make([]byte, 4), 0)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+	_, err = stream.Seek(0, io.SeekStart)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+}
@@ -33,6 +33,9 @@ func (p *filePackage) ensureInfoNotNil() error {
 //
 // Specification: api_metadata.md: 1. Comment Management
 func (p *filePackage) SetAppID(appID uint64) error {
+	if err := p.checkNotSigned("SetAppID"); err != nil {
+		return err
+	}
 	if err := p.ensureInfoNotNil(); err != nil {
 		return err
 	}
@@ -94,6 +97,9 @@ func (p *filePackage) HasAppID() bool {
 //
 // Specification: api_metadata.md: 2. AppID Management
 func (p *filePackage) SetVendorID(vendorID uint32) error {
+	if err := p.checkNotSigned("SetVendorID"); err != nil {
+		return err
+	}
 	if err := p.ensureInfoNotNil(); err != nil {
 		return err
 	}
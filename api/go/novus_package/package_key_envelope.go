@@ -0,0 +1,479 @@
+// This file implements multi-recipient key envelopes. A package key envelope is a
+// special file (type 65005) holding one randomly generated AES-256-GCM content key
+// wrapped once per recipient, either with the recipient's AES-256 key (AES key
+// wrap) or to the recipient's ML-KEM encapsulation key. Files added with
+// AddFileOptions.UseKeyEnvelope are encrypted with the content key, so any one
+// recipient key unlocks all of them, and recipients can be added or revoked
+// without re-encrypting file data. This file should contain only envelope
+// encoding, recipient management and content key unlocking.
+//
+// Specification: api_security.md: 6. Key Envelopes
+
+package novus_package
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"slices"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/generics"
+	"github.com/novus-engine/novuspack/api/go/internal"
+	"github.com/novus-engine/novuspack/api/go/metadata"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+const (
+	// keyEnvelopeFileType is the special file type holding the package key envelope.
+	keyEnvelopeFileType = uint16(fileformat.FileTypeKeyEnvelope)
+
+	// keyEnvelopeFilePath is the stored path of the key envelope special file.
+	keyEnvelopeFilePath = "/__NVPK_KEYS_65005__.nvpkkeys"
+
+	// keyEnvelopeVersion is the key envelope encoding version written by this implementation.
+	keyEnvelopeVersion = 1
+
+	// keyWrapAES256 wraps the content key with AES key wrap (RFC 3394) under an AES-256 key.
+	keyWrapAES256 = 0x01
+
+	// keyWrapMLKEM encrypts the content key to an ML-KEM encapsulation key using the
+	// quantum-safe hybrid framing.
+	keyWrapMLKEM = 0x02
+
+	// contentKeyIDPrefix prefixes the generated ID of an envelope content key.
+	contentKeyIDPrefix = "nvpk-content-"
+)
+
+// keyEnvelope is the parsed key envelope special file.
+type keyEnvelope struct {
+	contentKeyID   string                 // KeyID recorded in file entries encrypted with the content key
+	contentKeyType uint8                  // On-disk encryption type of the content key
+	recipients     []keyEnvelopeRecipient // Wrapped copies of the content key, in insertion order
+}
+
+// keyEnvelopeRecipient is one wrapped copy of the content key.
+type keyEnvelopeRecipient struct {
+	id         string // KeyID of the recipient key
+	wrapType   uint8  // keyWrapAES256 or keyWrapMLKEM
+	wrappedKey []byte // Content key wrapped for the recipient
+}
+
+// AddKeyRecipient wraps the package content key for recipient and stores it in
+// the key envelope special file.
+//
+// The first call creates the envelope and a new random content key; files added
+// afterwards with AddFileOptions.UseKeyEnvelope are encrypted with it. Later calls
+// need the content key unlocked, either in this session or through a recipient key
+// registered with AddEncryptionKey. AES-256-GCM recipient keys wrap the content key
+// with AES key wrap; ML-KEM recipient keys only need the public encapsulation key.
+// File data is not re-encrypted.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - recipient: Recipient key; its KeyID identifies the recipient
+//
+// Returns:
+//   - error: *PackageError on failure
+//
+// Specification: api_security.md: 6.2 Package.AddKeyRecipient Method
+func (p *filePackage) AddKeyRecipient(ctx context.Context, recipient *EncryptionKey) error {
+	if err := internal.CheckContext(ctx, "AddKeyRecipient"); err != nil {
+		return err
+	}
+	if err := p.checkNotSigned("AddKeyRecipient"); err != nil {
+		return err
+	}
+	return p.setKeyRecipient(ctx, recipient, false, "AddKeyRecipient")
+}
+
+// setKeyRecipient wraps the content key for recipient and stores the envelope,
+// creating the envelope on first use. An existing recipient with the same ID is
+// replaced if replace is set and rejected with ErrTypeValidation otherwise.
+func (p *filePackage) setKeyRecipient(ctx context.Context, recipient *EncryptionKey, replace bool, operation string) error {
+	if err := recipient.validate(operation); err != nil {
+		return err
+	}
+
+	env, err := p.loadKeyEnvelope(ctx)
+	if err != nil {
+		return err
+	}
+	var contentKey *EncryptionKey
+	index := -1
+	if env == nil {
+		if env, contentKey, err = p.newKeyEnvelope(); err != nil {
+			return err
+		}
+	} else {
+		index = slices.IndexFunc(env.recipients, func(r keyEnvelopeRecipient) bool { return r.id == recipient.KeyID })
+		if index >= 0 && !replace {
+			return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "key envelope recipient already exists", nil, pkgerrors.ValidationErrorContext{
+				Field:    "KeyID",
+				Value:    recipient.KeyID,
+				Expected: "ID not used by another recipient",
+			})
+		}
+		if contentKey, err = p.unlockKeyEnvelope(env); err != nil {
+			return err
+		}
+	}
+
+	material, err := contentKey.GetKey()
+	if err != nil {
+		return err
+	}
+	defer clear(material)
+	entry, err := wrapContentKey(recipient, material)
+	if err != nil {
+		return err
+	}
+
+	if index >= 0 {
+		env.recipients[index] = entry
+	} else {
+		env.recipients = append(env.recipients, entry)
+	}
+	p.storeKeyEnvelope(env)
+	return p.AddEncryptionKey(contentKey)
+}
+
+// RevokeKeyRecipient removes the wrapped content key of the recipient with
+// recipientID from the key envelope. The recipient can no longer unlock packages
+// written afterwards; file data is not re-encrypted, so copies written before the
+// revocation stay readable with the recipient's key.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - recipientID: KeyID of the recipient key
+//
+// Returns:
+//   - error: *PackageError with ErrTypeValidation if the recipient does not exist or
+//     is the last recipient
+//
+// Specification: api_security.md: 6.3 Package.RevokeKeyRecipient Method
+func (p *filePackage) RevokeKeyRecipient(ctx context.Context, recipientID string) error {
+	if err := internal.CheckContext(ctx, "RevokeKeyRecipient"); err != nil {
+		return err
+	}
+	if err := p.checkNotSigned("RevokeKeyRecipient"); err != nil {
+		return err
+	}
+	env, err := p.loadKeyEnvelope(ctx)
+	if err != nil {
+		return err
+	}
+	index := -1
+	if env != nil {
+		index = slices.IndexFunc(env.recipients, func(r keyEnvelopeRecipient) bool { return r.id == recipientID })
+	}
+	if index < 0 {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "key envelope recipient not found", nil, pkgerrors.ValidationErrorContext{
+			Field:    "recipientID",
+			Value:    recipientID,
+			Expected: "ID of a key envelope recipient",
+		})
+	}
+	if len(env.recipients) == 1 {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "cannot revoke the last key envelope recipient", nil, pkgerrors.ValidationErrorContext{
+			Field:    "recipientID",
+			Value:    recipientID,
+			Expected: "recipient of an envelope with other recipients",
+		})
+	}
+
+	env.recipients = slices.Delete(env.recipients, index, index+1)
+	p.storeKeyEnvelope(env)
+	return nil
+}
+
+// keyEnvelopeContentKey returns the unlocked content key of the package key envelope.
+// Returns ErrTypeValidation if the package has no key envelope and
+// ErrTypeEncryption if no recipient key is available.
+func (p *filePackage) keyEnvelopeContentKey(ctx context.Context) (*EncryptionKey, error) {
+	env, err := p.loadKeyEnvelope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if env == nil {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "package has no key envelope", nil, pkgerrors.ValidationErrorContext{
+			Field:    "UseKeyEnvelope",
+			Value:    true,
+			Expected: "at least one recipient added with AddKeyRecipient",
+		})
+	}
+	return p.unlockKeyEnvelope(env)
+}
+
+// unlockKeyEnvelopeFor returns the envelope content key if keyID is its ID and a
+// recipient key is registered. Returns nil without error if keyID does not belong
+// to the envelope.
+func (p *filePackage) unlockKeyEnvelopeFor(ctx context.Context, keyID string) (*EncryptionKey, error) {
+	if _, exists := p.SpecialFiles[keyEnvelopeFileType]; !exists {
+		return nil, nil
+	}
+	env, err := p.loadKeyEnvelope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if env == nil || env.contentKeyID != keyID {
+		return nil, nil
+	}
+	return p.unlockKeyEnvelope(env)
+}
+
+// unlockKeyEnvelope returns the content key of env, unwrapping it with the first
+// registered recipient key that opens its entry and registering it in the key ring.
+// Returns ErrTypeEncryption if no registered key opens the envelope.
+func (p *filePackage) unlockKeyEnvelope(env *keyEnvelope) (*EncryptionKey, error) {
+	if key, ok := p.registeredEncryptionKey(env.contentKeyID); ok {
+		return key, nil
+	}
+	for _, r := range env.recipients {
+		recipient, ok := p.registeredEncryptionKey(r.id)
+		if !ok {
+			continue
+		}
+		material, err := unwrapContentKey(recipient, r)
+		if err != nil {
+			continue
+		}
+		contentKey := NewEncryptionKey(EncryptionAES256GCM, env.contentKeyID, material)
+		clear(material)
+		if err := p.AddEncryptionKey(contentKey); err != nil {
+			return nil, err
+		}
+		return contentKey, nil
+	}
+	return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeEncryption, "key envelope locked: no recipient key available", nil, EncryptionErrorContext{
+		Operation:  "UnlockKeyEnvelope",
+		KeyID:      env.contentKeyID,
+		ErrorStage: "key_lookup",
+	})
+}
+
+// newKeyEnvelope creates an empty envelope with a new random content key and
+// registers the content key with the package.
+func (p *filePackage) newKeyEnvelope() (*keyEnvelope, *EncryptionKey, error) {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeEncryption, "failed to generate content key ID")
+	}
+	contentKey, err := GenerateEncryptionKey(EncryptionAES256GCM, contentKeyIDPrefix+hex.EncodeToString(suffix))
+	if err != nil {
+		return nil, nil, err
+	}
+	env := &keyEnvelope{contentKeyID: contentKey.KeyID, contentKeyType: fileformat.EncryptionAES256GCM}
+	return env, contentKey, nil
+}
+
+// wrapContentKey wraps the content key material for recipient.
+// Returns ErrTypeUnsupported for recipient key types that cannot wrap keys.
+func wrapContentKey(recipient *EncryptionKey, contentKey []byte) (keyEnvelopeRecipient, error) {
+	material, err := recipient.GetKey()
+	if err != nil {
+		return keyEnvelopeRecipient{}, err
+	}
+	defer clear(material)
+
+	entry := keyEnvelopeRecipient{id: recipient.KeyID}
+	switch level := mlkemLevel(recipient.KeyType); {
+	case recipient.KeyType == EncryptionAES256GCM:
+		entry.wrapType = keyWrapAES256
+		entry.wrappedKey, err = internal.WrapKey(material, contentKey)
+	case level != 0:
+		encapsulationKey := material
+		if len(material) == internal.MLKEMSeedSize {
+			if encapsulationKey, err = internal.MLKEMEncapsulationKey(level, material); err != nil {
+				return keyEnvelopeRecipient{}, err
+			}
+		}
+		entry.wrapType = keyWrapMLKEM
+		entry.wrappedKey, err = internal.EncryptData(contentKey, fileformat.EncryptionQuantumSafe, encapsulationKey)
+	default:
+		return keyEnvelopeRecipient{}, pkgerrors.NewPackageError(pkgerrors.ErrTypeUnsupported, "key type cannot be a key envelope recipient", nil, EncryptionErrorContext{
+			Operation:      "AddKeyRecipient",
+			EncryptionType: recipient.KeyType,
+			KeyID:          recipient.KeyID,
+			ErrorStage:     "key_wrap",
+		})
+	}
+	if err != nil {
+		return keyEnvelopeRecipient{}, err
+	}
+	return entry, nil
+}
+
+// unwrapContentKey unwraps the content key of entry with the recipient key.
+// Returns ErrTypeEncryption if the key does not open the entry.
+func unwrapContentKey(recipient *EncryptionKey, entry keyEnvelopeRecipient) ([]byte, error) {
+	material, err := recipient.GetKey()
+	if err != nil {
+		return nil, err
+	}
+	defer clear(material)
+
+	switch {
+	case entry.wrapType == keyWrapAES256 && recipient.KeyType == EncryptionAES256GCM:
+		return internal.UnwrapKey(material, entry.wrappedKey)
+	case entry.wrapType == keyWrapMLKEM && mlkemLevel(recipient.KeyType) != 0 && len(material) == internal.MLKEMSeedSize:
+		return internal.DecryptData(entry.wrappedKey, fileformat.EncryptionQuantumSafe, material)
+	default:
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeEncryption, "recipient key cannot unwrap content key", nil, EncryptionErrorContext{
+			Operation:      "UnlockKeyEnvelope",
+			EncryptionType: recipient.KeyType,
+			KeyID:          recipient.KeyID,
+			ErrorStage:     "key_unwrap",
+		})
+	}
+}
+
+// loadKeyEnvelope returns the parsed key envelope special file, or nil if the
+// package has none. The parsed envelope is cached on the package; concurrent first
+// loads keep the envelope cached first.
+func (p *filePackage) loadKeyEnvelope(ctx context.Context) (*keyEnvelope, error) {
+	p.mu.RLock()
+	cached := p.keyEnvelope
+	p.mu.RUnlock()
+	if cached != nil {
+		return cached, nil
+	}
+	specialFile, exists := p.SpecialFiles[keyEnvelopeFileType]
+	if !exists {
+		return nil, nil
+	}
+	data, err := p.fileEntryContent(ctx, specialFile)
+	if err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to read key envelope file")
+	}
+	env, err := decodeKeyEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.keyEnvelope != nil {
+		return p.keyEnvelope, nil
+	}
+	p.keyEnvelope = env
+	return env, nil
+}
+
+// storeKeyEnvelope writes env to the key envelope special file, creating the special
+// file if needed, and updates the cache.
+func (p *filePackage) storeKeyEnvelope(env *keyEnvelope) {
+	data := encodeKeyEnvelope(env)
+
+	specialFile, exists := p.SpecialFiles[keyEnvelopeFileType]
+	if !exists {
+		specialFile = metadata.NewFileEntry()
+		specialFile.FileID = p.allocateNextFileID()
+		specialFile.Type = keyEnvelopeFileType
+		specialFile.Paths = []generics.PathEntry{
+			{PathLength: uint16(len(keyEnvelopeFilePath)), Path: keyEnvelopeFilePath},
+		}
+		specialFile.PathCount = 1
+		specialFile.CompressionType = fileformat.CompressionNone // Wrapped keys do not compress
+		specialFile.EncryptionType = fileformat.EncryptionNone   // Wrapped keys are already encrypted
+
+		if p.SpecialFiles == nil {
+			p.SpecialFiles = make(map[uint16]*metadata.FileEntry)
+		}
+		p.SpecialFiles[keyEnvelopeFileType] = specialFile
+		p.FileEntries = append(p.FileEntries, specialFile)
+	}
+	specialFile.OriginalSize = uint64(len(data))
+	specialFile.StoredSize = uint64(len(data))
+	specialFile.SetData(data)
+
+	p.mu.Lock()
+	p.keyEnvelope = env
+	p.mu.Unlock()
+}
+
+// encodeKeyEnvelope serializes env, little-endian:
+// [Version: 1][ContentKeyType: 1][ContentKeyIDLen: 1][ContentKeyID][RecipientCount: 2]
+// followed by RecipientCount records of
+// [RecipientIDLen: 1][RecipientID][WrapType: 1][WrappedKeyLen: 2][WrappedKey].
+func encodeKeyEnvelope(env *keyEnvelope) []byte {
+	out := []byte{keyEnvelopeVersion, env.contentKeyType, byte(len(env.contentKeyID))}
+	out = append(out, env.contentKeyID...)
+	out = binary.LittleEndian.AppendUint16(out, uint16(len(env.recipients)))
+	for _, r := range env.recipients {
+		out = append(out, byte(len(r.id)))
+		out = append(out, r.id...)
+		out = append(out, r.wrapType)
+		out = binary.LittleEndian.AppendUint16(out, uint16(len(r.wrappedKey)))
+		out = append(out, r.wrappedKey...)
+	}
+	return out
+}
+
+// decodeKeyEnvelope parses the key envelope special file.
+// Returns ErrTypeUnsupported for unknown versions and ErrTypeCorruption if the
+// data is truncated.
+func decodeKeyEnvelope(data []byte) (*keyEnvelope, error) {
+	r := envelopeReader{data: data}
+	version := r.byte()
+	if r.err == nil && version != keyEnvelopeVersion {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeUnsupported, "unsupported key envelope version", nil, pkgerrors.ValidationErrorContext{
+			Field:    "KeyEnvelopeVersion",
+			Value:    version,
+			Expected: "1",
+		})
+	}
+	env := &keyEnvelope{contentKeyType: r.byte()}
+	env.contentKeyID = string(r.bytes(int(r.byte())))
+	count := int(r.uint16())
+	for i := 0; i < count && r.err == nil; i++ {
+		var entry keyEnvelopeRecipient
+		entry.id = string(r.bytes(int(r.byte())))
+		entry.wrapType = r.byte()
+		entry.wrappedKey = r.bytes(int(r.uint16()))
+		env.recipients = append(env.recipients, entry)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return env, nil
+}
+
+// envelopeReader reads key envelope fields, recording the first truncation error.
+type envelopeReader struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+func (r *envelopeReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n > len(r.data)-r.offset {
+		r.err = pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "truncated key envelope", nil, pkgerrors.ValidationErrorContext{
+			Field:    "KeyEnvelopeFile",
+			Value:    r.offset,
+			Expected: "well-formed key envelope",
+		})
+		return nil
+	}
+	out := r.data[r.offset : r.offset+n]
+	r.offset += n
+	return out
+}
+
+func (r *envelopeReader) byte() byte {
+	if b := r.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *envelopeReader) uint16() uint16 {
+	if b := r.bytes(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
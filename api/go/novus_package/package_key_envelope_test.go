@@ -0,0 +1,209 @@
+// This file contains tests for multi-recipient key envelopes: adding and revoking
+// recipients, unlocking the content key with any recipient key, and envelope
+// encoding.
+//
+// Specification: api_security.md: 6. Key Envelopes
+
+package novus_package
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// envelopeOptions returns AddFileOptions encrypting with the key envelope content key.
+func envelopeOptions() *AddFileOptions {
+	opts := &AddFileOptions{}
+	opts.UseKeyEnvelope.Set(true)
+	return opts
+}
+
+// reopenPackage opens another session on the package file behind pkg.
+func reopenPackage(t *testing.T, ctx context.Context, pkg Package) Package {
+	t.Helper()
+	reopened, err := OpenPackage(ctx, pkg.(*filePackage).FilePath)
+	if err != nil {
+		t.Fatalf("OpenPackage failed: %v", err)
+	}
+	t.Cleanup(func() { _ = reopened.Close() })
+	return reopened
+}
+
+func TestPackage_KeyEnvelope_MultipleRecipients(t *testing.T) {
+	ctx := context.Background()
+	secret := bytes.Repeat([]byte("season pass content "), 100)
+	storeA := testEncryptionKey("store-a", 0xA1)
+	storeB, err := GenerateMLKEMKey(3)
+	if err != nil {
+		t.Fatalf("GenerateMLKEMKey failed: %v", err)
+	}
+	storeBPublic := &MLKEMKey{PublicKey: storeB.GetPublicKey(), Level: storeB.GetLevel()}
+
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	if err := pkg.AddKeyRecipient(ctx, storeA); err != nil {
+		t.Fatalf("AddKeyRecipient(AES) failed: %v", err)
+	}
+	if err := pkg.AddKeyRecipient(ctx, storeBPublic.EncryptionKey("store-b")); err != nil {
+		t.Fatalf("AddKeyRecipient(ML-KEM) failed: %v", err)
+	}
+	fe, err := pkg.AddFileFromMemory(ctx, "/season/pass.bin", secret, envelopeOptions())
+	if err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	if fe.EncryptionType != fileformat.EncryptionAES256GCM {
+		t.Errorf("EncryptionType = %d, want AES-256-GCM", fe.EncryptionType)
+	}
+	written := writeAndReopen(t, ctx, pkg)
+
+	_, err = written.ReadFile(ctx, "/season/pass.bin")
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+
+	// Each recipient key unlocks the content key on its own
+	if err := written.AddEncryptionKey(testEncryptionKey("store-a", 0xA1)); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	if got, err := written.ReadFile(ctx, "/season/pass.bin"); err != nil || !bytes.Equal(got, secret) {
+		t.Errorf("ReadFile(store-a) mismatch, err = %v", err)
+	}
+
+	other := reopenPackage(t, ctx, written)
+	if err := other.AddEncryptionKey(storeB.EncryptionKey("store-b")); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	if got, err := other.ReadFile(ctx, "/season/pass.bin"); err != nil || !bytes.Equal(got, secret) {
+		t.Errorf("ReadFile(store-b) mismatch, err = %v", err)
+	}
+
+	// A public-only ML-KEM key cannot unlock the envelope
+	publicOnly := reopenPackage(t, ctx, written)
+	if err := publicOnly.AddEncryptionKey(storeBPublic.EncryptionKey("store-b")); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	_, err = publicOnly.ReadFile(ctx, "/season/pass.bin")
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+}
+
+func TestPackage_KeyEnvelope_RevokeAndAddRecipient(t *testing.T) {
+	ctx := context.Background()
+	secret := bytes.Repeat([]byte("backend asset "), 100)
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	for _, key := range []*EncryptionKey{testEncryptionKey("store-a", 0xA1), testEncryptionKey("store-b", 0xB2)} {
+		if err := pkg.AddKeyRecipient(ctx, key); err != nil {
+			t.Fatalf("AddKeyRecipient failed: %v", err)
+		}
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/asset.bin", secret, envelopeOptions()); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	written := writeAndReopen(t, ctx, pkg)
+	before, err := written.(*filePackage).findFileEntryByPath("/asset.bin")
+	if err != nil {
+		t.Fatalf("findFileEntryByPath failed: %v", err)
+	}
+
+	// Adding a recipient needs the content key; revoking does not
+	err = written.AddKeyRecipient(ctx, testEncryptionKey("store-c", 0xC3))
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+	if err := written.RevokeKeyRecipient(ctx, "store-a"); err != nil {
+		t.Fatalf("RevokeKeyRecipient failed: %v", err)
+	}
+	if err := written.AddEncryptionKey(testEncryptionKey("store-b", 0xB2)); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	if err := written.AddKeyRecipient(ctx, testEncryptionKey("store-c", 0xC3)); err != nil {
+		t.Fatalf("AddKeyRecipient failed: %v", err)
+	}
+	rewritten := writeAndReopen(t, ctx, written)
+
+	after, err := rewritten.(*filePackage).findFileEntryByPath("/asset.bin")
+	if err != nil {
+		t.Fatalf("findFileEntryByPath failed: %v", err)
+	}
+	if after.StoredChecksum != before.StoredChecksum {
+		t.Error("file data was re-encrypted")
+	}
+
+	if err := rewritten.AddEncryptionKey(testEncryptionKey("store-a", 0xA1)); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	_, err = rewritten.ReadFile(ctx, "/asset.bin")
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+
+	if err := rewritten.AddEncryptionKey(testEncryptionKey("store-c", 0xC3)); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	if got, err := rewritten.ReadFile(ctx, "/asset.bin"); err != nil || !bytes.Equal(got, secret) {
+		t.Errorf("ReadFile(store-c) mismatch, err = %v", err)
+	}
+}
+
+func TestPackage_KeyEnvelope_Errors(t *testing.T) {
+	ctx := context.Background()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+
+	_, err = pkg.AddFileFromMemory(ctx, "/a.bin", []byte("a"), envelopeOptions())
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+	assertPackageErrorType(t, pkg.RevokeKeyRecipient(ctx, "store-a"), pkgerrors.ErrTypeValidation)
+
+	chacha := NewEncryptionKey(EncryptionChaCha20Poly1305, "chacha", bytes.Repeat([]byte{0x01}, 32))
+	assertPackageErrorType(t, pkg.AddKeyRecipient(ctx, chacha), pkgerrors.ErrTypeUnsupported)
+	assertPackageErrorType(t, pkg.AddKeyRecipient(ctx, nil), pkgerrors.ErrTypeEncryption)
+
+	if err := pkg.AddKeyRecipient(ctx, testEncryptionKey("store-a", 0xA1)); err != nil {
+		t.Fatalf("AddKeyRecipient failed: %v", err)
+	}
+	assertPackageErrorType(t, pkg.AddKeyRecipient(ctx, testEncryptionKey("store-a", 0xA2)), pkgerrors.ErrTypeValidation)
+	assertPackageErrorType(t, pkg.RevokeKeyRecipient(ctx, "store-b"), pkgerrors.ErrTypeValidation)
+	assertPackageErrorType(t, pkg.RevokeKeyRecipient(ctx, "store-a"), pkgerrors.ErrTypeValidation)
+
+	both := envelopeOptions()
+	both.EncryptionKey.Set(testEncryptionKey("k", 0x01))
+	_, err = pkg.AddFileFromMemory(ctx, "/a.bin", []byte("a"), both)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assertPackageErrorType(t, pkg.AddKeyRecipient(cancelled, testEncryptionKey("store-b", 0xB2)), pkgerrors.ErrTypeContext)
+}
+
+func TestKeyEnvelope_EncodeDecode(t *testing.T) {
+	env := &keyEnvelope{
+		contentKeyID:   "nvpk-content-0011223344556677",
+		contentKeyType: fileformat.EncryptionAES256GCM,
+		recipients: []keyEnvelopeRecipient{
+			{id: "store-a", wrapType: keyWrapAES256, wrappedKey: bytes.Repeat([]byte{0x01}, 40)},
+			{id: "store-b", wrapType: keyWrapMLKEM, wrappedKey: bytes.Repeat([]byte{0x02}, 1150)},
+		},
+	}
+	data := encodeKeyEnvelope(env)
+	got, err := decodeKeyEnvelope(data)
+	if err != nil {
+		t.Fatalf("decodeKeyEnvelope failed: %v", err)
+	}
+	if got.contentKeyID != env.contentKeyID || got.contentKeyType != env.contentKeyType || len(got.recipients) != 2 {
+		t.Fatalf("decodeKeyEnvelope = %+v", got)
+	}
+	for i, r := range got.recipients {
+		if r.id != env.recipients[i].id || r.wrapType != env.recipients[i].wrapType || !bytes.Equal(r.wrappedKey, env.recipients[i].wrappedKey) {
+			t.Errorf("recipient %d = %+v, want %+v", i, r, env.recipients[i])
+		}
+	}
+
+	_, err = decodeKeyEnvelope(data[:len(data)-1])
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption)
+	_, err = decodeKeyEnvelope(append([]byte{2}, data[1:]...))
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeUnsupported)
+}
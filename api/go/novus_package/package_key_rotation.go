@@ -0,0 +1,346 @@
+// This file implements key rotation. RotateEncryptionKey re-encrypts every file
+// entry encrypted with one key under another, one entry at a time: stored data is
+// decrypted with the old key, encrypted with the new key and spooled to a temporary
+// file that the next Write copies into the package. Entries whose data has not been
+// written yet are only switched to the new key. RotateContentKey does the same for
+// the key envelope content key and rewraps the new content key for every recipient.
+// This file should contain only key rotation and rotation temp file handling.
+//
+// Specification: api_security.md: 9. Key Rotation
+
+package novus_package
+
+import (
+	"context"
+	"hash/crc32"
+	"io"
+	"os"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/internal"
+	"github.com/novus-engine/novuspack/api/go/metadata"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// rotatedEntry is the re-encrypted stored data of a file entry, spooled to a
+// temporary file until the rotation is committed.
+type rotatedEntry struct {
+	fe       *metadata.FileEntry
+	file     *os.File // Temporary file holding the stored data; nil for unwritten entries
+	size     int64    // Stored size
+	checksum uint32   // CRC32 of the stored data
+}
+
+// RotateEncryptionKey re-encrypts every file entry encrypted with oldKey under
+// newKey. Entries are streamed one at a time into temporary files, one encrypted
+// chunk at a time, so memory use does not depend on entry sizes; the package file
+// changes on the next Write.
+//
+// Each re-encrypted entry gets a new StoredChecksum and its FileVersion is
+// incremented; PackageDataVersion is incremented once if any entry changed. A
+// confidential index encrypted with oldKey moves to newKey. Both keys are
+// validated before any entry changes, and a failure leaves all entries on oldKey.
+// oldKey is unregistered afterwards and newKey stays registered.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - oldKey: Key the entries are encrypted with
+//   - newKey: Replacement key, with a different KeyID
+//
+// Returns:
+//   - int: Number of re-encrypted file entries
+//   - error: *PackageError with ErrTypeValidation if the keys share a KeyID,
+//     ErrTypeEncryption if oldKey does not decrypt an entry or the confidential
+//     index is locked
+//
+// Specification: api_security.md: 9.1 Package.RotateEncryptionKey Method
+func (p *filePackage) RotateEncryptionKey(ctx context.Context, oldKey, newKey *EncryptionKey) (int, error) {
+	if err := internal.CheckContext(ctx, "RotateEncryptionKey"); err != nil {
+		return 0, err
+	}
+	if err := p.checkNotSigned("RotateEncryptionKey"); err != nil {
+		return 0, err
+	}
+	if err := p.checkIndexUnlocked("RotateEncryptionKey"); err != nil {
+		return 0, err
+	}
+	if err := oldKey.validate("RotateEncryptionKey"); err != nil {
+		return 0, err
+	}
+	if err := newKey.validate("RotateEncryptionKey"); err != nil {
+		return 0, err
+	}
+	if oldKey.KeyID == newKey.KeyID {
+		return 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "new key must have a different KeyID", nil, pkgerrors.ValidationErrorContext{
+			Field:    "KeyID",
+			Value:    newKey.KeyID,
+			Expected: "KeyID other than " + oldKey.KeyID,
+		})
+	}
+	newType, err := onDiskEncryptionType(newKey.KeyType)
+	if err != nil {
+		return 0, err
+	}
+	if p.confidentialIndex != nil && p.confidentialIndex.keyID == oldKey.KeyID && newType == fileformat.EncryptionQuantumSafe {
+		return 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeUnsupported, "index key must be symmetric", nil, EncryptionErrorContext{
+			Operation:      "RotateEncryptionKey",
+			EncryptionType: newKey.KeyType,
+			KeyID:          newKey.KeyID,
+			ErrorStage:     "validation",
+		})
+	}
+	if err := p.AddEncryptionKey(oldKey); err != nil {
+		return 0, err
+	}
+	if err := p.AddEncryptionKey(newKey); err != nil {
+		return 0, err
+	}
+
+	// Re-encrypt into temporary files first; entries change only once all succeed
+	var rotated []rotatedEntry
+	for _, fe := range p.FileEntries {
+		if fe == nil || fe.EncryptionType == fileformat.EncryptionNone {
+			continue
+		}
+		if keyID, _ := fe.GetEncryptionKeyID(); keyID != oldKey.KeyID {
+			continue
+		}
+		entry, err := p.rotateFileEntryData(ctx, fe, newKey, newType)
+		if err != nil {
+			removeRotatedEntries(rotated)
+			return 0, err
+		}
+		rotated = append(rotated, entry)
+	}
+
+	for _, entry := range rotated {
+		commitRotatedEntry(entry, newKey.KeyID, newType)
+	}
+	if p.confidentialIndex != nil && p.confidentialIndex.keyID == oldKey.KeyID {
+		p.confidentialIndex.keyID = newKey.KeyID
+		p.confidentialIndex.encryptionType = newType
+		p.storeConfidentialIndex()
+	}
+	if len(rotated) > 0 {
+		if p.Info == nil {
+			p.Info = metadata.NewPackageInfo()
+		}
+		p.Info.PackageDataVersion++
+	}
+	p.mu.Lock()
+	delete(p.encryptionKeys, oldKey.KeyID)
+	p.mu.Unlock()
+	return len(rotated), nil
+}
+
+// RotateContentKey replaces the key envelope content key with a new random key.
+// Every file entry encrypted with the old content key is re-encrypted under the new
+// one like RotateEncryptionKey, and the new content key is wrapped for every current
+// envelope recipient, so recipients keep access while the old content key no longer
+// opens data written afterwards.
+//
+// The content key must be unlocked, and the key of every recipient must be
+// registered with AddEncryptionKey (ML-KEM recipients need only the encapsulation
+// key; the passphrase recipient is registered by SetPassphrase or
+// UnlockPassphrase). Revoke recipients whose keys are unavailable first. A failure
+// leaves the envelope and all entries unchanged.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//
+// Returns:
+//   - int: Number of re-encrypted file entries
+//   - error: *PackageError with ErrTypeValidation if the package has no key
+//     envelope, ErrTypeEncryption if the content key is locked or a recipient key
+//     is not registered
+//
+// Specification: api_security.md: 9.2 Package.RotateContentKey Method
+func (p *filePackage) RotateContentKey(ctx context.Context) (int, error) {
+	if err := internal.CheckContext(ctx, "RotateContentKey"); err != nil {
+		return 0, err
+	}
+	if err := p.checkNotSigned("RotateContentKey"); err != nil {
+		return 0, err
+	}
+	oldKey, err := p.keyEnvelopeContentKey(ctx)
+	if err != nil {
+		return 0, err
+	}
+	env, err := p.loadKeyEnvelope(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	// Wrap the new content key for every recipient before any entry changes
+	rotated, newKey, err := p.newKeyEnvelope()
+	if err != nil {
+		return 0, err
+	}
+	material, err := newKey.GetKey()
+	if err != nil {
+		return 0, err
+	}
+	defer clear(material)
+	for _, r := range env.recipients {
+		recipient, ok := p.registeredEncryptionKey(r.id)
+		if !ok {
+			return 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeEncryption, "key envelope recipient key not registered", nil, EncryptionErrorContext{
+				Operation:  "RotateContentKey",
+				KeyID:      r.id,
+				ErrorStage: "key_lookup",
+			})
+		}
+		entry, err := wrapContentKey(recipient, material)
+		if err != nil {
+			return 0, err
+		}
+		rotated.recipients = append(rotated.recipients, entry)
+	}
+
+	count, err := p.RotateEncryptionKey(ctx, oldKey, newKey)
+	if err != nil {
+		return 0, err
+	}
+	p.storeKeyEnvelope(rotated)
+	return count, nil
+}
+
+// rotateFileEntryData re-encrypts the stored data of fe under newKey into a
+// temporary file, streaming it one encrypted chunk at a time. Entries whose data is
+// not in stored form yet are encrypted on Write and need no temporary file. The
+// entry itself is not changed.
+func (p *filePackage) rotateFileEntryData(ctx context.Context, fe *metadata.FileEntry, newKey *EncryptionKey, newType uint8) (rotatedEntry, error) {
+	if fe.IsDataLoaded || (fe.SourceFile != nil && fe.ProcessingState == metadata.ProcessingStateRaw) {
+		return rotatedEntry{fe: fe}, nil
+	}
+	checksum, err := p.storedDataChecksum(ctx, fe)
+	if err != nil {
+		return rotatedEntry{}, err
+	}
+	if fe.StoredChecksum != 0 && checksum != fe.StoredChecksum {
+		return rotatedEntry{}, pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "stored data checksum mismatch", nil, pkgerrors.ValidationErrorContext{
+			Field: "StoredChecksum", Value: fe.StoredChecksum, Expected: "checksum of stored data",
+		})
+	}
+	oldKey, err := p.fileEntryEncryptionKey(ctx, fe, "ReadFile")
+	if err != nil {
+		return rotatedEntry{}, err
+	}
+	payload, err := newFileEntryDecryptReader(fe, oldKey, p.storedSection(fe), int64(fe.StoredSize))
+	if err != nil {
+		return rotatedEntry{}, err
+	}
+
+	// Encrypt through a copy so fe keeps its old key until the rotation commits
+	target := *fe
+	target.EncryptionType = newType
+	target.OptionalData = append([]metadata.OptionalDataEntry(nil), fe.OptionalData...)
+	target.SetEncryptionKeyID(newKey.KeyID)
+
+	file, err := os.CreateTemp("", "novuspack-rekey-*")
+	if err != nil {
+		return rotatedEntry{}, pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to create key rotation temp file")
+	}
+	rotated := rotatedEntry{fe: fe, file: file}
+	if err := p.writeRotatedData(ctx, &rotated, &target, payload); err != nil {
+		removeRotatedEntries([]rotatedEntry{rotated})
+		return rotatedEntry{}, err
+	}
+	return rotated, nil
+}
+
+// writeRotatedData encrypts payload for target into the temporary file of rotated
+// and records its stored size and checksum.
+func (p *filePackage) writeRotatedData(ctx context.Context, rotated *rotatedEntry, target *metadata.FileEntry, payload *internal.DecryptReader) error {
+	hasher := crc32.NewIEEE()
+	encrypter, err := p.newFileEntryEncryptWriter(ctx, target, io.MultiWriter(rotated.file, hasher))
+	if err != nil {
+		return err
+	}
+	if _, err := copyWithPooledBuffer(ctx, encrypter, io.NewSectionReader(payload, 0, payload.Size()), payload.Size()); err != nil {
+		return err
+	}
+	if err := encrypter.Close(); err != nil {
+		return err
+	}
+	info, err := rotated.file.Stat()
+	if err != nil {
+		return pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeIO, "failed to stat key rotation temp file", pkgerrors.ValidationErrorContext{
+			Field: "TempFilePath",
+			Value: rotated.file.Name(),
+		})
+	}
+	rotated.size, rotated.checksum = info.Size(), hasher.Sum32()
+	return nil
+}
+
+// commitRotatedEntry switches the entry to the new key and, for stored entries,
+// to the re-encrypted data in its temporary file.
+func commitRotatedEntry(entry rotatedEntry, keyID string, encryptionType uint8) {
+	fe := entry.fe
+	fe.EncryptionType = encryptionType
+	fe.SetEncryptionKeyID(keyID)
+	fe.FileVersion++
+	if entry.file == nil {
+		return
+	}
+	removeFileEntryTempFile(fe)
+	fe.SourceFile = entry.file
+	fe.SourceOffset = 0
+	fe.SourceSize = entry.size
+	fe.StoredSize = uint64(entry.size)
+	fe.StoredChecksum = entry.checksum
+	fe.TempFilePath = entry.file.Name()
+	fe.IsTempFile = true
+	fe.ProcessingState = storedProcessingState(fe)
+}
+
+// storedProcessingState returns the ProcessingState of fe once its SourceFile holds
+// the stored form of its data.
+func storedProcessingState(fe *metadata.FileEntry) metadata.ProcessingState {
+	compressed := fe.CompressionType != fileformat.CompressionNone
+	switch {
+	case fe.EncryptionType == fileformat.EncryptionNone && compressed:
+		return metadata.ProcessingStateCompressed
+	case fe.EncryptionType == fileformat.EncryptionNone:
+		return metadata.ProcessingStateRaw
+	case compressed:
+		return metadata.ProcessingStateCompressedAndEncrypted
+	default:
+		return metadata.ProcessingStateEncrypted
+	}
+}
+
+// removeRotatedEntries deletes the temporary files of an aborted rotation.
+func removeRotatedEntries(rotated []rotatedEntry) {
+	for _, entry := range rotated {
+		if entry.file != nil {
+			_ = entry.file.Close()
+			_ = os.Remove(entry.file.Name())
+		}
+	}
+}
+
+// removeFileEntryTempFile closes and deletes the temporary file holding the stored
+// data of fe, if any.
+func removeFileEntryTempFile(fe *metadata.FileEntry) {
+	if !fe.IsTempFile || fe.TempFilePath == "" {
+		return
+	}
+	if fe.SourceFile != nil && fe.SourceFile.Name() == fe.TempFilePath {
+		_ = fe.SourceFile.Close()
+		fe.SourceFile = nil
+	}
+	_ = os.Remove(fe.TempFilePath)
+	fe.TempFilePath = ""
+	fe.IsTempFile = false
+}
+
+// removeTempFiles deletes the temporary files of all file entries.
+func (p *filePackage) removeTempFiles() {
+	for _, fe := range p.FileEntries {
+		if fe != nil {
+			removeFileEntryTempFile(fe)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+// This file contains tests for key rotation: re-encrypting stored and unwritten
+// file entries under a new key, version and checksum updates, moving the
+// confidential index key, rotating the key envelope content key, and leaving
+// entries unchanged on failure.
+//
+// Specification: api_security.md: 9. Key Rotation
+
+package novus_package
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/metadata"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+func TestPackage_RotateEncryptionKey(t *testing.T) {
+	ctx := context.Background()
+	oldKey := testEncryptionKey("2025", 0x11)
+	newKey := NewEncryptionKey(EncryptionChaCha20Poly1305, "2026", bytes.Repeat([]byte{0x22}, 32))
+	textures := bytes.Repeat([]byte("texture atlas "), 512)
+	compressed := encryptedOptions(oldKey)
+	compressed.Compress.Set(true)
+	compressed.CompressionType.Set(fileformat.CompressionZstd)
+
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/secret.txt", []byte("launch codes"), encryptedOptions(oldKey)); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/textures.bin", textures, compressed); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/readme.txt", []byte("public"), nil); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	written := writeAndReopen(t, ctx, pkg)
+	fp := written.(*filePackage)
+
+	secret := findTestEntry(t, fp, "secret.txt")
+	oldChecksum, oldVersion := secret.StoredChecksum, secret.FileVersion
+	oldDataVersion := fp.Info.PackageDataVersion
+	readme := findTestEntry(t, fp, "readme.txt")
+	readmeVersion := readme.FileVersion
+
+	n, err := written.RotateEncryptionKey(ctx, oldKey, newKey)
+	if err != nil {
+		t.Fatalf("RotateEncryptionKey failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("RotateEncryptionKey = %d, want 2", n)
+	}
+	if keyID, _ := secret.GetEncryptionKeyID(); keyID != "2026" || secret.EncryptionType != fileformat.EncryptionChaCha20Poly1305 {
+		t.Errorf("entry key = %q type %d, want 2026 ChaCha20-Poly1305", keyID, secret.EncryptionType)
+	}
+	if secret.StoredChecksum == oldChecksum || secret.FileVersion != oldVersion+1 {
+		t.Errorf("StoredChecksum = %08x (was %08x), FileVersion = %d (was %d)", secret.StoredChecksum, oldChecksum, secret.FileVersion, oldVersion)
+	}
+	if fp.Info.PackageDataVersion != oldDataVersion+1 {
+		t.Errorf("PackageDataVersion = %d, want %d", fp.Info.PackageDataVersion, oldDataVersion+1)
+	}
+	if readme.FileVersion != readmeVersion {
+		t.Errorf("unencrypted entry FileVersion = %d, want %d", readme.FileVersion, readmeVersion)
+	}
+	if _, ok := fp.encryptionKeys["2025"]; ok {
+		t.Error("old key still registered after rotation")
+	}
+	if got, err := written.ReadFile(ctx, "/secret.txt"); err != nil || string(got) != "launch codes" {
+		t.Errorf("ReadFile after rotation = %q, err = %v", got, err)
+	}
+	tempPath := secret.TempFilePath
+	if _, err := os.Stat(tempPath); err != nil {
+		t.Fatalf("rotation temp file missing: %v", err)
+	}
+
+	rewritten := writeAndReopen(t, ctx, written)
+	if err := written.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := os.Stat(tempPath); !os.IsNotExist(err) {
+		t.Errorf("rotation temp file %s not removed on Close", tempPath)
+	}
+	if _, err := rewritten.ReadFile(ctx, "/secret.txt"); err == nil {
+		t.Error("ReadFile succeeded without the new key")
+	}
+	if err := rewritten.AddEncryptionKey(oldKey); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	if _, err := rewritten.ReadFile(ctx, "/secret.txt"); err == nil {
+		t.Error("ReadFile succeeded with the old key")
+	}
+	if err := rewritten.AddEncryptionKey(newKey); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	if got, err := rewritten.ReadFile(ctx, "/secret.txt"); err != nil || string(got) != "launch codes" {
+		t.Errorf("ReadFile(secret) = %q, err = %v", got, err)
+	}
+	if got, err := rewritten.ReadFile(ctx, "/textures.bin"); err != nil || !bytes.Equal(got, textures) {
+		t.Errorf("ReadFile(textures) mismatch, err = %v", err)
+	}
+	if entry := findTestEntry(t, rewritten.(*filePackage), "secret.txt"); entry.FileVersion != oldVersion+1 {
+		t.Errorf("FileVersion after rewrite = %d, want %d", entry.FileVersion, oldVersion+1)
+	}
+}
+
+func TestPackage_RotateEncryptionKey_Unwritten(t *testing.T) {
+	ctx := context.Background()
+	oldKey := testEncryptionKey("old", 0x11)
+	newKey := testEncryptionKey("new", 0x22)
+
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	fe, err := pkg.AddFileFromMemory(ctx, "/save.dat", []byte("progress"), encryptedOptions(oldKey))
+	if err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	if n, err := pkg.RotateEncryptionKey(ctx, oldKey, newKey); err != nil || n != 1 {
+		t.Fatalf("RotateEncryptionKey = %d, %v; want 1", n, err)
+	}
+	if fe.IsTempFile {
+		t.Error("unwritten entry was spooled to a temp file")
+	}
+
+	written := writeAndReopen(t, ctx, pkg)
+	if err := written.AddEncryptionKey(newKey); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	if got, err := written.ReadFile(ctx, "/save.dat"); err != nil || string(got) != "progress" {
+		t.Errorf("ReadFile = %q, err = %v", got, err)
+	}
+}
+
+func TestPackage_RotateEncryptionKey_ConfidentialIndex(t *testing.T) {
+	ctx := context.Background()
+	oldKey := testEncryptionKey("index", 0x1D)
+	newKey := testEncryptionKey("index-2026", 0x2E)
+
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	if err := pkg.EnableConfidentialIndex(ctx, oldKey); err != nil {
+		t.Fatalf("EnableConfidentialIndex failed: %v", err)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/levels/boss.map", []byte("boss"), encryptedOptions(oldKey)); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	written := writeAndReopen(t, ctx, pkg)
+
+	_, err = written.RotateEncryptionKey(ctx, oldKey, newKey)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+	if err := written.AddEncryptionKey(oldKey); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	if err := written.UnlockIndex(ctx); err != nil {
+		t.Fatalf("UnlockIndex failed: %v", err)
+	}
+	if _, err := written.RotateEncryptionKey(ctx, oldKey, newKey); err != nil {
+		t.Fatalf("RotateEncryptionKey failed: %v", err)
+	}
+
+	rewritten := writeAndReopen(t, ctx, written)
+	if err := rewritten.AddEncryptionKey(newKey); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	if err := rewritten.UnlockIndex(ctx); err != nil {
+		t.Fatalf("UnlockIndex with the new key failed: %v", err)
+	}
+	if got, err := rewritten.ReadFile(ctx, "/levels/boss.map"); err != nil || string(got) != "boss" {
+		t.Errorf("ReadFile = %q, err = %v", got, err)
+	}
+}
+
+func TestPackage_RotateEncryptionKey_Errors(t *testing.T) {
+	ctx := context.Background()
+	oldKey := testEncryptionKey("old", 0x11)
+
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/a.txt", []byte("alpha"), encryptedOptions(oldKey)); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	written := writeAndReopen(t, ctx, pkg)
+	entry := findTestEntry(t, written.(*filePackage), "a.txt")
+	checksum := entry.StoredChecksum
+
+	_, err = written.RotateEncryptionKey(ctx, oldKey, testEncryptionKey("old", 0x22))
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+	_, err = written.RotateEncryptionKey(ctx, nil, testEncryptionKey("new", 0x22))
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+	_, err = written.RotateEncryptionKey(ctx, oldKey, nil)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+
+	// A wrong old key with the right KeyID fails to decrypt and changes nothing
+	_, err = written.RotateEncryptionKey(ctx, testEncryptionKey("old", 0x33), testEncryptionKey("new", 0x22))
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+	if keyID, _ := entry.GetEncryptionKeyID(); keyID != "old" || entry.StoredChecksum != checksum || entry.IsTempFile {
+		t.Errorf("entry changed by failed rotation: key %q checksum %08x temp %v", keyID, entry.StoredChecksum, entry.IsTempFile)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = written.RotateEncryptionKey(cancelled, oldKey, testEncryptionKey("new", 0x22))
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeContext)
+}
+
+func TestPackage_RotateContentKey(t *testing.T) {
+	ctx := context.Background()
+	secret := bytes.Repeat([]byte("season pass content "), 100)
+	storeA := testEncryptionKey("store-a", 0xA1)
+	storeB, err := GenerateMLKEMKey(3)
+	if err != nil {
+		t.Fatalf("GenerateMLKEMKey failed: %v", err)
+	}
+	storeBPublic := &MLKEMKey{PublicKey: storeB.GetPublicKey(), Level: storeB.GetLevel()}
+
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	if err := pkg.AddKeyRecipient(ctx, storeA); err != nil {
+		t.Fatalf("AddKeyRecipient(AES) failed: %v", err)
+	}
+	if err := pkg.AddKeyRecipient(ctx, storeBPublic.EncryptionKey("store-b")); err != nil {
+		t.Fatalf("AddKeyRecipient(ML-KEM) failed: %v", err)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/season/pass.bin", secret, envelopeOptions()); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	written := writeAndReopen(t, ctx, pkg)
+	fp := written.(*filePackage)
+	oldEnv, err := fp.loadKeyEnvelope(ctx)
+	if err != nil {
+		t.Fatalf("loadKeyEnvelope failed: %v", err)
+	}
+	oldContentKeyID := oldEnv.contentKeyID
+
+	// Rotation needs the content key and every recipient key
+	_, err = written.RotateContentKey(ctx)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+	if err := written.AddEncryptionKey(testEncryptionKey("store-a", 0xA1)); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	_, err = written.RotateContentKey(ctx)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+	if keyID, _ := findTestEntry(t, fp, "season/pass.bin").GetEncryptionKeyID(); keyID != oldContentKeyID {
+		t.Errorf("entry key = %q after failed rotation, want %q", keyID, oldContentKeyID)
+	}
+
+	if err := written.AddEncryptionKey(storeBPublic.EncryptionKey("store-b")); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	n, err := written.RotateContentKey(ctx)
+	if err != nil {
+		t.Fatalf("RotateContentKey failed: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("RotateContentKey = %d, want 1", n)
+	}
+	rewritten := writeAndReopen(t, ctx, written)
+
+	newEnv, err := rewritten.(*filePackage).loadKeyEnvelope(ctx)
+	if err != nil {
+		t.Fatalf("loadKeyEnvelope failed: %v", err)
+	}
+	if newEnv.contentKeyID == oldContentKeyID || len(newEnv.recipients) != 2 {
+		t.Errorf("envelope content key %q with %d recipients, want a new key for 2", newEnv.contentKeyID, len(newEnv.recipients))
+	}
+	if keyID, _ := findTestEntry(t, rewritten.(*filePackage), "season/pass.bin").GetEncryptionKeyID(); keyID != newEnv.contentKeyID {
+		t.Errorf("entry key = %q, want %q", keyID, newEnv.contentKeyID)
+	}
+
+	// Both recipients still unlock the rotated content key
+	if err := rewritten.AddEncryptionKey(testEncryptionKey("store-a", 0xA1)); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	if got, err := rewritten.ReadFile(ctx, "/season/pass.bin"); err != nil || !bytes.Equal(got, secret) {
+		t.Errorf("ReadFile(store-a) mismatch, err = %v", err)
+	}
+	other := reopenPackage(t, ctx, rewritten)
+	if err := other.AddEncryptionKey(storeB.EncryptionKey("store-b")); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	if got, err := other.ReadFile(ctx, "/season/pass.bin"); err != nil || !bytes.Equal(got, secret) {
+		t.Errorf("ReadFile(store-b) mismatch, err = %v", err)
+	}
+}
+
+func TestPackage_RotateContentKey_Errors(t *testing.T) {
+	ctx := context.Background()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	_, err = pkg.RotateContentKey(ctx)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = pkg.RotateContentKey(cancelled)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeContext)
+}
+
+// findTestEntry returns the file entry whose primary path is path.
+func findTestEntry(t *testing.T, p *filePackage, path string) *metadata.FileEntry {
+	t.Helper()
+	for _, fe := range p.FileEntries {
+		if fe.GetPrimaryPath() == path {
+			return fe
+		}
+	}
+	t.Fatalf("file entry %q not found", path)
+	return nil
+}
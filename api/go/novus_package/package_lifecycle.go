@@ -13,9 +13,13 @@ package novus_package
 
 import (
 	"context"
+	"crypto"
+	"crypto/x509"
 	"encoding/binary"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"time"
@@ -209,6 +213,39 @@ func OpenPackage(ctx context.Context, path string) (Package, error) {
 		return nil, err
 	}
 
+	// Decode compressed packages into an uncompressed spool file and parse the spool
+	packageCompression := extractCompressionType(header)
+	var compressedSize int64
+	spoolPath := ""
+	var compressedFile *os.File
+	keepCompressed := false
+	opened := false
+	defer func() {
+		if !opened && spoolPath != "" {
+			_ = os.Remove(spoolPath)
+		}
+		// Signatures cover the compressed file, so a signed package keeps it open
+		if compressedFile != nil && (!opened || !keepCompressed) {
+			_ = compressedFile.Close()
+		}
+	}()
+	if packageCompression != fileformat.CompressionNone {
+		if compressedSize, err = fileSize(file); err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+		compressedFile = file
+		spool, err := openPackageSpool(ctx, file, header)
+		if err != nil {
+			return nil, err
+		}
+		file, spoolPath = spool, spool.Name()
+		if header, err = internal.ReadAndValidateHeader(ctx, file); err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+	}
+
 	// Load file index if it exists
 	index := fileformat.NewFileIndex()
 	if header.IndexStart > 0 && header.IndexSize > 0 {
@@ -240,6 +277,7 @@ func OpenPackage(ctx context.Context, path string) (Package, error) {
 		index:        index,
 		fileHandle:   file,
 		isOpen:       true,
+		spoolPath:    spoolPath,
 	}
 
 	// Initialize package info and sync from header (header is source on disk, PackageInfo is source in memory)
@@ -269,8 +307,12 @@ func OpenPackage(ctx context.Context, path string) (Package, error) {
 			default:
 			}
 
-			// Load FileEntry from offset
-			entry, err := internal.LoadFileEntry(file, indexEntry.Offset)
+			// Load FileEntry from offset; path sections of a confidential index stay encrypted
+			loadFileEntry := internal.LoadFileEntry
+			if header.Flags&fileformat.FlagConfidentialIndex != 0 {
+				loadFileEntry = internal.LoadFileEntryWithEncryptedPaths
+			}
+			entry, err := loadFileEntry(file, indexEntry.Offset)
 			if err != nil {
 				_ = file.Close()
 				return nil, pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeIO, fmt.Sprintf("failed to load file entry for FileID %d", indexEntry.FileID), pkgerrors.ValidationErrorContext{
@@ -355,35 +397,53 @@ func OpenPackage(ctx context.Context, path string) (Package, error) {
 		pkg.Info.Comment = extractCommentText(comment) // Strip null terminator
 	}
 
-	// Load signature metadata if it exists
+	// Load signature metadata if it exists; signatures are verified by Verify
 	if header.SignatureOffset > 0 {
-		// TODO: Implement full signature loading (incremental signing support)
-		// For now, just mark that signatures exist
-		pkg.Info.HasSignatures = true
-		pkg.Info.IsImmutable = true
-		// SignatureCount and Signatures will be populated when signature loading is fully implemented
-		pkg.Info.SignatureCount = 0
-		pkg.Info.Signatures = []signatures.SignatureInfo{}
-		pkg.Info.SecurityLevel = metadata.SecurityLevelNone // Will be calculated from signatures
+		if err := pkg.loadSignatures(file, header); err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+		pkg.signedPath = filepath.Clean(path)
+		pkg.signedFile = file
+		if compressedFile != nil {
+			pkg.signedFile, keepCompressed = compressedFile, true
+		}
 	}
+	// Signatures are unverified until Verify, so the initial level reflects encryption only
+	pkg.Info.SecurityLevel = pkg.securityStatus(pkg.Info.Signatures).SecurityLevel
 
-	// Load path metadata from special metadata files
-	if err := pkg.LoadPathMetadataFile(ctx); err != nil {
-		_ = file.Close()
-		return nil, pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeIO, "failed to load path metadata", struct{}{})
-	}
+	// A confidential index stays locked until UnlockIndex; path metadata is loaded then
+	if header.Flags&fileformat.FlagConfidentialIndex != 0 {
+		if err := pkg.loadConfidentialIndex(ctx); err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+	} else {
+		// Load path metadata from special metadata files
+		if err := pkg.LoadPathMetadataFile(ctx); err != nil {
+			_ = file.Close()
+			return nil, pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeIO, "failed to load path metadata", struct{}{})
+		}
 
-	// Build file-path associations
-	if err := pkg.UpdateFilePathAssociations(ctx); err != nil {
-		_ = file.Close()
-		return nil, pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeIO, "failed to build file-path associations", struct{}{})
+		// Build file-path associations
+		if err := pkg.UpdateFilePathAssociations(ctx); err != nil {
+			_ = file.Close()
+			return nil, pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeIO, "failed to build file-path associations", struct{}{})
+		}
 	}
 
-	// Update compression info from header
-	pkg.Info.PackageCompression = extractCompressionType(header)
-	pkg.Info.IsPackageCompressed = (pkg.Info.PackageCompression != 0)
-	// TODO: Calculate PackageOriginalSize and PackageCompressedSize when package compression is implemented
+	// Update compression info; the spool header has the compression flags cleared
+	pkg.setPackageCompression(packageCompression)
+	if packageCompression != fileformat.CompressionNone {
+		originalSize, err := fileSize(file)
+		if err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+		pkg.setPackageCompressionSizes(originalSize, compressedSize)
+	}
 
+	opened = true
 	return pkg, nil
 }
 
@@ -432,6 +492,47 @@ func OpenPackageReadOnly(ctx context.Context, path string) (Package, error) {
 	return &readOnlyPackage{inner: pkg}, nil
 }
 
+// OpenPackageReadOnlyWithOptions opens a package in read-only mode like
+// OpenPackageReadOnly and applies options.
+//
+// With options.MemoryMap, the package file is memory-mapped. ReadFile and
+// ReadFileRange then return uncompressed, unencrypted content as slices of the
+// mapping without copying, and FileStream reads stored data from the mapping. These
+// slices are read-only: writing to them crashes the program, and they must not be
+// used after Close, which releases the mapping. If the file cannot be mapped, the
+// package silently uses regular I/O. A nil options behaves like OpenPackageReadOnly.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - path: File path to the package to open
+//   - options: Read-only open options
+//
+// Returns:
+//   - Package: The opened Package instance in read-only mode
+//   - error: *PackageError on failure
+//
+// Example:
+//
+//	pkg, err := novuspack.OpenPackageReadOnlyWithOptions(ctx, "assets.nvpk", &novuspack.ReadOnlyOptions{MemoryMap: true})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer pkg.Close()
+//	texture, err := pkg.ReadFile(ctx, "/textures/stone.raw") // No copy for stored files
+//
+// Specification: api_basic_operations.md: 11.7 OpenPackageReadOnlyWithOptions Function
+func OpenPackageReadOnlyWithOptions(ctx context.Context, path string, options *ReadOnlyOptions) (Package, error) {
+	pkg, err := OpenPackage(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if options != nil && options.MemoryMap {
+		pkg.(*filePackage).mapPackageFile()
+	}
+
+	return &readOnlyPackage{inner: pkg}, nil
+}
+
 // OpenBrokenPackage opens a package that may be invalid or partially corrupted.
 //
 // This function is intended for repair workflows and forensic inspection.
@@ -561,6 +662,82 @@ func (p *readOnlyPackage) ReadFile(ctx context.Context, path string) ([]byte, er
 	return p.inner.ReadFile(ctx, path)
 }
 
+// Encryption keys are session state, so read-only packages accept them for decryption.
+func (p *readOnlyPackage) AddEncryptionKey(key *EncryptionKey) error {
+	return p.inner.AddEncryptionKey(key)
+}
+
+func (p *readOnlyPackage) RemoveEncryptionKey(keyID string) error {
+	return p.inner.RemoveEncryptionKey(keyID)
+}
+
+// Key rotation re-encrypts file data and is rejected.
+func (p *readOnlyPackage) RotateEncryptionKey(ctx context.Context, oldKey, newKey *EncryptionKey) (int, error) {
+	return 0, p.readOnlyError("RotateEncryptionKey")
+}
+
+func (p *readOnlyPackage) RotateContentKey(ctx context.Context) (int, error) {
+	return 0, p.readOnlyError("RotateContentKey")
+}
+
+// Key envelope changes modify the package and are rejected.
+func (p *readOnlyPackage) AddKeyRecipient(ctx context.Context, recipient *EncryptionKey) error {
+	return p.readOnlyError("AddKeyRecipient")
+}
+
+func (p *readOnlyPackage) RevokeKeyRecipient(ctx context.Context, recipientID string) error {
+	return p.readOnlyError("RevokeKeyRecipient")
+}
+
+// Setting a passphrase modifies the package and is rejected; unlocking only
+// registers keys for this session.
+func (p *readOnlyPackage) SetPassphrase(ctx context.Context, passphrase string, options *PassphraseOptions) error {
+	return p.readOnlyError("SetPassphrase")
+}
+
+func (p *readOnlyPackage) UnlockPassphrase(ctx context.Context, passphrase string) error {
+	return p.inner.UnlockPassphrase(ctx, passphrase)
+}
+
+// Signing appends to the package file and is rejected.
+func (p *readOnlyPackage) Sign(ctx context.Context, signer crypto.Signer, comment string) error {
+	return p.readOnlyError("Sign")
+}
+
+func (p *readOnlyPackage) SignWithCertificate(ctx context.Context, signer crypto.Signer, chain []*x509.Certificate, comment string) error {
+	return p.readOnlyError("SignWithCertificate")
+}
+
+func (p *readOnlyPackage) ClearAllSignatures(ctx context.Context) error {
+	return p.readOnlyError("ClearAllSignatures")
+}
+
+func (p *readOnlyPackage) Verify(ctx context.Context, trust TrustStore) ([]signatures.SignatureInfo, error) {
+	return p.inner.Verify(ctx, trust)
+}
+
+func (p *readOnlyPackage) GetSecurityStatus(ctx context.Context, trust TrustStore) (*metadata.SecurityStatus, error) {
+	return p.inner.GetSecurityStatus(ctx, trust)
+}
+
+// Enabling a confidential index modifies the package and is rejected; unlocking
+// only decrypts the index for this session.
+func (p *readOnlyPackage) EnableConfidentialIndex(ctx context.Context, key *EncryptionKey) error {
+	return p.readOnlyError("EnableConfidentialIndex")
+}
+
+func (p *readOnlyPackage) UnlockIndex(ctx context.Context) error {
+	return p.inner.UnlockIndex(ctx)
+}
+
+func (p *readOnlyPackage) ReadFileRange(ctx context.Context, path string, offset, length int64) ([]byte, error) {
+	return p.inner.ReadFileRange(ctx, path, offset, length)
+}
+
+func (p *readOnlyPackage) OpenFile(ctx context.Context, path string) (*FileStream, error) {
+	return p.inner.OpenFile(ctx, path)
+}
+
 func (p *readOnlyPackage) ListFiles() ([]FileInfo, error) {
 	return p.inner.ListFiles()
 }
@@ -577,6 +754,10 @@ func (p *readOnlyPackage) Validate(ctx context.Context) error {
 	return p.inner.Validate(ctx)
 }
 
+func (p *readOnlyPackage) ValidateWithOptions(ctx context.Context, options *ValidateOptions) error {
+	return p.inner.ValidateWithOptions(ctx, options)
+}
+
 func (p *readOnlyPackage) Close() error {
 	return p.inner.Close()
 }
@@ -675,6 +856,10 @@ func (p *readOnlyPackage) AddFileFromMemory(ctx context.Context, path string, da
 	return nil, p.readOnlyError("AddFileFromMemory")
 }
 
+func (p *readOnlyPackage) AddFileFromReader(ctx context.Context, path string, r io.Reader, size int64, options *AddFileOptions) (*metadata.FileEntry, error) {
+	return nil, p.readOnlyError("AddFileFromReader")
+}
+
 func (p *readOnlyPackage) AddFilePattern(ctx context.Context, pattern string, options *AddFileOptions) ([]*metadata.FileEntry, error) {
 	return nil, p.readOnlyError("AddFilePattern")
 }
@@ -695,6 +880,36 @@ func (p *readOnlyPackage) RemoveDirectory(ctx context.Context, dirPath string, o
 	return nil, p.readOnlyError("RemoveDirectory")
 }
 
+// Compression dictionary and solid group operations are rejected.
+func (p *readOnlyPackage) TrainCompressionDictionary(ctx context.Context, samplePaths []string, maxSize int) (uint32, error) {
+	return 0, p.readOnlyError("TrainCompressionDictionary")
+}
+
+func (p *readOnlyPackage) SetFileCompressionDictionary(ctx context.Context, path string, dictID uint32) error {
+	return p.readOnlyError("SetFileCompressionDictionary")
+}
+
+func (p *readOnlyPackage) CreateSolidGroup(ctx context.Context, paths []string) (uint32, error) {
+	return 0, p.readOnlyError("CreateSolidGroup")
+}
+
+// Package compression operations are rejected.
+func (p *readOnlyPackage) CompressPackage(ctx context.Context, compressionType uint8) error {
+	return p.readOnlyError("CompressPackage")
+}
+
+func (p *readOnlyPackage) DecompressPackage(ctx context.Context) error {
+	return p.readOnlyError("DecompressPackage")
+}
+
+func (p *readOnlyPackage) CompressPackageFile(ctx context.Context, path string, compressionType uint8, overwrite bool) error {
+	return p.readOnlyError("CompressPackageFile")
+}
+
+func (p *readOnlyPackage) DecompressPackageFile(ctx context.Context, path string, overwrite bool) error {
+	return p.readOnlyError("DecompressPackageFile")
+}
+
 // Target path management is rejected.
 func (p *readOnlyPackage) SetTargetPath(ctx context.Context, path string) error {
 	return p.readOnlyError("SetTargetPath")
@@ -938,15 +1153,25 @@ func validatePackageHeader(header *fileformat.PackageHeader) error {
 //
 // Specification: api_basic_operations.md: 13. Package.Close Method
 func (p *filePackage) Close() error {
+	// Remove temporary files left by key rotation and streamed file additions;
+	// packages built in memory are never opened but may hold them too
+	p.removeTempFiles()
+
 	// If already closed, this is a no-op (idempotent)
 	if !p.isOpen && p.fileHandle == nil {
 		return nil
 	}
 
+	// Release the memory mapping before the file it maps
+	unmapErr := p.unmapPackageFile()
+
+	p.setSignedFile(nil)
+
 	// Close file handle if it exists
 	if p.fileHandle != nil {
 		err := p.fileHandle.Close()
 		p.fileHandle = nil
+		p.removeSpool()
 		if err != nil {
 			// Mark as closed even on error to prevent resource leaks
 			p.isOpen = false
@@ -957,6 +1182,9 @@ func (p *filePackage) Close() error {
 	// Mark as closed
 	p.isOpen = false
 
+	if unmapErr != nil {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeIO, "failed to release package file mapping", unmapErr, struct{}{})
+	}
 	return nil
 }
 
@@ -1005,6 +1233,11 @@ func (p *filePackage) CreateWithOptions(ctx context.Context, path string, option
 		if options.AppID != 0 {
 			p.Info.AppID = options.AppID
 		}
+		if options.Passphrase != "" {
+			if err := p.SetPassphrase(ctx, options.Passphrase, nil); err != nil {
+				return err
+			}
+		}
 		// Permissions are stored for later use during Write operations
 		// TODO: Store permissions in package state
 	}
@@ -1039,6 +1272,13 @@ func (p *filePackage) CloseWithCleanup(ctx context.Context) error {
 	// Clear file entries
 	p.FileEntries = nil
 	p.SpecialFiles = nil
+	p.mu.Lock()
+	p.compressionDictionaries = nil
+	p.solidGroupBlocks = solidGroupCache{}
+	p.keyEnvelope = nil
+	p.encryptionKeys = nil
+	p.mu.Unlock()
+	p.confidentialIndex = nil
 
 	// Reset state
 	p.header = nil
@@ -6,6 +6,7 @@ package novus_package
 
 import (
 	"context"
+	"crypto/ed25519"
 	"path/filepath"
 	"testing"
 
@@ -228,6 +229,108 @@ func TestReadOnlyPackage_WriteOperationsAllFail(t *testing.T) {
 				return pkg.ClearPackageIdentity()
 			},
 		},
+		{
+			name: "TrainCompressionDictionary",
+			op: func() error {
+				_, err := pkg.TrainCompressionDictionary(ctx, []string{"/test.txt"}, 0)
+				return err
+			},
+		},
+		{
+			name: "SetFileCompressionDictionary",
+			op: func() error {
+				return pkg.SetFileCompressionDictionary(ctx, "/test.txt", 32768)
+			},
+		},
+		{
+			name: "AddKeyRecipient",
+			op: func() error {
+				return pkg.AddKeyRecipient(ctx, testEncryptionKey("store", 0x01))
+			},
+		},
+		{
+			name: "RevokeKeyRecipient",
+			op: func() error {
+				return pkg.RevokeKeyRecipient(ctx, "store")
+			},
+		},
+		{
+			name: "SetPassphrase",
+			op: func() error {
+				return pkg.SetPassphrase(ctx, "passphrase", nil)
+			},
+		},
+		{
+			name: "EnableConfidentialIndex",
+			op: func() error {
+				return pkg.EnableConfidentialIndex(ctx, testEncryptionKey("index", 0x01))
+			},
+		},
+		{
+			name: "Sign",
+			op: func() error {
+				_, signer, _ := ed25519.GenerateKey(nil)
+				return pkg.Sign(ctx, signer, "")
+			},
+		},
+		{
+			name: "SignWithCertificate",
+			op: func() error {
+				_, signer, _ := ed25519.GenerateKey(nil)
+				return pkg.SignWithCertificate(ctx, signer, nil, "")
+			},
+		},
+		{
+			name: "ClearAllSignatures",
+			op: func() error {
+				return pkg.ClearAllSignatures(ctx)
+			},
+		},
+		{
+			name: "RotateEncryptionKey",
+			op: func() error {
+				_, err := pkg.RotateEncryptionKey(ctx, testEncryptionKey("old", 0x01), testEncryptionKey("new", 0x02))
+				return err
+			},
+		},
+		{
+			name: "RotateContentKey",
+			op: func() error {
+				_, err := pkg.RotateContentKey(ctx)
+				return err
+			},
+		},
+		{
+			name: "CreateSolidGroup",
+			op: func() error {
+				_, err := pkg.CreateSolidGroup(ctx, []string{"/test.txt"})
+				return err
+			},
+		},
+		{
+			name: "CompressPackage",
+			op: func() error {
+				return pkg.CompressPackage(ctx, 1)
+			},
+		},
+		{
+			name: "DecompressPackage",
+			op: func() error {
+				return pkg.DecompressPackage(ctx)
+			},
+		},
+		{
+			name: "CompressPackageFile",
+			op: func() error {
+				return pkg.CompressPackageFile(ctx, filepath.Join(tmpDir, "compressed.nvpk"), 1, false)
+			},
+		},
+		{
+			name: "DecompressPackageFile",
+			op: func() error {
+				return pkg.DecompressPackageFile(ctx, filepath.Join(tmpDir, "plain.nvpk"), false)
+			},
+		},
 	}
 
 	for _, tt := range tests {
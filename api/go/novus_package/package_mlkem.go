@@ -0,0 +1,169 @@
+// This file implements MLKEMKey: ML-KEM key pair generation, hybrid encryption of
+// data to a key, and conversion to an EncryptionKey for quantum-safe file
+// encryption. Supported parameter sets are ML-KEM-768 (level 3) and ML-KEM-1024
+// (level 5) from crypto/mlkem. This file should contain only ML-KEM key handling;
+// the hybrid cipher belongs in internal.
+//
+// Specification: api_security.md: 5. ML-KEM Key Structure and Operations
+
+package novus_package
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/internal"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// GenerateMLKEMKey creates a new ML-KEM key pair with the given security level
+// (3 for ML-KEM-768, 5 for ML-KEM-1024).
+// Returns ErrTypeUnsupported for other levels.
+//
+// Specification: api_security.md: 5.1 MLKEMKey Struct
+func GenerateMLKEMKey(level int) (*MLKEMKey, error) {
+	if mlkemEncryptionType(level) == EncryptionNone {
+		return nil, mlkemLevelError("GenerateMLKEMKey", level)
+	}
+	seed := make([]byte, internal.MLKEMSeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeEncryption, "failed to generate key material")
+	}
+	publicKey, err := internal.MLKEMEncapsulationKey(level, seed)
+	if err != nil {
+		return nil, err
+	}
+	return &MLKEMKey{PublicKey: publicKey, PrivateKey: seed, Level: level}, nil
+}
+
+// Encrypt encrypts plaintext to the key's public key. The result holds the KEM
+// ciphertext followed by the AES-256-GCM nonce, ciphertext and tag.
+//
+// Specification: api_security.md: 5.2.1 MLKEMKey.Encrypt Method
+func (k *MLKEMKey) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	if err := internal.CheckContext(ctx, "MLKEMKey.Encrypt"); err != nil {
+		return nil, err
+	}
+	if err := k.validate("Encrypt"); err != nil {
+		return nil, err
+	}
+	return internal.EncryptData(plaintext, fileformat.EncryptionQuantumSafe, k.PublicKey)
+}
+
+// Decrypt decrypts ciphertext produced by Encrypt with the key's private key.
+// Returns ErrTypeEncryption if the key has no private key or authentication fails.
+//
+// Specification: api_security.md: 5.2.2 MLKEMKey.Decrypt Method
+func (k *MLKEMKey) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	if err := internal.CheckContext(ctx, "MLKEMKey.Decrypt"); err != nil {
+		return nil, err
+	}
+	if err := k.validate("Decrypt"); err != nil {
+		return nil, err
+	}
+	if len(k.PrivateKey) == 0 {
+		return nil, k.error("Decrypt", "private key not available", "decryption")
+	}
+	return internal.DecryptData(ciphertext, fileformat.EncryptionQuantumSafe, k.PrivateKey)
+}
+
+// GetPublicKey returns a copy of the public (encapsulation) key.
+//
+// Specification: api_security.md: 5.3.1 MLKEMKey.GetPublicKey Method
+func (k *MLKEMKey) GetPublicKey() []byte {
+	return bytes.Clone(k.PublicKey)
+}
+
+// GetLevel returns the security level of the key.
+//
+// Specification: api_security.md: 5.3.2 MLKEMKey.GetLevel Method
+func (k *MLKEMKey) GetLevel() int {
+	return k.Level
+}
+
+// Clear overwrites and removes the private key. The public key is kept.
+//
+// Specification: api_security.md: 5.3.3 MLKEMKey.Clear Method
+func (k *MLKEMKey) Clear() {
+	clear(k.PrivateKey)
+	k.PrivateKey = nil
+}
+
+// EncryptionKey returns an EncryptionKey with keyID for adding files with
+// AddFileOptions.EncryptionKey or reading them after AddEncryptionKey. The key
+// holds the private key if set, so it can decrypt, and the public key otherwise.
+//
+// Specification: api_security.md: 5.3.10 MLKEMKey.EncryptionKey Method
+func (k *MLKEMKey) EncryptionKey(keyID string) *EncryptionKey {
+	material := k.PublicKey
+	if len(k.PrivateKey) != 0 {
+		material = k.PrivateKey
+	}
+	return NewEncryptionKey(mlkemEncryptionType(k.Level), keyID, material)
+}
+
+// validate checks that the level is supported and that the public key, and the
+// private key if set, belong to it.
+func (k *MLKEMKey) validate(operation string) error {
+	if k == nil {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeEncryption, "key not set", nil, EncryptionErrorContext{Operation: operation, ErrorStage: "key_validation"})
+	}
+	if mlkemEncryptionType(k.Level) == EncryptionNone {
+		return mlkemLevelError(operation, k.Level)
+	}
+	if len(k.PublicKey) != internal.MLKEMEncapsulationKeySize(k.Level) {
+		return k.error(operation, "key invalid", "key_validation")
+	}
+	if len(k.PrivateKey) != 0 {
+		publicKey, err := internal.MLKEMEncapsulationKey(k.Level, k.PrivateKey)
+		if err != nil || !bytes.Equal(publicKey, k.PublicKey) {
+			return k.error(operation, "public key does not match private key", "key_validation")
+		}
+	}
+	return nil
+}
+
+func (k *MLKEMKey) error(operation, message, stage string) error {
+	return pkgerrors.NewPackageError(pkgerrors.ErrTypeEncryption, message, nil, EncryptionErrorContext{
+		Operation:      operation,
+		EncryptionType: mlkemEncryptionType(k.Level),
+		KeySize:        len(k.PublicKey) * 8,
+		ErrorStage:     stage,
+	})
+}
+
+// mlkemEncryptionType returns the encryption type of an ML-KEM security level, or
+// EncryptionNone if the level is not supported.
+func mlkemEncryptionType(level int) EncryptionType {
+	switch level {
+	case internal.MLKEM768Level:
+		return EncryptionMLKEM768
+	case internal.MLKEM1024Level:
+		return EncryptionMLKEM1024
+	default:
+		return EncryptionNone
+	}
+}
+
+// mlkemLevel returns the security level of an ML-KEM encryption type, or 0 if the
+// type is not a supported ML-KEM parameter set.
+func mlkemLevel(encType EncryptionType) int {
+	switch encType {
+	case EncryptionMLKEM768:
+		return internal.MLKEM768Level
+	case EncryptionMLKEM1024:
+		return internal.MLKEM1024Level
+	default:
+		return 0
+	}
+}
+
+func mlkemLevelError(operation string, level int) error {
+	return pkgerrors.NewPackageError(pkgerrors.ErrTypeUnsupported, "unsupported ML-KEM security level", nil, pkgerrors.ValidationErrorContext{
+		Field:    "Level",
+		Value:    level,
+		Expected: "3 (ML-KEM-768) or 5 (ML-KEM-1024) for " + operation,
+	})
+}
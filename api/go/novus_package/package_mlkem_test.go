@@ -0,0 +1,150 @@
+// This file contains tests for MLKEMKey generation, hybrid Encrypt/Decrypt, and
+// quantum-safe file encryption with ML-KEM keys registered in the package key ring.
+//
+// Specification: api_security.md: 5. ML-KEM Key Structure and Operations
+
+package novus_package
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+func TestMLKEMKey_EncryptDecrypt(t *testing.T) {
+	ctx := context.Background()
+	for _, level := range []int{3, 5} {
+		key, err := GenerateMLKEMKey(level)
+		if err != nil {
+			t.Fatalf("GenerateMLKEMKey(%d) failed: %v", level, err)
+		}
+		if key.GetLevel() != level || len(key.GetPublicKey()) == 0 {
+			t.Errorf("GenerateMLKEMKey(%d) = level %d, %d byte public key", level, key.GetLevel(), len(key.GetPublicKey()))
+		}
+
+		sealed, err := key.Encrypt(ctx, []byte("quantum-safe payload"))
+		if err != nil {
+			t.Fatalf("Encrypt failed: %v", err)
+		}
+		got, err := key.Decrypt(ctx, sealed)
+		if err != nil {
+			t.Fatalf("Decrypt failed: %v", err)
+		}
+		if string(got) != "quantum-safe payload" {
+			t.Errorf("Decrypt = %q", got)
+		}
+
+		// Anyone holding the public key can encrypt, but not decrypt
+		public := &MLKEMKey{PublicKey: key.GetPublicKey(), Level: level}
+		sealed, err = public.Encrypt(ctx, []byte("to the key holder"))
+		if err != nil {
+			t.Fatalf("Encrypt(public only) failed: %v", err)
+		}
+		_, err = public.Decrypt(ctx, sealed)
+		assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+		if got, err := key.Decrypt(ctx, sealed); err != nil || string(got) != "to the key holder" {
+			t.Errorf("Decrypt = %q, %v", got, err)
+		}
+
+		key.Clear()
+		if key.PrivateKey != nil {
+			t.Error("Clear() kept the private key")
+		}
+	}
+}
+
+func TestMLKEMKey_Errors(t *testing.T) {
+	ctx := context.Background()
+	_, err := GenerateMLKEMKey(1)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeUnsupported)
+
+	first, err := GenerateMLKEMKey(3)
+	if err != nil {
+		t.Fatalf("GenerateMLKEMKey failed: %v", err)
+	}
+	second, err := GenerateMLKEMKey(3)
+	if err != nil {
+		t.Fatalf("GenerateMLKEMKey failed: %v", err)
+	}
+	mismatched := &MLKEMKey{PublicKey: first.PublicKey, PrivateKey: second.PrivateKey, Level: 3}
+	_, err = mismatched.Encrypt(ctx, []byte("x"))
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+
+	wrongLevel := &MLKEMKey{PublicKey: first.PublicKey, Level: 5}
+	_, err = wrongLevel.Encrypt(ctx, []byte("x"))
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+
+	sealed, err := first.Encrypt(ctx, []byte("x"))
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	_, err = second.Decrypt(ctx, sealed)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = first.Encrypt(cancelled, []byte("x"))
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeContext)
+}
+
+func TestPackage_Encryption_MLKEM(t *testing.T) {
+	ctx := context.Background()
+	secret := bytes.Repeat([]byte("post-quantum archive "), 100)
+	pair, err := GenerateMLKEMKey(3)
+	if err != nil {
+		t.Fatalf("GenerateMLKEMKey failed: %v", err)
+	}
+
+	// The writer only holds the public key
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	public := &MLKEMKey{PublicKey: pair.GetPublicKey(), Level: pair.GetLevel()}
+	if _, err := pkg.AddFileFromMemory(ctx, "/archive.bin", secret, encryptedOptions(public.EncryptionKey("archive"))); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	reopened := writeAndReopen(t, ctx, pkg)
+
+	raw, err := os.ReadFile(reopened.(*filePackage).FilePath)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if bytes.Contains(raw, []byte("post-quantum archive")) {
+		t.Error("package file contains plaintext of an encrypted file")
+	}
+	fe, err := reopened.(*filePackage).findFileEntryByPath("/archive.bin")
+	if err != nil {
+		t.Fatalf("findFileEntryByPath failed: %v", err)
+	}
+	if fe.EncryptionType != fileformat.EncryptionQuantumSafe {
+		t.Errorf("EncryptionType = %d, want quantum-safe", fe.EncryptionType)
+	}
+
+	if err := reopened.AddEncryptionKey(public.EncryptionKey("archive")); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	_, err = reopened.ReadFile(ctx, "/archive.bin")
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+
+	if err := reopened.AddEncryptionKey(pair.EncryptionKey("archive")); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	got, err := reopened.ReadFile(ctx, "/archive.bin")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if !bytes.Equal(got, secret) {
+		t.Error("ReadFile content mismatch")
+	}
+
+	generated, err := GenerateEncryptionKey(EncryptionMLKEM1024, "generated")
+	if err != nil || !generated.IsValid() {
+		t.Errorf("GenerateEncryptionKey(ML-KEM-1024) = %v, %v; want valid key", generated, err)
+	}
+	assertPackageErrorType(t, NewEncryptionKey(EncryptionMLKEM768, "short", make([]byte, 32)).SetKey(make([]byte, 32)), pkgerrors.ErrTypeEncryption)
+}
@@ -0,0 +1,86 @@
+// This file implements the memory-mapped read mode of read-only packages. The
+// package file is mapped once at open; uncompressed, unencrypted files are then
+// served as slices of the mapping and file streams read stored data from it
+// without a system call per read. Systems or files that cannot be mapped use
+// regular I/O. This file should contain only mapping management and lookups; the
+// platform calls are in package_mmap_unix.go and package_mmap_other.go.
+//
+// Specification: api_basic_operations.md: 11.7 OpenPackageReadOnlyWithOptions Function
+
+package novus_package
+
+import (
+	"bytes"
+	"io"
+	"math"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/metadata"
+)
+
+// mapPackageFile memory-maps the open package file. The package keeps using
+// regular I/O if the file is empty, too large to map or the system cannot map it.
+func (p *filePackage) mapPackageFile() {
+	if p.fileHandle == nil || p.mapped != nil {
+		return
+	}
+	info, err := p.fileHandle.Stat()
+	if err != nil || info.Size() == 0 || info.Size() > math.MaxInt {
+		return
+	}
+	data, err := mapFile(p.fileHandle, int(info.Size()))
+	if err != nil {
+		return
+	}
+	p.mapped = data
+}
+
+// unmapPackageFile releases the mapping created by mapPackageFile, if any. Slices
+// returned from the mapping must not be used afterwards.
+func (p *filePackage) unmapPackageFile() error {
+	if p.mapped == nil {
+		return nil
+	}
+	data := p.mapped
+	p.mapped = nil
+	return unmapFile(data)
+}
+
+// mappedStoredData returns the stored bytes of fe as a slice of the mapping, or
+// false if the package is not mapped or fe is not stored in the mapped file.
+func (p *filePackage) mappedStoredData(fe *metadata.FileEntry) ([]byte, bool) {
+	if p.mapped == nil || fe.SourceFile == nil || fe.SourceFile != p.fileHandle || fe.SourceOffset < 0 {
+		return nil, false
+	}
+	start := uint64(fe.SourceOffset)
+	if start > uint64(len(p.mapped)) || fe.StoredSize > uint64(len(p.mapped))-start {
+		return nil, false
+	}
+	end := start + fe.StoredSize
+	return p.mapped[start:end:end], true
+}
+
+// mappedFileContent returns the content of an uncompressed, unencrypted file as a
+// slice of the mapping without copying, or false if it cannot be served that way.
+func (p *filePackage) mappedFileContent(fe *metadata.FileEntry) ([]byte, bool) {
+	if _, _, solid := fe.GetSolidGroup(); solid || fe.IsDataLoaded {
+		return nil, false
+	}
+	if fe.CompressionType != fileformat.CompressionNone || fe.EncryptionType != fileformat.EncryptionNone {
+		return nil, false
+	}
+	data, ok := p.mappedStoredData(fe)
+	if !ok || uint64(len(data)) != fe.OriginalSize {
+		return nil, false
+	}
+	return data, true
+}
+
+// storedSection returns a reader over the stored bytes of fe, reading from the
+// mapping when possible and from fe.SourceFile otherwise.
+func (p *filePackage) storedSection(fe *metadata.FileEntry) *io.SectionReader {
+	if data, ok := p.mappedStoredData(fe); ok {
+		return io.NewSectionReader(bytes.NewReader(data), 0, int64(len(data)))
+	}
+	return io.NewSectionReader(fe.SourceFile, fe.SourceOffset, int64(fe.StoredSize))
+}
@@ -0,0 +1,24 @@
+//go:build !unix
+
+// This file provides the memory mapping fallback for systems without mmap support;
+// packages opened with ReadOnlyOptions.MemoryMap use regular I/O there. This file
+// should contain only the platform mapping calls used by package_mmap.go.
+//
+// Specification: api_basic_operations.md: 11.7 OpenPackageReadOnlyWithOptions Function
+
+package novus_package
+
+import (
+	"errors"
+	"os"
+)
+
+// mapFile reports that memory mapping is not supported on this system.
+func mapFile(file *os.File, size int) ([]byte, error) {
+	return nil, errors.ErrUnsupported
+}
+
+// unmapFile is never called without a mapping on this system.
+func unmapFile(data []byte) error {
+	return nil
+}
@@ -0,0 +1,181 @@
+// This file contains tests for the memory-mapped read mode of read-only packages:
+// zero-copy reads of stored files, reads of other stored forms from the mapping,
+// the regular I/O fallback and releasing the mapping on Close.
+//
+// Specification: api_basic_operations.md: 11.7 OpenPackageReadOnlyWithOptions Function
+
+package novus_package
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"runtime"
+	"testing"
+	"unsafe"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/metadata"
+)
+
+// writeMemoryMapTestPackage writes a package with stored, compressed, framed and
+// encrypted files of size bytes and returns its path and the content of each file.
+func writeMemoryMapTestPackage(t *testing.T, ctx context.Context, key *EncryptionKey, size int) (string, map[string][]byte) {
+	t.Helper()
+	compressed := &AddFileOptions{}
+	compressed.CompressionType.Set(fileformat.CompressionZstd)
+
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	files := map[string]*AddFileOptions{
+		"/assets/stored.raw":     nil,
+		"/assets/compressed.raw": compressed,
+		"/assets/framed.raw":     framedRangeOptions(fileformat.CompressionLZ4),
+		"/assets/encrypted.raw":  encryptedOptions(key),
+	}
+	contents := make(map[string][]byte)
+	for path, opts := range files {
+		// Distinct content keeps deduplication from sharing entries between forms
+		content := append([]byte(path), rangeTestPayload(size)...)[:size]
+		if _, err := pkg.AddFileFromMemory(ctx, path, content, opts); err != nil {
+			t.Fatalf("AddFileFromMemory(%q) failed: %v", path, err)
+		}
+		contents[path] = content
+	}
+	return writeAndReopen(t, ctx, pkg).(*filePackage).FilePath, contents
+}
+
+// mappedTestFile returns an open temporary file closed at the end of the test.
+func mappedTestFile(t *testing.T) *os.File {
+	t.Helper()
+	file, err := os.CreateTemp(t.TempDir(), "mapped")
+	if err != nil {
+		t.Fatalf("CreateTemp failed: %v", err)
+	}
+	t.Cleanup(func() { _ = file.Close() })
+	return file
+}
+
+// withinMapping reports whether data points into the package's mapping.
+func withinMapping(p *filePackage, data []byte) bool {
+	if len(data) == 0 || len(p.mapped) == 0 {
+		return false
+	}
+	start := uintptr(unsafe.Pointer(&p.mapped[0]))
+	addr := uintptr(unsafe.Pointer(&data[0]))
+	return addr >= start && addr < start+uintptr(len(p.mapped))
+}
+
+func TestOpenPackageReadOnlyWithOptions_MemoryMap(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" || runtime.GOOS == "js" || runtime.GOOS == "wasip1" {
+		t.Skip("memory mapping is not supported on " + runtime.GOOS)
+	}
+	ctx := context.Background()
+	key := testEncryptionKey("mmap", 0x4D)
+	path, contents := writeMemoryMapTestPackage(t, ctx, key, 5*rangeTestFrameSize+17)
+	content := contents["/assets/stored.raw"]
+
+	pkg, err := OpenPackageReadOnlyWithOptions(ctx, path, &ReadOnlyOptions{MemoryMap: true})
+	if err != nil {
+		t.Fatalf("OpenPackageReadOnlyWithOptions failed: %v", err)
+	}
+	inner := pkg.(*readOnlyPackage).inner.(*filePackage)
+	if inner.mapped == nil {
+		t.Fatal("package file was not memory-mapped")
+	}
+	if err := pkg.AddEncryptionKey(key); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+
+	got, err := pkg.ReadFile(ctx, "/assets/stored.raw")
+	if err != nil || !bytes.Equal(got, content) {
+		t.Fatalf("ReadFile(stored) = %d bytes, %v; want %d matching bytes", len(got), err, len(content))
+	}
+	if !withinMapping(inner, got) || cap(got) != len(got) {
+		t.Error("ReadFile(stored) did not return a capped slice of the mapping")
+	}
+	got, err = pkg.ReadFileRange(ctx, "/assets/stored.raw", 100, 50)
+	if err != nil || !bytes.Equal(got, content[100:150]) || !withinMapping(inner, got) {
+		t.Errorf("ReadFileRange(stored) = %d bytes, %v; want 50 bytes from the mapping", len(got), err)
+	}
+
+	for _, path := range []string{"/assets/compressed.raw", "/assets/framed.raw", "/assets/encrypted.raw"} {
+		content := contents[path]
+		got, err := pkg.ReadFile(ctx, path)
+		if err != nil || !bytes.Equal(got, content) {
+			t.Errorf("ReadFile(%q) = %d bytes, %v; want %d matching bytes", path, len(got), err, len(content))
+		}
+		if withinMapping(inner, got) {
+			t.Errorf("ReadFile(%q) returned decoded content inside the mapping", path)
+		}
+		stream, err := pkg.OpenFile(ctx, path)
+		if err != nil {
+			t.Fatalf("OpenFile(%q) failed: %v", path, err)
+		}
+		got, err = io.ReadAll(stream)
+		_ = stream.Close()
+		if err != nil || !bytes.Equal(got, content) {
+			t.Errorf("stream ReadAll(%q) = %d bytes, %v", path, len(got), err)
+		}
+	}
+
+	if err := pkg.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if inner.mapped != nil {
+		t.Error("Close did not release the mapping")
+	}
+	if err := pkg.Close(); err != nil {
+		t.Errorf("second Close failed: %v", err)
+	}
+}
+
+func TestOpenPackageReadOnlyWithOptions_RegularIO(t *testing.T) {
+	ctx := context.Background()
+	key := testEncryptionKey("mmap", 0x4D)
+	path, contents := writeMemoryMapTestPackage(t, ctx, key, 3000)
+	content := contents["/assets/stored.raw"]
+
+	for _, options := range []*ReadOnlyOptions{nil, {}} {
+		pkg, err := OpenPackageReadOnlyWithOptions(ctx, path, options)
+		if err != nil {
+			t.Fatalf("OpenPackageReadOnlyWithOptions(%+v) failed: %v", options, err)
+		}
+		inner := pkg.(*readOnlyPackage).inner.(*filePackage)
+		if inner.mapped != nil {
+			t.Errorf("OpenPackageReadOnlyWithOptions(%+v) mapped the package file", options)
+		}
+		got, err := pkg.ReadFile(ctx, "/assets/stored.raw")
+		if err != nil || !bytes.Equal(got, content) {
+			t.Errorf("ReadFile = %d bytes, %v; want %d matching bytes", len(got), err, len(content))
+		}
+		_ = pkg.Close()
+	}
+}
+
+func TestPackage_MappedStoredData_Bounds(t *testing.T) {
+	file := mappedTestFile(t)
+	p := &filePackage{fileHandle: file, mapped: make([]byte, 100)}
+
+	tests := []struct {
+		name   string
+		entry  *metadata.FileEntry
+		wantOK bool
+	}{
+		{"inside", &metadata.FileEntry{SourceFile: file, SourceOffset: 10, StoredSize: 90, OriginalSize: 90}, true},
+		{"past end", &metadata.FileEntry{SourceFile: file, SourceOffset: 10, StoredSize: 91, OriginalSize: 91}, false},
+		{"offset past end", &metadata.FileEntry{SourceFile: file, SourceOffset: 101, StoredSize: 0}, false},
+		{"other file", &metadata.FileEntry{SourceFile: mappedTestFile(t), SourceOffset: 10, StoredSize: 5, OriginalSize: 5}, false},
+		{"compressed", &metadata.FileEntry{SourceFile: file, SourceOffset: 10, StoredSize: 5, OriginalSize: 9, CompressionType: fileformat.CompressionZstd}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := p.mappedFileContent(tt.entry); ok != tt.wantOK {
+				t.Errorf("mappedFileContent() ok = %v, want %v", ok, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+//go:build unix
+
+// This file implements memory mapping of package files on Unix systems. This file
+// should contain only the platform mapping calls used by package_mmap.go.
+//
+// Specification: api_basic_operations.md: 11.7 OpenPackageReadOnlyWithOptions Function
+
+package novus_package
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile maps size bytes of file read-only into memory.
+func mapFile(file *os.File, size int) ([]byte, error) {
+	return syscall.Mmap(int(file.Fd()), 0, size, syscall.PROT_READ, syscall.MAP_SHARED)
+}
+
+// unmapFile releases a mapping created by mapFile.
+func unmapFile(data []byte) error {
+	return syscall.Munmap(data)
+}
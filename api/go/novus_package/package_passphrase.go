@@ -0,0 +1,316 @@
+// This file implements passphrase protection. SetPassphrase derives an AES-256 key
+// from a passphrase with a salted PBKDF2 and adds it as a recipient of the package
+// key envelope; the salt and iteration count are stored in the passphrase KDF
+// special file (type 65006). Files added to a passphrase-protected package are
+// encrypted with the envelope content key by default, and UnlockPassphrase or
+// OpenPackageWithPassphrase makes them readable again. This file should contain only
+// KDF parameter storage and passphrase key handling.
+//
+// Specification: api_security.md: 7. Passphrase Protection
+
+package novus_package
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"slices"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/generics"
+	"github.com/novus-engine/novuspack/api/go/internal"
+	"github.com/novus-engine/novuspack/api/go/metadata"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+const (
+	// DefaultPassphraseIterations is the PBKDF2 iteration count used when
+	// PassphraseOptions does not set one.
+	DefaultPassphraseIterations = 600000
+
+	// MinPassphraseIterations is the smallest PBKDF2 iteration count SetPassphrase accepts.
+	MinPassphraseIterations = 1000
+
+	// MaxPassphraseIterations is the largest PBKDF2 iteration count SetPassphrase
+	// accepts and a package KDF file may record, bounding the time spent deriving a
+	// key from an untrusted package.
+	MaxPassphraseIterations = 10000000
+
+	// passphraseFileType is the special file type holding the passphrase KDF parameters.
+	passphraseFileType = uint16(fileformat.FileTypePassphraseKDF)
+
+	// passphraseFilePath is the stored path of the passphrase KDF special file.
+	passphraseFilePath = "/__NVPK_KDF_65006__.nvpkkdf"
+
+	// passphraseParamsVersion is the KDF file encoding version written by this implementation.
+	passphraseParamsVersion = 1
+
+	// passphraseRecipientID is the key envelope recipient ID of the passphrase key.
+	passphraseRecipientID = "nvpk-passphrase"
+)
+
+// passphraseParams is the parsed passphrase KDF special file.
+type passphraseParams struct {
+	kdf        uint8  // Key derivation function (internal.KDFPBKDF2SHA256)
+	iterations uint32 // PBKDF2 iteration count
+	salt       []byte // Random salt
+}
+
+// SetPassphrase protects the package with passphrase.
+//
+// The passphrase key is derived with PBKDF2-HMAC-SHA256 and a new random salt, and
+// added to the package key envelope as a recipient; the KDF parameters are stored
+// in the passphrase KDF special file. Files added afterwards are encrypted with the
+// envelope content key unless AddFileOptions says otherwise. Calling SetPassphrase
+// on a passphrase-protected package changes the passphrase; the content key must
+// be unlocked, for example with UnlockPassphrase. File data is not re-encrypted.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - passphrase: Non-empty passphrase
+//   - options: Key derivation options (nil: defaults)
+//
+// Returns:
+//   - error: *PackageError with ErrTypeValidation for an empty passphrase or an
+//     iteration count outside MinPassphraseIterations to MaxPassphraseIterations,
+//     ErrTypeEncryption if the content key is locked
+//
+// Specification: api_security.md: 7.2 Package.SetPassphrase Method
+func (p *filePackage) SetPassphrase(ctx context.Context, passphrase string, options *PassphraseOptions) error {
+	if err := internal.CheckContext(ctx, "SetPassphrase"); err != nil {
+		return err
+	}
+	if err := p.checkNotSigned("SetPassphrase"); err != nil {
+		return err
+	}
+	iterations := DefaultPassphraseIterations
+	if options != nil && options.Iterations != 0 {
+		iterations = options.Iterations
+	}
+	if iterations < MinPassphraseIterations || iterations > MaxPassphraseIterations {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "passphrase iteration count out of range", nil, pkgerrors.ValidationErrorContext{
+			Field:    "Iterations",
+			Value:    iterations,
+			Expected: "1000 to 10000000",
+		})
+	}
+
+	params := &passphraseParams{
+		kdf:        internal.KDFPBKDF2SHA256,
+		iterations: uint32(iterations),
+		salt:       make([]byte, internal.PassphraseSaltSize),
+	}
+	if _, err := rand.Read(params.salt); err != nil {
+		return pkgerrors.WrapError(err, pkgerrors.ErrTypeEncryption, "failed to generate passphrase salt")
+	}
+	key, err := derivePassphraseKey(passphrase, params)
+	if err != nil {
+		return err
+	}
+	if err := p.setKeyRecipient(ctx, key, true, "SetPassphrase"); err != nil {
+		return err
+	}
+	p.storePassphraseParams(params)
+	return p.AddEncryptionKey(key)
+}
+
+// UnlockPassphrase derives the passphrase key from the stored KDF parameters and
+// unlocks the package key envelope with it, so ReadFile decrypts passphrase
+// protected files. A confidential index encrypted with the envelope content key is
+// unlocked as well. Only keys of this session change; the package is not modified.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - passphrase: Package passphrase
+//
+// Returns:
+//   - error: *PackageError with ErrTypeValidation if the package is not
+//     passphrase protected, ErrTypeEncryption if the passphrase is wrong,
+//     ErrTypeCorruption if the stored iteration count is out of range
+//
+// Specification: api_security.md: 7.3 Package.UnlockPassphrase Method
+func (p *filePackage) UnlockPassphrase(ctx context.Context, passphrase string) error {
+	if err := internal.CheckContext(ctx, "UnlockPassphrase"); err != nil {
+		return err
+	}
+	params, err := p.loadPassphraseParams(ctx)
+	if err != nil {
+		return err
+	}
+	env, err := p.loadKeyEnvelope(ctx)
+	if err != nil {
+		return err
+	}
+	index := -1
+	if env != nil {
+		index = slices.IndexFunc(env.recipients, func(r keyEnvelopeRecipient) bool { return r.id == passphraseRecipientID })
+	}
+	if params == nil || index < 0 {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "package is not passphrase protected", nil, pkgerrors.ValidationErrorContext{
+			Field:    "passphrase",
+			Value:    "",
+			Expected: "package protected with SetPassphrase",
+		})
+	}
+
+	key, err := derivePassphraseKey(passphrase, params)
+	if err != nil {
+		return err
+	}
+	if err := internal.CheckContext(ctx, "UnlockPassphrase"); err != nil {
+		return err
+	}
+	material, err := unwrapContentKey(key, env.recipients[index])
+	if err != nil {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeEncryption, "incorrect passphrase", err, EncryptionErrorContext{
+			Operation:  "UnlockPassphrase",
+			KeyID:      passphraseRecipientID,
+			ErrorStage: "key_unwrap",
+		})
+	}
+	contentKey := NewEncryptionKey(EncryptionAES256GCM, env.contentKeyID, material)
+	clear(material)
+	if err := p.AddEncryptionKey(key); err != nil {
+		return err
+	}
+	if err := p.AddEncryptionKey(contentKey); err != nil {
+		return err
+	}
+	// A confidential index encrypted with the content key unlocks with it
+	if p.indexLocked() && p.confidentialIndex.keyID == env.contentKeyID {
+		return p.UnlockIndex(ctx)
+	}
+	return nil
+}
+
+// OpenPackageWithPassphrase opens the package at path and unlocks it with passphrase.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - path: File path to the package to open
+//   - passphrase: Package passphrase
+//
+// Returns:
+//   - Package: The opened, unlocked package
+//   - error: All errors from OpenPackage and UnlockPassphrase
+//
+// Specification: api_security.md: 7.4 OpenPackageWithPassphrase Function
+func OpenPackageWithPassphrase(ctx context.Context, path, passphrase string) (Package, error) {
+	pkg, err := OpenPackage(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if err := pkg.UnlockPassphrase(ctx, passphrase); err != nil {
+		_ = pkg.Close() // Ignore error on cleanup path
+		return nil, err
+	}
+	return pkg, nil
+}
+
+// isPassphraseProtected reports whether the package has a passphrase KDF file.
+func (p *filePackage) isPassphraseProtected() bool {
+	_, exists := p.SpecialFiles[passphraseFileType]
+	return exists
+}
+
+// derivePassphraseKey derives the passphrase recipient key with params.
+// Returns ErrTypeUnsupported for unknown key derivation functions.
+func derivePassphraseKey(passphrase string, params *passphraseParams) (*EncryptionKey, error) {
+	if params.kdf != internal.KDFPBKDF2SHA256 {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeUnsupported, "unsupported passphrase key derivation function", nil, pkgerrors.ValidationErrorContext{
+			Field:    "KDF",
+			Value:    params.kdf,
+			Expected: "1 (PBKDF2-HMAC-SHA256)",
+		})
+	}
+	material, err := internal.DerivePassphraseKey(passphrase, params.salt, int(params.iterations))
+	if err != nil {
+		return nil, err
+	}
+	key := NewEncryptionKey(EncryptionAES256GCM, passphraseRecipientID, material)
+	clear(material)
+	return key, nil
+}
+
+// loadPassphraseParams returns the parsed passphrase KDF special file, or nil if
+// the package has none.
+func (p *filePackage) loadPassphraseParams(ctx context.Context) (*passphraseParams, error) {
+	specialFile, exists := p.SpecialFiles[passphraseFileType]
+	if !exists {
+		return nil, nil
+	}
+	data, err := p.fileEntryContent(ctx, specialFile)
+	if err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to read passphrase KDF file")
+	}
+	return decodePassphraseParams(data)
+}
+
+// storePassphraseParams writes params to the passphrase KDF special file, creating
+// the special file if needed.
+func (p *filePackage) storePassphraseParams(params *passphraseParams) {
+	data := encodePassphraseParams(params)
+
+	specialFile, exists := p.SpecialFiles[passphraseFileType]
+	if !exists {
+		specialFile = metadata.NewFileEntry()
+		specialFile.FileID = p.allocateNextFileID()
+		specialFile.Type = passphraseFileType
+		specialFile.Paths = []generics.PathEntry{
+			{PathLength: uint16(len(passphraseFilePath)), Path: passphraseFilePath},
+		}
+		specialFile.PathCount = 1
+		specialFile.CompressionType = fileformat.CompressionNone
+		specialFile.EncryptionType = fileformat.EncryptionNone // KDF parameters are not secret
+
+		if p.SpecialFiles == nil {
+			p.SpecialFiles = make(map[uint16]*metadata.FileEntry)
+		}
+		p.SpecialFiles[passphraseFileType] = specialFile
+		p.FileEntries = append(p.FileEntries, specialFile)
+	}
+	specialFile.OriginalSize = uint64(len(data))
+	specialFile.StoredSize = uint64(len(data))
+	specialFile.SetData(data)
+}
+
+// encodePassphraseParams serializes params, little-endian:
+// [Version: 1][KDF: 1][Iterations: 4][SaltLen: 1][Salt].
+func encodePassphraseParams(params *passphraseParams) []byte {
+	out := []byte{passphraseParamsVersion, params.kdf}
+	out = binary.LittleEndian.AppendUint32(out, params.iterations)
+	out = append(out, byte(len(params.salt)))
+	return append(out, params.salt...)
+}
+
+// decodePassphraseParams parses the passphrase KDF special file.
+// Returns ErrTypeUnsupported for unknown versions and ErrTypeCorruption if the
+// data is truncated or the iteration count is outside MinPassphraseIterations to
+// MaxPassphraseIterations.
+func decodePassphraseParams(data []byte) (*passphraseParams, error) {
+	r := envelopeReader{data: data}
+	version := r.byte()
+	if r.err == nil && version != passphraseParamsVersion {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeUnsupported, "unsupported passphrase KDF file version", nil, pkgerrors.ValidationErrorContext{
+			Field:    "PassphraseKDFVersion",
+			Value:    version,
+			Expected: "1",
+		})
+	}
+	params := &passphraseParams{kdf: r.byte()}
+	if b := r.bytes(4); b != nil {
+		params.iterations = binary.LittleEndian.Uint32(b)
+	}
+	params.salt = r.bytes(int(r.byte()))
+	if r.err != nil {
+		return nil, r.err
+	}
+	if params.iterations < MinPassphraseIterations || params.iterations > MaxPassphraseIterations {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "passphrase KDF iteration count out of range", nil, pkgerrors.ValidationErrorContext{
+			Field:    "Iterations",
+			Value:    params.iterations,
+			Expected: "1000 to 10000000",
+		})
+	}
+	return params, nil
+}
@@ -0,0 +1,202 @@
+// This file contains tests for passphrase protection: deriving the passphrase key,
+// storing KDF parameters, unlocking on open and changing the passphrase.
+//
+// Specification: api_security.md: 7. Passphrase Protection
+
+package novus_package
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/internal"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// fastPassphraseOptions keeps key derivation cheap in tests.
+var fastPassphraseOptions = &PassphraseOptions{Iterations: MinPassphraseIterations}
+
+func TestPackage_Passphrase_CreateAndOpen(t *testing.T) {
+	ctx := context.Background()
+	secret := bytes.Repeat([]byte("qa build asset "), 100)
+	path := filepath.Join(t.TempDir(), "qa.nvpk")
+
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	defer func() { _ = pkg.Close() }()
+	if err := pkg.CreateWithOptions(ctx, path, &CreateOptions{Passphrase: "correct horse"}); err != nil {
+		t.Fatalf("CreateWithOptions failed: %v", err)
+	}
+	fe, err := pkg.AddFileFromMemory(ctx, "/asset.bin", secret, nil)
+	if err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	if fe.EncryptionType != fileformat.EncryptionAES256GCM {
+		t.Errorf("EncryptionType = %d, want AES-256-GCM", fe.EncryptionType)
+	}
+	plain := &AddFileOptions{}
+	plain.UseKeyEnvelope.Set(false)
+	if fe, err := pkg.AddFileFromMemory(ctx, "/readme.txt", []byte("readme"), plain); err != nil || fe.EncryptionType != fileformat.EncryptionNone {
+		t.Fatalf("AddFileFromMemory(UseKeyEnvelope=false) = %v, err = %v", fe, err)
+	}
+	if err := pkg.Write(ctx); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	locked := reopenPackage(t, ctx, pkg)
+	_, err = locked.ReadFile(ctx, "/asset.bin")
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+	params, err := locked.(*filePackage).loadPassphraseParams(ctx)
+	if err != nil || params == nil {
+		t.Fatalf("loadPassphraseParams = %v, err = %v", params, err)
+	}
+	if params.iterations != DefaultPassphraseIterations || len(params.salt) != internal.PassphraseSaltSize {
+		t.Errorf("KDF parameters = %+v", params)
+	}
+
+	_, err = OpenPackageWithPassphrase(ctx, path, "wrong horse")
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeEncryption)
+
+	unlocked, err := OpenPackageWithPassphrase(ctx, path, "correct horse")
+	if err != nil {
+		t.Fatalf("OpenPackageWithPassphrase failed: %v", err)
+	}
+	defer func() { _ = unlocked.Close() }()
+	if got, err := unlocked.ReadFile(ctx, "/asset.bin"); err != nil || !bytes.Equal(got, secret) {
+		t.Errorf("ReadFile mismatch, err = %v", err)
+	}
+
+	readOnly, err := OpenPackageReadOnly(ctx, path)
+	if err != nil {
+		t.Fatalf("OpenPackageReadOnly failed: %v", err)
+	}
+	defer func() { _ = readOnly.Close() }()
+	if err := readOnly.UnlockPassphrase(ctx, "correct horse"); err != nil {
+		t.Fatalf("UnlockPassphrase(read-only) failed: %v", err)
+	}
+	if got, err := readOnly.ReadFile(ctx, "/asset.bin"); err != nil || !bytes.Equal(got, secret) {
+		t.Errorf("ReadFile(read-only) mismatch, err = %v", err)
+	}
+}
+
+func TestPackage_Passphrase_Change(t *testing.T) {
+	ctx := context.Background()
+	secret := bytes.Repeat([]byte("mod texture "), 100)
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	if err := pkg.SetPassphrase(ctx, "first", fastPassphraseOptions); err != nil {
+		t.Fatalf("SetPassphrase failed: %v", err)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/tex.dds", secret, nil); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	written := writeAndReopen(t, ctx, pkg)
+
+	// Changing the passphrase needs the content key
+	assertPackageErrorType(t, written.SetPassphrase(ctx, "second", fastPassphraseOptions), pkgerrors.ErrTypeEncryption)
+	if err := written.UnlockPassphrase(ctx, "first"); err != nil {
+		t.Fatalf("UnlockPassphrase failed: %v", err)
+	}
+	if err := written.SetPassphrase(ctx, "second", fastPassphraseOptions); err != nil {
+		t.Fatalf("SetPassphrase failed: %v", err)
+	}
+	rewritten := writeAndReopen(t, ctx, written)
+
+	assertPackageErrorType(t, rewritten.UnlockPassphrase(ctx, "first"), pkgerrors.ErrTypeEncryption)
+	if err := rewritten.UnlockPassphrase(ctx, "second"); err != nil {
+		t.Fatalf("UnlockPassphrase failed: %v", err)
+	}
+	if got, err := rewritten.ReadFile(ctx, "/tex.dds"); err != nil || !bytes.Equal(got, secret) {
+		t.Errorf("ReadFile mismatch, err = %v", err)
+	}
+	env, err := rewritten.(*filePackage).loadKeyEnvelope(ctx)
+	if err != nil || len(env.recipients) != 1 {
+		t.Fatalf("key envelope = %+v, err = %v", env, err)
+	}
+}
+
+func TestPackage_Passphrase_WithKeyRecipient(t *testing.T) {
+	ctx := context.Background()
+	secret := []byte("shared with the build farm")
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	if err := pkg.SetPassphrase(ctx, "qa", fastPassphraseOptions); err != nil {
+		t.Fatalf("SetPassphrase failed: %v", err)
+	}
+	if err := pkg.AddKeyRecipient(ctx, testEncryptionKey("build-farm", 0xBF)); err != nil {
+		t.Fatalf("AddKeyRecipient failed: %v", err)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/a.bin", secret, nil); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	written := writeAndReopen(t, ctx, pkg)
+
+	if err := written.AddEncryptionKey(testEncryptionKey("build-farm", 0xBF)); err != nil {
+		t.Fatalf("AddEncryptionKey failed: %v", err)
+	}
+	if got, err := written.ReadFile(ctx, "/a.bin"); err != nil || !bytes.Equal(got, secret) {
+		t.Errorf("ReadFile(build-farm) mismatch, err = %v", err)
+	}
+}
+
+func TestPackage_Passphrase_Errors(t *testing.T) {
+	ctx := context.Background()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+
+	assertPackageErrorType(t, pkg.UnlockPassphrase(ctx, "anything"), pkgerrors.ErrTypeValidation)
+	assertPackageErrorType(t, pkg.SetPassphrase(ctx, "", fastPassphraseOptions), pkgerrors.ErrTypeValidation)
+	assertPackageErrorType(t, pkg.SetPassphrase(ctx, "weak", &PassphraseOptions{Iterations: 10}), pkgerrors.ErrTypeValidation)
+	assertPackageErrorType(t, pkg.SetPassphrase(ctx, "slow", &PassphraseOptions{Iterations: MaxPassphraseIterations + 1}), pkgerrors.ErrTypeValidation)
+	if pkg.(*filePackage).isPassphraseProtected() {
+		t.Error("failed SetPassphrase left the package passphrase protected")
+	}
+
+	if err := pkg.AddKeyRecipient(ctx, testEncryptionKey("store-a", 0xA1)); err != nil {
+		t.Fatalf("AddKeyRecipient failed: %v", err)
+	}
+	assertPackageErrorType(t, pkg.UnlockPassphrase(ctx, "anything"), pkgerrors.ErrTypeValidation)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assertPackageErrorType(t, pkg.SetPassphrase(cancelled, "qa", fastPassphraseOptions), pkgerrors.ErrTypeContext)
+	assertPackageErrorType(t, pkg.UnlockPassphrase(cancelled, "qa"), pkgerrors.ErrTypeContext)
+}
+
+func TestPassphraseParams_EncodeDecode(t *testing.T) {
+	params := &passphraseParams{kdf: internal.KDFPBKDF2SHA256, iterations: 250000, salt: bytes.Repeat([]byte{0x5A}, 16)}
+	data := encodePassphraseParams(params)
+	got, err := decodePassphraseParams(data)
+	if err != nil {
+		t.Fatalf("decodePassphraseParams failed: %v", err)
+	}
+	if got.kdf != params.kdf || got.iterations != params.iterations || !bytes.Equal(got.salt, params.salt) {
+		t.Errorf("decodePassphraseParams = %+v, want %+v", got, params)
+	}
+
+	_, err = decodePassphraseParams(data[:len(data)-1])
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption)
+	_, err = decodePassphraseParams(append([]byte{2}, data[1:]...))
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeUnsupported)
+
+	// Iteration counts outside the accepted range are rejected before any derivation
+	for _, iterations := range []uint32{0, MinPassphraseIterations - 1, MaxPassphraseIterations + 1, 0xFFFFFFFF} {
+		crafted := encodePassphraseParams(&passphraseParams{kdf: internal.KDFPBKDF2SHA256, iterations: iterations, salt: params.salt})
+		_, err = decodePassphraseParams(crafted)
+		assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption)
+	}
+
+	_, err = derivePassphraseKey("qa", &passphraseParams{kdf: 0x7F, iterations: 1000, salt: params.salt})
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeUnsupported)
+}
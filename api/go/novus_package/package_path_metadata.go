@@ -76,6 +76,9 @@ func (p *filePackage) SetPathMetadata(ctx context.Context, entries []*metadata.P
 	if err := internal.CheckContext(ctx, "SetPathMetadata"); err != nil {
 		return err
 	}
+	if err := p.checkNotSigned("SetPathMetadata"); err != nil {
+		return err
+	}
 
 	// Validate all entries
 	for i, entry := range entries {
@@ -118,6 +121,9 @@ func (p *filePackage) AddPathMetadata(ctx context.Context, path string, pathType
 	if err := internal.CheckContext(ctx, "AddPathMetadata"); err != nil {
 		return err
 	}
+	if err := p.checkNotSigned("AddPathMetadata"); err != nil {
+		return err
+	}
 
 	// Load existing path metadata
 	entries, err := p.GetPathMetadata(ctx)
@@ -185,6 +191,9 @@ func (p *filePackage) RemovePathMetadata(ctx context.Context, path string) error
 	if err := internal.CheckContext(ctx, "RemovePathMetadata"); err != nil {
 		return err
 	}
+	if err := p.checkNotSigned("RemovePathMetadata"); err != nil {
+		return err
+	}
 
 	// Load existing path metadata
 	entries, err := p.GetPathMetadata(ctx)
@@ -232,6 +241,9 @@ func (p *filePackage) UpdatePathMetadata(ctx context.Context, path string, prope
 	if err := internal.CheckContext(ctx, "UpdatePathMetadata"); err != nil {
 		return err
 	}
+	if err := p.checkNotSigned("UpdatePathMetadata"); err != nil {
+		return err
+	}
 
 	// Load existing path metadata
 	entries, err := p.GetPathMetadata(ctx)
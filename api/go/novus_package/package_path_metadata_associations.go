@@ -32,6 +32,9 @@ func (p *filePackage) AssociateFileWithPath(ctx context.Context, filePath, path
 	if err := internal.CheckContext(ctx, "AssociateFileWithPath"); err != nil {
 		return err
 	}
+	if err := p.checkNotSigned("AssociateFileWithPath"); err != nil {
+		return err
+	}
 
 	// Find FileEntry by filePath
 	foundFE, err := p.findFileEntryByPath(filePath)
@@ -75,6 +78,9 @@ func (p *filePackage) DisassociateFileFromPath(ctx context.Context, filePath str
 	if err := internal.CheckContext(ctx, "DisassociateFileFromPath"); err != nil {
 		return err
 	}
+	if err := p.checkNotSigned("DisassociateFileFromPath"); err != nil {
+		return err
+	}
 
 	// Find FileEntry by filePath
 	foundFE, err := p.findFileEntryByPath(filePath)
@@ -37,6 +37,9 @@ func (p *filePackage) AddDirectoryMetadata(ctx context.Context, path string, pro
 	if err := internal.CheckContext(ctx, "AddDirectoryMetadata"); err != nil {
 		return err
 	}
+	if err := p.checkNotSigned("AddDirectoryMetadata"); err != nil {
+		return err
+	}
 
 	// Ensure path ends with /
 	if path != "" && path[len(path)-1] != '/' {
@@ -70,6 +73,9 @@ func (p *filePackage) RemoveDirectoryMetadata(ctx context.Context, path string)
 	if err := internal.CheckContext(ctx, "RemoveDirectoryMetadata"); err != nil {
 		return err
 	}
+	if err := p.checkNotSigned("RemoveDirectoryMetadata"); err != nil {
+		return err
+	}
 
 	// Ensure path ends with /
 	if path != "" && path[len(path)-1] != '/' {
@@ -99,6 +105,9 @@ func (p *filePackage) UpdateDirectoryMetadata(ctx context.Context, path string,
 	if err := internal.CheckContext(ctx, "UpdateDirectoryMetadata"); err != nil {
 		return err
 	}
+	if err := p.checkNotSigned("UpdateDirectoryMetadata"); err != nil {
+		return err
+	}
 
 	// Ensure path ends with /
 	if path != "" && path[len(path)-1] != '/' {
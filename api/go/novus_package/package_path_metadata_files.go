@@ -115,6 +115,9 @@ func (p *filePackage) SavePathMetadataFile(ctx context.Context) error {
 		p.FileEntries = append(p.FileEntries, specialFile)
 	}
 
+	// Path metadata of a confidential index is encrypted with the index key
+	p.encryptPathMetadataFile(specialFile)
+
 	// Update package header flags
 	return p.UpdateSpecialMetadataFlags(ctx)
 }
@@ -20,8 +20,12 @@ import (
 //
 // Returns:
 //   - *metadata.FileEntry: The found FileEntry, or nil if not found
-//   - error: *PackageError if file not found
+//   - error: *PackageError if file not found, ErrTypeEncryption if the
+//     confidential index is locked
 func (p *filePackage) findFileEntryByPath(pathStr string) (*metadata.FileEntry, error) {
+	if err := p.checkIndexUnlocked("findFileEntryByPath"); err != nil {
+		return nil, err
+	}
 	for _, fe := range p.FileEntries {
 		if fe == nil {
 			continue
@@ -94,10 +94,15 @@ func (p *filePackage) GetPathInfo(path string) (*PathInfo, error) {
 //
 // Returns:
 //   - []PathInfo: All path information entries
-//   - error: *PackageError on failure
+//   - error: *PackageError on failure, ErrTypeEncryption if the confidential
+//     index is locked
 //
 // Specification: api_metadata.md: 8.2 PathMetadata Management Methods
 func (p *filePackage) ListPaths() ([]PathInfo, error) {
+	if err := p.checkIndexUnlocked("ListPaths"); err != nil {
+		return nil, err
+	}
+
 	// This is a pure in-memory operation - path metadata is already loaded
 	if p.PathMetadataEntries == nil {
 		return []PathInfo{}, nil
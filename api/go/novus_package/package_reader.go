@@ -13,9 +13,11 @@ package novus_package
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"maps"
+	"slices"
 	"sort"
 
 	"github.com/novus-engine/novuspack/api/go/internal"
@@ -127,6 +129,8 @@ func (p *filePackage) GetMetadata() (*metadata.PackageMetadata, error) {
 //
 // This method reads file content from the package, applying decryption and
 // decompression as needed. The path must be a valid package-internal path.
+// In a package opened with ReadOnlyOptions.MemoryMap, uncompressed, unencrypted
+// content is returned as a read-only slice of the mapping, valid until Close.
 //
 // Parameters:
 //   - ctx: Context for cancellation and timeout control
@@ -140,6 +144,7 @@ func (p *filePackage) GetMetadata() (*metadata.PackageMetadata, error) {
 //   - ErrTypeContext: Context is cancelled or has deadline exceeded
 //   - ErrTypeValidation: Path is invalid or file not found
 //   - ErrTypeIO: Failed to read file data
+//   - ErrTypeEncryption: File is encrypted and its key was not supplied through AddEncryptionKey, or decryption failed
 //
 // Specification: api_core.md: 1.2.2 Package.ReadFile Method
 func (p *filePackage) ReadFile(ctx context.Context, path string) ([]byte, error) {
@@ -151,6 +156,9 @@ func (p *filePackage) ReadFile(ctx context.Context, path string) ([]byte, error)
 	if fileEntry.IsDataLoaded {
 		return fileEntry.Data, nil
 	}
+	if data, ok := p.mappedFileContent(fileEntry); ok {
+		return data, nil
+	}
 	return p.readFileDataFromSource(ctx, fileEntry)
 }
 
@@ -169,6 +177,9 @@ func (p *filePackage) readFileValidateAndResolve(ctx context.Context, path strin
 	if err != nil {
 		return "", nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeValidation, "error during ReadFile: path normalization failed")
 	}
+	if err := p.checkIndexUnlocked("ReadFile"); err != nil {
+		return "", nil, err
+	}
 	fileEntry, err := p.findFileEntryByPath(normalizedPath)
 	if err != nil {
 		return "", nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeValidation, "error during ReadFile: file not found")
@@ -176,56 +187,119 @@ func (p *filePackage) readFileValidateAndResolve(ctx context.Context, path strin
 	return normalizedPath, fileEntry, nil
 }
 
-// readFileDataFromSource reads file data from the package file using SourceFile/SourceOffset.
+// readContentSizeHint caps the content capacity ReadFile allocates up front from
+// a recorded size. Recorded sizes come from untrusted package data, so larger
+// content grows as it is decoded.
+const readContentSizeHint = 64 << 20
+
+// readFileDataFromSource reads file data from the package file using SourceFile/SourceOffset,
+// decrypting it with the entry's registered key and decompressing it as needed. Members of a solid compression group are served from the group's decompressed block.
+// Encrypted and compressed data is decoded through a stream source, so only the
+// content is held in memory and the stored data is read a chunk at a time.
 func (p *filePackage) readFileDataFromSource(ctx context.Context, fileEntry *metadata.FileEntry) ([]byte, error) {
+	if groupID, offset, ok := fileEntry.GetSolidGroup(); ok {
+		return p.readSolidGroupMember(ctx, fileEntry, groupID, offset)
+	}
+	if fileEntry.EncryptionType == 0 && fileEntry.CompressionType == 0 {
+		return readStoredFileData(ctx, fileEntry)
+	}
+
+	source, err := p.newStreamSource(ctx, fileEntry)
+	if err != nil {
+		return nil, err
+	}
+	// The stored data is checked before it is decoded, so damage is reported as
+	// corruption rather than as a decoding or decryption failure
+	if source.storedChecksum != 0 {
+		checksum, err := p.storedDataChecksum(ctx, fileEntry)
+		if err != nil {
+			return nil, err
+		}
+		if checksum != source.storedChecksum {
+			return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "stored data checksum mismatch", nil, pkgerrors.ValidationErrorContext{
+				Field: "StoredChecksum", Value: source.storedChecksum, Expected: "checksum of stored data",
+			})
+		}
+		source.storedChecksum = 0
+	}
+	cursor, err := source.open(0)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.close()
+
+	// The cursor verifies RawChecksum when it reaches the end of the content
+	content := make([]byte, 0, min(source.size, readContentSizeHint))
+	for {
+		if len(content) == cap(content) && int64(len(content)) < source.size {
+			content = slices.Grow(content, 1)
+		}
+		n, err := cursor.read(content[len(content):cap(content)])
+		content = content[:len(content)+n]
+		switch {
+		case errors.Is(err, io.EOF):
+			return content, nil
+		case err != nil:
+			return nil, err
+		}
+	}
+}
+
+// readStoredFileData reads the stored (possibly compressed) bytes of a file entry
+// from its SourceFile at SourceOffset.
+func readStoredFileData(ctx context.Context, fileEntry *metadata.FileEntry) ([]byte, error) {
+	if err := checkStoredFileSource(ctx, fileEntry); err != nil {
+		return nil, err
+	}
+	data := make([]byte, fileEntry.StoredSize)
+	if err := readStoredFileDataInto(ctx, fileEntry, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// checkStoredFileSource reports whether the stored bytes of a file entry can be
+// read from its SourceFile.
+func checkStoredFileSource(ctx context.Context, fileEntry *metadata.FileEntry) error {
 	select {
 	case <-ctx.Done():
-		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeContext, "context cancelled", ctx.Err(), struct{}{})
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeContext, "context cancelled", ctx.Err(), struct{}{})
 	default:
 	}
 	if fileEntry.SourceFile == nil {
-		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "file source is not available", nil, pkgerrors.ValidationErrorContext{
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "file source is not available", nil, pkgerrors.ValidationErrorContext{
 			Field: "SourceFile", Value: "nil", Expected: "valid file handle",
 		})
 	}
-	if fileEntry.SourceOffset == 0 {
-		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "file source offset is not set", nil, pkgerrors.ValidationErrorContext{
+	if fileEntry.SourceOffset == 0 && !fileEntry.IsTempFile {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "file source offset is not set", nil, pkgerrors.ValidationErrorContext{
 			Field: "SourceOffset", Value: 0, Expected: "valid file offset",
 		})
 	}
-	if _, err := fileEntry.SourceFile.Seek(fileEntry.SourceOffset, 0); err != nil {
-		return nil, pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeIO, "failed to seek to file data", pkgerrors.ValidationErrorContext{
-			Field: "SourceOffset", Value: fileEntry.SourceOffset, Expected: "seek successful",
-		})
-	}
+	return nil
+}
+
+// readStoredFileDataInto fills data with the stored bytes of a file entry; data must
+// hold exactly StoredSize bytes. It reads with ReadAt so concurrent reads of the
+// shared package file do not race on its offset.
+func readStoredFileDataInto(ctx context.Context, fileEntry *metadata.FileEntry, data []byte) error {
 	select {
 	case <-ctx.Done():
-		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeContext, "context cancelled", ctx.Err(), struct{}{})
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeContext, "context cancelled", ctx.Err(), struct{}{})
 	default:
 	}
-	data := make([]byte, fileEntry.StoredSize)
-	n, err := fileEntry.SourceFile.Read(data)
+	n, err := fileEntry.SourceFile.ReadAt(data, fileEntry.SourceOffset)
 	if err != nil && err != io.EOF {
-		return nil, pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeIO, "failed to read file data", pkgerrors.ValidationErrorContext{
+		return pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeIO, "failed to read file data", pkgerrors.ValidationErrorContext{
 			Field: "StoredSize", Value: fileEntry.StoredSize, Expected: "read successful",
 		})
 	}
 	if uint64(n) != fileEntry.StoredSize {
-		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "incomplete file data read", nil, pkgerrors.ValidationErrorContext{
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "incomplete file data read", nil, pkgerrors.ValidationErrorContext{
 			Field: "Data", Value: n, Expected: fmt.Sprintf("%d bytes", fileEntry.StoredSize),
 		})
 	}
-	if fileEntry.EncryptionType != 0 {
-		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeUnsupported, "file decryption not yet implemented", nil, pkgerrors.ValidationErrorContext{
-			Field: "EncryptionType", Value: fileEntry.EncryptionType, Expected: "decryption support",
-		})
-	}
-	if fileEntry.CompressionType != 0 {
-		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeUnsupported, "file decompression not yet implemented", nil, pkgerrors.ValidationErrorContext{
-			Field: "CompressionType", Value: fileEntry.CompressionType, Expected: "decompression support",
-		})
-	}
-	return data, nil
+	return nil
 }
 
 // ListFiles returns a list of all files in the package.
@@ -243,6 +317,7 @@ func (p *filePackage) readFileDataFromSource(ctx context.Context, fileEntry *met
 //
 // Error Conditions:
 //   - ErrTypeValidation: Package is closed or path normalization fails
+//   - ErrTypeEncryption: Confidential index is locked
 //
 // Specification: api_core.md: 1.2.3 Package.ListFiles Method
 //
@@ -255,6 +330,9 @@ func (p *filePackage) ListFiles() ([]FileInfo, error) {
 	if p.Info == nil || p.FileEntries == nil {
 		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "package metadata is not loaded", nil, struct{}{})
 	}
+	if err := p.checkIndexUnlocked("ListFiles"); err != nil {
+		return nil, err
+	}
 
 	// Build list of FileInfo from loaded FileEntries
 	// Each FileEntry becomes one FileInfo (not one per path)
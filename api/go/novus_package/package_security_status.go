@@ -0,0 +1,258 @@
+// This file implements package security assessment. The security level is derived
+// from the package's verified signatures and the encryption of its regular files;
+// GetSecurityStatus reports the inputs, per-signature results, checksum state and
+// the reasons for the assigned level. This file should contain only security status
+// computation and security level assessment.
+//
+// Specification: api_security.md: 2 SecurityStatus Structure
+
+package novus_package
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"sort"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/internal"
+	"github.com/novus-engine/novuspack/api/go/metadata"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+	"github.com/novus-engine/novuspack/api/go/signatures"
+)
+
+// GetSecurityStatus verifies the package's signatures against trust, checks the
+// stored checksums of its files and reports the resulting security status.
+//
+// The package's SecurityLevel in PackageInfo is updated to the assessed level. A
+// nil trust trusts nothing, so signatures count at most as valid. Signature and
+// checksum failures are reported in the status rather than as errors.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - trust: Keys and certificates to verify signatures against
+//
+// Returns:
+//   - *metadata.SecurityStatus: Security status and the reasons for its level
+//   - error: *PackageError with ErrTypeContext if the context is cancelled, or any
+//     Verify error
+//
+// Specification: api_security.md: 1.1.2 Package.GetSecurityStatus Method
+func (p *filePackage) GetSecurityStatus(ctx context.Context, trust TrustStore) (*metadata.SecurityStatus, error) {
+	if err := internal.CheckContext(ctx, "GetSecurityStatus"); err != nil {
+		return nil, err
+	}
+	var infos []signatures.SignatureInfo
+	if p.isSigned() {
+		var err error
+		if infos, err = p.Verify(ctx, trust); err != nil {
+			return nil, err
+		}
+	}
+	status := p.securityStatus(infos)
+	if err := p.checkStoredChecksums(ctx, status); err != nil {
+		return nil, err
+	}
+	if p.Info != nil {
+		p.Info.SecurityLevel = status.SecurityLevel
+	}
+	return status, nil
+}
+
+// securityStatus summarizes the signatures described by infos and the encryption
+// of the package's regular files, and assesses the security level. Checksums are
+// not checked.
+func (p *filePackage) securityStatus(infos []signatures.SignatureInfo) *metadata.SecurityStatus {
+	status := &metadata.SecurityStatus{SignatureCount: len(infos)}
+	for _, info := range infos {
+		result := signatures.SignatureValidationResult{
+			Index:     info.Index,
+			Type:      info.Type,
+			Valid:     info.Valid,
+			Trusted:   info.Valid && info.Trusted,
+			Error:     info.Error,
+			Timestamp: info.Timestamp,
+		}
+		if len(info.CertificateChain) > 0 {
+			result.PublicKey = info.CertificateChain[0].RawSubjectPublicKeyInfo
+		}
+		if result.Valid {
+			status.ValidSignatures++
+		}
+		if result.Trusted {
+			status.TrustedSignatures++
+			if isQuantumSafeSignatureType(info.Type) {
+				status.QuantumSafeSignatures++
+			}
+		}
+		if info.Error != "" {
+			status.ValidationErrors = append(status.ValidationErrors, fmt.Sprintf("signature %d: %s", info.Index, info.Error))
+		}
+		status.SignatureResults = append(status.SignatureResults, result)
+	}
+
+	status.TrustedSigners = trustedSigners(infos)
+
+	algorithms := make(map[string]bool)
+	for _, fe := range p.FileEntries {
+		if fileformat.IsSpecialFile(fileformat.FileType(fe.Type)) {
+			continue
+		}
+		status.FileCount++
+		if fe.EncryptionType == fileformat.EncryptionNone {
+			continue
+		}
+		status.EncryptedFiles++
+		if fe.EncryptionType == fileformat.EncryptionQuantumSafe {
+			status.QuantumSafeFiles++
+		}
+		algorithms[onDiskEncryptionName(fe.EncryptionType)] = true
+	}
+	for name := range algorithms {
+		status.EncryptionAlgorithms = append(status.EncryptionAlgorithms, name)
+	}
+	sort.Strings(status.EncryptionAlgorithms)
+
+	assessSecurityLevel(status)
+	return status
+}
+
+// checkStoredChecksums verifies the stored data of every file entry that has a
+// stored checksum and is backed by the package file, recording mismatches and
+// read failures in status.
+func (p *filePackage) checkStoredChecksums(ctx context.Context, status *metadata.SecurityStatus) error {
+	status.ChecksumsValid = true
+	for _, fe := range p.FileEntries {
+		if err := internal.CheckContext(ctx, "GetSecurityStatus"); err != nil {
+			return err
+		}
+		if fe.StoredChecksum == 0 || fe.SourceFile == nil {
+			continue
+		}
+		status.HasChecksums = true
+		checksum, err := p.storedDataChecksum(ctx, fe)
+		if err != nil {
+			status.ChecksumsValid = false
+			status.ValidationErrors = append(status.ValidationErrors, fmt.Sprintf("%s: %v", fe.GetPrimaryPath(), err))
+			continue
+		}
+		if checksum != fe.StoredChecksum {
+			status.ChecksumsValid = false
+			status.ValidationErrors = append(status.ValidationErrors, fmt.Sprintf("%s: stored data checksum mismatch", fe.GetPrimaryPath()))
+		}
+	}
+	if !status.HasChecksums {
+		status.ChecksumsValid = false
+	}
+	return nil
+}
+
+// storedDataChecksum returns the CRC32 of the stored bytes of fe, streaming them
+// through a pooled buffer rather than reading the whole entry into memory.
+func (p *filePackage) storedDataChecksum(ctx context.Context, fe *metadata.FileEntry) (uint32, error) {
+	if err := checkStoredFileSource(ctx, fe); err != nil {
+		return 0, err
+	}
+	hasher := crc32.NewIEEE()
+	n, err := copyWithPooledBuffer(ctx, hasher, p.storedSection(fe), int64(fe.StoredSize))
+	if err != nil {
+		return 0, err
+	}
+	if uint64(n) != fe.StoredSize {
+		return 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "incomplete file data read", nil, pkgerrors.ValidationErrorContext{
+			Field: "Data", Value: n, Expected: fmt.Sprintf("%d bytes", fe.StoredSize),
+		})
+	}
+	return hasher.Sum32(), nil
+}
+
+// assessSecurityLevel sets status.SecurityLevel from the signature and encryption
+// counts in status and records the reasons.
+//
+// Levels, highest first:
+//   - Maximum: all files encrypted, a trusted signature, and either trusted
+//     signatures from two or more distinct signers, a trusted quantum-safe
+//     signature, or quantum-safe encryption of all files
+//   - High: all files encrypted and a trusted signature, or trusted signatures
+//     from two or more distinct signers, or a trusted quantum-safe signature
+//   - Medium: a trusted signature or all files encrypted
+//   - Low: some files encrypted or a valid but untrusted signature
+//   - None: otherwise
+//
+// Specification: api_security.md: 2.4 Security Level Assessment
+func assessSecurityLevel(status *metadata.SecurityStatus) {
+	trusted := status.TrustedSignatures
+	allEncrypted := status.AllFilesEncrypted()
+	strongSignatures := status.TrustedSigners >= 2 || status.QuantumSafeSignatures > 0
+	quantumSafeEncryption := allEncrypted && status.QuantumSafeFiles == status.FileCount
+
+	var reasons []string
+	switch {
+	case status.QuantumSafeSignatures > 0:
+		reasons = append(reasons, fmt.Sprintf("%s, %d quantum-safe", countNoun(trusted, "trusted signature"), status.QuantumSafeSignatures))
+	case trusted > 0:
+		reasons = append(reasons, countNoun(trusted, "trusted signature"))
+	case status.ValidSignatures > 0:
+		reasons = append(reasons, countNoun(status.ValidSignatures, "valid signature")+", none trusted")
+	case status.SignatureCount > 0:
+		reasons = append(reasons, countNoun(status.SignatureCount, "signature")+", none verified")
+	default:
+		reasons = append(reasons, "no signatures")
+	}
+	switch {
+	case quantumSafeEncryption:
+		reasons = append(reasons, fmt.Sprintf("all %s encrypted with quantum-safe encryption", countNoun(status.FileCount, "file")))
+	case allEncrypted:
+		reasons = append(reasons, fmt.Sprintf("all %s encrypted", countNoun(status.FileCount, "file")))
+	case status.EncryptedFiles > 0:
+		reasons = append(reasons, fmt.Sprintf("%d of %s encrypted", status.EncryptedFiles, countNoun(status.FileCount, "file")))
+	case status.FileCount == 0:
+		reasons = append(reasons, "no files")
+	default:
+		reasons = append(reasons, "no files encrypted")
+	}
+
+	switch {
+	case allEncrypted && trusted > 0 && (strongSignatures || quantumSafeEncryption):
+		status.SecurityLevel = metadata.SecurityLevelMaximum
+	case allEncrypted && trusted > 0, strongSignatures:
+		status.SecurityLevel = metadata.SecurityLevelHigh
+	case allEncrypted || trusted > 0:
+		status.SecurityLevel = metadata.SecurityLevelMedium
+	case status.EncryptedFiles > 0 || status.ValidSignatures > 0:
+		status.SecurityLevel = metadata.SecurityLevelLow
+	default:
+		status.SecurityLevel = metadata.SecurityLevelNone
+	}
+	status.Reasons = reasons
+}
+
+// isQuantumSafeSignatureType reports whether signatureType is a post-quantum
+// signature algorithm.
+func isQuantumSafeSignatureType(signatureType uint32) bool {
+	return signatureType == signatures.SignatureTypeMLDSA || signatureType == signatures.SignatureTypeSLHDSA
+}
+
+// onDiskEncryptionName returns the algorithm name of an on-disk EncryptionType value.
+//
+// Specification: api_security.md: 3.3 On-Disk Mapping
+func onDiskEncryptionName(encryptionType uint8) string {
+	switch encryptionType {
+	case fileformat.EncryptionAES256GCM:
+		return GetEncryptionTypeName(EncryptionAES256GCM)
+	case fileformat.EncryptionChaCha20Poly1305:
+		return GetEncryptionTypeName(EncryptionChaCha20Poly1305)
+	case fileformat.EncryptionQuantumSafe:
+		return "ML-KEM"
+	default:
+		return fmt.Sprintf("unknown (0x%02X)", encryptionType)
+	}
+}
+
+// countNoun formats n with noun, pluralized with "s" unless n is 1.
+func countNoun(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,275 @@
+// This file contains tests for package security assessment: security level rules,
+// GetSecurityStatus on signed and encrypted packages, and checksum checking.
+//
+// Specification: api_security.md: 2 SecurityStatus Structure
+
+package novus_package
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/metadata"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+	"github.com/novus-engine/novuspack/api/go/signatures"
+)
+
+// writeEncryptedTestPackage writes a package with two files, encrypting the first
+// with key and the second too if encryptAll is set, and returns its path.
+func writeEncryptedTestPackage(t *testing.T, ctx context.Context, encryptAll bool) string {
+	t.Helper()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	defer func() { _ = pkg.Close() }()
+	path := filepath.Join(t.TempDir(), "secure.nvpk")
+	if err := pkg.Create(ctx, path); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	key := testEncryptionKey("content", 0x42)
+	if _, err := pkg.AddFileFromMemory(ctx, "/data/a.bin", []byte("first secret"), encryptedOptions(key)); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	var opts *AddFileOptions
+	if encryptAll {
+		opts = encryptedOptions(key)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/data/b.bin", []byte("second file"), opts); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	if err := pkg.Write(ctx); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	return path
+}
+
+// openSecurityTestPackage opens the package at path, signed with signers first.
+func openSecurityTestPackage(t *testing.T, ctx context.Context, path string, signers ...ed25519.PrivateKey) Package {
+	t.Helper()
+	pkg, err := OpenPackage(ctx, path)
+	if err != nil {
+		t.Fatalf("OpenPackage failed: %v", err)
+	}
+	for _, signer := range signers {
+		if err := pkg.Sign(ctx, signer, ""); err != nil {
+			t.Fatalf("Sign failed: %v", err)
+		}
+	}
+	t.Cleanup(func() { _ = pkg.Close() })
+	return pkg
+}
+
+func TestAssessSecurityLevel(t *testing.T) {
+	tests := []struct {
+		name   string
+		status metadata.SecurityStatus
+		want   metadata.SecurityLevel
+	}{
+		{"empty", metadata.SecurityStatus{}, metadata.SecurityLevelNone},
+		{"unsigned plain files", metadata.SecurityStatus{FileCount: 2}, metadata.SecurityLevelNone},
+		{"unverified signature", metadata.SecurityStatus{FileCount: 2, SignatureCount: 1}, metadata.SecurityLevelNone},
+		{"some files encrypted", metadata.SecurityStatus{FileCount: 2, EncryptedFiles: 1}, metadata.SecurityLevelLow},
+		{"valid untrusted signature", metadata.SecurityStatus{FileCount: 2, SignatureCount: 1, ValidSignatures: 1}, metadata.SecurityLevelLow},
+		{"trusted signature", metadata.SecurityStatus{FileCount: 2, SignatureCount: 1, ValidSignatures: 1, TrustedSignatures: 1, TrustedSigners: 1}, metadata.SecurityLevelMedium},
+		{"all files encrypted", metadata.SecurityStatus{FileCount: 2, EncryptedFiles: 2}, metadata.SecurityLevelMedium},
+		{"two trusted signatures", metadata.SecurityStatus{FileCount: 2, SignatureCount: 2, ValidSignatures: 2, TrustedSignatures: 2, TrustedSigners: 2}, metadata.SecurityLevelHigh},
+		{"two trusted signatures by one signer", metadata.SecurityStatus{FileCount: 2, SignatureCount: 2, ValidSignatures: 2, TrustedSignatures: 2, TrustedSigners: 1}, metadata.SecurityLevelMedium},
+		{"trusted quantum-safe signature", metadata.SecurityStatus{FileCount: 2, SignatureCount: 1, ValidSignatures: 1, TrustedSignatures: 1, QuantumSafeSignatures: 1}, metadata.SecurityLevelHigh},
+		{"trusted signature and all encrypted", metadata.SecurityStatus{FileCount: 2, EncryptedFiles: 2, SignatureCount: 1, ValidSignatures: 1, TrustedSignatures: 1, TrustedSigners: 1}, metadata.SecurityLevelHigh},
+		{"trusted signature and some encrypted", metadata.SecurityStatus{FileCount: 2, EncryptedFiles: 1, SignatureCount: 2, ValidSignatures: 1, TrustedSignatures: 1, TrustedSigners: 1}, metadata.SecurityLevelMedium},
+		{"two trusted signatures and all encrypted", metadata.SecurityStatus{FileCount: 2, EncryptedFiles: 2, SignatureCount: 2, ValidSignatures: 2, TrustedSignatures: 2, TrustedSigners: 2}, metadata.SecurityLevelMaximum},
+		{"trusted signature and quantum-safe encryption", metadata.SecurityStatus{FileCount: 2, EncryptedFiles: 2, QuantumSafeFiles: 2, SignatureCount: 1, ValidSignatures: 1, TrustedSignatures: 1, TrustedSigners: 1}, metadata.SecurityLevelMaximum},
+		{"no files", metadata.SecurityStatus{SignatureCount: 1, ValidSignatures: 1, TrustedSignatures: 1, TrustedSigners: 1}, metadata.SecurityLevelMedium},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := tt.status
+			assessSecurityLevel(&status)
+			if status.SecurityLevel != tt.want {
+				t.Errorf("SecurityLevel = %v, want %v (reasons %q)", status.SecurityLevel, tt.want, status.Reasons)
+			}
+			if len(status.Reasons) != 2 {
+				t.Errorf("Reasons = %q, want a signature and an encryption reason", status.Reasons)
+			}
+		})
+	}
+}
+
+func TestPackage_GetSecurityStatus(t *testing.T) {
+	ctx := context.Background()
+	_, keyA, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	_, keyB, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	trustA := NewTrustStore(keyA.Public())
+	trustBoth := NewTrustStore(keyA.Public(), keyB.Public())
+
+	tests := []struct {
+		name       string
+		encryptAll bool
+		signers    []ed25519.PrivateKey
+		trust      TrustStore
+		want       metadata.SecurityLevel
+		trusted    int
+		encrypted  int
+	}{
+		{"partially encrypted unsigned", false, nil, nil, metadata.SecurityLevelLow, 0, 1},
+		{"encrypted unsigned", true, nil, nil, metadata.SecurityLevelMedium, 0, 2},
+		{"encrypted signed untrusted", true, []ed25519.PrivateKey{keyA}, nil, metadata.SecurityLevelMedium, 0, 2},
+		{"partially encrypted signed trusted", false, []ed25519.PrivateKey{keyA}, trustA, metadata.SecurityLevelMedium, 1, 1},
+		{"encrypted signed trusted", true, []ed25519.PrivateKey{keyA}, trustA, metadata.SecurityLevelHigh, 1, 2},
+		{"encrypted two trusted signatures", true, []ed25519.PrivateKey{keyA, keyB}, trustBoth, metadata.SecurityLevelMaximum, 2, 2},
+		{"encrypted one of two signatures trusted", true, []ed25519.PrivateKey{keyA, keyB}, trustA, metadata.SecurityLevelHigh, 1, 2},
+		{"encrypted signed twice by one key", true, []ed25519.PrivateKey{keyA, keyA}, trustBoth, metadata.SecurityLevelHigh, 2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg := openSecurityTestPackage(t, ctx, writeEncryptedTestPackage(t, ctx, tt.encryptAll), tt.signers...)
+			status, err := pkg.GetSecurityStatus(ctx, tt.trust)
+			if err != nil {
+				t.Fatalf("GetSecurityStatus failed: %v", err)
+			}
+			if status.SecurityLevel != tt.want {
+				t.Errorf("SecurityLevel = %v, want %v (reasons %q)", status.SecurityLevel, tt.want, status.Reasons)
+			}
+			if status.SignatureCount != len(tt.signers) || status.TrustedSignatures != tt.trusted || len(status.SignatureResults) != len(tt.signers) {
+				t.Errorf("signatures: count %d trusted %d results %d, want %d, %d", status.SignatureCount, status.TrustedSignatures, len(status.SignatureResults), len(tt.signers), tt.trusted)
+			}
+			if status.FileCount != 2 || status.EncryptedFiles != tt.encrypted {
+				t.Errorf("files: %d encrypted of %d, want %d of 2", status.EncryptedFiles, status.FileCount, tt.encrypted)
+			}
+			if len(status.EncryptionAlgorithms) != 1 || status.EncryptionAlgorithms[0] != "AES-256-GCM" {
+				t.Errorf("EncryptionAlgorithms = %q, want [AES-256-GCM]", status.EncryptionAlgorithms)
+			}
+			if !status.HasChecksums || !status.ChecksumsValid {
+				t.Errorf("HasChecksums %v ChecksumsValid %v, want true (errors %q)", status.HasChecksums, status.ChecksumsValid, status.ValidationErrors)
+			}
+			if len(status.ValidationErrors) != len(tt.signers)-tt.trusted {
+				t.Errorf("ValidationErrors = %q, want one per untrusted signature", status.ValidationErrors)
+			}
+			if info, _ := pkg.GetInfo(); info.SecurityLevel != tt.want {
+				t.Errorf("PackageInfo.SecurityLevel = %v, want %v", info.SecurityLevel, tt.want)
+			}
+		})
+	}
+}
+
+func TestPackage_SecurityLevel_OpenAndVerify(t *testing.T) {
+	ctx := context.Background()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	path := writeEncryptedTestPackage(t, ctx, true)
+	signer := openSecurityTestPackage(t, ctx, path, key)
+	_ = signer.Close()
+
+	pkg := openSecurityTestPackage(t, ctx, path)
+	info, err := pkg.GetInfo()
+	if err != nil {
+		t.Fatalf("GetInfo failed: %v", err)
+	}
+	// Signatures are unverified after open
+	if info.SecurityLevel != metadata.SecurityLevelMedium {
+		t.Errorf("SecurityLevel after open = %v, want Medium", info.SecurityLevel)
+	}
+	if _, err := pkg.Verify(ctx, NewTrustStore(key.Public())); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if info.SecurityLevel != metadata.SecurityLevelHigh {
+		t.Errorf("SecurityLevel after Verify = %v, want High", info.SecurityLevel)
+	}
+}
+
+func TestPackage_GetSecurityStatus_X509(t *testing.T) {
+	ctx := context.Background()
+	pki := newTestPKI(t, "Security")
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	now := time.Now()
+	leaf := pki.leaf(t, "Publisher", key.Public(), now.Add(-time.Hour), now.Add(time.Hour))
+	pkg := writeSigningTestPackage(t, ctx)
+	if err := pkg.SignWithCertificate(ctx, key, []*x509.Certificate{leaf, pki.intermediate}, ""); err != nil {
+		t.Fatalf("SignWithCertificate failed: %v", err)
+	}
+
+	// The leaf verifies the signature, but no root is trusted
+	status, err := pkg.GetSecurityStatus(ctx, nil)
+	if err != nil {
+		t.Fatalf("GetSecurityStatus failed: %v", err)
+	}
+	if status.SecurityLevel != metadata.SecurityLevelLow || status.ValidSignatures != 1 || status.TrustedSignatures != 0 {
+		t.Errorf("untrusted: level %v valid %d trusted %d, want Low, 1, 0", status.SecurityLevel, status.ValidSignatures, status.TrustedSignatures)
+	}
+
+	status, err = pkg.GetSecurityStatus(ctx, &StaticTrustStore{Roots: pki.roots})
+	if err != nil {
+		t.Fatalf("GetSecurityStatus failed: %v", err)
+	}
+	if status.SecurityLevel != metadata.SecurityLevelMedium || status.TrustedSignatures != 1 {
+		t.Errorf("trusted: level %v trusted %d, want Medium, 1", status.SecurityLevel, status.TrustedSignatures)
+	}
+	result := status.SignatureResults[0]
+	if result.Type != signatures.SignatureTypeX509 || !result.Valid || !result.Trusted || string(result.PublicKey) != string(leaf.RawSubjectPublicKeyInfo) {
+		t.Errorf("SignatureResults[0] = %+v, want trusted X.509 result with the leaf public key", result)
+	}
+}
+
+func TestPackage_GetSecurityStatus_Checksums(t *testing.T) {
+	ctx := context.Background()
+	path := writeEncryptedTestPackage(t, ctx, false)
+	pkg := openSecurityTestPackage(t, ctx, path)
+
+	fe := pkg.(*filePackage).FileEntries[1]
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	if _, err := file.WriteAt([]byte{'X'}, fe.SourceOffset); err != nil {
+		t.Fatalf("WriteAt failed: %v", err)
+	}
+	_ = file.Close()
+
+	status, err := pkg.GetSecurityStatus(ctx, nil)
+	if err != nil {
+		t.Fatalf("GetSecurityStatus failed: %v", err)
+	}
+	if !status.HasChecksums || status.ChecksumsValid || len(status.ValidationErrors) != 1 {
+		t.Errorf("HasChecksums %v ChecksumsValid %v errors %q, want one checksum mismatch", status.HasChecksums, status.ChecksumsValid, status.ValidationErrors)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = pkg.GetSecurityStatus(cancelled, nil)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeContext)
+}
+
+func TestSecurityStatus_IgnoresSpecialFiles(t *testing.T) {
+	p := &filePackage{FileEntries: []*metadata.FileEntry{
+		{Type: uint16(fileformat.FileTypeSpecialStart), EncryptionType: fileformat.EncryptionNone},
+		{Type: 0, EncryptionType: fileformat.EncryptionQuantumSafe},
+	}}
+	status := p.securityStatus(nil)
+	if status.FileCount != 1 || status.EncryptedFiles != 1 || status.QuantumSafeFiles != 1 {
+		t.Errorf("files %d encrypted %d quantum-safe %d, want 1, 1, 1", status.FileCount, status.EncryptedFiles, status.QuantumSafeFiles)
+	}
+	if len(status.EncryptionAlgorithms) != 1 || status.EncryptionAlgorithms[0] != "ML-KEM" {
+		t.Errorf("EncryptionAlgorithms = %q, want [ML-KEM]", status.EncryptionAlgorithms)
+	}
+}
@@ -0,0 +1,142 @@
+// This file implements immutability of signed packages. Once a package carries a
+// signature, operations that modify its content are rejected until the caller
+// strips the signatures with ClearAllSignatures; a signed package retargeted to
+// another path is written there as a new, unsigned package. This file should
+// contain only the signed-package checks and signature stripping.
+//
+// Specification: api_signatures.md: 1.3 Immutability Check
+
+package novus_package
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/internal"
+	"github.com/novus-engine/novuspack/api/go/metadata"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// SignedPackageErrorContext provides typed context for errors from operations
+// rejected because the package is signed.
+type SignedPackageErrorContext struct {
+	Operation      string
+	SignatureCount int
+}
+
+// isSigned reports whether the package carries signatures.
+func (p *filePackage) isSigned() bool {
+	return (p.header != nil && p.header.SignatureOffset != 0) || (p.Info != nil && p.Info.HasSignatures)
+}
+
+// checkNotSigned returns ErrTypeSecurity if the package is signed.
+//
+// Specification: api_signatures.md: 1.3 Immutability Check
+func (p *filePackage) checkNotSigned(operation string) error {
+	if !p.isSigned() {
+		return nil
+	}
+	count := 0
+	if p.Info != nil {
+		count = p.Info.SignatureCount
+	}
+	return pkgerrors.NewPackageError(pkgerrors.ErrTypeSecurity, "package is signed and immutable: call ClearAllSignatures to modify it", nil, SignedPackageErrorContext{
+		Operation:      operation,
+		SignatureCount: count,
+	})
+}
+
+// writesUnsigned reports whether the package is signed but targets a path other
+// than the package file its signatures belong to. Writing it creates a new,
+// unsigned package and leaves the signed file unchanged.
+func (p *filePackage) writesUnsigned() bool {
+	return p.isSigned() && p.signedPath != "" && filepath.Clean(p.FilePath) != p.signedPath
+}
+
+// checkSignedWrite returns ErrTypeSecurity if writing would overwrite the signed
+// package file.
+//
+// Specification: api_writing.md: 4.2.1 Signed Package Protection
+func (p *filePackage) checkSignedWrite(operation string) error {
+	if p.writesUnsigned() {
+		return nil
+	}
+	return p.checkNotSigned(operation)
+}
+
+// stripSignaturesForWrite clears the in-memory signatures before the package is
+// written to a new path and returns a function that restores them, for use when
+// the write fails.
+func (p *filePackage) stripSignaturesForWrite() (restore func()) {
+	var offset uint64
+	var flags uint32
+	if p.header != nil {
+		offset, flags = p.header.SignatureOffset, p.header.Flags
+	}
+	var info metadata.PackageInfo
+	if p.Info != nil {
+		info = *p.Info
+	}
+	signedPath := p.signedPath
+	p.clearSignatures()
+	return func() {
+		if p.header != nil {
+			p.header.SignatureOffset, p.header.Flags = offset, flags
+		}
+		if p.Info != nil {
+			p.Info.HasSignatures = info.HasSignatures
+			p.Info.IsImmutable = info.IsImmutable
+			p.Info.Signatures = info.Signatures
+			p.Info.SignatureCount = info.SignatureCount
+		}
+		p.signedPath = signedPath
+	}
+}
+
+// setSignedFile replaces the open handle of the signed package file, closing the
+// previous one unless it is the package file handle.
+func (p *filePackage) setSignedFile(file *os.File) {
+	if p.signedFile != nil && p.signedFile != p.fileHandle && p.signedFile != file {
+		_ = p.signedFile.Close()
+	}
+	p.signedFile = file
+}
+
+// ClearAllSignatures strips all signatures from the package so it can be modified.
+//
+// Signatures are removed from the in-memory package: the header no longer
+// references them and PackageInfo lists none. The package file keeps them until
+// the package is written with Write, which produces an unsigned package.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//
+// Returns:
+//   - error: *PackageError with ErrTypeContext if the context is cancelled
+//
+// Specification: api_signatures.md: 1.1.6 Package.ClearAllSignatures Method
+func (p *filePackage) ClearAllSignatures(ctx context.Context) error {
+	if err := internal.CheckContext(ctx, "ClearAllSignatures"); err != nil {
+		return err
+	}
+	p.clearSignatures()
+	p.setSignedFile(nil)
+	return nil
+}
+
+// clearSignatures removes all signature state from the header and PackageInfo.
+func (p *filePackage) clearSignatures() {
+	p.signedPath = ""
+	if p.header != nil {
+		p.header.SignatureOffset = 0
+		p.header.Flags &^= fileformat.FlagHasSignatures
+	}
+	if p.Info != nil {
+		p.Info.HasSignatures = false
+		p.Info.IsImmutable = false
+		p.Info.Signatures = nil
+		p.Info.SignatureCount = 0
+	}
+}
@@ -0,0 +1,184 @@
+// This file contains tests for signed package immutability: content mutations on
+// signed packages, stripping signatures with ClearAllSignatures, and SetTargetPath.
+//
+// Specification: api_signatures.md: 1.3 Immutability Check
+
+package novus_package
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// signedTestPackage writes and signs a package and returns it reopened.
+func signedTestPackage(t *testing.T, ctx context.Context) Package {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	pkg := writeSigningTestPackage(t, ctx)
+	if err := pkg.Sign(ctx, private, ""); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	reopened, err := OpenPackage(ctx, pkg.(*filePackage).FilePath)
+	if err != nil {
+		t.Fatalf("OpenPackage failed: %v", err)
+	}
+	t.Cleanup(func() { _ = reopened.Close() })
+	return reopened
+}
+
+func TestSignedPackage_MutationsRejected(t *testing.T) {
+	ctx := context.Background()
+	pkg := signedTestPackage(t, ctx)
+	fp := pkg.(*filePackage)
+
+	tests := []struct {
+		name string
+		op   func() error
+	}{
+		{"AddFileFromMemory", func() error {
+			_, err := pkg.AddFileFromMemory(ctx, "/extra.txt", []byte("extra"), nil)
+			return err
+		}},
+		{"AddFile", func() error {
+			_, err := pkg.AddFile(ctx, filepath.Join(t.TempDir(), "missing.txt"), nil)
+			return err
+		}},
+		{"RemoveFile", func() error { return pkg.RemoveFile(ctx, "/patch/notes.txt") }},
+		{"SetComment", func() error { return pkg.SetComment("changed") }},
+		{"ClearComment", func() error { return pkg.ClearComment() }},
+		{"SetAppID", func() error { return pkg.SetAppID(7) }},
+		{"SetVendorID", func() error { return pkg.SetVendorID(7) }},
+		{"SetPackageIdentity", func() error { return pkg.SetPackageIdentity(1, 2) }},
+		{"Write", func() error { return pkg.Write(ctx) }},
+		{"SafeWrite", func() error { return pkg.SafeWrite(ctx, true) }},
+		{"CompressPackage", func() error { return pkg.CompressPackage(ctx, fileformat.CompressionZstd) }},
+		{"SetPassphrase", func() error { return pkg.SetPassphrase(ctx, "secret", nil) }},
+		{"AddKeyRecipient", func() error { return pkg.AddKeyRecipient(ctx, testEncryptionKey("r", 0x01)) }},
+		{"EnableConfidentialIndex", func() error { return pkg.EnableConfidentialIndex(ctx, testEncryptionKey("i", 0x02)) }},
+		{"AddDirectoryMetadata", func() error { return fp.AddDirectoryMetadata(ctx, "/patch", nil, nil, nil) }},
+		{"AssociateFileWithPath", func() error { return fp.AssociateFileWithPath(ctx, "/patch/notes.txt", "/patch/") }},
+		{"SetPathMetadata", func() error { return fp.SetPathMetadata(ctx, nil) }},
+		{"CreateSolidGroup", func() error {
+			_, err := pkg.CreateSolidGroup(ctx, []string{"/patch/notes.txt"})
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertPackageErrorType(t, tt.op(), pkgerrors.ErrTypeSecurity)
+		})
+	}
+
+	files, err := pkg.ListFiles()
+	if err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	if len(files) != 1 {
+		t.Errorf("signed package has %d files after rejected mutations, want 1", len(files))
+	}
+	if got, err := pkg.ReadFile(ctx, "/patch/notes.txt"); err != nil || string(got) != "patch 1.2.3" {
+		t.Errorf("ReadFile(signed) = %q, err = %v", got, err)
+	}
+}
+
+func TestPackage_ClearAllSignatures(t *testing.T) {
+	ctx := context.Background()
+	pkg := signedTestPackage(t, ctx)
+	path := pkg.(*filePackage).FilePath
+
+	if err := pkg.ClearAllSignatures(ctx); err != nil {
+		t.Fatalf("ClearAllSignatures failed: %v", err)
+	}
+	info, err := pkg.GetInfo()
+	if err != nil {
+		t.Fatalf("GetInfo failed: %v", err)
+	}
+	if info.HasSignatures || info.IsImmutable || info.SignatureCount != 0 {
+		t.Errorf("after ClearAllSignatures: HasSignatures %v IsImmutable %v count %d", info.HasSignatures, info.IsImmutable, info.SignatureCount)
+	}
+
+	if _, err := pkg.AddFileFromMemory(ctx, "/patch/extra.txt", []byte("extra"), nil); err != nil {
+		t.Fatalf("AddFileFromMemory after ClearAllSignatures failed: %v", err)
+	}
+	if err := pkg.Write(ctx); err != nil {
+		t.Fatalf("Write after ClearAllSignatures failed: %v", err)
+	}
+	header, err := ReadHeaderFromPath(ctx, path)
+	if err != nil {
+		t.Fatalf("ReadHeaderFromPath failed: %v", err)
+	}
+	if header.SignatureOffset != 0 || header.Flags&fileformat.FlagHasSignatures != 0 {
+		t.Errorf("rewritten header offset %d flags %#x, want unsigned", header.SignatureOffset, header.Flags)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assertPackageErrorType(t, pkg.ClearAllSignatures(cancelled), pkgerrors.ErrTypeContext)
+}
+
+func TestSetTargetPath_SignedPackage(t *testing.T) {
+	ctx := context.Background()
+	pkg := signedTestPackage(t, ctx)
+	path := pkg.(*filePackage).FilePath
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+
+	if err := pkg.SetTargetPath(ctx, path); err != nil {
+		t.Fatalf("SetTargetPath(same) failed: %v", err)
+	}
+	if info, _ := pkg.GetInfo(); !info.HasSignatures {
+		t.Error("SetTargetPath to the same path cleared signatures")
+	}
+
+	// Retargeting keeps the signatures and the content immutable
+	copyPath := filepath.Join(t.TempDir(), "copy.nvpk")
+	if err := pkg.SetTargetPath(ctx, copyPath); err != nil {
+		t.Fatalf("SetTargetPath(new) failed: %v", err)
+	}
+	if info, _ := pkg.GetInfo(); !info.HasSignatures {
+		t.Error("SetTargetPath to a new path cleared signatures before Write")
+	}
+	_, err = pkg.AddFileFromMemory(ctx, "/patch/extra.txt", []byte("extra"), nil)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeSecurity)
+	assertPackageErrorType(t, pkg.Sign(ctx, private, ""), pkgerrors.ErrTypeValidation)
+
+	// Retargeting back to the signed file restores its protection
+	if err := pkg.SetTargetPath(ctx, path); err != nil {
+		t.Fatalf("SetTargetPath(original) failed: %v", err)
+	}
+	assertPackageErrorType(t, pkg.Write(ctx), pkgerrors.ErrTypeSecurity)
+
+	if err := pkg.SetTargetPath(ctx, copyPath); err != nil {
+		t.Fatalf("SetTargetPath(new) failed: %v", err)
+	}
+	if err := pkg.Write(ctx); err != nil {
+		t.Fatalf("Write to new path failed: %v", err)
+	}
+	if header, err := ReadHeaderFromPath(ctx, path); err != nil || header.SignatureOffset == 0 {
+		t.Errorf("original package no longer signed: %+v, err = %v", header, err)
+	}
+	header, err := ReadHeaderFromPath(ctx, copyPath)
+	if err != nil {
+		t.Fatalf("ReadHeaderFromPath(copy) failed: %v", err)
+	}
+	if header.SignatureOffset != 0 || header.Flags&fileformat.FlagHasSignatures != 0 {
+		t.Errorf("copy header offset %d flags %#x, want unsigned", header.SignatureOffset, header.Flags)
+	}
+	if info, _ := pkg.GetInfo(); info.HasSignatures || info.IsImmutable {
+		t.Error("signatures kept in memory after writing the unsigned copy")
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/patch/extra.txt", []byte("extra"), nil); err != nil {
+		t.Errorf("AddFileFromMemory after writing the unsigned copy failed: %v", err)
+	}
+}
@@ -0,0 +1,416 @@
+// This file implements signature loading and verification. Signatures are read from
+// the signature block on open; Verify checks each against the package digest and a
+// caller-provided TrustStore, and ValidateWithOptions can require a number of
+// distinct trusted signers. This file should contain only signature reading,
+// verification and trust store types.
+//
+// Specification: api_signatures.md: 2.7 Signature Validation
+
+package novus_package
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/internal"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+	"github.com/novus-engine/novuspack/api/go/signatures"
+)
+
+// TrustStore provides the keys and certificates a caller trusts for signature
+// verification.
+//
+// Specification: api_signatures.md: 2.7.4 TrustStore Interface
+type TrustStore interface {
+	// PublicKeys returns the trusted public keys for key-based signatures
+	// (Ed25519 and ECDSA P-256).
+	PublicKeys() []crypto.PublicKey
+
+	// CertPool returns the trusted root certificates for certificate-based
+	// signatures, or nil if none are trusted.
+	CertPool() *x509.CertPool
+}
+
+// CurrentTimeTrustStore is an optional TrustStore extension. When
+// VerifyAtCurrentTime returns true, X.509 certificate chains are verified at the
+// current time instead of at SignatureTimestamp.
+//
+// Specification: api_signatures.md: 2.7.4.1 CurrentTimeTrustStore Interface
+type CurrentTimeTrustStore interface {
+	TrustStore
+
+	// VerifyAtCurrentTime reports whether chains are verified at the current time.
+	VerifyAtCurrentTime() bool
+}
+
+// StaticTrustStore is a TrustStore backed by fixed keys and roots.
+//
+// Specification: api_signatures.md: 2.7.4.2 StaticTrustStore Structure
+type StaticTrustStore struct {
+	Keys          []crypto.PublicKey // Trusted public keys
+	Roots         *x509.CertPool     // Trusted root certificates (optional)
+	AtCurrentTime bool               // Verify X.509 chains at the current time instead of SignatureTimestamp
+}
+
+// NewTrustStore returns a TrustStore trusting keys.
+//
+// Specification: api_signatures.md: 2.7.4.3 NewTrustStore Function
+func NewTrustStore(keys ...crypto.PublicKey) *StaticTrustStore {
+	return &StaticTrustStore{Keys: keys}
+}
+
+// PublicKeys returns the trusted public keys.
+//
+// Specification: api_signatures.md: 2.7.4.4 StaticTrustStore.PublicKeys Method
+func (s *StaticTrustStore) PublicKeys() []crypto.PublicKey {
+	if s == nil {
+		return nil
+	}
+	return s.Keys
+}
+
+// CertPool returns the trusted root certificates.
+//
+// Specification: api_signatures.md: 2.7.4.5 StaticTrustStore.CertPool Method
+func (s *StaticTrustStore) CertPool() *x509.CertPool {
+	if s == nil {
+		return nil
+	}
+	return s.Roots
+}
+
+// VerifyAtCurrentTime reports whether X.509 chains are verified at the current time.
+//
+// Specification: api_signatures.md: 2.7.4.6 StaticTrustStore.VerifyAtCurrentTime Method
+func (s *StaticTrustStore) VerifyAtCurrentTime() bool {
+	return s != nil && s.AtCurrentTime
+}
+
+// storedSignature is a signature read from the signature block with its offset.
+type storedSignature struct {
+	sig    *signatures.Signature
+	offset int64
+}
+
+// Verify checks every signature in the package file against the package digest
+// and marks it trusted if it verifies with a key from trust.
+//
+// The file is read through the handle the package was opened with, or the handle
+// Sign wrote through, never by reopening its path, so a file replaced on disk after
+// open is not what gets verified. A package without signatures in memory has none
+// to verify.
+//
+// Each returned SignatureInfo has Valid, Trusted and Error set; a signature that
+// does not verify is reported there rather than as an error. The results also
+// replace PackageInfo.Signatures and update PackageInfo.SecurityLevel. Key-based signatures carry no public key, so
+// they are valid only if a trusted key verifies them. X.509 signatures are valid
+// if their leaf certificate verifies them and trusted if the chain builds to a
+// root in trust.CertPool for code signing, at the signature time unless trust is a
+// CurrentTimeTrustStore asking for the current time. A nil trust trusts nothing.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - trust: Keys and certificates to verify against
+//
+// Returns:
+//   - []signatures.SignatureInfo: One entry per signature, in file order
+//   - error: *PackageError with ErrTypeCorruption for a malformed signature
+//     block, ErrTypeValidation if the signed package file is not open, ErrTypeIO
+//     on file errors
+//
+// Specification: api_signatures.md: 2.7.1.4 Package.Verify Method
+func (p *filePackage) Verify(ctx context.Context, trust TrustStore) ([]signatures.SignatureInfo, error) {
+	if err := internal.CheckContext(ctx, "Verify"); err != nil {
+		return nil, err
+	}
+	var stored []storedSignature
+	var header *fileformat.PackageHeader
+	var file io.ReaderAt
+	if p.isSigned() {
+		var err error
+		if file, err = p.signatureSource(); err != nil {
+			return nil, err
+		}
+		header, err = ReadHeader(ctx, io.NewSectionReader(file, 0, fileformat.PackageHeaderSize))
+		if err != nil {
+			return nil, err
+		}
+		if stored, err = readSignatureBlock(file, header); err != nil {
+			return nil, err
+		}
+	}
+
+	var keys []crypto.PublicKey
+	var roots *x509.CertPool
+	atCurrentTime := false
+	if trust != nil {
+		keys = trust.PublicKeys()
+		roots = trust.CertPool()
+	}
+	if t, ok := trust.(CurrentTimeTrustStore); ok {
+		atCurrentTime = t.VerifyAtCurrentTime()
+	}
+	infos := make([]signatures.SignatureInfo, 0, len(stored))
+	for i, s := range stored {
+		info := newSignatureInfo(i, s)
+		digest, err := signatureDigest(ctx, header, file, s.offset, s.sig)
+		if err != nil {
+			return nil, err
+		}
+		if s.sig.SignatureType == signatures.SignatureTypeX509 {
+			verifyX509Signature(&info, s.sig, digest, roots, atCurrentTime)
+		} else if key, verifyErr := verifyWithKeys(s.sig, digest, keys); verifyErr != "" {
+			info.Error = verifyErr
+		} else {
+			info.Valid = true
+			info.Trusted = true
+			info.SignerID = publicKeySignerID(key)
+		}
+		infos = append(infos, info)
+	}
+
+	if p.Info != nil {
+		p.Info.HasSignatures = len(infos) > 0
+		p.Info.Signatures = append([]signatures.SignatureInfo(nil), infos...)
+		p.Info.SignatureCount = len(infos)
+		p.Info.SecurityLevel = p.securityStatus(infos).SecurityLevel
+	}
+	return infos, nil
+}
+
+// signatureSource returns the package file the in-memory signatures belong to: the
+// file as opened, or as written by Sign. The path is not reopened, so replacing the
+// file on disk after open does not change what Verify checks.
+func (p *filePackage) signatureSource() (io.ReaderAt, error) {
+	if p.signedFile != nil {
+		return p.signedFile, nil
+	}
+	// Broken packages are opened without loading signatures and never spooled
+	if p.fileHandle != nil && p.spoolPath == "" {
+		return p.fileHandle, nil
+	}
+	return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "signed package file is not open", nil, pkgerrors.ValidationErrorContext{
+		Field:    "FilePath",
+		Value:    p.FilePath,
+		Expected: "package opened or signed in this session",
+	})
+}
+
+// ValidateWithOptions validates the package like Validate and then applies the
+// signature requirements in options. RequiredSignatures counts distinct trusted
+// signers, so repeated signatures by one key count once.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - options: Signature requirements; nil validates like Validate
+//
+// Returns:
+//   - error: *PackageError with ErrTypeSecurity if fewer distinct signers are
+//     trusted than required, ErrTypeValidation if signatures are required without a
+//     trust store, or any Validate or Verify error
+//
+// Specification: api_basic_operations.md: 15.4 Package.ValidateWithOptions Method
+func (p *filePackage) ValidateWithOptions(ctx context.Context, options *ValidateOptions) error {
+	if err := p.Validate(ctx); err != nil {
+		return err
+	}
+	if options == nil || options.RequiredSignatures <= 0 {
+		return nil
+	}
+	if options.TrustStore == nil {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "required signatures need a trust store", nil, pkgerrors.ValidationErrorContext{
+			Field:    "TrustStore",
+			Value:    nil,
+			Expected: "non-nil trust store",
+		})
+	}
+	infos, err := p.Verify(ctx, options.TrustStore)
+	if err != nil {
+		return err
+	}
+	trusted := trustedSigners(infos)
+	if trusted < options.RequiredSignatures {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeSecurity, "not enough trusted signers", nil, pkgerrors.ValidationErrorContext{
+			Field:    "Signatures",
+			Value:    trusted,
+			Expected: fmt.Sprintf("signatures from at least %d distinct trusted signers", options.RequiredSignatures),
+		})
+	}
+	return nil
+}
+
+// loadSignatures reads the signature block of an opened package into PackageInfo.
+// Signatures are not verified until Verify.
+func (p *filePackage) loadSignatures(r io.ReaderAt, header *fileformat.PackageHeader) error {
+	stored, err := readSignatureBlock(r, header)
+	if err != nil {
+		return err
+	}
+	p.Info.HasSignatures = len(stored) > 0
+	p.Info.IsImmutable = header.SignatureOffset != 0
+	p.Info.Signatures = make([]signatures.SignatureInfo, 0, len(stored))
+	for i, s := range stored {
+		p.Info.Signatures = append(p.Info.Signatures, newSignatureInfo(i, s))
+	}
+	p.Info.SignatureCount = len(p.Info.Signatures)
+	return nil
+}
+
+// newSignatureInfo describes the stored signature at index.
+func newSignatureInfo(index int, s storedSignature) signatures.SignatureInfo {
+	info := signatures.SignatureInfo{
+		Index:     index,
+		Type:      s.sig.SignatureType,
+		Size:      s.sig.SignatureSize,
+		Offset:    uint64(s.offset),
+		Flags:     s.sig.SignatureFlags,
+		Timestamp: s.sig.SignatureTimestamp,
+		Comment:   s.sig.SignatureComment,
+		Algorithm: signatureAlgorithmName(s.sig.SignatureType),
+	}
+	if s.sig.SignatureType == signatures.SignatureTypeX509 {
+		if _, certs, err := parseX509SignatureData(s.sig.SignatureData); err == nil {
+			info.Subject = certs[0].Subject.String()
+			info.CertificateChain = certs
+		}
+	}
+	return info
+}
+
+// readSignatureBlock reads the signatures stored sequentially from SignatureOffset
+// to the end of r.
+//
+// Specification: api_signatures.md: 1.2.3 Key Implementation Points
+func readSignatureBlock(r io.ReaderAt, header *fileformat.PackageHeader) ([]storedSignature, error) {
+	if header.SignatureOffset == 0 {
+		return nil, nil
+	}
+	end, err := readerSize(r)
+	if err != nil {
+		return nil, err
+	}
+	var stored []storedSignature
+	for offset := int64(header.SignatureOffset); offset < end; {
+		sig, n, err := readSignatureAt(r, offset, end)
+		if err != nil {
+			return nil, err
+		}
+		stored = append(stored, storedSignature{sig: sig, offset: offset})
+		offset += n
+	}
+	if len(stored) == 0 {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "signature offset past end of file", nil, pkgerrors.ValidationErrorContext{
+			Field:    "SignatureOffset",
+			Value:    header.SignatureOffset,
+			Expected: fmt.Sprintf("less than file size %d", end),
+		})
+	}
+	return stored, nil
+}
+
+// readSignatureAt reads the signature at offset and returns it with its size.
+// Reads are bounded by end, so a signature running past it is truncated.
+func readSignatureAt(r io.ReaderAt, offset, end int64) (*signatures.Signature, int64, error) {
+	sig := signatures.NewSignature()
+	n, err := sig.ReadFrom(io.NewSectionReader(r, offset, end-offset))
+	if err != nil {
+		return nil, 0, err
+	}
+	return sig, n, nil
+}
+
+// readerSize returns the size of r, which must be a file or have a Size method.
+func readerSize(r io.ReaderAt) (int64, error) {
+	switch v := r.(type) {
+	case interface{ Stat() (os.FileInfo, error) }:
+		info, err := v.Stat()
+		if err != nil {
+			return 0, pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to stat package file")
+		}
+		return info.Size(), nil
+	case interface{ Size() int64 }:
+		return v.Size(), nil
+	default:
+		return 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "package reader has no size", nil, struct{}{})
+	}
+}
+
+// verifyWithKeys verifies sig over digest with each key of the matching type and
+// returns the key that verified it, or the reason none did.
+func verifyWithKeys(sig *signatures.Signature, digest []byte, keys []crypto.PublicKey) (crypto.PublicKey, string) {
+	if sig.SignatureType != signatures.SignatureTypeEd25519 && sig.SignatureType != signatures.SignatureTypeECDSAP256 {
+		return nil, fmt.Sprintf("unsupported signature type %s", signatureAlgorithmName(sig.SignatureType))
+	}
+	candidates := 0
+	for _, key := range keys {
+		if t, err := signerSignatureType(key); err != nil || t != sig.SignatureType {
+			continue
+		}
+		candidates++
+		if verifySignatureData(key, sig.SignatureType, digest, sig.SignatureData) {
+			return key, ""
+		}
+	}
+	if candidates == 0 {
+		return nil, fmt.Sprintf("no trusted %s key", signatureAlgorithmName(sig.SignatureType))
+	}
+	return nil, "signature does not verify with any trusted key"
+}
+
+// publicKeySignerID returns the SignerID of key, or an empty string if key has no
+// PKIX encoding.
+func publicKeySignerID(key crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return ""
+	}
+	return signerID(der)
+}
+
+// signerID returns the SignerID of a DER-encoded PKIX public key.
+func signerID(spki []byte) string {
+	sum := sha256.Sum256(spki)
+	return hex.EncodeToString(sum[:])
+}
+
+// trustedSigners returns the number of distinct signers among the valid, trusted
+// signatures in infos.
+func trustedSigners(infos []signatures.SignatureInfo) int {
+	signers := make(map[string]struct{})
+	for _, info := range infos {
+		if info.Valid && info.Trusted && info.SignerID != "" {
+			signers[info.SignerID] = struct{}{}
+		}
+	}
+	return len(signers)
+}
+
+// verifySignatureData reports whether data is a valid signature of digest by key.
+func verifySignatureData(key crypto.PublicKey, signatureType uint32, digest, data []byte) bool {
+	switch signatureType {
+	case signatures.SignatureTypeEd25519:
+		pub, ok := key.(ed25519.PublicKey)
+		return ok && ed25519.Verify(pub, digest, data)
+	case signatures.SignatureTypeECDSAP256:
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || pub.Curve != elliptic.P256() || len(data) != signatures.SignatureSizeECDSAP256 {
+			return false
+		}
+		r := new(big.Int).SetBytes(data[:32])
+		s := new(big.Int).SetBytes(data[32:])
+		return ecdsa.Verify(pub, digest, r, s)
+	default:
+		return false
+	}
+}
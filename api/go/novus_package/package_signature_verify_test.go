@@ -0,0 +1,295 @@
+// This file contains tests for signature loading and verification: signatures
+// loaded on open, Verify against a trust store, tampering, and required trusted
+// signatures in ValidateWithOptions.
+//
+// Specification: api_signatures.md: 2.7 Signature Validation
+
+package novus_package
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"os"
+	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+func TestPackage_Verify(t *testing.T) {
+	ctx := context.Background()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	otherPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+
+	pkg := writeSigningTestPackage(t, ctx)
+	if err := pkg.Sign(ctx, private, "publisher"); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	path := pkg.(*filePackage).FilePath
+
+	reopened, err := OpenPackage(ctx, path)
+	if err != nil {
+		t.Fatalf("OpenPackage failed: %v", err)
+	}
+	defer func() { _ = reopened.Close() }()
+	info, err := reopened.GetInfo()
+	if err != nil {
+		t.Fatalf("GetInfo failed: %v", err)
+	}
+	if info.SignatureCount != 1 || info.Signatures[0].Comment != "publisher" || info.Signatures[0].Algorithm != "Ed25519" {
+		t.Fatalf("loaded signatures = %d %+v", info.SignatureCount, info.Signatures)
+	}
+	if info.Signatures[0].Valid || info.Signatures[0].Trusted {
+		t.Error("signature marked valid before Verify")
+	}
+
+	tests := []struct {
+		name    string
+		trust   TrustStore
+		trusted bool
+		errText string
+	}{
+		{"trusted key", NewTrustStore(otherPublic, public), true, ""},
+		{"other key", NewTrustStore(otherPublic), false, "signature does not verify with any trusted key"},
+		{"other key type", NewTrustStore(&ecdsaKey.PublicKey), false, "no trusted Ed25519 key"},
+		{"nil trust store", nil, false, "no trusted Ed25519 key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			infos, err := reopened.Verify(ctx, tt.trust)
+			if err != nil {
+				t.Fatalf("Verify failed: %v", err)
+			}
+			if len(infos) != 1 || infos[0].Valid != tt.trusted || infos[0].Trusted != tt.trusted || infos[0].Error != tt.errText {
+				t.Errorf("Verify = %+v, want trusted %v error %q", infos, tt.trusted, tt.errText)
+			}
+		})
+	}
+	if _, err := reopened.Verify(ctx, NewTrustStore(public)); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if info, _ := reopened.GetInfo(); !info.Signatures[0].Trusted {
+		t.Errorf("PackageInfo not updated by Verify: %+v", info.Signatures)
+	}
+
+	// Tampering with the signed content invalidates the signature
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	raw[bytes.Index(raw, []byte("patch 1.2.3"))] ^= 0xFF
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	infos, err := reopened.Verify(ctx, NewTrustStore(public))
+	if err != nil {
+		t.Fatalf("Verify(tampered) failed: %v", err)
+	}
+	if infos[0].Valid || infos[0].Trusted {
+		t.Error("tampered package signature verifies")
+	}
+}
+
+func TestPackage_Verify_ECDSAP256(t *testing.T) {
+	ctx := context.Background()
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	pkg := writeSigningTestPackage(t, ctx)
+	if err := pkg.Sign(ctx, private, ""); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	infos, err := pkg.Verify(ctx, NewTrustStore(private.Public()))
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if len(infos) != 1 || !infos[0].Valid || !infos[0].Trusted {
+		t.Errorf("Verify = %+v, want one trusted signature", infos)
+	}
+}
+
+func TestPackage_ValidateWithOptions_RequiredSignatures(t *testing.T) {
+	ctx := context.Background()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	trust := NewTrustStore(public)
+
+	unsigned := writeSigningTestPackage(t, ctx)
+	path := unsigned.(*filePackage).FilePath
+	opened, err := OpenPackage(ctx, path)
+	if err != nil {
+		t.Fatalf("OpenPackage failed: %v", err)
+	}
+	defer func() { _ = opened.Close() }()
+	if err := opened.ValidateWithOptions(ctx, nil); err != nil {
+		t.Errorf("ValidateWithOptions(nil) = %v", err)
+	}
+	assertPackageErrorType(t, opened.ValidateWithOptions(ctx, &ValidateOptions{TrustStore: trust, RequiredSignatures: 1}), pkgerrors.ErrTypeSecurity)
+	assertPackageErrorType(t, opened.ValidateWithOptions(ctx, &ValidateOptions{RequiredSignatures: 1}), pkgerrors.ErrTypeValidation)
+
+	if err := unsigned.Sign(ctx, private, ""); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	signed, err := OpenPackageReadOnly(ctx, path)
+	if err != nil {
+		t.Fatalf("OpenPackageReadOnly failed: %v", err)
+	}
+	defer func() { _ = signed.Close() }()
+	if err := signed.ValidateWithOptions(ctx, &ValidateOptions{TrustStore: trust, RequiredSignatures: 1}); err != nil {
+		t.Errorf("ValidateWithOptions(1 required) = %v", err)
+	}
+	assertPackageErrorType(t, signed.ValidateWithOptions(ctx, &ValidateOptions{TrustStore: trust, RequiredSignatures: 2}), pkgerrors.ErrTypeSecurity)
+	untrusted := NewTrustStore(crypto.PublicKey(ed25519.PublicKey(make([]byte, ed25519.PublicKeySize))))
+	assertPackageErrorType(t, signed.ValidateWithOptions(ctx, &ValidateOptions{TrustStore: untrusted, RequiredSignatures: 1}), pkgerrors.ErrTypeSecurity)
+}
+
+func TestPackage_ValidateWithOptions_DistinctSigners(t *testing.T) {
+	ctx := context.Background()
+	publicA, privateA, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	publicB, privateB, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	trust := NewTrustStore(publicA, publicB)
+	required := &ValidateOptions{TrustStore: trust, RequiredSignatures: 2}
+
+	pkg := writeSigningTestPackage(t, ctx)
+	for _, comment := range []string{"first", "second"} {
+		if err := pkg.Sign(ctx, privateA, comment); err != nil {
+			t.Fatalf("Sign(%s) failed: %v", comment, err)
+		}
+	}
+	infos, err := pkg.Verify(ctx, trust)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if len(infos) != 2 || !infos[0].Trusted || !infos[1].Trusted || infos[0].SignerID != infos[1].SignerID {
+		t.Fatalf("Verify = %+v, want two trusted signatures by one signer", infos)
+	}
+	assertPackageErrorType(t, pkg.ValidateWithOptions(ctx, required), pkgerrors.ErrTypeSecurity)
+
+	if err := pkg.Sign(ctx, privateB, "third"); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if err := pkg.ValidateWithOptions(ctx, required); err != nil {
+		t.Errorf("ValidateWithOptions(2 signers) = %v", err)
+	}
+}
+
+func TestReadSignatureBlock_Corruption(t *testing.T) {
+	ctx := context.Background()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	pkg := writeSigningTestPackage(t, ctx)
+	if err := pkg.Sign(ctx, private, "truncated"); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	path := pkg.(*filePackage).FilePath
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if err := os.WriteFile(path, raw[:len(raw)-10], 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	_, err = OpenPackage(ctx, path)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption)
+	_, err = pkg.Verify(ctx, nil)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption)
+}
+
+func TestPackage_Verify_ReplacedFile(t *testing.T) {
+	ctx := context.Background()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	signedPackage := func() string {
+		pkg := writeSigningTestPackage(t, ctx)
+		if err := pkg.Sign(ctx, private, ""); err != nil {
+			t.Fatalf("Sign failed: %v", err)
+		}
+		return pkg.(*filePackage).FilePath
+	}
+	unsignedPackage := func() string {
+		return writeSigningTestPackage(t, ctx).(*filePackage).FilePath
+	}
+
+	tests := []struct {
+		name        string
+		opened      string
+		replacement string
+		trusted     int
+	}{
+		{"signed package replaced by unsigned", signedPackage(), unsignedPackage(), 1},
+		{"unsigned package replaced by signed", unsignedPackage(), signedPackage(), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg, err := OpenPackage(ctx, tt.opened)
+			if err != nil {
+				t.Fatalf("OpenPackage failed: %v", err)
+			}
+			defer func() { _ = pkg.Close() }()
+			// Verify checks the package as opened, not the file now at its path
+			if err := os.Rename(tt.replacement, tt.opened); err != nil {
+				t.Fatalf("Rename failed: %v", err)
+			}
+			infos, err := pkg.Verify(ctx, NewTrustStore(public))
+			if err != nil {
+				t.Fatalf("Verify failed: %v", err)
+			}
+			if got := trustedSigners(infos); got != tt.trusted {
+				t.Errorf("trusted signers = %d, want %d (%+v)", got, tt.trusted, infos)
+			}
+		})
+	}
+}
+
+func TestPackage_Verify_CompressedPackage(t *testing.T) {
+	ctx := context.Background()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	pkg := newPackageCompressionTestPackage(t, ctx, packageCompressionTestFiles())
+	if err := pkg.CompressPackage(ctx, fileformat.CompressionZstd); err != nil {
+		t.Fatalf("CompressPackage failed: %v", err)
+	}
+	compressed := writeAndReopen(t, ctx, pkg)
+	if err := compressed.Sign(ctx, private, ""); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if infos, err := compressed.Verify(ctx, NewTrustStore(public)); err != nil || trustedSigners(infos) != 1 {
+		t.Fatalf("Verify after Sign = %+v, err = %v", infos, err)
+	}
+
+	// The signatures cover the compressed file, not the decompressed spool
+	reopened := reopenPackage(t, ctx, compressed)
+	if infos, err := reopened.Verify(ctx, NewTrustStore(public)); err != nil || trustedSigners(infos) != 1 {
+		t.Errorf("Verify after reopen = %+v, err = %v", infos, err)
+	}
+}
@@ -0,0 +1,295 @@
+// This file implements package signing. Sign computes the package digest over the
+// written package file and appends an Ed25519 or ECDSA P-256 signature at
+// SignatureOffset. This file should contain only signature creation, the package
+// digest and signature serialization.
+//
+// Specification: api_signatures.md: 2.8 Existing Package Signing
+
+package novus_package
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/internal"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+	"github.com/novus-engine/novuspack/api/go/signatures"
+)
+
+// Sign signs the package file as last written and appends the signature.
+//
+// The signature covers the package header with SignatureOffset zeroed, all content
+// up to the signature including earlier signatures, and the signature's own
+// metadata and comment. The first signature sets the has-signatures flag and
+// SignatureOffset in the header; later signatures are appended after it and leave
+// earlier signatures valid. Unwritten changes must be written with Write first.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - signer: Ed25519 or ECDSA P-256 private key
+//   - comment: Human-readable signature comment; empty for none
+//
+// Returns:
+//   - error: *PackageError with ErrTypeUnsupported for other key types,
+//     ErrTypeValidation if the package has no written file, ErrTypeIO on file
+//     errors
+//
+// Specification: api_signatures.md: 2.8.1.3 Package.Sign Method
+func (p *filePackage) Sign(ctx context.Context, signer crypto.Signer, comment string) error {
+	if err := internal.CheckContext(ctx, "Sign"); err != nil {
+		return err
+	}
+	if signer == nil {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "signer cannot be nil", nil, pkgerrors.ValidationErrorContext{
+			Field:    "signer",
+			Expected: "Ed25519 or ECDSA P-256 private key",
+		})
+	}
+	signatureType, err := signerSignatureType(signer.Public())
+	if err != nil {
+		return err
+	}
+	return p.appendSignature(ctx, signatureType, signatureDataSize(signatureType), comment, func(digest []byte) ([]byte, error) {
+		return signDigest(signer, signatureType, digest)
+	})
+}
+
+// appendSignature appends a signature of signatureType with size bytes of
+// signature data to the package file. sign is called with the package digest and
+// must return exactly size bytes.
+func (p *filePackage) appendSignature(ctx context.Context, signatureType, size uint32, comment string, sign func(digest []byte) ([]byte, error)) error {
+	if len(comment) > 0xFFFF {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "signature comment too long", nil, pkgerrors.ValidationErrorContext{
+			Field:    "comment",
+			Value:    len(comment),
+			Expected: "at most 65535 bytes",
+		})
+	}
+	if p.FilePath == "" {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "package has no file path configured", nil, struct{}{})
+	}
+	// The signatures in memory belong to another file until the package is written
+	if p.writesUnsigned() {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "signed package must be written to its target path before signing", nil, pkgerrors.ValidationErrorContext{
+			Field:    "FilePath",
+			Value:    p.FilePath,
+			Expected: "written package file",
+		})
+	}
+
+	file, err := os.OpenFile(p.FilePath, os.O_RDWR, 0)
+	if err != nil {
+		return pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeIO, "failed to open package file for signing", pkgerrors.ValidationErrorContext{
+			Field:    "FilePath",
+			Value:    p.FilePath,
+			Expected: "written package file",
+		})
+	}
+	signed := false
+	defer func() {
+		if !signed {
+			_ = file.Close()
+		}
+	}()
+
+	header, err := ReadHeader(ctx, io.NewSectionReader(file, 0, fileformat.PackageHeaderSize))
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to stat package file")
+	}
+	offset := info.Size()
+
+	// The first signature sets the flag and offset before signing so the signature
+	// covers the flag; later signatures leave the header unchanged
+	first := header.SignatureOffset == 0
+	if first {
+		header.Flags |= fileformat.FlagHasSignatures
+		header.SignatureOffset = uint64(offset)
+	}
+
+	sig := &signatures.Signature{
+		SignatureType:      signatureType,
+		SignatureSize:      size,
+		SignatureFlags:     signatures.SignatureFlagHasTimestamp,
+		SignatureTimestamp: uint32(time.Now().Unix()),
+		CommentLength:      uint16(len(comment)),
+		SignatureComment:   comment,
+	}
+	digest, err := signatureDigest(ctx, header, file, offset, sig)
+	if err != nil {
+		return err
+	}
+	if sig.SignatureData, err = sign(digest); err != nil {
+		return err
+	}
+	if len(sig.SignatureData) != int(size) {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeSecurity, "signature data size does not match SignatureSize", nil, pkgerrors.ValidationErrorContext{
+			Field:    "SignatureData",
+			Value:    len(sig.SignatureData),
+			Expected: fmt.Sprintf("%d bytes", size),
+		})
+	}
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to seek to signature offset")
+	}
+	if _, err := sig.WriteTo(file); err != nil {
+		return err
+	}
+	if first {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to seek to header")
+		}
+		if _, err := writePackageHeader(file, header); err != nil {
+			return err
+		}
+	}
+	if err := file.Sync(); err != nil {
+		return pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to sync signed package file")
+	}
+
+	if p.header != nil {
+		p.header.Flags = header.Flags
+		p.header.SignatureOffset = header.SignatureOffset
+	}
+	p.recordSignature(sig, uint64(offset))
+	// Verify reads the signed bytes through this handle rather than the path
+	p.signedPath = filepath.Clean(p.FilePath)
+	p.setSignedFile(file)
+	signed = true
+	return nil
+}
+
+// recordSignature adds sig, stored at offset, to the signature list in PackageInfo.
+func (p *filePackage) recordSignature(sig *signatures.Signature, offset uint64) {
+	if p.Info == nil {
+		return
+	}
+	p.Info.HasSignatures = true
+	p.Info.IsImmutable = true
+	p.Info.Signatures = append(p.Info.Signatures, signatures.SignatureInfo{
+		Index:     len(p.Info.Signatures),
+		Type:      sig.SignatureType,
+		Size:      sig.SignatureSize,
+		Offset:    offset,
+		Flags:     sig.SignatureFlags,
+		Timestamp: sig.SignatureTimestamp,
+		Comment:   sig.SignatureComment,
+		Algorithm: signatureAlgorithmName(sig.SignatureType),
+	})
+	p.Info.SignatureCount = len(p.Info.Signatures)
+}
+
+// signerSignatureType returns the signature type for a signer's public key.
+func signerSignatureType(public crypto.PublicKey) (uint32, error) {
+	switch key := public.(type) {
+	case ed25519.PublicKey:
+		return signatures.SignatureTypeEd25519, nil
+	case *ecdsa.PublicKey:
+		if key.Curve == elliptic.P256() {
+			return signatures.SignatureTypeECDSAP256, nil
+		}
+	}
+	return 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeUnsupported, "unsupported signing key", nil, pkgerrors.ValidationErrorContext{
+		Field:    "signer",
+		Value:    fmt.Sprintf("%T", public),
+		Expected: "Ed25519 or ECDSA P-256 key",
+	})
+}
+
+// signatureDataSize returns the fixed signature data size of signatureType.
+func signatureDataSize(signatureType uint32) uint32 {
+	switch signatureType {
+	case signatures.SignatureTypeEd25519:
+		return signatures.SignatureSizeEd25519
+	case signatures.SignatureTypeECDSAP256:
+		return signatures.SignatureSizeECDSAP256
+	default:
+		return 0
+	}
+}
+
+// signatureAlgorithmName returns a display name for signatureType.
+func signatureAlgorithmName(signatureType uint32) string {
+	switch signatureType {
+	case signatures.SignatureTypeMLDSA:
+		return "ML-DSA"
+	case signatures.SignatureTypeSLHDSA:
+		return "SLH-DSA"
+	case signatures.SignatureTypePGP:
+		return "PGP"
+	case signatures.SignatureTypeX509:
+		return "X.509"
+	case signatures.SignatureTypeEd25519:
+		return "Ed25519"
+	case signatures.SignatureTypeECDSAP256:
+		return "ECDSA P-256"
+	default:
+		return fmt.Sprintf("unknown (0x%02X)", signatureType)
+	}
+}
+
+// signDigest signs digest with signer. ECDSA signatures are converted from ASN.1
+// to the fixed-size r||s encoding.
+func signDigest(signer crypto.Signer, signatureType uint32, digest []byte) ([]byte, error) {
+	opts := crypto.SignerOpts(crypto.SHA256)
+	if signatureType == signatures.SignatureTypeEd25519 {
+		opts = crypto.Hash(0)
+	}
+	data, err := signer.Sign(rand.Reader, digest, opts)
+	if err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeSecurity, "failed to sign package digest")
+	}
+	if signatureType != signatures.SignatureTypeECDSAP256 {
+		return data, nil
+	}
+	var parsed struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(data, &parsed); err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeSecurity, "invalid ECDSA signature from signer")
+	}
+	raw := make([]byte, signatures.SignatureSizeECDSAP256)
+	parsed.R.FillBytes(raw[:32])
+	parsed.S.FillBytes(raw[32:])
+	return raw, nil
+}
+
+// signatureDigest returns the SHA-256 digest a signature at offset signs: the
+// header with SignatureOffset zeroed, the file content from the end of the header
+// to offset, and the signature metadata header and comment.
+//
+// Specification: package_file_format.md: 8.5 Signed Data
+func signatureDigest(ctx context.Context, header *fileformat.PackageHeader, r io.ReaderAt, offset int64, sig *signatures.Signature) ([]byte, error) {
+	h := sha256.New()
+	signedHeader := *header
+	signedHeader.SignatureOffset = 0
+	if _, err := writePackageHeader(h, &signedHeader); err != nil {
+		return nil, err
+	}
+	content := io.NewSectionReader(r, fileformat.PackageHeaderSize, offset-fileformat.PackageHeaderSize)
+	if err := internal.CheckContext(ctx, "Sign"); err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(h, content); err != nil {
+		return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to read package content for signature")
+	}
+	if _, err := sig.WriteMetadataTo(h); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,238 @@
+// This file contains tests for package signing: Ed25519 and ECDSA P-256 signatures
+// appended at SignatureOffset, the signed digest, header flags and signing errors.
+//
+// Specification: api_signatures.md: 2.8 Existing Package Signing
+
+package novus_package
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+	"github.com/novus-engine/novuspack/api/go/signatures"
+)
+
+// writeSigningTestPackage writes a package with one file and returns it open.
+func writeSigningTestPackage(t *testing.T, ctx context.Context) Package {
+	t.Helper()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	if err := pkg.Create(ctx, filepath.Join(t.TempDir(), "patch.nvpk")); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/patch/notes.txt", []byte("patch 1.2.3"), nil); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	if err := pkg.Write(ctx); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	t.Cleanup(func() { _ = pkg.Close() })
+	return pkg
+}
+
+// readTestSignature reads the header and the signature at SignatureOffset of the
+// package file at path and returns the digest that signature signs.
+func readTestSignature(t *testing.T, ctx context.Context, path string) (*signatures.Signature, []byte) {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+	header, err := ReadHeader(ctx, file)
+	if err != nil {
+		t.Fatalf("ReadHeader failed: %v", err)
+	}
+	if header.SignatureOffset == 0 || header.Flags&fileformat.FlagHasSignatures == 0 {
+		t.Fatalf("header not signed: offset %d flags %#x", header.SignatureOffset, header.Flags)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	sig := signatures.NewSignature()
+	if _, err := sig.ReadFrom(bytes.NewReader(raw[header.SignatureOffset:])); err != nil {
+		t.Fatalf("ReadFrom failed: %v", err)
+	}
+	digest, err := signatureDigest(ctx, header, file, int64(header.SignatureOffset), sig)
+	if err != nil {
+		t.Fatalf("signatureDigest failed: %v", err)
+	}
+	return sig, digest
+}
+
+func TestPackage_Sign_Ed25519(t *testing.T) {
+	ctx := context.Background()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	pkg := writeSigningTestPackage(t, ctx)
+	if err := pkg.Sign(ctx, private, "release build"); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	path := pkg.(*filePackage).FilePath
+	sig, digest := readTestSignature(t, ctx, path)
+	if sig.SignatureType != signatures.SignatureTypeEd25519 || sig.SignatureComment != "release build" {
+		t.Errorf("signature type %d comment %q", sig.SignatureType, sig.SignatureComment)
+	}
+	if sig.SignatureFlags&signatures.SignatureFlagHasTimestamp == 0 || sig.SignatureTimestamp == 0 {
+		t.Errorf("signature timestamp not set: flags %#x timestamp %d", sig.SignatureFlags, sig.SignatureTimestamp)
+	}
+	if !ed25519.Verify(public, digest, sig.SignatureData) {
+		t.Error("Ed25519 signature does not verify against the package digest")
+	}
+
+	info, err := pkg.GetInfo()
+	if err != nil {
+		t.Fatalf("GetInfo failed: %v", err)
+	}
+	if !info.HasSignatures || info.SignatureCount != 1 || info.Signatures[0].Algorithm != "Ed25519" {
+		t.Errorf("GetInfo signatures = %v %d %+v", info.HasSignatures, info.SignatureCount, info.Signatures)
+	}
+
+	reopened, err := OpenPackage(ctx, path)
+	if err != nil {
+		t.Fatalf("OpenPackage(signed) failed: %v", err)
+	}
+	defer func() { _ = reopened.Close() }()
+	if info, _ := reopened.GetInfo(); !info.HasSignatures {
+		t.Error("reopened package has no signatures")
+	}
+	if got, err := reopened.ReadFile(ctx, "/patch/notes.txt"); err != nil || string(got) != "patch 1.2.3" {
+		t.Errorf("ReadFile(signed) = %q, err = %v", got, err)
+	}
+
+	// Any change to the signed content changes the digest
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	i := bytes.Index(raw, []byte("patch 1.2.3"))
+	if i < 0 {
+		t.Fatal("file data not found in package")
+	}
+	raw[i] ^= 0xFF
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	sig, digest = readTestSignature(t, ctx, path)
+	if ed25519.Verify(public, digest, sig.SignatureData) {
+		t.Error("signature verifies after the content was modified")
+	}
+}
+
+func TestPackage_Sign_ECDSAP256(t *testing.T) {
+	ctx := context.Background()
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	pkg := writeSigningTestPackage(t, ctx)
+	if err := pkg.Sign(ctx, private, ""); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	sig, digest := readTestSignature(t, ctx, pkg.(*filePackage).FilePath)
+	if sig.SignatureType != signatures.SignatureTypeECDSAP256 || sig.SignatureSize != signatures.SignatureSizeECDSAP256 {
+		t.Errorf("signature type %d size %d", sig.SignatureType, sig.SignatureSize)
+	}
+	r := new(big.Int).SetBytes(sig.SignatureData[:32])
+	s := new(big.Int).SetBytes(sig.SignatureData[32:])
+	if !ecdsa.Verify(&private.PublicKey, digest, r, s) {
+		t.Error("ECDSA signature does not verify against the package digest")
+	}
+}
+
+func TestPackage_Sign_Errors(t *testing.T) {
+	ctx := context.Background()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+
+	unwritten, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	assertPackageErrorType(t, unwritten.Sign(ctx, private, ""), pkgerrors.ErrTypeValidation)
+
+	pkg := writeSigningTestPackage(t, ctx)
+	assertPackageErrorType(t, pkg.Sign(ctx, nil, ""), pkgerrors.ErrTypeValidation)
+	assertPackageErrorType(t, pkg.Sign(ctx, p384, ""), pkgerrors.ErrTypeUnsupported)
+	assertPackageErrorType(t, pkg.Sign(ctx, private, string(make([]byte, 0x10000))), pkgerrors.ErrTypeValidation)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assertPackageErrorType(t, pkg.Sign(cancelled, private, ""), pkgerrors.ErrTypeContext)
+}
+
+func TestPackage_Sign_Incremental(t *testing.T) {
+	ctx := context.Background()
+	firstPublic, firstPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	second, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	pkg := writeSigningTestPackage(t, ctx)
+	path := pkg.(*filePackage).FilePath
+	if err := pkg.Sign(ctx, firstPrivate, "publisher"); err != nil {
+		t.Fatalf("Sign(first) failed: %v", err)
+	}
+	firstHeader, err := ReadHeaderFromPath(ctx, path)
+	if err != nil {
+		t.Fatalf("ReadHeaderFromPath failed: %v", err)
+	}
+
+	reopened, err := OpenPackage(ctx, path)
+	if err != nil {
+		t.Fatalf("OpenPackage failed: %v", err)
+	}
+	defer func() { _ = reopened.Close() }()
+	if err := reopened.Sign(ctx, second, "distributor"); err != nil {
+		t.Fatalf("Sign(second) failed: %v", err)
+	}
+
+	header, err := ReadHeaderFromPath(ctx, path)
+	if err != nil {
+		t.Fatalf("ReadHeaderFromPath failed: %v", err)
+	}
+	if *header != *firstHeader {
+		t.Errorf("second signature changed the header: %+v, want %+v", header, firstHeader)
+	}
+	infos, err := reopened.Verify(ctx, NewTrustStore(firstPublic, second.Public()))
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if len(infos) != 2 || infos[0].Comment != "publisher" || infos[1].Comment != "distributor" {
+		t.Fatalf("Verify = %+v, want publisher and distributor signatures", infos)
+	}
+	for _, info := range infos {
+		if !info.Valid || !info.Trusted {
+			t.Errorf("signature %d (%s) does not verify: %s", info.Index, info.Comment, info.Error)
+		}
+	}
+	if infos[1].Offset <= infos[0].Offset {
+		t.Errorf("second signature offset %d not after first %d", infos[1].Offset, infos[0].Offset)
+	}
+}
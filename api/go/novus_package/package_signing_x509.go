@@ -0,0 +1,272 @@
+// This file implements X.509 certificate-chain signatures. SignWithCertificate
+// appends an X.509 signature whose SignatureData embeds the signer's certificate
+// chain and the detached signature of the package digest; verification checks the
+// signature with the leaf certificate and builds the chain to trusted roots. This
+// file should contain only X.509 signature creation, encoding and verification.
+//
+// Specification: api_signatures.md: 2.6 X.509/PKCS#7 Implementation
+
+package novus_package
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"time"
+
+	"github.com/novus-engine/novuspack/api/go/internal"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+	"github.com/novus-engine/novuspack/api/go/signatures"
+)
+
+// x509SignatureDataVersion is the version of the X.509 SignatureData structure.
+const x509SignatureDataVersion = 1
+
+// Signature algorithm identifiers used in X.509 SignatureData.
+var (
+	oidSignatureEd25519         = asn1.ObjectIdentifier{1, 3, 101, 112}
+	oidSignatureECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidSignatureSHA256WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+)
+
+// x509SignatureData is the DER-encoded SignatureData of an X.509 signature: a
+// detached, CMS-like structure carrying the certificate chain (leaf first), the
+// signature algorithm and the signature of the package digest.
+//
+// Specification: package_file_format.md: 8.2.5 X.509 Signature Data
+type x509SignatureData struct {
+	Version      int
+	Certificates []asn1.RawValue
+	Algorithm    asn1.ObjectIdentifier
+	Signature    []byte
+}
+
+// SignWithCertificate signs the package file as last written with a certificate
+// chain and appends an X.509 signature.
+//
+// The signature covers the same data as Sign. SignatureData embeds chain, leaf
+// first, followed by any intermediates; the root is supplied by the verifier.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - signer: Private key of the leaf certificate (Ed25519, ECDSA P-256 or RSA)
+//   - chain: Leaf certificate followed by intermediate certificates
+//   - comment: Human-readable signature comment; empty for none
+//
+// Returns:
+//   - error: *PackageError with ErrTypeValidation if the chain is empty or the
+//     signer does not match the leaf certificate, ErrTypeUnsupported for other key
+//     types, ErrTypeIO on file errors
+//
+// Specification: api_signatures.md: 2.8.3.3 Package.SignWithCertificate Method
+func (p *filePackage) SignWithCertificate(ctx context.Context, signer crypto.Signer, chain []*x509.Certificate, comment string) error {
+	if err := internal.CheckContext(ctx, "SignWithCertificate"); err != nil {
+		return err
+	}
+	if signer == nil {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "signer cannot be nil", nil, pkgerrors.ValidationErrorContext{
+			Field:    "signer",
+			Expected: "private key of the leaf certificate",
+		})
+	}
+	if len(chain) == 0 || chain[0] == nil {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "certificate chain cannot be empty", nil, pkgerrors.ValidationErrorContext{
+			Field:    "chain",
+			Value:    len(chain),
+			Expected: "leaf certificate followed by intermediates",
+		})
+	}
+	algorithm, rawSize, err := certificateSignatureAlgorithm(signer.Public())
+	if err != nil {
+		return err
+	}
+	public, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !public.Equal(chain[0].PublicKey) {
+		return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "signer does not match the leaf certificate", nil, pkgerrors.ValidationErrorContext{
+			Field:    "signer",
+			Value:    chain[0].Subject.String(),
+			Expected: "private key of the leaf certificate",
+		})
+	}
+
+	data := x509SignatureData{
+		Version:   x509SignatureDataVersion,
+		Algorithm: algorithm,
+		Signature: make([]byte, rawSize),
+	}
+	for i, cert := range chain {
+		if cert == nil {
+			return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "certificate chain contains nil certificate", nil, pkgerrors.ValidationErrorContext{
+				Field:    "chain",
+				Value:    i,
+				Expected: "non-nil certificates",
+			})
+		}
+		data.Certificates = append(data.Certificates, asn1.RawValue{FullBytes: cert.Raw})
+	}
+	// The raw signature has a fixed size, so the encoded size is known before signing
+	encoded, err := asn1.Marshal(data)
+	if err != nil {
+		return pkgerrors.WrapError(err, pkgerrors.ErrTypeValidation, "failed to encode certificate chain")
+	}
+
+	return p.appendSignature(ctx, signatures.SignatureTypeX509, uint32(len(encoded)), comment, func(digest []byte) ([]byte, error) {
+		raw, err := signCertificateDigest(signer, digest)
+		if err != nil {
+			return nil, err
+		}
+		data.Signature = raw
+		encoded, err := asn1.Marshal(data)
+		if err != nil {
+			return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeSecurity, "failed to encode X.509 signature data")
+		}
+		return encoded, nil
+	})
+}
+
+// certificateSignatureAlgorithm returns the signature algorithm and fixed raw
+// signature size for a certificate key.
+func certificateSignatureAlgorithm(public crypto.PublicKey) (asn1.ObjectIdentifier, int, error) {
+	switch key := public.(type) {
+	case ed25519.PublicKey:
+		return oidSignatureEd25519, ed25519.SignatureSize, nil
+	case *ecdsa.PublicKey:
+		if key.Curve == elliptic.P256() {
+			return oidSignatureECDSAWithSHA256, signatures.SignatureSizeECDSAP256, nil
+		}
+	case *rsa.PublicKey:
+		return oidSignatureSHA256WithRSA, key.Size(), nil
+	}
+	return nil, 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeUnsupported, "unsupported certificate key", nil, pkgerrors.ValidationErrorContext{
+		Field:    "signer",
+		Value:    fmt.Sprintf("%T", public),
+		Expected: "Ed25519, ECDSA P-256 or RSA key",
+	})
+}
+
+// signCertificateDigest signs digest with a certificate key. Ed25519 and ECDSA use
+// the same encoding as key-based signatures; RSA uses PKCS #1 v1.5 with SHA-256.
+func signCertificateDigest(signer crypto.Signer, digest []byte) ([]byte, error) {
+	if _, ok := signer.Public().(*rsa.PublicKey); ok {
+		data, err := signer.Sign(rand.Reader, digest, crypto.SHA256)
+		if err != nil {
+			return nil, pkgerrors.WrapError(err, pkgerrors.ErrTypeSecurity, "failed to sign package digest")
+		}
+		return data, nil
+	}
+	signatureType, err := signerSignatureType(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	return signDigest(signer, signatureType, digest)
+}
+
+// parseX509SignatureData decodes X.509 SignatureData and its certificates.
+func parseX509SignatureData(raw []byte) (*x509SignatureData, []*x509.Certificate, error) {
+	var data x509SignatureData
+	rest, err := asn1.Unmarshal(raw, &data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rest) != 0 {
+		return nil, nil, fmt.Errorf("%d trailing bytes", len(rest))
+	}
+	if data.Version != x509SignatureDataVersion {
+		return nil, nil, fmt.Errorf("unsupported version %d", data.Version)
+	}
+	if len(data.Certificates) == 0 {
+		return nil, nil, fmt.Errorf("no certificates")
+	}
+	certs := make([]*x509.Certificate, 0, len(data.Certificates))
+	for _, rv := range data.Certificates {
+		cert, err := x509.ParseCertificate(rv.FullBytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		certs = append(certs, cert)
+	}
+	return &data, certs, nil
+}
+
+// verifyX509Signature verifies an X.509 signature over digest with its leaf
+// certificate and builds the chain to roots at the signature time, or at the
+// current time if atCurrentTime is set. The leaf must allow code signing. The
+// signature is Valid when the leaf certificate verifies it and Trusted when the
+// chain verifies; the subject and chain are recorded in info.
+//
+// Specification: api_signatures.md: 2.7.3.3 X.509 Chain Verification
+func verifyX509Signature(info *signatures.SignatureInfo, sig *signatures.Signature, digest []byte, roots *x509.CertPool, atCurrentTime bool) {
+	data, certs, err := parseX509SignatureData(sig.SignatureData)
+	if err != nil {
+		info.Error = fmt.Sprintf("malformed X.509 signature data: %v", err)
+		return
+	}
+	leaf := certs[0]
+	info.Subject = leaf.Subject.String()
+	info.CertificateChain = certs
+	if !verifyCertificateSignature(leaf.PublicKey, data.Algorithm, digest, data.Signature) {
+		info.Error = "signature does not verify with the signing certificate"
+		return
+	}
+	info.Valid = true
+	info.SignerID = signerID(leaf.RawSubjectPublicKeyInfo)
+
+	if roots == nil {
+		info.Error = "no trusted root certificates"
+		return
+	}
+	// Certificates must have been valid when the package was signed, unless the
+	// trust store asks for the current time; a timestamp in the future is never
+	// accepted
+	now := time.Now()
+	verifyTime := now
+	if sig.SignatureFlags&signatures.SignatureFlagHasTimestamp != 0 {
+		signedAt := time.Unix(int64(sig.SignatureTimestamp), 0)
+		if signedAt.After(now) {
+			info.Error = "signature timestamp is in the future"
+			return
+		}
+		if !atCurrentTime {
+			verifyTime = signedAt
+		}
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	chains, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   verifyTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	if err != nil {
+		info.Error = fmt.Sprintf("certificate chain: %v", err)
+		return
+	}
+	info.Trusted = true
+	info.CertificateChain = chains[0]
+}
+
+// verifyCertificateSignature reports whether raw is a valid signature of digest by
+// a certificate key using algorithm.
+func verifyCertificateSignature(key crypto.PublicKey, algorithm asn1.ObjectIdentifier, digest, raw []byte) bool {
+	switch {
+	case algorithm.Equal(oidSignatureEd25519):
+		return verifySignatureData(key, signatures.SignatureTypeEd25519, digest, raw)
+	case algorithm.Equal(oidSignatureECDSAWithSHA256):
+		return verifySignatureData(key, signatures.SignatureTypeECDSAP256, digest, raw)
+	case algorithm.Equal(oidSignatureSHA256WithRSA):
+		pub, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest, raw) == nil
+	default:
+		return false
+	}
+}
@@ -0,0 +1,389 @@
+// This file contains tests for X.509 certificate-chain signatures: signing with a
+// leaf and intermediate, chain building to trusted roots, validity windows and
+// signing errors.
+//
+// Specification: api_signatures.md: 2.6 X.509/PKCS#7 Implementation
+
+package novus_package
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+	"github.com/novus-engine/novuspack/api/go/signatures"
+)
+
+// testCertificate creates a certificate for public signed by parent (self-signed
+// if parent is nil) and valid from notBefore to notAfter.
+// Leaf certificates get the given extended key usages.
+func testCertificate(t *testing.T, cn string, public crypto.PublicKey, parent *x509.Certificate, parentKey crypto.Signer, isCA bool, notBefore, notAfter time.Time, usages ...x509.ExtKeyUsage) *x509.Certificate {
+	t.Helper()
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatalf("rand.Int failed: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		ExtKeyUsage:           usages,
+	}
+	if isCA {
+		template.KeyUsage |= x509.KeyUsageCertSign
+	}
+	if parent == nil {
+		parent = template
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, public, parentKey)
+	if err != nil {
+		t.Fatalf("CreateCertificate(%s) failed: %v", cn, err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate(%s) failed: %v", cn, err)
+	}
+	return cert
+}
+
+// testPKI is a root, an intermediate and the root pool used to sign leaves.
+type testPKI struct {
+	root            *x509.Certificate
+	intermediate    *x509.Certificate
+	intermediateKey crypto.Signer
+	roots           *x509.CertPool
+}
+
+func newTestPKI(t *testing.T, name string) *testPKI {
+	t.Helper()
+	now := time.Now()
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	intermediateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	root := testCertificate(t, name+" Root", rootKey.Public(), nil, rootKey, true, now.Add(-time.Hour), now.Add(24*time.Hour))
+	intermediate := testCertificate(t, name+" Intermediate", intermediateKey.Public(), root, rootKey, true, now.Add(-time.Hour), now.Add(24*time.Hour))
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	return &testPKI{root: root, intermediate: intermediate, intermediateKey: intermediateKey, roots: roots}
+}
+
+// leaf issues a code signing leaf certificate for public from the intermediate.
+func (pki *testPKI) leaf(t *testing.T, cn string, public crypto.PublicKey, notBefore, notAfter time.Time) *x509.Certificate {
+	t.Helper()
+	return testCertificate(t, cn, public, pki.intermediate, pki.intermediateKey, false, notBefore, notAfter, x509.ExtKeyUsageCodeSigning)
+}
+
+// x509TestSignature returns an X.509 signature of digest by signer with the given
+// timestamp, built like SignWithCertificate.
+func x509TestSignature(t *testing.T, signer crypto.Signer, chain []*x509.Certificate, digest []byte, timestamp time.Time) *signatures.Signature {
+	t.Helper()
+	algorithm, _, err := certificateSignatureAlgorithm(signer.Public())
+	if err != nil {
+		t.Fatalf("certificateSignatureAlgorithm failed: %v", err)
+	}
+	raw, err := signCertificateDigest(signer, digest)
+	if err != nil {
+		t.Fatalf("signCertificateDigest failed: %v", err)
+	}
+	data := x509SignatureData{Version: x509SignatureDataVersion, Algorithm: algorithm, Signature: raw}
+	for _, cert := range chain {
+		data.Certificates = append(data.Certificates, asn1.RawValue{FullBytes: cert.Raw})
+	}
+	encoded, err := asn1.Marshal(data)
+	if err != nil {
+		t.Fatalf("asn1.Marshal failed: %v", err)
+	}
+	return &signatures.Signature{
+		SignatureType:      signatures.SignatureTypeX509,
+		SignatureSize:      uint32(len(encoded)),
+		SignatureFlags:     signatures.SignatureFlagHasTimestamp,
+		SignatureTimestamp: uint32(timestamp.Unix()),
+		SignatureData:      encoded,
+	}
+}
+
+func TestPackage_SignWithCertificate(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	pki := newTestPKI(t, "Storefront")
+	otherPKI := newTestPKI(t, "Other")
+
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+
+	signers := []struct {
+		name   string
+		signer crypto.Signer
+		public crypto.PublicKey
+	}{
+		{"Ed25519", edPrivate, edPublic},
+		{"ECDSA P-256", ecKey, ecKey.Public()},
+		{"RSA", rsaKey, rsaKey.Public()},
+	}
+	for _, s := range signers {
+		t.Run(s.name, func(t *testing.T) {
+			leaf := pki.leaf(t, "Publisher "+s.name, s.public, now.Add(-time.Hour), now.Add(time.Hour))
+			pkg := writeSigningTestPackage(t, ctx)
+			if err := pkg.SignWithCertificate(ctx, s.signer, []*x509.Certificate{leaf, pki.intermediate}, "storefront"); err != nil {
+				t.Fatalf("SignWithCertificate failed: %v", err)
+			}
+
+			reopened, err := OpenPackage(ctx, pkg.(*filePackage).FilePath)
+			if err != nil {
+				t.Fatalf("OpenPackage failed: %v", err)
+			}
+			defer func() { _ = reopened.Close() }()
+			info, err := reopened.GetInfo()
+			if err != nil {
+				t.Fatalf("GetInfo failed: %v", err)
+			}
+			loaded := info.Signatures[0]
+			if loaded.Type != signatures.SignatureTypeX509 || loaded.Subject != "CN=Publisher "+s.name || len(loaded.CertificateChain) != 2 {
+				t.Errorf("loaded signature type %d subject %q chain %d", loaded.Type, loaded.Subject, len(loaded.CertificateChain))
+			}
+
+			infos, err := reopened.Verify(ctx, &StaticTrustStore{Roots: pki.roots})
+			if err != nil {
+				t.Fatalf("Verify failed: %v", err)
+			}
+			got := infos[0]
+			if !got.Valid || !got.Trusted || got.Error != "" {
+				t.Fatalf("Verify = %+v, want trusted", got)
+			}
+			if len(got.CertificateChain) != 3 || !got.CertificateChain[2].Equal(pki.root) {
+				t.Errorf("verified chain has %d certificates, want leaf, intermediate and root", len(got.CertificateChain))
+			}
+			if err := reopened.ValidateWithOptions(ctx, &ValidateOptions{TrustStore: &StaticTrustStore{Roots: pki.roots}, RequiredSignatures: 1}); err != nil {
+				t.Errorf("ValidateWithOptions = %v", err)
+			}
+
+			tests := []struct {
+				name    string
+				trust   TrustStore
+				errText string
+			}{
+				{"no roots", NewTrustStore(s.public), "no trusted root certificates"},
+				{"other root", &StaticTrustStore{Roots: otherPKI.roots}, "certificate chain:"},
+			}
+			for _, tt := range tests {
+				infos, err := reopened.Verify(ctx, tt.trust)
+				if err != nil {
+					t.Fatalf("Verify(%s) failed: %v", tt.name, err)
+				}
+				if !infos[0].Valid || infos[0].Trusted || !strings.HasPrefix(infos[0].Error, tt.errText) {
+					t.Errorf("Verify(%s) = valid %v trusted %v error %q, want untrusted %q", tt.name, infos[0].Valid, infos[0].Trusted, infos[0].Error, tt.errText)
+				}
+			}
+		})
+	}
+}
+
+func TestPackage_SignWithCertificate_ValidityWindow(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	pki := newTestPKI(t, "Storefront")
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		notBefore time.Time
+		notAfter  time.Time
+	}{
+		{"expired", now.Add(-2 * time.Hour), now.Add(-time.Hour)},
+		{"not yet valid", now.Add(time.Hour), now.Add(2 * time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leaf := pki.leaf(t, "Publisher", public, tt.notBefore, tt.notAfter)
+			pkg := writeSigningTestPackage(t, ctx)
+			if err := pkg.SignWithCertificate(ctx, private, []*x509.Certificate{leaf, pki.intermediate}, ""); err != nil {
+				t.Fatalf("SignWithCertificate failed: %v", err)
+			}
+			infos, err := pkg.Verify(ctx, &StaticTrustStore{Roots: pki.roots})
+			if err != nil {
+				t.Fatalf("Verify failed: %v", err)
+			}
+			if infos[0].Trusted || !strings.Contains(infos[0].Error, "expired or is not yet valid") {
+				t.Errorf("Verify = trusted %v error %q, want validity window failure", infos[0].Trusted, infos[0].Error)
+			}
+		})
+	}
+}
+
+func TestPackage_SignWithCertificate_KeyUsage(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	pki := newTestPKI(t, "Storefront")
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	leaf := testCertificate(t, "Web Server", public, pki.intermediate, pki.intermediateKey, false, now.Add(-time.Hour), now.Add(time.Hour), x509.ExtKeyUsageServerAuth)
+
+	pkg := writeSigningTestPackage(t, ctx)
+	if err := pkg.SignWithCertificate(ctx, private, []*x509.Certificate{leaf, pki.intermediate}, ""); err != nil {
+		t.Fatalf("SignWithCertificate failed: %v", err)
+	}
+	infos, err := pkg.Verify(ctx, &StaticTrustStore{Roots: pki.roots})
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if !infos[0].Valid || infos[0].Trusted || !strings.HasPrefix(infos[0].Error, "certificate chain:") {
+		t.Errorf("Verify = valid %v trusted %v error %q, want untrusted without code signing usage", infos[0].Valid, infos[0].Trusted, infos[0].Error)
+	}
+}
+
+func TestVerifyX509Signature_Time(t *testing.T) {
+	now := time.Now()
+	pki := newTestPKI(t, "Storefront")
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	digest := bytes.Repeat([]byte{0x5A}, 32)
+	expired := pki.leaf(t, "Publisher", key.Public(), now.Add(-50*time.Minute), now.Add(-10*time.Minute))
+	current := pki.leaf(t, "Publisher", key.Public(), now.Add(-time.Hour), now.Add(time.Hour))
+
+	tests := []struct {
+		name          string
+		leaf          *x509.Certificate
+		signedAt      time.Time
+		atCurrentTime bool
+		errText       string
+	}{
+		{"expired after signing", expired, now.Add(-30 * time.Minute), false, ""},
+		{"expired at current time", expired, now.Add(-30 * time.Minute), true, "certificate chain:"},
+		{"future timestamp", current, now.Add(time.Hour), false, "signature timestamp is in the future"},
+		{"future timestamp at current time", current, now.Add(time.Hour), true, "signature timestamp is in the future"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig := x509TestSignature(t, key, []*x509.Certificate{tt.leaf, pki.intermediate}, digest, tt.signedAt)
+			var info signatures.SignatureInfo
+			verifyX509Signature(&info, sig, digest, pki.roots, tt.atCurrentTime)
+			if !info.Valid {
+				t.Fatalf("Valid = false, error %q", info.Error)
+			}
+			if info.Trusted != (tt.errText == "") || !strings.HasPrefix(info.Error, tt.errText) {
+				t.Errorf("trusted %v error %q, want error %q", info.Trusted, info.Error, tt.errText)
+			}
+		})
+	}
+}
+
+func TestPackage_SignWithCertificate_TamperedAndIncremental(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	pki := newTestPKI(t, "Storefront")
+	keyPublic, keyPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	leaf := pki.leaf(t, "Publisher", leafKey.Public(), now.Add(-time.Hour), now.Add(time.Hour))
+
+	pkg := writeSigningTestPackage(t, ctx)
+	if err := pkg.Sign(ctx, keyPrivate, "developer"); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if err := pkg.SignWithCertificate(ctx, leafKey, []*x509.Certificate{leaf, pki.intermediate}, "storefront"); err != nil {
+		t.Fatalf("SignWithCertificate failed: %v", err)
+	}
+	trust := &StaticTrustStore{Keys: []crypto.PublicKey{keyPublic}, Roots: pki.roots}
+	infos, err := pkg.Verify(ctx, trust)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if len(infos) != 2 || !infos[0].Trusted || !infos[1].Trusted {
+		t.Fatalf("Verify = %+v, want key and certificate signatures trusted", infos)
+	}
+
+	path := pkg.(*filePackage).FilePath
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	raw[bytes.Index(raw, []byte("patch 1.2.3"))] ^= 0xFF
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if infos, err = pkg.Verify(ctx, trust); err != nil {
+		t.Fatalf("Verify(tampered) failed: %v", err)
+	}
+	if infos[1].Valid || infos[1].Trusted || infos[1].Error != "signature does not verify with the signing certificate" {
+		t.Errorf("tampered X.509 signature = %+v", infos[1])
+	}
+}
+
+func TestPackage_SignWithCertificate_Errors(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	pki := newTestPKI(t, "Storefront")
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	_, otherPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	leaf := pki.leaf(t, "Publisher", public, now.Add(-time.Hour), now.Add(time.Hour))
+	pkg := writeSigningTestPackage(t, ctx)
+
+	assertPackageErrorType(t, pkg.SignWithCertificate(ctx, nil, []*x509.Certificate{leaf}, ""), pkgerrors.ErrTypeValidation)
+	assertPackageErrorType(t, pkg.SignWithCertificate(ctx, private, nil, ""), pkgerrors.ErrTypeValidation)
+	assertPackageErrorType(t, pkg.SignWithCertificate(ctx, private, []*x509.Certificate{leaf, nil}, ""), pkgerrors.ErrTypeValidation)
+	assertPackageErrorType(t, pkg.SignWithCertificate(ctx, otherPrivate, []*x509.Certificate{leaf}, ""), pkgerrors.ErrTypeValidation)
+	assertPackageErrorType(t, pkg.SignWithCertificate(ctx, p384, []*x509.Certificate{leaf}, ""), pkgerrors.ErrTypeUnsupported)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assertPackageErrorType(t, pkg.SignWithCertificate(cancelled, private, []*x509.Certificate{leaf}, ""), pkgerrors.ErrTypeContext)
+
+	if info, _ := pkg.GetInfo(); info.SignatureCount != 0 {
+		t.Errorf("failed signing recorded %d signatures", info.SignatureCount)
+	}
+}
@@ -0,0 +1,455 @@
+// This file implements solid compression groups. A solid group compresses the
+// contents of several related files as one Zstd block. The block is stored as the
+// data of the group's first member (the leader); every member records its group
+// ID and its offset inside the decompressed block through the SolidGroupID
+// optional data entry (0x04). This file should contain only group creation,
+// block building and member lookup; writing blocks belongs in the writer.
+//
+// Specification: package_file_format.md: 4.1.4.4 Optional Data
+
+package novus_package
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/internal"
+	"github.com/novus-engine/novuspack/api/go/metadata"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// CreateSolidGroup places files into a new solid compression group.
+//
+// The files are compressed together as a single Zstd block on the next Write, which
+// gives many small, related files (for example, all scripts of one level) a much
+// better ratio than compressing each file on its own. Reading any member decompresses
+// the block once; later reads of other members are served from the cached block
+// while it stays within the package's bounded block cache.
+// Group compression replaces per-file compression and compression dictionaries.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - paths: Package paths of the files to group
+//
+// Returns:
+//   - uint32: ID of the new solid group
+//   - error: *PackageError on failure
+//
+// Error Conditions:
+//   - ErrTypeValidation: No paths, a path is not found, names a special file, or
+//     the file already belongs to a solid group
+//   - ErrTypeUnsupported: A file is encrypted
+//
+// Specification: package_file_format.md: 4.1.4.4 Optional Data
+func (p *filePackage) CreateSolidGroup(ctx context.Context, paths []string) (uint32, error) {
+	if err := internal.CheckContext(ctx, "CreateSolidGroup"); err != nil {
+		return 0, err
+	}
+	if err := p.checkNotSigned("CreateSolidGroup"); err != nil {
+		return 0, err
+	}
+	if len(paths) == 0 {
+		return 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "no files to group", nil, pkgerrors.ValidationErrorContext{
+			Field:    "paths",
+			Value:    0,
+			Expected: "at least one package path",
+		})
+	}
+
+	var members []*metadata.FileEntry
+	seen := make(map[*metadata.FileEntry]bool, len(paths))
+	for _, path := range paths {
+		fe, err := p.findRegularFileEntry(path)
+		if err != nil {
+			return 0, err
+		}
+		if seen[fe] {
+			continue
+		}
+		if groupID, _, ok := fe.GetSolidGroup(); ok {
+			return 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "file already belongs to a solid group", nil, pkgerrors.ValidationErrorContext{
+				Field:    "SolidGroupID",
+				Value:    groupID,
+				Expected: "file outside any solid group",
+			})
+		}
+		if fe.EncryptionType != fileformat.EncryptionNone {
+			return 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeUnsupported, "cannot add encrypted file to solid group", nil, pkgerrors.ValidationErrorContext{
+				Field:    "EncryptionType",
+				Value:    fe.EncryptionType,
+				Expected: "unencrypted file",
+			})
+		}
+		seen[fe] = true
+		members = append(members, fe)
+	}
+
+	// Load every member before changing any of them
+	contents := make([][]byte, len(members))
+	for i, fe := range members {
+		data, err := p.fileEntryContent(ctx, fe)
+		if err != nil {
+			return 0, err
+		}
+		contents[i] = data
+	}
+
+	groupID := uint32(1)
+	for _, fe := range p.FileEntries {
+		if id, _, ok := fe.GetSolidGroup(); ok && id >= groupID {
+			groupID = id + 1
+		}
+	}
+
+	for i, fe := range members {
+		fe.SetData(contents[i])
+		fe.ClearCompressionDictionaryID()
+		fe.ClearCompressionFrameSize()
+		fe.CompressionType = fileformat.CompressionZstd
+		fe.CompressionLevel = 0
+		fe.SetSolidGroup(groupID, 0) // Offsets are assigned when the block is built
+	}
+	return groupID, nil
+}
+
+// solidGroupMembers returns the members of each solid group in FileEntries order,
+// keyed by group ID, along with the group IDs in order of first appearance.
+func (p *filePackage) solidGroupMembers() (map[uint32][]*metadata.FileEntry, []uint32) {
+	groups := make(map[uint32][]*metadata.FileEntry)
+	var order []uint32
+	for _, fe := range p.FileEntries {
+		if fe == nil {
+			continue
+		}
+		groupID, _, ok := fe.GetSolidGroup()
+		if !ok {
+			continue
+		}
+		if _, exists := groups[groupID]; !exists {
+			order = append(order, groupID)
+		}
+		groups[groupID] = append(groups[groupID], fe)
+	}
+	return groups, order
+}
+
+// buildSolidGroupBlocks compresses the block of every solid group that changed since
+// it was last written and returns the data to write for each member: the block for
+// the group leader and an empty slice for the other members.
+//
+// Groups whose members are all still in stored form are left out and copied as-is.
+// Members of rebuilt groups keep their content in memory, so they stay readable
+// after the write.
+func (p *filePackage) buildSolidGroupBlocks(ctx context.Context) (map[*metadata.FileEntry][]byte, error) {
+	groups, order := p.solidGroupMembers()
+	blocks := make(map[*metadata.FileEntry][]byte)
+
+	for _, groupID := range order {
+		members := groups[groupID]
+		if solidGroupIntact(members) {
+			continue
+		}
+
+		contents := make([][]byte, len(members))
+		offsets := make([]uint32, len(members))
+		blockSize := uint64(0)
+		for i, fe := range members {
+			data, err := p.fileEntryContent(ctx, fe)
+			if err != nil {
+				return nil, err
+			}
+			if blockSize+uint64(len(data)) > math.MaxUint32 {
+				return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "solid group is too large", nil, pkgerrors.ValidationErrorContext{
+					Field:    "SolidGroupID",
+					Value:    groupID,
+					Expected: fmt.Sprintf("at most %d bytes of content", uint64(math.MaxUint32)),
+				})
+			}
+			contents[i] = data
+			offsets[i] = uint32(blockSize)
+			blockSize += uint64(len(data))
+		}
+
+		level, err := internal.ResolveCompressionLevel(int(members[0].CompressionLevel))
+		if err != nil {
+			return nil, err
+		}
+		block, err := internal.CompressData(bytes.Join(contents, nil), fileformat.CompressionZstd, level)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, fe := range members {
+			fe.SetData(contents[i])
+			fe.SetSolidGroup(groupID, offsets[i])
+			fe.CompressionType = fileformat.CompressionZstd
+			fe.CompressionLevel = uint8(level)
+			fe.OriginalSize = uint64(len(contents[i]))
+			fe.RawChecksum = internal.CalculateCRC32(contents[i])
+			if i == 0 {
+				fe.StoredSize = uint64(len(block))
+				fe.StoredChecksum = internal.CalculateCRC32(block)
+				blocks[fe] = block
+			} else {
+				fe.StoredSize = 0
+				fe.StoredChecksum = 0
+				blocks[fe] = []byte{}
+			}
+		}
+		p.dropSolidGroupBlock(groupID)
+	}
+	return blocks, nil
+}
+
+// solidGroupIntact reports whether a group can be copied as-is: no member holds raw
+// data and exactly one member (the leader) holds the stored block.
+func solidGroupIntact(members []*metadata.FileEntry) bool {
+	leaders := 0
+	for _, fe := range members {
+		if fe.IsDataLoaded || fe.ProcessingState == metadata.ProcessingStateRaw {
+			return false
+		}
+		if fe.StoredSize > 0 {
+			leaders++
+		}
+	}
+	return leaders == 1
+}
+
+// readSolidGroupMember returns the content of a solid group member from the group's
+// decompressed block.
+func (p *filePackage) readSolidGroupMember(ctx context.Context, fe *metadata.FileEntry, groupID, offset uint32) ([]byte, error) {
+	block, err := p.solidGroupBlock(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	end := uint64(offset) + fe.OriginalSize
+	if end > uint64(len(block)) {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "solid group member outside group block", nil, pkgerrors.ValidationErrorContext{
+			Field:    "SolidGroupID",
+			Value:    end,
+			Expected: fmt.Sprintf("member end within %d-byte block", len(block)),
+		})
+	}
+	data := bytes.Clone(block[offset:end])
+	if fe.RawChecksum != 0 && internal.CalculateCRC32(data) != fe.RawChecksum {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "solid group member checksum mismatch", nil, pkgerrors.ValidationErrorContext{
+			Field:    "RawChecksum",
+			Value:    fe.RawChecksum,
+			Expected: "checksum of member content",
+		})
+	}
+	return data, nil
+}
+
+// solidGroupBlock returns the decompressed block of a solid group, reading and
+// decompressing it from the leader's stored data on first use.
+func (p *filePackage) solidGroupBlock(ctx context.Context, groupID uint32) ([]byte, error) {
+	if block, ok := p.cachedSolidGroupBlock(groupID); ok {
+		return block, nil
+	}
+	leader, blockSize, err := p.solidGroupLeader(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := readStoredFileData(ctx, leader)
+	if err != nil {
+		return nil, err
+	}
+	if leader.StoredChecksum != 0 && internal.CalculateCRC32(data) != leader.StoredChecksum {
+		return nil, pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "stored data checksum mismatch", nil, pkgerrors.ValidationErrorContext{
+			Field: "StoredChecksum", Value: leader.StoredChecksum, Expected: "checksum of stored data",
+		})
+	}
+	block, err := internal.DecompressData(data, leader.CompressionType, blockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if cached, ok := p.solidGroupBlocks.get(groupID); ok {
+		return cached, nil
+	}
+	p.solidGroupBlocks.put(groupID, block)
+	return block, nil
+}
+
+// cachedSolidGroupBlock returns the decompressed block of a solid group if it is
+// cached.
+func (p *filePackage) cachedSolidGroupBlock(groupID uint32) ([]byte, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.solidGroupBlocks.get(groupID)
+}
+
+// solidGroupLeader returns the member of a solid group that holds the stored
+// block, and the decompressed block size checked against the member layout.
+func (p *filePackage) solidGroupLeader(groupID uint32) (*metadata.FileEntry, uint64, error) {
+	var leader *metadata.FileEntry
+	var members []*metadata.FileEntry
+	for _, fe := range p.FileEntries {
+		id, _, ok := fe.GetSolidGroup()
+		if !ok || id != groupID {
+			continue
+		}
+		if fe.StoredSize > 0 && leader == nil {
+			leader = fe
+		}
+		members = append(members, fe)
+	}
+	if leader == nil {
+		return nil, 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "solid group has no stored block", nil, pkgerrors.ValidationErrorContext{
+			Field:    "SolidGroupID",
+			Value:    groupID,
+			Expected: "group with a leader holding the block",
+		})
+	}
+	blockSize, err := solidGroupBlockSize(leader, members)
+	if err != nil {
+		return nil, 0, err
+	}
+	return leader, blockSize, nil
+}
+
+// solidGroupBlockSize returns the decompressed size of a solid group block from the
+// member entries read from the package. The writer lays members out back to back
+// from offset 0, starting with the leader, in a block of at most math.MaxUint32
+// bytes. The sizes come from untrusted entries and bound the decompression of the
+// block, so a leader that does not start the block, overlapping members or a larger
+// block are reported as corruption before decompressing.
+func solidGroupBlockSize(leader *metadata.FileEntry, members []*metadata.FileEntry) (uint64, error) {
+	if _, offset, _ := leader.GetSolidGroup(); offset != 0 {
+		return 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "solid group leader not at block start", nil, pkgerrors.ValidationErrorContext{
+			Field:    "SolidGroupID",
+			Value:    offset,
+			Expected: "leader offset 0",
+		})
+	}
+	sorted := slices.Clone(members)
+	slices.SortStableFunc(sorted, func(a, b *metadata.FileEntry) int {
+		_, offsetA, _ := a.GetSolidGroup()
+		_, offsetB, _ := b.GetSolidGroup()
+		return cmp.Compare(offsetA, offsetB)
+	})
+	blockSize := uint64(0)
+	for _, fe := range sorted {
+		_, offset, _ := fe.GetSolidGroup()
+		if uint64(offset) < blockSize {
+			return 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "solid group members overlap", nil, pkgerrors.ValidationErrorContext{
+				Field:    "SolidGroupID",
+				Value:    offset,
+				Expected: fmt.Sprintf("member offset of at least %d", blockSize),
+			})
+		}
+		if fe.OriginalSize > math.MaxUint32-uint64(offset) {
+			return 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "solid group block is too large", nil, pkgerrors.ValidationErrorContext{
+				Field:    "OriginalSize",
+				Value:    fe.OriginalSize,
+				Expected: fmt.Sprintf("block of at most %d bytes", uint64(math.MaxUint32)),
+			})
+		}
+		blockSize = uint64(offset) + fe.OriginalSize
+	}
+	return blockSize, nil
+}
+
+// dropSolidGroupBlock removes the cached decompressed block of a solid group.
+func (p *filePackage) dropSolidGroupBlock(groupID uint32) {
+	p.mu.Lock()
+	p.solidGroupBlocks.drop(groupID)
+	p.mu.Unlock()
+}
+
+// solidGroupCacheLimit is the default number of decompressed solid group block bytes
+// cached on one package.
+const solidGroupCacheLimit = 64 << 20
+
+// solidGroupCache holds decompressed solid group blocks, keyed by group ID, so reads
+// of other members of a group skip decompression. The cache holds at most limit
+// bytes (solidGroupCacheLimit when zero) and evicts the least recently used blocks
+// first; a block larger than the limit is not cached. The zero value is an empty
+// cache. Callers hold filePackage.mu.
+type solidGroupCache struct {
+	blocks map[uint32][]byte
+	order  []uint32 // Cached group IDs, least recently used first
+	size   int
+	limit  int
+}
+
+// get returns the cached block of a group and marks it most recently used.
+func (c *solidGroupCache) get(groupID uint32) ([]byte, bool) {
+	block, ok := c.blocks[groupID]
+	if ok {
+		c.order = append(slices.DeleteFunc(c.order, func(id uint32) bool { return id == groupID }), groupID)
+	}
+	return block, ok
+}
+
+// put caches the block of a group, evicting the least recently used blocks until
+// the cache is within its limit.
+func (c *solidGroupCache) put(groupID uint32, block []byte) {
+	limit := c.limit
+	if limit == 0 {
+		limit = solidGroupCacheLimit
+	}
+	if len(block) > limit {
+		return
+	}
+	c.drop(groupID)
+	for len(c.order) > 0 && c.size+len(block) > limit {
+		c.drop(c.order[0])
+	}
+	if c.blocks == nil {
+		c.blocks = make(map[uint32][]byte)
+	}
+	c.blocks[groupID] = block
+	c.order = append(c.order, groupID)
+	c.size += len(block)
+}
+
+// drop removes the cached block of a group.
+func (c *solidGroupCache) drop(groupID uint32) {
+	block, ok := c.blocks[groupID]
+	if !ok {
+		return
+	}
+	delete(c.blocks, groupID)
+	c.order = slices.DeleteFunc(c.order, func(id uint32) bool { return id == groupID })
+	c.size -= len(block)
+}
+
+// releaseSolidGroupMember prepares the removal of a solid group member by loading the
+// content of the other members into memory, so the group block is rebuilt without the
+// removed member on the next Write.
+func (p *filePackage) releaseSolidGroupMember(ctx context.Context, removed *metadata.FileEntry) error {
+	groupID, _, ok := removed.GetSolidGroup()
+	if !ok {
+		return nil
+	}
+
+	groups, _ := p.solidGroupMembers()
+	members := groups[groupID]
+	contents := make(map[*metadata.FileEntry][]byte, len(members))
+	for _, fe := range members {
+		if fe == removed {
+			continue
+		}
+		data, err := p.fileEntryContent(ctx, fe)
+		if err != nil {
+			return err
+		}
+		contents[fe] = data
+	}
+	for fe, data := range contents {
+		fe.SetData(data)
+	}
+	p.dropSolidGroupBlock(groupID)
+	return nil
+}
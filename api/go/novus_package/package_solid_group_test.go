@@ -0,0 +1,346 @@
+// This file contains tests for solid compression groups: grouping files, writing
+// the shared block, and reading members back through the decompressed group cache.
+//
+// Specification: package_file_format.md: 4.1.4.4 Optional Data
+
+package novus_package
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
+	"github.com/novus-engine/novuspack/api/go/pkgerrors"
+)
+
+// solidGroupTestScripts returns small, related script files under one directory.
+func solidGroupTestScripts(n int) map[string][]byte {
+	scripts := make(map[string][]byte, n)
+	for i := 0; i < n; i++ {
+		path := fmt.Sprintf("/levels/level1/scripts/trigger%02d.lua", i)
+		scripts[path] = fmt.Appendf(nil, "-- trigger %d\nfunction on_enter_%d(player)\n  spawn_wave(%d, \"raiders\")\n  play_sound(\"alarm\")\nend\n", i, i, i%5)
+	}
+	return scripts
+}
+
+// sortedPaths returns the keys of files in sorted order.
+func sortedPaths(files map[string][]byte) []string {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// newSolidGroupTestPackage creates a package holding scripts in one solid group.
+// Files are added in sorted path order, so the first sorted path is the group leader.
+func newSolidGroupTestPackage(t *testing.T, ctx context.Context, scripts map[string][]byte) (Package, uint32) {
+	t.Helper()
+	pkg, err := NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage failed: %v", err)
+	}
+	for _, path := range sortedPaths(scripts) {
+		if _, err := pkg.AddFileFromMemory(ctx, path, scripts[path], nil); err != nil {
+			t.Fatalf("AddFileFromMemory(%q) failed: %v", path, err)
+		}
+	}
+	groupID, err := pkg.CreateSolidGroup(ctx, sortedPaths(scripts))
+	if err != nil {
+		t.Fatalf("CreateSolidGroup failed: %v", err)
+	}
+	return pkg, groupID
+}
+
+// assertReadsBack verifies every file in files reads back with its content.
+func assertReadsBack(t *testing.T, ctx context.Context, pkg Package, files map[string][]byte) {
+	t.Helper()
+	for _, path := range sortedPaths(files) {
+		got, err := pkg.ReadFile(ctx, path)
+		if err != nil {
+			t.Fatalf("ReadFile(%q) failed: %v", path, err)
+		}
+		if !bytes.Equal(got, files[path]) {
+			t.Errorf("ReadFile(%q) content mismatch", path)
+		}
+	}
+}
+
+func TestPackage_SolidGroup_WriteAndReadBack(t *testing.T) {
+	ctx := context.Background()
+	scripts := solidGroupTestScripts(40)
+	pkg, groupID := newSolidGroupTestPackage(t, ctx, scripts)
+	if groupID != 1 {
+		t.Errorf("first group ID = %d, want 1", groupID)
+	}
+
+	reopened := writeAndReopen(t, ctx, pkg)
+	fp := reopened.(*filePackage)
+
+	var leaders int
+	var storedTotal, originalTotal uint64
+	offsets := make(map[uint32]bool)
+	for _, path := range sortedPaths(scripts) {
+		fe, err := fp.findFileEntryByPath(path)
+		if err != nil {
+			t.Fatalf("findFileEntryByPath(%q) failed: %v", path, err)
+		}
+		id, offset, ok := fe.GetSolidGroup()
+		if !ok || id != groupID {
+			t.Errorf("%s: GetSolidGroup() = %d, %v; want %d, true", path, id, ok, groupID)
+		}
+		if offsets[offset] {
+			t.Errorf("%s: duplicate group offset %d", path, offset)
+		}
+		offsets[offset] = true
+		if fe.CompressionType != fileformat.CompressionZstd {
+			t.Errorf("%s: CompressionType = %d, want Zstd", path, fe.CompressionType)
+		}
+		if fe.StoredSize > 0 {
+			leaders++
+		}
+		storedTotal += fe.StoredSize
+		originalTotal += fe.OriginalSize
+	}
+	if leaders != 1 {
+		t.Errorf("members holding the block = %d, want 1", leaders)
+	}
+	if storedTotal*5 > originalTotal {
+		t.Errorf("group stored %d of %d bytes, want at least 80%% savings", storedTotal, originalTotal)
+	}
+
+	assertReadsBack(t, ctx, reopened, scripts)
+	if len(fp.solidGroupBlocks.blocks) != 1 {
+		t.Errorf("cached group blocks = %d, want 1", len(fp.solidGroupBlocks.blocks))
+	}
+}
+
+func TestPackage_SolidGroup_ReadsServedFromCache(t *testing.T) {
+	ctx := context.Background()
+	scripts := solidGroupTestScripts(8)
+	pkg, _ := newSolidGroupTestPackage(t, ctx, scripts)
+	reopened := writeAndReopen(t, ctx, pkg)
+	fp := reopened.(*filePackage)
+
+	paths := sortedPaths(scripts)
+	if _, err := reopened.ReadFile(ctx, paths[0]); err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+
+	// Detach every member from the package file; later reads must come from the cache
+	for _, path := range paths {
+		fe, err := fp.findFileEntryByPath(path)
+		if err != nil {
+			t.Fatalf("findFileEntryByPath(%q) failed: %v", path, err)
+		}
+		fe.SourceFile = nil
+	}
+	assertReadsBack(t, ctx, reopened, scripts)
+}
+
+func TestPackage_SolidGroup_CacheBounded(t *testing.T) {
+	var cache solidGroupCache
+	cache.limit = 10
+	cache.put(1, make([]byte, 4))
+	cache.put(2, make([]byte, 4))
+	if _, ok := cache.get(1); !ok {
+		t.Fatal("group 1 not cached")
+	}
+
+	// Group 2 is now the least recently used and is evicted first
+	cache.put(3, make([]byte, 4))
+	if _, ok := cache.get(2); ok {
+		t.Error("group 2 still cached after eviction")
+	}
+	if _, ok := cache.get(1); !ok {
+		t.Error("recently used group 1 was evicted")
+	}
+	cache.put(4, make([]byte, 11))
+	if _, ok := cache.get(4); ok {
+		t.Error("block larger than the limit was cached")
+	}
+	if cache.size != 8 || len(cache.blocks) != 2 {
+		t.Errorf("cache holds %d blocks of %d bytes, want 2 blocks of 8 bytes", len(cache.blocks), cache.size)
+	}
+
+	// Reads still succeed when blocks cannot be cached
+	ctx := context.Background()
+	scripts := solidGroupTestScripts(8)
+	pkg, _ := newSolidGroupTestPackage(t, ctx, scripts)
+	reopened := writeAndReopen(t, ctx, pkg)
+	fp := reopened.(*filePackage)
+	fp.solidGroupBlocks.limit = 1
+	assertReadsBack(t, ctx, reopened, scripts)
+	if len(fp.solidGroupBlocks.blocks) != 0 {
+		t.Errorf("cached group blocks = %d, want 0", len(fp.solidGroupBlocks.blocks))
+	}
+}
+
+func TestPackage_SolidGroup_RewriteKeepsGroup(t *testing.T) {
+	ctx := context.Background()
+	scripts := solidGroupTestScripts(10)
+	pkg, _ := newSolidGroupTestPackage(t, ctx, scripts)
+	reopened := writeAndReopen(t, ctx, pkg)
+
+	if _, err := reopened.AddFileFromMemory(ctx, "/levels/level1/map.bin", []byte{1, 2, 3}, nil); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	second := writeAndReopen(t, ctx, reopened)
+	assertReadsBack(t, ctx, second, scripts)
+
+	groupID, err := second.CreateSolidGroup(ctx, []string{"/levels/level1/map.bin"})
+	if err != nil {
+		t.Fatalf("CreateSolidGroup failed: %v", err)
+	}
+	if groupID != 2 {
+		t.Errorf("second group ID = %d, want 2", groupID)
+	}
+}
+
+func TestPackage_SolidGroup_RemoveMembers(t *testing.T) {
+	ctx := context.Background()
+	scripts := solidGroupTestScripts(6)
+	pkg, _ := newSolidGroupTestPackage(t, ctx, scripts)
+	reopened := writeAndReopen(t, ctx, pkg)
+
+	// Remove the leader (first member) and the last member of the block
+	paths := sortedPaths(scripts)
+	for _, path := range []string{paths[0], paths[len(paths)-1]} {
+		if err := reopened.RemoveFile(ctx, path); err != nil {
+			t.Fatalf("RemoveFile(%q) failed: %v", path, err)
+		}
+		delete(scripts, path)
+	}
+	assertReadsBack(t, ctx, reopened, scripts)
+
+	second := writeAndReopen(t, ctx, reopened)
+	assertReadsBack(t, ctx, second, scripts)
+
+	files, err := second.ListFiles()
+	if err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	if len(files) != len(scripts) {
+		t.Errorf("ListFiles returned %d files, want %d", len(files), len(scripts))
+	}
+}
+
+func TestPackage_SolidGroup_Errors(t *testing.T) {
+	ctx := context.Background()
+	scripts := solidGroupTestScripts(3)
+	pkg, groupID := newSolidGroupTestPackage(t, ctx, scripts)
+	paths := sortedPaths(scripts)
+
+	_, err := pkg.CreateSolidGroup(ctx, nil)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	_, err = pkg.CreateSolidGroup(ctx, []string{"/missing.lua"})
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	_, err = pkg.CreateSolidGroup(ctx, paths[:1])
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	dictID := trainTestDictionary(t, ctx, pkg, scripts)
+	err = pkg.SetFileCompressionDictionary(ctx, paths[0], dictID)
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	_, err = pkg.CreateSolidGroup(ctx, []string{compressionDictionaryFilePath})
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeValidation)
+
+	if _, err := pkg.AddFileFromMemory(ctx, "/secret.bin", []byte("secret"), nil); err != nil {
+		t.Fatalf("AddFileFromMemory failed: %v", err)
+	}
+	fe, err := pkg.(*filePackage).findFileEntryByPath("/secret.bin")
+	if err != nil {
+		t.Fatalf("findFileEntryByPath failed: %v", err)
+	}
+	fe.EncryptionType = fileformat.EncryptionAES256GCM
+	_, err = pkg.CreateSolidGroup(ctx, []string{"/secret.bin"})
+	assertPackageErrorType(t, err, pkgerrors.ErrTypeUnsupported)
+
+	if groupID != 1 {
+		t.Errorf("group ID = %d, want 1", groupID)
+	}
+}
+
+func TestPackage_SolidGroup_Corruption(t *testing.T) {
+	ctx := context.Background()
+	scripts := solidGroupTestScripts(4)
+	paths := sortedPaths(scripts)
+
+	t.Run("member outside block", func(t *testing.T) {
+		pkg, groupID := newSolidGroupTestPackage(t, ctx, scripts)
+		reopened := writeAndReopen(t, ctx, pkg)
+		fe, err := reopened.(*filePackage).findFileEntryByPath(paths[1])
+		if err != nil {
+			t.Fatalf("findFileEntryByPath failed: %v", err)
+		}
+		fe.SetSolidGroup(groupID, 1<<20)
+		_, err = reopened.ReadFile(ctx, paths[2])
+		assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption)
+	})
+
+	t.Run("oversized member", func(t *testing.T) {
+		pkg, _ := newSolidGroupTestPackage(t, ctx, scripts)
+		reopened := writeAndReopen(t, ctx, pkg)
+		fe, err := reopened.(*filePackage).findFileEntryByPath(paths[3])
+		if err != nil {
+			t.Fatalf("findFileEntryByPath failed: %v", err)
+		}
+		fe.OriginalSize = 1 << 40
+		_, err = reopened.ReadFile(ctx, paths[1])
+		assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption)
+	})
+
+	t.Run("overlapping members", func(t *testing.T) {
+		pkg, groupID := newSolidGroupTestPackage(t, ctx, scripts)
+		reopened := writeAndReopen(t, ctx, pkg)
+		fe, err := reopened.(*filePackage).findFileEntryByPath(paths[2])
+		if err != nil {
+			t.Fatalf("findFileEntryByPath failed: %v", err)
+		}
+		fe.SetSolidGroup(groupID, 1)
+		_, err = reopened.ReadFile(ctx, paths[1])
+		assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption)
+	})
+
+	t.Run("leader not at block start", func(t *testing.T) {
+		pkg, groupID := newSolidGroupTestPackage(t, ctx, scripts)
+		reopened := writeAndReopen(t, ctx, pkg)
+		leader, err := reopened.(*filePackage).findFileEntryByPath(paths[0])
+		if err != nil {
+			t.Fatalf("findFileEntryByPath failed: %v", err)
+		}
+		leader.SetSolidGroup(groupID, 1<<20)
+		_, err = reopened.ReadFile(ctx, paths[1])
+		assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption)
+	})
+
+	t.Run("block checksum", func(t *testing.T) {
+		pkg, _ := newSolidGroupTestPackage(t, ctx, scripts)
+		reopened := writeAndReopen(t, ctx, pkg)
+		leader, err := reopened.(*filePackage).findFileEntryByPath(paths[0])
+		if err != nil {
+			t.Fatalf("findFileEntryByPath failed: %v", err)
+		}
+		leader.StoredChecksum ^= 0xFFFFFFFF
+		_, err = reopened.ReadFile(ctx, paths[1])
+		assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption)
+	})
+
+	t.Run("no leader", func(t *testing.T) {
+		pkg, _ := newSolidGroupTestPackage(t, ctx, scripts)
+		reopened := writeAndReopen(t, ctx, pkg)
+		leader, err := reopened.(*filePackage).findFileEntryByPath(paths[0])
+		if err != nil {
+			t.Fatalf("findFileEntryByPath failed: %v", err)
+		}
+		leader.StoredSize = 0
+		_, err = reopened.ReadFile(ctx, paths[1])
+		assertPackageErrorType(t, err, pkgerrors.ErrTypeCorruption)
+	})
+}
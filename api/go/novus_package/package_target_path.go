@@ -27,14 +27,11 @@ import (
 // target directory is writable, even though it doesn't write to disk. This validation
 // requires minimal filesystem I/O to check directory existence and permissions.
 //
-// Signature Clearing: If the package is signed and the new path differs from the
-// current path, this function MUST clear all signature information from the in-memory
-// package. This is required because signed packages are immutable and writing to a new
-// location creates a new, unsigned package.
-//
-// Important: Signature clearing only occurs when the new path differs from the current
-// path. If SetTargetPath is called with the same path as the current path, signatures
-// are NOT cleared.
+// Signed Packages: Signatures are kept in memory. While a signed package targets a
+// path other than its signed file, Write and SafeWrite create a new, unsigned package
+// there, leave the signed file unchanged and clear the in-memory signatures once the
+// write succeeds. Retargeting back to the signed file restores the protection of the
+// signed file; content stays immutable until ClearAllSignatures.
 //
 // Parameters:
 //   - ctx: Context for cancellation and timeout handling
@@ -137,9 +134,6 @@ func (p *filePackage) SetTargetPath(ctx context.Context, path string) error {
 	_ = tempFile.Close()
 	_ = os.Remove(tempPath)
 
-	// Note: In v2, if the new path differs from current path and package is signed,
-	// signatures would be cleared here. For v1, packages are never signed.
-
 	// Update the target path
 	p.FilePath = cleanPath
 
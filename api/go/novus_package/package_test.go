@@ -32,6 +32,21 @@ func asPackageError(err error, target *pkgerrors.PackageError) bool {
 	return false
 }
 
+// assertPackageErrorType verifies err is a *PackageError of the wanted type.
+func assertPackageErrorType(t *testing.T, err error, want pkgerrors.ErrorType) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("expected %v error, got nil", want)
+	}
+	var pkgErr *pkgerrors.PackageError
+	if !pkgerrors.As(err, &pkgErr) {
+		t.Fatalf("expected *PackageError, got %T: %v", err, err)
+	}
+	if pkgErr.Type != want {
+		t.Errorf("error type = %v, want %v", pkgErr.Type, want)
+	}
+}
+
 // runContextCancelledTest creates a package, calls the given method with a cancelled context,
 // and asserts the error is a PackageError with ErrTypeContext.
 func runContextCancelledTest(t *testing.T, call func(*filePackage, context.Context) error) {
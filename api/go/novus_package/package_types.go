@@ -15,6 +15,7 @@ package novus_package
 
 import (
 	"os"
+	"time"
 
 	"github.com/novus-engine/novuspack/api/go/generics"
 )
@@ -122,8 +123,19 @@ type AddFileOptions struct {
 	FileType         generics.Option[uint16]               // File type identifier (override auto-detection)
 	Tags             generics.Option[[]*generics.Tag[any]] // Per-file tags
 
-	// Encryption options (deferred to Priority 6)
-	EncryptionKey generics.Option[*EncryptionKey] // Encryption key (enables encryption when set)
+	// Automatic compression options
+	AutoCompress           generics.Option[bool] // Select compression from the file type (default: false)
+	AutoCompressMinSavings generics.Option[int]  // Minimum size reduction in percent to keep auto compression (default: 10)
+
+	// Dictionary compression options
+	CompressionDictionaryID generics.Option[uint32] // Stored compression dictionary to compress with (implies Zstd)
+
+	// Seekable compression options
+	CompressionFrameSize generics.Option[int] // Compress in independently decodable frames of this size for range reads (4 KiB-64 MiB)
+
+	// Encryption options
+	EncryptionKey  generics.Option[*EncryptionKey] // Encryption key (enables encryption with the key's KeyType when set)
+	UseKeyEnvelope generics.Option[bool]           // Encrypt with the content key of the package key envelope (see AddKeyRecipient)
 
 	// Multi-stage transformation pipeline options
 	MaxTransformStages      generics.Option[int]  // Maximum transformation stages per pipeline (default: 10)
@@ -186,17 +198,33 @@ const (
 	EncryptionMLKEM1024        EncryptionType = EncryptionAlgorithmMLKEM1024
 )
 
-// EncryptionKey represents an encryption key for file encryption.
+// EncryptionKey holds the key material used to encrypt and decrypt file data.
 //
-// This is a placeholder type for Priority 6 (Encryption Support).
-// The actual implementation will be defined when encryption features are added.
+// KeyType selects the algorithm and KeyID identifies the key; every encrypted
+// FileEntry records the KeyID of its key so ReadFile can select it. The key
+// material is unexported and accessed through GetKey and SetKey, which copy it.
 //
-// TODO: Priority 6 - Define complete EncryptionKey structure with:
-//   - Key data
-//   - Algorithm identifier
-//   - Key metadata (creation time, expiry, etc.)
+// Specification: api_security.md: 4.1.3 EncryptionKey Struct
 type EncryptionKey struct {
-	// Placeholder - will be implemented in Priority 6
+	KeyType   EncryptionType // Encryption algorithm the key is used with
+	KeyID     string         // Key identifier recorded in encrypted file entries (1-255 bytes)
+	CreatedAt time.Time      // Key creation time
+	ExpiresAt *time.Time     // Expiration time (nil: never expires)
+
+	key generics.Option[[]byte] // Key material
+}
+
+// MLKEMKey holds an ML-KEM key pair used for quantum-safe file encryption.
+//
+// PublicKey is the encapsulation key, which is enough to encrypt files for the
+// key holder. PrivateKey is the 64-byte seed form of the decapsulation key and is
+// required to decrypt; it is nil for keys distributed to writers only.
+//
+// Specification: api_security.md: 5.1 MLKEMKey Struct
+type MLKEMKey struct {
+	PublicKey  []byte // ML-KEM encapsulation key
+	PrivateKey []byte // ML-KEM decapsulation key seed (nil: encrypt only)
+	Level      int    // Security level (3: ML-KEM-768, 5: ML-KEM-1024)
 }
 
 // CreateOptions represents options for creating a package.
@@ -210,4 +238,27 @@ type CreateOptions struct {
 	VendorID    uint32      // Vendor identifier
 	AppID       uint64      // Application identifier
 	Permissions os.FileMode // File permissions (default: 0644)
+	Passphrase  string      // Protects added files with a passphrase (see Package.SetPassphrase)
+}
+
+// ReadOnlyOptions configures OpenPackageReadOnlyWithOptions.
+//
+// Specification: api_basic_operations.md: 11.7 OpenPackageReadOnlyWithOptions Function
+type ReadOnlyOptions struct {
+	MemoryMap bool // Memory-map the package file and serve uncompressed, unencrypted files without copying
+}
+
+// ValidateOptions configures signature requirements for Package.ValidateWithOptions.
+//
+// Specification: api_basic_operations.md: 15.4.1 ValidateOptions Structure
+type ValidateOptions struct {
+	TrustStore         TrustStore // Keys and certificates trusted for signatures
+	RequiredSignatures int        // Minimum number of distinct trusted signers (0: none)
+}
+
+// PassphraseOptions configures passphrase key derivation.
+//
+// Specification: api_security.md: 7.2.1 PassphraseOptions Structure
+type PassphraseOptions struct {
+	Iterations int // PBKDF2 iteration count (0: DefaultPassphraseIterations)
 }
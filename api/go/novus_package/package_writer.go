@@ -14,6 +14,7 @@
 package novus_package
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
 	"fmt"
@@ -43,6 +44,13 @@ func (p *filePackage) Write(ctx context.Context) error {
 	if err := internal.CheckContext(ctx, "Write"); err != nil {
 		return err
 	}
+	if err := p.checkSignedWrite("Write"); err != nil {
+		return err
+	}
+	// Path metadata of a locked confidential index is not loaded and would be lost
+	if err := p.checkIndexUnlocked("Write"); err != nil {
+		return err
+	}
 
 	// Ensure path metadata special file is up to date
 	if err := p.SavePathMetadataFile(ctx); err != nil {
@@ -63,7 +71,8 @@ func (p *filePackage) Write(ctx context.Context) error {
 //   - ctx: Context for cancellation and timeout control
 //   - overwrite: Whether to overwrite existing file (false = fail if exists)
 //
-// This baseline implementation writes uncompressed and unencrypted files only.
+// File entries that request compression are compressed as they are written.
+// This baseline implementation writes unencrypted files only.
 //
 // Specification: api_core.md: 1.3 Package Write Operations
 // Specification: api_writing.md: 5.3.3 Package.Write Method
@@ -72,29 +81,49 @@ func (p *filePackage) SafeWrite(ctx context.Context, overwrite bool) error {
 	if err := internal.CheckContext(ctx, "SafeWrite"); err != nil {
 		return err
 	}
+	if err := p.checkSignedWrite("SafeWrite"); err != nil {
+		return err
+	}
 
 	// Validate package has a file path configured
 	if p.FilePath == "" {
 		return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "package has no file path configured", nil, struct{}{})
 	}
 
+	// A signed package retargeted to another path is written there unsigned; the
+	// signatures stay in memory until the write succeeds
+	if p.writesUnsigned() {
+		restore := p.stripSignaturesForWrite()
+		if err := p.safeWriteTo(ctx, p.FilePath, overwrite); err != nil {
+			restore()
+			return err
+		}
+		p.setSignedFile(nil)
+		return nil
+	}
+	return p.safeWriteTo(ctx, p.FilePath, overwrite)
+}
+
+// safeWriteTo writes the package to targetPath through a temporary file in the same
+// directory and an atomic rename.
+func (p *filePackage) safeWriteTo(ctx context.Context, targetPath string, overwrite bool) error {
 	// Check if file exists and handle overwrite flag
 	if !overwrite {
-		if _, err := os.Stat(p.FilePath); err == nil {
+		if _, err := os.Stat(targetPath); err == nil {
 			return pkgerrors.NewPackageError(pkgerrors.ErrTypeValidation, "file already exists and overwrite is false", nil, pkgerrors.ValidationErrorContext{
 				Field:    "FilePath",
-				Value:    p.FilePath,
+				Value:    targetPath,
 				Expected: "non-existing file or overwrite=true",
 			})
 		}
 	}
 
 	// Create temp file in same directory as target (for atomic rename)
-	tempFile, err := os.CreateTemp(filepath.Dir(p.FilePath), ".nvpk-temp-*")
+	tempFile, err := os.CreateTemp(filepath.Dir(targetPath), ".nvpk-temp-*")
 	if err != nil {
 		return pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeIO, "failed to create temp file", pkgerrors.ValidationErrorContext{
 			Field: "FilePath",
-			Value: p.FilePath,
+			Value: targetPath,
 		})
 	}
 	tempPath := tempFile.Name()
@@ -109,7 +138,7 @@ func (p *filePackage) SafeWrite(ctx context.Context, overwrite bool) error {
 	}()
 
 	// Write package to temp file
-	if err := p.writePackageToFile(ctx, tempFile); err != nil {
+	if err := p.writePackageContent(ctx, tempFile); err != nil {
 		writeErr = err
 		return err
 	}
@@ -124,10 +153,10 @@ func (p *filePackage) SafeWrite(ctx context.Context, overwrite bool) error {
 	}
 
 	// Atomic rename to target path
-	if err := os.Rename(tempPath, p.FilePath); err != nil {
+	if err := os.Rename(tempPath, targetPath); err != nil {
 		writeErr = pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeIO, "failed to rename temp file to target", pkgerrors.ValidationErrorContext{
 			Field:    "FilePath",
-			Value:    p.FilePath,
+			Value:    targetPath,
 			Expected: "successful rename",
 		})
 		return writeErr
@@ -221,13 +250,26 @@ func (p *filePackage) writePackageToFile(ctx context.Context, file *os.File) err
 	index.FirstEntryOffset = currentOffset
 	index.Entries = make([]fileformat.IndexEntry, 0, len(p.FileEntries))
 
+	// Build solid group blocks before any member metadata is written
+	solidBlocks, err := p.buildSolidGroupBlocks(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Encrypt path sections of a confidential index
+	if err := p.sealPathSections(ctx); err != nil {
+		return err
+	}
+
 	// Write interleaved file entry metadata and file data
 	for _, fe := range p.FileEntries {
 		if fe == nil {
 			continue
 		}
 
-		if fe.IsDataLoaded {
+		storedData, inSolidGroup := solidBlocks[fe]
+		encode := !inSolidGroup && needsFileEntryEncoding(fe)
+		if !inSolidGroup && fe.IsDataLoaded {
 			p.syncStoredMetadataFromMemory(fe)
 		}
 
@@ -247,6 +289,28 @@ func (p *filePackage) writePackageToFile(ctx context.Context, file *os.File) err
 
 		// Write file data
 		switch {
+		case encode:
+			// Stream raw data through compression and encryption, then rewrite the
+			// metadata with the stored size and checksums
+			n, err := p.writeEncodedFileEntryData(ctx, fe, file, int64(currentOffset))
+			if err != nil {
+				return err
+			}
+			currentOffset += uint64(n)
+
+			if err := p.rewriteFileEntryMeta(file, entryOffset, fe); err != nil {
+				return err
+			}
+			if _, err := file.Seek(int64(currentOffset), io.SeekStart); err != nil {
+				return pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to seek back to end after metadata rewrite")
+			}
+		case storedData != nil:
+			// Write the solid group block
+			n, err := file.Write(storedData)
+			if err != nil {
+				return pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to write encoded file data")
+			}
+			currentOffset += uint64(n)
 		case fe.IsDataLoaded:
 			// Write in-memory data
 			n, err := file.Write(fe.Data)
@@ -268,9 +332,9 @@ func (p *filePackage) writePackageToFile(ctx context.Context, file *os.File) err
 			if needsChecksums {
 				hasher := crc32.NewIEEE()
 				writer := io.MultiWriter(file, hasher)
-				n, err := io.CopyN(writer, fe.SourceFile, dataSize)
+				n, err := copyWithPooledBuffer(ctx, writer, fe.SourceFile, dataSize)
 				if err != nil {
-					return pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to copy file data from source")
+					return err
 				}
 				if n != dataSize {
 					return pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "source file size mismatch during write", nil, pkgerrors.ValidationErrorContext{
@@ -280,8 +344,10 @@ func (p *filePackage) writePackageToFile(ctx context.Context, file *os.File) err
 					})
 				}
 
+				// Stored data that is compressed or encrypted does not give the
+				// raw checksum; an empty file's raw checksum is 0
 				checksum := hasher.Sum32()
-				if fe.RawChecksum == 0 {
+				if fe.RawChecksum == 0 && fe.ProcessingState == metadata.ProcessingStateRaw {
 					fe.RawChecksum = checksum
 				}
 				if fe.StoredChecksum == 0 {
@@ -349,6 +415,21 @@ func (p *filePackage) writePackageToFile(ctx context.Context, file *os.File) err
 	// Update index metadata
 	index.EntryCount = uint32(len(index.Entries))
 
+	// Record whether any file entry is stored compressed or encrypted, and whether
+	// the index is confidential
+	p.header.Flags &^= fileformat.FlagHasCompressedFiles | fileformat.FlagHasEncryptedFiles | fileformat.FlagConfidentialIndex
+	if p.confidentialIndex != nil {
+		p.header.Flags |= fileformat.FlagConfidentialIndex
+	}
+	for _, fe := range p.FileEntries {
+		if fe != nil && fe.CompressionType != fileformat.CompressionNone {
+			p.header.Flags |= fileformat.FlagHasCompressedFiles
+		}
+		if fe != nil && fe.EncryptionType != fileformat.EncryptionNone {
+			p.header.Flags |= fileformat.FlagHasEncryptedFiles
+		}
+	}
+
 	// Write file index
 	indexStart := currentOffset
 	indexWritten, err := writeFileIndexTo(file, index)
@@ -384,9 +465,6 @@ func (p *filePackage) writePackageToFile(ctx context.Context, file *os.File) err
 		p.header.CommentSize = 0
 	}
 
-	// No signatures in baseline implementation
-	p.header.SignatureOffset = 0
-
 	// Seek back to beginning and write updated header
 	if _, err := file.Seek(0, 0); err != nil {
 		return pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to seek to beginning for header update")
@@ -456,6 +534,196 @@ func (p *filePackage) syncStoredMetadataFromMemory(fe *metadata.FileEntry) {
 	}
 }
 
+// needsFileEntryEncoding reports whether a file entry requests compression or
+// encryption and holds raw data that has not been written in stored form yet.
+// Entries whose data is already in stored form, for example entries loaded from
+// an existing package or staged by AddFileFromReader, are written as-is.
+func needsFileEntryEncoding(fe *metadata.FileEntry) bool {
+	if fe.CompressionType == fileformat.CompressionNone && fe.EncryptionType == fileformat.EncryptionNone {
+		return false
+	}
+	return fe.IsDataLoaded || (fe.SourceFile != nil && fe.ProcessingState == metadata.ProcessingStateRaw)
+}
+
+// writeEncodedFileEntryData streams the raw data of a file entry from memory or
+// its staged source file through compression, then encryption with the entry's
+// registered key, into file at dataOffset, the current end of file. The entry's
+// CompressionLevel, StoredSize and StoredChecksum are updated, and OriginalSize
+// and RawChecksum are set when unset. Memory use does not depend on the size of
+// the data.
+//
+// Returns:
+//   - int64: Number of stored bytes written
+//   - error: *PackageError on failure
+func (p *filePackage) writeEncodedFileEntryData(ctx context.Context, fe *metadata.FileEntry, file *os.File, dataOffset int64) (int64, error) {
+	// Resolve the dictionary before taking a pooled buffer for frames or copying
+	var dict []byte
+	if dictID, ok := fe.GetCompressionDictionaryID(); ok && fe.CompressionType == fileformat.CompressionZstd {
+		var err error
+		if dict, err = p.compressionDictionary(ctx, dictID); err != nil {
+			return 0, err
+		}
+	}
+
+	source, size := rawFileEntrySource(fe)
+	if fe.OriginalSize == 0 {
+		fe.OriginalSize = uint64(size)
+	}
+	rawHash := crc32.NewIEEE()
+	raw := io.TeeReader(source, rawHash)
+	if fe.CompressionType != fileformat.CompressionNone && fe.CompressionLevel == 0 {
+		fe.CompressionLevel = internal.DefaultCompressionLevel
+	}
+
+	storedHash := crc32.NewIEEE()
+	frameSize, framed := fe.GetCompressionFrameSize()
+	if framed {
+		if err := p.encodeFramedData(ctx, fe, io.NewOffsetWriter(file, dataOffset), raw, frameSize); err != nil {
+			return 0, err
+		}
+	} else {
+		read, err := p.encodeStreamData(ctx, fe, io.MultiWriter(file, storedHash), raw, dict)
+		if err != nil {
+			return 0, err
+		}
+		if read != size {
+			return 0, pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "source file size mismatch during write", nil, pkgerrors.ValidationErrorContext{
+				Field:    "SourceSize",
+				Value:    read,
+				Expected: fmt.Sprintf("%d", size),
+			})
+		}
+	}
+
+	end, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to seek to end of encoded file data")
+	}
+	stored := end - dataOffset
+	if framed {
+		// The frame table is filled in behind the frames, so the stored data is
+		// hashed once it is complete
+		if _, err := copyWithPooledBuffer(ctx, storedHash, io.NewSectionReader(file, dataOffset, stored), stored); err != nil {
+			return 0, err
+		}
+	}
+
+	if fe.RawChecksum == 0 {
+		fe.RawChecksum = rawHash.Sum32()
+	}
+	fe.StoredSize = uint64(stored)
+	fe.StoredChecksum = storedHash.Sum32()
+	return stored, nil
+}
+
+// encodeFramedData compresses the raw data read from raw into seekable frames
+// written to w from offset 0, one frame at a time. Encrypted frames are sealed as
+// they are written; the chunks holding the frame table, which is filled in as
+// frames are written, are sealed last.
+func (p *filePackage) encodeFramedData(ctx context.Context, fe *metadata.FileEntry, w io.WriterAt, raw io.Reader, frameSize uint32) error {
+	// Resolve the key before taking a pooled buffer for frames
+	out := w
+	var sealer *internal.EncryptWriterAt
+	if fe.EncryptionType != fileformat.EncryptionNone {
+		tableSize := internal.FrameTableSize(internal.FrameCount(fe.OriginalSize, frameSize))
+		var err error
+		if sealer, err = p.newFileEntryEncryptWriterAt(ctx, fe, w, int64(tableSize)); err != nil {
+			return err
+		}
+		out = sealer
+	}
+	buf, release, err := acquireBuffer(ctx, int64(frameSize))
+	if err != nil {
+		return err
+	}
+	_, err = internal.CompressFramesTo(out, raw, fe.CompressionType, int(fe.CompressionLevel), frameSize, fe.OriginalSize, buf)
+	release()
+	if err != nil || sealer == nil {
+		return err
+	}
+	return sealer.Close()
+}
+
+// encodeStreamData streams the raw data read from raw through the entry's
+// compressor and, for encrypted entries, the chunk sealer into w. It returns the
+// number of raw bytes read; a short read is reported by the caller.
+func (p *filePackage) encodeStreamData(ctx context.Context, fe *metadata.FileEntry, w io.Writer, raw io.Reader, dict []byte) (int64, error) {
+	size := int64(fe.OriginalSize)
+	out := w
+	var sealer io.WriteCloser
+	if fe.EncryptionType != fileformat.EncryptionNone {
+		var err error
+		if sealer, err = p.newFileEntryEncryptWriter(ctx, fe, w); err != nil {
+			return 0, err
+		}
+		out = sealer
+	}
+	encoder, err := internal.NewCompressWriter(out, fe.CompressionType, int(fe.CompressionLevel), dict, size)
+	if err != nil {
+		return 0, err
+	}
+	read, err := copyWithPooledBuffer(ctx, encoder, raw, size)
+	closeErr := encoder.Close()
+	// A short stream also fails Close; the caller reports it as a short read
+	if err != nil || read != size {
+		return read, err
+	}
+	if closeErr != nil {
+		return read, closeErr
+	}
+	if sealer != nil {
+		return read, sealer.Close()
+	}
+	return read, nil
+}
+
+// rawFileEntrySource returns a reader of the raw data of a file entry for which
+// needsFileEntryEncoding reports true, and the size of that data.
+func rawFileEntrySource(fe *metadata.FileEntry) (io.Reader, int64) {
+	if fe.IsDataLoaded {
+		return bytes.NewReader(fe.Data), int64(len(fe.Data))
+	}
+	size := fe.SourceSize
+	if size == 0 {
+		size = int64(fe.OriginalSize)
+	}
+	return io.NewSectionReader(fe.SourceFile, fe.SourceOffset, size), size
+}
+
+// rawFileEntryData returns the uncompressed data of a file entry that has not been
+// written in stored form yet: data held in memory, or raw data in SourceFile.
+// Returns false when the entry's data is already in stored form.
+func rawFileEntryData(fe *metadata.FileEntry) ([]byte, bool, error) {
+	switch {
+	case fe.IsDataLoaded:
+		return fe.Data, true, nil
+	case fe.SourceFile != nil && fe.ProcessingState == metadata.ProcessingStateRaw:
+		size := fe.SourceSize
+		if size == 0 {
+			size = int64(fe.OriginalSize)
+		}
+		raw := make([]byte, size)
+		if err := readRawSourceInto(fe, raw); err != nil {
+			return nil, false, err
+		}
+		return raw, true, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+// readRawSourceInto fills raw with the staged source data of a file entry.
+func readRawSourceInto(fe *metadata.FileEntry, raw []byte) error {
+	if _, err := fe.SourceFile.ReadAt(raw, fe.SourceOffset); err != nil {
+		return pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeIO, "failed to read source file data", pkgerrors.ValidationErrorContext{
+			Field:    "SourceSize",
+			Value:    len(raw),
+			Expected: "readable source data",
+		})
+	}
+	return nil
+}
+
 func (p *filePackage) rewriteFileEntryMeta(file *os.File, entryOffset uint64, fe *metadata.FileEntry) error {
 	if _, err := file.Seek(int64(entryOffset), io.SeekStart); err != nil {
 		return pkgerrors.WrapError(err, pkgerrors.ErrTypeIO, "failed to seek to file entry metadata for rewrite")
@@ -6,10 +6,15 @@
 package novus_package
 
 import (
+	"bytes"
 	"context"
+	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
+
+	"github.com/novus-engine/novuspack/api/go/fileformat"
 )
 
 func TestPackage_WritePackageToFile_StreamFromDisk(t *testing.T) {
@@ -183,3 +188,74 @@ func TestPackage_Write_MultipleWriteCycles(t *testing.T) {
 		t.Fatalf("Second Write failed: %v", err)
 	}
 }
+
+func TestPackage_Write_EncodesStagedFileInBoundedMemory(t *testing.T) {
+	ctx := context.Background()
+	const size = 64 << 20
+	key := testEncryptionKey("stream", 0x3c)
+
+	source := filepath.Join(t.TempDir(), "large.bin")
+	file, err := os.Create(source)
+	if err != nil {
+		t.Fatalf("Failed to create source file: %v", err)
+	}
+	if _, err := io.Copy(file, &patternReader{remaining: size}); err != nil {
+		t.Fatalf("Failed to write source file: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatalf("Failed to close source file: %v", err)
+	}
+
+	compressedEncrypted := compressionOptions(fileformat.CompressionZstd, 0)
+	compressedEncrypted.EncryptionKey.Set(key)
+	framedEncrypted := framedRangeOptions(fileformat.CompressionLZ4)
+	framedEncrypted.EncryptionKey.Set(key)
+	// Seekable compression allocates each compressed frame, so only streamed
+	// encodings are checked against the allocation bound
+	tests := []struct {
+		name     string
+		opts     *AddFileOptions
+		streamed bool
+	}{
+		{"compressed", compressionOptions(fileformat.CompressionZstd, 0), true},
+		{"encrypted", encryptedOptions(key), true},
+		{"compressed and encrypted", compressedEncrypted, true},
+		{"framed and encrypted", framedEncrypted, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg, err := NewPackage()
+			if err != nil {
+				t.Fatalf("NewPackage failed: %v", err)
+			}
+			defer func() { _ = pkg.Close() }()
+			if err := pkg.AddEncryptionKey(key); err != nil {
+				t.Fatalf("AddEncryptionKey failed: %v", err)
+			}
+			fe, err := pkg.AddFile(ctx, source, tt.opts)
+			if err != nil {
+				t.Fatalf("AddFile failed: %v", err)
+			}
+
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			reopened := writeAndReopen(t, ctx, pkg)
+			runtime.ReadMemStats(&after)
+			defer func() { _ = reopened.Close() }()
+
+			if allocated := after.TotalAlloc - before.TotalAlloc; tt.streamed && allocated > size/2 {
+				t.Errorf("writing a %d byte file allocated %d bytes", size, allocated)
+			}
+			if err := reopened.AddEncryptionKey(key); err != nil {
+				t.Fatalf("AddEncryptionKey failed: %v", err)
+			}
+			got, err := reopened.ReadFile(ctx, fe.Paths[0].Path)
+			if err != nil {
+				t.Fatalf("ReadFile failed: %v", err)
+			}
+			if want, _ := io.ReadAll(&patternReader{remaining: size}); !bytes.Equal(got, want) {
+				t.Errorf("ReadFile returned %d bytes not matching the %d byte source", len(got), size)
+			}
+		})
+	}
+}
@@ -40,11 +40,21 @@ type (
 // Re-export package operation types from novus_package
 type (
 	FileInfo               = novus_package.FileInfo
+	FileStream             = novus_package.FileStream
 	AddFileOptions         = novus_package.AddFileOptions
 	RemoveDirectoryOptions = novus_package.RemoveDirectoryOptions
 	CreateOptions          = novus_package.CreateOptions
 	CompressionType        = novus_package.CompressionType
+	EncryptionAlgorithm    = novus_package.EncryptionAlgorithm
 	EncryptionType         = novus_package.EncryptionType
+	EncryptionKey          = novus_package.EncryptionKey
+	MLKEMKey               = novus_package.MLKEMKey
+	PassphraseOptions      = novus_package.PassphraseOptions
+	ValidateOptions        = novus_package.ValidateOptions
+	ReadOnlyOptions        = novus_package.ReadOnlyOptions
+	TrustStore             = novus_package.TrustStore
+	StaticTrustStore       = novus_package.StaticTrustStore
+	CurrentTimeTrustStore  = novus_package.CurrentTimeTrustStore
 )
 
 // Re-export types from pkgerrors
@@ -58,6 +68,7 @@ type (
 	PackageHeader = fileformat.PackageHeader
 	FileIndex     = fileformat.FileIndex
 	IndexEntry    = fileformat.IndexEntry
+	FileType      = fileformat.FileType
 )
 
 // Re-export types from metadata
@@ -78,12 +89,17 @@ type (
 	PackageComment = metadata.PackageComment
 	PackageInfo    = metadata.PackageInfo
 	SecurityLevel  = metadata.SecurityLevel
+	SecurityStatus = metadata.SecurityStatus
+
+	SecurityValidationResult = metadata.SecurityValidationResult
 )
 
 // Re-export types from signatures
 type (
 	Signature     = signatures.Signature
 	SignatureInfo = signatures.SignatureInfo
+
+	SignatureValidationResult = signatures.SignatureValidationResult
 )
 
 // Re-export generic types from generics package
@@ -121,6 +137,19 @@ type (
 
 	// TagValueType represents the type of a tag value.
 	TagValueType = generics.TagValueType
+
+	// BufferPool manages reusable buffers in size classes under a shared memory limit.
+	// Packages take transient buffers from the pool returned by SharedBufferPool.
+	BufferPool[T any] = generics.BufferPool[T]
+
+	// BufferConfig configures buffer pool limits and eviction.
+	BufferConfig = generics.BufferConfig
+
+	// BufferPoolStats provides statistics about buffer pool usage.
+	BufferPoolStats = generics.BufferPoolStats
+
+	// ThreadSafetyMode defines the level of thread safety guarantees.
+	ThreadSafetyMode = generics.ThreadSafetyMode
 )
 
 // Re-export constants from errors
@@ -133,9 +162,40 @@ const (
 	ErrTypeCorruption  = pkgerrors.ErrTypeCorruption
 )
 
+// Re-export encryption key types from novus_package. These are EncryptionKey.KeyType
+// values; the Encryption* constants below are on-disk FileEntry encryption types.
+const (
+	EncryptionAlgorithmAES256GCM        = novus_package.EncryptionAlgorithmAES256GCM
+	EncryptionAlgorithmChaCha20Poly1305 = novus_package.EncryptionAlgorithmChaCha20Poly1305
+	EncryptionAlgorithmMLKEM512         = novus_package.EncryptionAlgorithmMLKEM512
+	EncryptionAlgorithmMLKEM768         = novus_package.EncryptionAlgorithmMLKEM768
+	EncryptionAlgorithmMLKEM1024        = novus_package.EncryptionAlgorithmMLKEM1024
+)
+
+// Re-export thread safety modes from generics
+const (
+	ThreadSafetyNone       = generics.ThreadSafetyNone
+	ThreadSafetyReadOnly   = generics.ThreadSafetyReadOnly
+	ThreadSafetyConcurrent = generics.ThreadSafetyConcurrent
+	ThreadSafetyFull       = generics.ThreadSafetyFull
+)
+
+// Re-export buffer pool eviction policies from generics
+const (
+	EvictionPolicyLRU  = generics.EvictionPolicyLRU
+	EvictionPolicyTime = generics.EvictionPolicyTime
+)
+
 // Re-export constants from metadata
 const (
 	MaxCommentLength = metadata.MaxCommentLength
+
+	// Package security levels
+	SecurityLevelNone    = metadata.SecurityLevelNone
+	SecurityLevelLow     = metadata.SecurityLevelLow
+	SecurityLevelMedium  = metadata.SecurityLevelMedium
+	SecurityLevelHigh    = metadata.SecurityLevelHigh
+	SecurityLevelMaximum = metadata.SecurityLevelMaximum
 )
 
 // Re-export constants from fileformat
@@ -152,9 +212,10 @@ const (
 	CompressionLZMA = fileformat.CompressionLZMA
 
 	// Encryption types
-	EncryptionNone        = fileformat.EncryptionNone
-	EncryptionAES256GCM   = fileformat.EncryptionAES256GCM
-	EncryptionQuantumSafe = fileformat.EncryptionQuantumSafe
+	EncryptionNone             = fileformat.EncryptionNone
+	EncryptionAES256GCM        = fileformat.EncryptionAES256GCM
+	EncryptionQuantumSafe      = fileformat.EncryptionQuantumSafe
+	EncryptionChaCha20Poly1305 = fileformat.EncryptionChaCha20Poly1305
 
 	// Hash types
 	HashTypeSHA256   = fileformat.HashTypeSHA256
@@ -196,6 +257,9 @@ const (
 	FlagHasSpecialMetadata = fileformat.FlagHasSpecialMetadata
 	FlagMetadataOnly       = fileformat.FlagMetadataOnly
 
+	// Package index flags
+	FlagConfidentialIndex = fileformat.FlagConfidentialIndex
+
 	// Flags field bit masks
 	FlagsMaskFeatures        = fileformat.FlagsMaskFeatures
 	FlagsMaskCompressionType = fileformat.FlagsMaskCompressionType
@@ -234,6 +298,9 @@ const (
 	SignatureTypeSLHDSA = signatures.SignatureTypeSLHDSA
 	SignatureTypePGP    = signatures.SignatureTypePGP
 	SignatureTypeX509   = signatures.SignatureTypeX509
+
+	SignatureTypeEd25519   = signatures.SignatureTypeEd25519
+	SignatureTypeECDSAP256 = signatures.SignatureTypeECDSAP256
 )
 
 // Re-export constants from generics (tag value types)
@@ -293,6 +360,25 @@ const (
 	TagValueTypeNovusPackMetadata = generics.TagValueTypeNovusPackMetadata
 )
 
+// Re-export passphrase constants from novus_package
+const (
+	DefaultPassphraseIterations = novus_package.DefaultPassphraseIterations
+	MinPassphraseIterations     = novus_package.MinPassphraseIterations
+	MaxPassphraseIterations     = novus_package.MaxPassphraseIterations
+)
+
+// Re-export encryption key functions from novus_package
+var (
+	NewEncryptionKey      = novus_package.NewEncryptionKey
+	GenerateEncryptionKey = novus_package.GenerateEncryptionKey
+	GenerateMLKEMKey      = novus_package.GenerateMLKEMKey
+)
+
+// Re-export signature verification functions from novus_package
+var (
+	NewTrustStore = novus_package.NewTrustStore
+)
+
 // Re-export functions from metadata
 var (
 	NewPackageComment = metadata.NewPackageComment
@@ -301,8 +387,10 @@ var (
 
 // Re-export functions from fileformat
 var (
-	NewPackageHeader = fileformat.NewPackageHeader
-	NewFileIndex     = fileformat.NewFileIndex
+	NewPackageHeader      = fileformat.NewPackageHeader
+	NewFileIndex          = fileformat.NewFileIndex
+	DetermineFileType     = fileformat.DetermineFileType
+	SelectCompressionType = fileformat.SelectCompressionType
 )
 
 // Re-export functions from signatures
@@ -425,6 +513,43 @@ func Err[T any](err error) Result[T] {
 	return generics.Err[T](err)
 }
 
+// NewBufferPool creates a new buffer pool for the specified type.
+//
+// A nil config selects DefaultBufferConfig.
+//
+// Example:
+//
+//	pool := NewBufferPool[byte](&BufferConfig{MaxTotalSize: 256 << 20})
+//	novuspack.SetSharedBufferPool(pool)
+//
+// Specification: api_generics.md: 2.3.2 NewBufferPool Function
+func NewBufferPool[T any](config *BufferConfig) *BufferPool[T] {
+	return generics.NewBufferPool[T](config)
+}
+
+// DefaultBufferConfig returns a buffer configuration with default values.
+//
+// Specification: api_streaming.md: 2.6.1 DefaultBufferConfig Function
+func DefaultBufferConfig() *BufferConfig {
+	return generics.DefaultBufferConfig()
+}
+
+// SharedBufferPool returns the buffer pool used by all packages for transient
+// buffers.
+//
+// Specification: api_streaming.md: 2.7.1 SharedBufferPool Function
+func SharedBufferPool() *BufferPool[byte] {
+	return novus_package.SharedBufferPool()
+}
+
+// SetSharedBufferPool replaces the buffer pool used by all packages; nil restores
+// the default pool.
+//
+// Specification: api_streaming.md: 2.7.2 SetSharedBufferPool Function
+func SetSharedBufferPool(pool *BufferPool[byte]) {
+	novus_package.SetSharedBufferPool(pool)
+}
+
 // =============================================================================
 // PATH AND VALIDATION UTILITIES
 // =============================================================================
@@ -609,6 +734,59 @@ func OpenPackageReadOnly(ctx context.Context, path string) (Package, error) {
 	return novus_package.OpenPackageReadOnly(ctx, path)
 }
 
+// OpenPackageReadOnlyWithOptions opens a package in read-only mode and applies options.
+//
+// With options.MemoryMap, the package file is memory-mapped and uncompressed,
+// unencrypted content is returned from ReadFile and ReadFileRange as read-only
+// slices of the mapping, valid until Close. Files that cannot be mapped use
+// regular I/O.
+//
+// Example:
+//
+//	pkg, err := novuspack.OpenPackageReadOnlyWithOptions(ctx, "assets.nvpk", &novuspack.ReadOnlyOptions{MemoryMap: true})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer pkg.Close()
+//
+// Specification: api_basic_operations.md: 11.7 OpenPackageReadOnlyWithOptions Function
+func OpenPackageReadOnlyWithOptions(ctx context.Context, path string, options *ReadOnlyOptions) (Package, error) {
+	return novus_package.OpenPackageReadOnlyWithOptions(ctx, path, options)
+}
+
+// OpenPackageWithPassphrase opens a passphrase-protected package and unlocks it.
+//
+// The passphrase key is derived from the KDF parameters stored in the package, so
+// ReadFile decrypts passphrase-protected files transparently.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - path: File path to the package to open
+//   - passphrase: Package passphrase
+//
+// Returns:
+//   - Package: The opened, unlocked package
+//   - error: *PackageError on failure
+//
+// Error Conditions:
+//   - All errors from OpenPackage
+//   - ErrTypeValidation: The package is not passphrase protected
+//   - ErrTypeEncryption: The passphrase is wrong
+//
+// Example:
+//
+//	pkg, err := novuspack.OpenPackageWithPassphrase(ctx, "mod.nvpk", passphrase)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer pkg.Close()
+//	data, err := pkg.ReadFile(ctx, "/textures/hero.dds")
+//
+// Specification: api_security.md: 7.4 OpenPackageWithPassphrase Function
+func OpenPackageWithPassphrase(ctx context.Context, path, passphrase string) (Package, error) {
+	return novus_package.OpenPackageWithPassphrase(ctx, path, passphrase)
+}
+
 // OpenBrokenPackage opens a package that may be invalid or partially corrupted.
 //
 // This function is intended for repair workflows and forensic inspection.
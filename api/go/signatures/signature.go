@@ -13,6 +13,7 @@
 package signatures
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -125,17 +126,6 @@ func NewSignature() *Signature {
 	return &Signature{}
 }
 
-// ReadFrom reads a Signature from the provided io.Reader.
-//
-// The binary format is:
-//   - SignatureType (4 bytes, little-endian uint32)
-//   - SignatureSize (4 bytes, little-endian uint32)
-//   - SignatureFlags (4 bytes, little-endian uint32)
-//   - SignatureTimestamp (4 bytes, little-endian uint32)
-//   - CommentLength (2 bytes, little-endian uint16)
-//   - SignatureComment (CommentLength bytes, UTF-8 string with null terminator)
-//   - SignatureData (SignatureSize bytes)
-//
 // readSignatureHeader reads the 18-byte fixed header into s; returns bytes read and error.
 func readSignatureHeader(r io.Reader, s *Signature) (int64, error) {
 	if err := binary.Read(r, binary.LittleEndian, &s.SignatureType); err != nil {
@@ -177,20 +167,12 @@ func readSignatureComment(r io.Reader, s *Signature) (int64, error) {
 		s.SignatureComment = ""
 		return 0, nil
 	}
-	commentBytes := make([]byte, s.CommentLength)
-	n, err := io.ReadFull(r, commentBytes)
+	commentBytes, err := readSignatureField(r, int64(s.CommentLength), "SignatureComment", "comment")
 	if err != nil {
-		return 0, pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeIO, "failed to read signature comment", pkgerrors.ValidationErrorContext{
-			Field: "SignatureComment", Value: s.CommentLength, Expected: "comment data",
-		})
-	}
-	if uint16(n) != s.CommentLength {
-		return int64(n), pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "incomplete comment read", nil, pkgerrors.ValidationErrorContext{
-			Field: "SignatureComment", Value: n, Expected: fmt.Sprintf("%d bytes", s.CommentLength),
-		})
+		return int64(len(commentBytes)), err
 	}
 	s.SignatureComment = string(commentBytes)
-	return int64(n), nil
+	return int64(len(commentBytes)), nil
 }
 
 // readSignatureData reads SignatureSize bytes into s.SignatureData; returns bytes read and error.
@@ -199,26 +181,47 @@ func readSignatureData(r io.Reader, s *Signature) (int64, error) {
 		s.SignatureData = nil
 		return 0, nil
 	}
-	signatureData := make([]byte, s.SignatureSize)
-	n, err := io.ReadFull(r, signatureData)
+	signatureData, err := readSignatureField(r, int64(s.SignatureSize), "SignatureData", "signature data")
 	if err != nil {
-		return 0, pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeIO, "failed to read signature data", pkgerrors.ValidationErrorContext{
-			Field: "SignatureData", Value: s.SignatureSize, Expected: "signature data",
+		return int64(len(signatureData)), err
+	}
+	s.SignatureData = signatureData
+	return int64(len(signatureData)), nil
+}
+
+// readSignatureField reads size bytes of a variable-length field. The buffer grows
+// with the data actually read, so a corrupt length cannot force a large allocation.
+func readSignatureField(r io.Reader, size int64, field, name string) ([]byte, error) {
+	var buf bytes.Buffer
+	n, err := io.CopyN(&buf, r, size)
+	if err == io.EOF {
+		return buf.Bytes(), pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "incomplete "+name+" read", nil, pkgerrors.ValidationErrorContext{
+			Field: field, Value: n, Expected: fmt.Sprintf("%d bytes", size),
 		})
 	}
-	if uint32(n) != s.SignatureSize {
-		return int64(n), pkgerrors.NewPackageError(pkgerrors.ErrTypeCorruption, "incomplete signature data read", nil, pkgerrors.ValidationErrorContext{
-			Field: "SignatureData", Value: n, Expected: fmt.Sprintf("%d bytes", s.SignatureSize),
+	if err != nil {
+		return buf.Bytes(), pkgerrors.WrapErrorWithContext(err, pkgerrors.ErrTypeIO, "failed to read "+name, pkgerrors.ValidationErrorContext{
+			Field: field, Value: size, Expected: name,
 		})
 	}
-	s.SignatureData = signatureData
-	return int64(n), nil
+	return buf.Bytes(), nil
 }
 
+// ReadFrom reads a Signature from the provided io.Reader.
+//
+// The binary format is:
+//   - SignatureType (4 bytes, little-endian uint32)
+//   - SignatureSize (4 bytes, little-endian uint32)
+//   - SignatureFlags (4 bytes, little-endian uint32)
+//   - SignatureTimestamp (4 bytes, little-endian uint32)
+//   - CommentLength (2 bytes, little-endian uint16)
+//   - SignatureComment (CommentLength bytes, UTF-8 string without a null terminator)
+//   - SignatureData (SignatureSize bytes)
+//
 // Returns the number of bytes read and any error encountered.
 //
-// Specification: package_file_format.md: 8.1 Signature Structure
-func (s *Signature) readFrom(r io.Reader) (int64, error) {
+// Specification: api_signatures.md: 1.4.1 Signature.ReadFrom Method
+func (s *Signature) ReadFrom(r io.Reader) (int64, error) {
 	n, err := readSignatureHeader(r, s)
 	if err != nil {
 		return n, err
@@ -236,22 +239,6 @@ func (s *Signature) readFrom(r io.Reader) (int64, error) {
 	return totalRead + n, nil
 }
 
-// WriteTo writes a Signature to the provided io.Writer.
-//
-// The binary format is:
-//   - SignatureType (4 bytes, little-endian uint32)
-//   - SignatureSize (4 bytes, little-endian uint32)
-//   - SignatureFlags (4 bytes, little-endian uint32)
-//   - SignatureTimestamp (4 bytes, little-endian uint32)
-//   - CommentLength (2 bytes, little-endian uint16)
-//   - SignatureComment (CommentLength bytes, UTF-8 string with null terminator)
-//   - SignatureData (SignatureSize bytes)
-//
-// Before writing, the method updates CommentLength and SignatureSize to match
-// the actual comment and data lengths.
-//
-// Returns the number of bytes written and any error encountered.
-//
 // writeSignatureHeader writes the 18-byte fixed header; s.CommentLength and s.SignatureSize must be set.
 func writeSignatureHeader(w io.Writer, s *Signature) (int64, error) {
 	if err := binary.Write(w, binary.LittleEndian, s.SignatureType); err != nil {
@@ -331,27 +318,57 @@ func writeSignatureData(w io.Writer, s *Signature) (int64, error) {
 	return int64(n), nil
 }
 
-// Specification: package_file_format.md: 8.1 Signature Structure
-func (s *Signature) writeTo(w io.Writer) (int64, error) {
-	s.CommentLength = uint16(len(s.SignatureComment))
+// WriteTo writes a Signature to the provided io.Writer.
+//
+// The binary format is:
+//   - SignatureType (4 bytes, little-endian uint32)
+//   - SignatureSize (4 bytes, little-endian uint32)
+//   - SignatureFlags (4 bytes, little-endian uint32)
+//   - SignatureTimestamp (4 bytes, little-endian uint32)
+//   - CommentLength (2 bytes, little-endian uint16)
+//   - SignatureComment (CommentLength bytes, UTF-8 string without a null terminator)
+//   - SignatureData (SignatureSize bytes)
+//
+// Before writing, the method updates CommentLength and SignatureSize to match
+// the actual comment and data lengths.
+//
+// Returns the number of bytes written and any error encountered.
+//
+// Specification: api_signatures.md: 1.4.2 Signature.WriteTo Method
+func (s *Signature) WriteTo(w io.Writer) (int64, error) {
 	s.SignatureSize = uint32(len(s.SignatureData))
-	n, err := writeSignatureHeader(w, s)
-	if err != nil {
-		return n, err
-	}
-	totalWritten := n
-	n, err = writeSignatureComment(w, s)
+	totalWritten, err := s.WriteMetadataTo(w)
 	if err != nil {
 		return totalWritten, err
 	}
-	totalWritten += n
-	n, err = writeSignatureData(w, s)
+	n, err := writeSignatureData(w, s)
 	if err != nil {
 		return totalWritten, err
 	}
 	return totalWritten + n, nil
 }
 
+// WriteMetadataTo writes the metadata header and comment of the Signature, the
+// part a signature covers in its own signed data. CommentLength is updated to
+// match the comment; SignatureSize is written as set, so it can be hashed before
+// SignatureData exists.
+//
+// Returns the number of bytes written and any error encountered.
+//
+// Specification: api_signatures.md: 1.4.3 Signature.WriteMetadataTo Method
+func (s *Signature) WriteMetadataTo(w io.Writer) (int64, error) {
+	s.CommentLength = uint16(len(s.SignatureComment))
+	n, err := writeSignatureHeader(w, s)
+	if err != nil {
+		return n, err
+	}
+	c, err := writeSignatureComment(w, s)
+	if err != nil {
+		return n, err
+	}
+	return n + c, nil
+}
+
 // HasFlag checks if a specific signature flag is set.
 //
 // Specification: package_file_format.md: 8.2.2 SignatureFlags Field
@@ -18,4 +18,20 @@ const (
 	SignatureTypeSLHDSA = 0x02 // SLH-DSA (Stateless Hash-based Digital Signature Algorithm)
 	SignatureTypePGP    = 0x03 // PGP (Pretty Good Privacy)
 	SignatureTypeX509   = 0x04 // X.509 Certificate-based signature
+
+	SignatureTypeEd25519   = 0x05 // Ed25519 (RFC 8032)
+	SignatureTypeECDSAP256 = 0x06 // ECDSA over NIST P-256, r||s encoding
+)
+
+// Signature size constants
+// Specification: package_file_format.md: 8.3 Signature Data Sizes
+const (
+	SignatureSizeEd25519   = 64 // Ed25519 signature
+	SignatureSizeECDSAP256 = 64 // ECDSA P-256 r and s, 32 bytes each
+)
+
+// Signature flag constants
+// Specification: package_file_format.md: 8.2.2 SignatureFlags Field
+const (
+	SignatureFlagHasTimestamp = 1 << 15 // Bit 15: SignatureTimestamp is set
 )
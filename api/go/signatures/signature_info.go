@@ -12,6 +12,8 @@
 // as specified in docs/tech_specs/package_file_format.md and api_security.md.
 package signatures
 
+import "crypto/x509"
+
 // SignatureInfo represents information about a digital signature in the package.
 //
 // SignatureInfo provides metadata about signatures in the package, including
@@ -49,6 +51,38 @@ type SignatureInfo struct {
 	// SecurityLevel is the security level (1-5)
 	SecurityLevel int
 
+	// Subject is the subject of the signing certificate (X.509 signatures only)
+	Subject string
+
+	// CertificateChain is the signer's certificate chain, leaf first (X.509
+	// signatures only). After successful verification it ends at the trusted root.
+	CertificateChain []*x509.Certificate
+
+	// SignerID identifies the key that verified the signature: the hex SHA-256 of
+	// its PKIX public key, or of the leaf certificate public key for X.509. It is
+	// empty for signatures that did not verify.
+	SignerID string
+
+	// Valid indicates whether signature is valid
+	Valid bool
+
+	// Trusted indicates whether signature is trusted
+	Trusted bool
+
+	// Error contains error message if validation failed
+	Error string
+}
+
+// SignatureValidationResult is the validation result of an individual signature.
+//
+// Specification: api_security.md: 2.3 SignatureValidationResult Struct
+type SignatureValidationResult struct {
+	// Index is the signature index in the package
+	Index int
+
+	// Type is the signature type identifier
+	Type uint32
+
 	// Valid indicates whether signature is valid
 	Valid bool
 
@@ -57,4 +91,10 @@ type SignatureInfo struct {
 
 	// Error contains error message if validation failed
 	Error string
+
+	// Timestamp is the Unix timestamp when signature was created
+	Timestamp uint32
+
+	// PublicKey is the PKIX-encoded public key that verified the signature (if available)
+	PublicKey []byte
 }
@@ -273,14 +273,14 @@ func TestSignatureReadFrom(t *testing.T) {
 
 			// Serialize using WriteTo
 			var writeBuf bytes.Buffer
-			_, writeErr := tt.sig.writeTo(&writeBuf)
+			_, writeErr := tt.sig.WriteTo(&writeBuf)
 			if writeErr != nil {
 				t.Fatalf("WriteTo() error = %v", writeErr)
 			}
 
 			// Deserialize using ReadFrom
 			var sig Signature
-			n, err := sig.readFrom(&writeBuf)
+			n, err := sig.ReadFrom(&writeBuf)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ReadFrom() error = %v, wantErr %v", err, tt.wantErr)
@@ -312,7 +312,7 @@ func TestSignatureReadFrom(t *testing.T) {
 
 				// Verify validation passes
 				if err := sig.validate(); err != nil {
-					t.Errorf("readFrom() signature validation failed: %v", err)
+					t.Errorf("ReadFrom() signature validation failed: %v", err)
 				}
 
 				// Verify SignatureFlags and SignatureTimestamp match
@@ -394,13 +394,13 @@ func TestSignatureReadFromIncompleteData(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			var sig Signature
 			r := bytes.NewReader(tt.data)
-			_, err := sig.readFrom(r)
+			_, err := sig.ReadFrom(r)
 
 			// Check if this is a valid case (zero sizes)
 			isValidZeroCase := strings.Contains(tt.name, "Valid signature with zero")
 			if isValidZeroCase {
 				if err != nil {
-					t.Errorf("readFrom() expected success for valid zero-size signature, got error: %v", err)
+					t.Errorf("ReadFrom() expected success for valid zero-size signature, got error: %v", err)
 				}
 				// Verify the signature was read correctly
 				if sig.SignatureType != 1 {
@@ -413,7 +413,7 @@ func TestSignatureReadFromIncompleteData(t *testing.T) {
 					t.Errorf("CommentLength = %d, want 0", sig.CommentLength)
 				}
 			} else if err == nil {
-				t.Errorf("readFrom() expected error for incomplete data, got nil")
+				t.Errorf("ReadFrom() expected error for incomplete data, got nil")
 			}
 		})
 	}
@@ -491,10 +491,10 @@ func TestSignatureReadFromNonEOFErrors(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			var sig Signature
-			_, err := sig.readFrom(tt.reader)
+			_, err := sig.ReadFrom(tt.reader)
 
 			if (err != nil) != tt.wantErr {
-				t.Errorf("readFrom() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("ReadFrom() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 
@@ -503,7 +503,7 @@ func TestSignatureReadFromNonEOFErrors(t *testing.T) {
 				if strings.Contains(tt.name, "Error reader") {
 					errStr := err.Error()
 					if strings.Contains(errStr, "EOF") || strings.Contains(errStr, "incomplete") {
-						t.Errorf("readFrom() error = %q, want non-EOF error for error reader", errStr)
+						t.Errorf("ReadFrom() error = %q, want non-EOF error for error reader", errStr)
 					}
 				}
 			}
@@ -595,7 +595,7 @@ func TestSignatureWriteTo(t *testing.T) {
 			tt.sig.SignatureSize = uint32(len(tt.sig.SignatureData))
 
 			var buf bytes.Buffer
-			n, err := tt.sig.writeTo(&buf)
+			n, err := tt.sig.WriteTo(&buf)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("WriteTo() error = %v, wantErr %v", err, tt.wantErr)
@@ -614,7 +614,7 @@ func TestSignatureWriteTo(t *testing.T) {
 
 				// Verify we can read it back
 				var sig Signature
-				_, readErr := sig.readFrom(&buf)
+				_, readErr := sig.ReadFrom(&buf)
 				if readErr != nil {
 					t.Errorf("Failed to read back written data: %v", readErr)
 				}
@@ -707,13 +707,13 @@ func TestSignatureRoundTrip(t *testing.T) {
 
 			// Write
 			var buf bytes.Buffer
-			if _, err := tt.sig.writeTo(&buf); err != nil {
+			if _, err := tt.sig.WriteTo(&buf); err != nil {
 				t.Fatalf("WriteTo() error = %v", err)
 			}
 
 			// Read
 			var sig Signature
-			if _, err := sig.readFrom(&buf); err != nil {
+			if _, err := sig.ReadFrom(&buf); err != nil {
 				t.Fatalf("ReadFrom() error = %v", err)
 			}
 
@@ -1037,7 +1037,7 @@ func TestSignatureWriteToErrorPaths(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// WriteTo updates lengths first
-			_, err := tt.sig.writeTo(tt.writer)
+			_, err := tt.sig.WriteTo(tt.writer)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("WriteTo() error = %v, wantErr %v", err, tt.wantErr)
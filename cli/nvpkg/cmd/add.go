@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	novuspack "github.com/novus-engine/novuspack/api/go"
 	"github.com/spf13/cobra"
@@ -25,8 +26,22 @@ var (
 	addNoFollowSymlinks    bool
 	addPreservePermissions bool
 	addPreserveOwnership   bool
+	addCompress            string
+	addCompressionLevel    int
+	addAutoMinSavings      int
 )
 
+// compressAuto is the --compress value that selects compression per file from its type.
+const compressAuto = "auto"
+
+// compressionTypesByName maps --compress values to per-file compression types.
+var compressionTypesByName = map[string]uint8{
+	"none": novuspack.CompressionNone,
+	"zstd": novuspack.CompressionZstd,
+	"lz4":  novuspack.CompressionLZ4,
+	"lzma": novuspack.CompressionLZMA,
+}
+
 func init() {
 	addCmd.Flags().StringVar(&addStoredPath, "as", "", "Store under this path (single file only)")
 	addCmd.Flags().StringVar(&addBasePath, "base-path", "", "Strip this prefix from source paths (at most one of --as, --base-path, --preserve-depth, --flatten)")
@@ -35,6 +50,9 @@ func init() {
 	addCmd.Flags().BoolVar(&addNoFollowSymlinks, "no-follow-symlinks", false, "Do not follow symlinks; reject them")
 	addCmd.Flags().BoolVar(&addPreservePermissions, "preserve-permissions", false, "Store Unix permission bits")
 	addCmd.Flags().BoolVar(&addPreserveOwnership, "preserve-ownership", false, "Store UID/GID (implies --preserve-permissions)")
+	addCmd.Flags().StringVar(&addCompress, "compress", "", "Compress file data: none, zstd, lz4, lzma, auto")
+	addCmd.Flags().IntVar(&addCompressionLevel, "compression-level", 0, "Compression level 1-9 (0=default)")
+	addCmd.Flags().IntVar(&addAutoMinSavings, "min-savings", 10, "With --compress=auto, store files raw unless compression saves this percent (0-99)")
 }
 
 func runAdd(_ *cobra.Command, args []string) error {
@@ -68,7 +86,7 @@ func openOrCreatePackage(ctx context.Context, pkgPath string) (novuspack.Package
 		if err != nil {
 			return nil, fmt.Errorf("new package: %w", err)
 		}
-		if err := pkg.Create(ctx, pkgPath); err != nil {
+		if err := pkg.CreateWithOptions(ctx, pkgPath, &novuspack.CreateOptions{Passphrase: packagePassword}); err != nil {
 			_ = pkg.Close()
 			return nil, fmt.Errorf("create: %w", err)
 		}
@@ -77,12 +95,24 @@ func openOrCreatePackage(ctx context.Context, pkgPath string) (novuspack.Package
 	return openPackage(ctx, pkgPath, false)
 }
 
-// openPackage opens an existing package, optionally read-only. Used by list, read, info, extract, validate.
+// openPackage opens an existing package, optionally read-only, and unlocks it with
+// --password when set. Used by list, read, info, extract, validate.
 func openPackage(ctx context.Context, path string, readOnly bool) (novuspack.Package, error) {
+	var pkg novuspack.Package
+	var err error
 	if readOnly {
-		return novuspack.OpenPackageReadOnly(ctx, path)
+		pkg, err = novuspack.OpenPackageReadOnly(ctx, path)
+	} else {
+		pkg, err = novuspack.OpenPackage(ctx, path)
+	}
+	if err != nil || packagePassword == "" {
+		return pkg, err
+	}
+	if err := pkg.UnlockPassphrase(ctx, packagePassword); err != nil {
+		_ = pkg.Close()
+		return nil, fmt.Errorf("unlock: %w", err)
 	}
-	return novuspack.OpenPackage(ctx, path)
+	return pkg, nil
 }
 
 const (
@@ -108,6 +138,9 @@ func buildAddFileOptions(flags map[string]string) (*novuspack.AddFileOptions, er
 		}
 	} else {
 		pathOptCount = applyAddFileOptionsFromVars(opts)
+		if err := applyCompressionOptions(opts, addCompress, addCompressionLevel, addAutoMinSavings); err != nil {
+			return nil, err
+		}
 	}
 	if pathOptCount > 1 {
 		return nil, fmt.Errorf("at most one of --as, --base-path, --preserve-depth, --flatten may be set")
@@ -146,6 +179,25 @@ func applyAddFileOptionsFromFlags(flags map[string]string, opts *novuspack.AddFi
 	if flagBool(flags["preserve-ownership"]) {
 		opts.PreserveOwnership.Set(true)
 	}
+	level := 0
+	if v := flags["compression-level"]; v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("invalid --compression-level: %s", v)
+		}
+		level = l
+	}
+	minSavings := -1
+	if v := flags["min-savings"]; v != "" {
+		m, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("invalid --min-savings: %s", v)
+		}
+		minSavings = m
+	}
+	if err := applyCompressionOptions(opts, flags["compress"], level, minSavings); err != nil {
+		return 0, err
+	}
 	return n, nil
 }
 
@@ -179,6 +231,40 @@ func applyAddFileOptionsFromVars(opts *novuspack.AddFileOptions) int {
 	return n
 }
 
+// applyCompressionOptions sets compression options from --compress, --compression-level and --min-savings.
+// An empty name leaves compression unset unless a level is given. minSavings only applies to
+// --compress=auto; a negative value keeps the library default.
+func applyCompressionOptions(opts *novuspack.AddFileOptions, name string, level, minSavings int) error {
+	if level < 0 || level > 9 {
+		return fmt.Errorf("invalid --compression-level: %d (want 0-9)", level)
+	}
+	if name == "" {
+		if level != 0 {
+			return fmt.Errorf("--compression-level requires --compress")
+		}
+		return nil
+	}
+	if level != 0 {
+		opts.CompressionLevel.Set(level)
+	}
+	if strings.EqualFold(name, compressAuto) {
+		if minSavings > 99 {
+			return fmt.Errorf("invalid --min-savings: %d (want 0-99)", minSavings)
+		}
+		opts.AutoCompress.Set(true)
+		if minSavings >= 0 {
+			opts.AutoCompressMinSavings.Set(minSavings)
+		}
+		return nil
+	}
+	compressionType, ok := compressionTypesByName[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("invalid --compress: %s (want none, zstd, lz4, lzma, auto)", name)
+	}
+	opts.CompressionType.Set(compressionType)
+	return nil
+}
+
 func addSources(ctx context.Context, pkg novuspack.Package, sources []string, opts *novuspack.AddFileOptions) error {
 	for _, src := range sources {
 		info, err := os.Stat(src)
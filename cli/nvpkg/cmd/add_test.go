@@ -1,10 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
+
+	novuspack "github.com/novus-engine/novuspack/api/go"
 )
 
 func TestRunAdd_SourceNotFound(t *testing.T) {
@@ -112,3 +116,150 @@ func TestRunAdd_PackagePathIsDirectory(t *testing.T) {
 		t.Error("runAdd with directory as package path should fail")
 	}
 }
+
+func TestApplyCompressionOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		compress string
+		level    int
+		wantType uint8
+		wantErr  bool
+	}{
+		{"unset", "", 0, 0, false},
+		{"zstd", "zstd", 0, 1, false},
+		{"lz4 with level", "LZ4", 3, 2, false},
+		{"lzma", "lzma", 9, 3, false},
+		{"none", "none", 0, 0, false},
+		{"auto", "auto", 4, 0, false},
+		{"unknown codec", "brotli", 0, 0, true},
+		{"level without codec", "", 5, 0, true},
+		{"level out of range", "lz4", 10, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := buildAddFileOptions(map[string]string{
+				"compress":          tt.compress,
+				"compression-level": strconv.Itoa(tt.level),
+			})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("buildAddFileOptions: want error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildAddFileOptions: %v", err)
+			}
+			if got := opts.CompressionType.GetOrDefault(0); got != tt.wantType {
+				t.Errorf("CompressionType = %d, want %d", got, tt.wantType)
+			}
+			if got := opts.AutoCompress.GetOrDefault(false); got != strings.EqualFold(tt.compress, compressAuto) {
+				t.Errorf("AutoCompress = %v for --compress %q", got, tt.compress)
+			}
+		})
+	}
+}
+
+func TestApplyCompressionOptions_MinSavings(t *testing.T) {
+	opts, err := buildAddFileOptions(map[string]string{"compress": "auto", "min-savings": "25"})
+	if err != nil {
+		t.Fatalf("buildAddFileOptions: %v", err)
+	}
+	if got := opts.AutoCompressMinSavings.GetOrDefault(-1); got != 25 {
+		t.Errorf("AutoCompressMinSavings = %d, want 25", got)
+	}
+
+	opts, err = buildAddFileOptions(map[string]string{"compress": "auto"})
+	if err != nil {
+		t.Fatalf("buildAddFileOptions: %v", err)
+	}
+	if opts.AutoCompressMinSavings.IsSet() {
+		t.Error("AutoCompressMinSavings set without --min-savings")
+	}
+
+	if _, err := buildAddFileOptions(map[string]string{"compress": "auto", "min-savings": "100"}); err == nil {
+		t.Error("buildAddFileOptions(--min-savings 100): want error, got nil")
+	}
+	if _, err := buildAddFileOptions(map[string]string{"compress": "auto", "min-savings": "x"}); err == nil {
+		t.Error("buildAddFileOptions(--min-savings x): want error, got nil")
+	}
+}
+
+func TestRunAdd_CompressLZ4_ReadAndExtract(t *testing.T) {
+	addStoredPath = "/hot/asset.bin"
+	addCompress = "lz4"
+	defer func() { addStoredPath = ""; addCompress = "" }()
+	dir := t.TempDir()
+	pkgPath := filepath.Join(dir, "lz4.nvpk")
+	srcPath := filepath.Join(dir, "asset.bin")
+	content := []byte(strings.Repeat("hot asset payload ", 200))
+	if err := os.WriteFile(srcPath, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runAdd(addCmd, []string{pkgPath, srcPath}); err != nil {
+		t.Fatalf("runAdd --compress lz4: %v", err)
+	}
+
+	outPath := filepath.Join(dir, "read-out.bin")
+	readOutput = outPath
+	defer func() { readOutput = "" }()
+	if err := runRead(readCmd, []string{pkgPath, "/hot/asset.bin"}); err != nil {
+		t.Fatalf("runRead: %v", err)
+	}
+	if got, _ := os.ReadFile(outPath); string(got) != string(content) {
+		t.Errorf("read output mismatch: got %d bytes, want %d", len(got), len(content))
+	}
+
+	extractDir := filepath.Join(dir, "out")
+	extractOutput = extractDir
+	defer func() { extractOutput = "" }()
+	if err := runExtract(extractCmd, []string{pkgPath}); err != nil {
+		t.Fatalf("runExtract: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(extractDir, "hot", "asset.bin")); string(got) != string(content) {
+		t.Errorf("extracted content mismatch: got %d bytes, want %d", len(got), len(content))
+	}
+}
+
+func TestRunAdd_CompressAuto(t *testing.T) {
+	addCompress = "auto"
+	defer func() { addCompress = "" }()
+	dir := t.TempDir()
+	pkgPath := filepath.Join(dir, "auto.nvpk")
+	textPath := filepath.Join(dir, "notes.txt")
+	pngPath := filepath.Join(dir, "icon.png")
+	text := []byte(strings.Repeat("release notes line ", 200))
+	png := append([]byte("\x89PNG\r\n\x1a\n"), []byte(strings.Repeat("image row ", 200))...)
+	if err := os.WriteFile(textPath, text, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pngPath, png, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runAdd(addCmd, []string{pkgPath, textPath, pngPath}); err != nil {
+		t.Fatalf("runAdd --compress auto: %v", err)
+	}
+
+	pkg, err := novuspack.OpenPackage(context.Background(), pkgPath)
+	if err != nil {
+		t.Fatalf("OpenPackage: %v", err)
+	}
+	defer func() { _ = pkg.Close() }()
+	files, err := pkg.ListFiles()
+	if err != nil {
+		t.Fatalf("ListFiles: %v", err)
+	}
+	want := map[string]uint8{"notes.txt": novuspack.CompressionZstd, "icon.png": novuspack.CompressionNone}
+	for _, f := range files {
+		name := filepath.Base(f.PrimaryPath)
+		if wantType, ok := want[name]; ok {
+			if f.CompressionType != wantType {
+				t.Errorf("%s: CompressionType = %d, want %d", name, f.CompressionType, wantType)
+			}
+			delete(want, name)
+		}
+	}
+	if len(want) != 0 {
+		t.Errorf("files missing from package: %v", want)
+	}
+}
@@ -22,6 +22,8 @@ var (
 	createVendorID uint32
 	createAppID    uint64
 	createModeStr  string
+
+	createConfidentialIndex bool
 )
 
 func init() {
@@ -29,11 +31,15 @@ func init() {
 	createCmd.Flags().Uint32Var(&createVendorID, "vendor-id", 0, "Vendor ID")
 	createCmd.Flags().Uint64Var(&createAppID, "app-id", 0, "Application ID")
 	createCmd.Flags().StringVar(&createModeStr, "mode", "", "File mode for created package file (e.g. 0644)")
+	createCmd.Flags().BoolVar(&createConfidentialIndex, "confidential-index", false, "Encrypt file names and path metadata with the package password key")
 }
 
 func runCreate(_ *cobra.Command, args []string) error {
 	path := args[0]
 	ctx := context.Background()
+	if createConfidentialIndex && packagePassword == "" {
+		return fmt.Errorf("--confidential-index requires a password (--password, --password-file or %s)", packagePasswordEnv)
+	}
 
 	pkg, err := novuspack.NewPackage()
 	if err != nil {
@@ -42,11 +48,12 @@ func runCreate(_ *cobra.Command, args []string) error {
 	defer func() { _ = pkg.Close() }()
 
 	var opts *novuspack.CreateOptions
-	if createComment != "" || createVendorID != 0 || createAppID != 0 || createModeStr != "" {
+	if createComment != "" || createVendorID != 0 || createAppID != 0 || createModeStr != "" || packagePassword != "" {
 		opts = &novuspack.CreateOptions{
-			Comment:  createComment,
-			VendorID: createVendorID,
-			AppID:    createAppID,
+			Comment:    createComment,
+			VendorID:   createVendorID,
+			AppID:      createAppID,
+			Passphrase: packagePassword,
 		}
 		if createModeStr != "" {
 			mode, err := parseFileMode(createModeStr)
@@ -65,6 +72,11 @@ func runCreate(_ *cobra.Command, args []string) error {
 			return fmt.Errorf("create: %w", err)
 		}
 	}
+	if createConfidentialIndex {
+		if err := pkg.EnableConfidentialIndex(ctx, nil); err != nil {
+			return fmt.Errorf("confidential index: %w", err)
+		}
+	}
 	if err := pkg.Write(ctx); err != nil {
 		return fmt.Errorf("write: %w", err)
 	}
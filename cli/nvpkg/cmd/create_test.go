@@ -39,6 +39,83 @@ func TestRunCreate_WithOptions(t *testing.T) {
 	}
 }
 
+func TestRunCreate_WithPassword(t *testing.T) {
+	packagePassword = "qa-build"
+	defer func() { packagePassword = "" }()
+	dir := t.TempDir()
+	pkgPath := filepath.Join(dir, "protected.nvpk")
+	srcPath := filepath.Join(dir, "asset.txt")
+	outPath := filepath.Join(dir, "out.txt")
+	if err := os.WriteFile(srcPath, []byte("protected asset"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCreate(createCmd, []string{pkgPath}); err != nil {
+		t.Fatalf("runCreate with password: %v", err)
+	}
+	addStoredPath = "/asset.txt"
+	defer func() { addStoredPath = "" }()
+	if err := runAdd(addCmd, []string{pkgPath, srcPath}); err != nil {
+		t.Fatalf("runAdd with password: %v", err)
+	}
+
+	readOutput = outPath
+	defer func() { readOutput = "" }()
+	if err := runRead(readCmd, []string{pkgPath, "/asset.txt"}); err != nil {
+		t.Fatalf("runRead with password: %v", err)
+	}
+	if got, err := os.ReadFile(outPath); err != nil || string(got) != "protected asset" {
+		t.Errorf("read output = %q, err = %v", got, err)
+	}
+
+	packagePassword = "wrong"
+	if err := runRead(readCmd, []string{pkgPath, "/asset.txt"}); err == nil || !strings.Contains(err.Error(), "unlock") {
+		t.Errorf("runRead with wrong password: want unlock error, got %v", err)
+	}
+	packagePassword = ""
+	if err := runRead(readCmd, []string{pkgPath, "/asset.txt"}); err == nil {
+		t.Error("runRead without password should fail")
+	}
+}
+
+func TestRunCreate_ConfidentialIndex(t *testing.T) {
+	dir := t.TempDir()
+	pkgPath := filepath.Join(dir, "confidential.nvpk")
+	srcPath := filepath.Join(dir, "roadmap.txt")
+	if err := os.WriteFile(srcPath, []byte("roadmap"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	createConfidentialIndex = true
+	defer func() { createConfidentialIndex = false }()
+	if err := runCreate(createCmd, []string{pkgPath}); err == nil {
+		t.Fatal("runCreate --confidential-index without password should fail")
+	}
+
+	packagePassword = "qa-build"
+	defer func() { packagePassword = "" }()
+	if err := runCreate(createCmd, []string{pkgPath}); err != nil {
+		t.Fatalf("runCreate --confidential-index: %v", err)
+	}
+	addStoredPath = "/roadmap.txt"
+	defer func() { addStoredPath = "" }()
+	if err := runAdd(addCmd, []string{pkgPath, srcPath}); err != nil {
+		t.Fatalf("runAdd with password: %v", err)
+	}
+	raw, err := os.ReadFile(pkgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "roadmap.txt") {
+		t.Error("package contains a plaintext file name")
+	}
+	if err := runList(listCmd, []string{pkgPath}); err != nil {
+		t.Errorf("runList with password: %v", err)
+	}
+	packagePassword = ""
+	if err := runList(listCmd, []string{pkgPath}); err == nil {
+		t.Error("runList without password should fail")
+	}
+}
+
 func TestRunCreate_InvalidPath(t *testing.T) {
 	err := runCreate(createCmd, []string{""})
 	if err == nil {
@@ -891,6 +891,9 @@ var interactiveFlags = []interactiveFlagDef{
 	{[]string{"--no-follow-symlinks"}, "no-follow-symlinks"},
 	{[]string{"--preserve-permissions"}, "preserve-permissions"},
 	{[]string{"--preserve-ownership"}, "preserve-ownership"},
+	{[]string{"--compress"}, "compress"},
+	{[]string{"--compression-level"}, "compression-level"},
+	{[]string{"--min-savings"}, "min-savings"},
 	{[]string{"--overwrite"}, "overwrite"},
 	{[]string{"--set"}, "set"},
 	{[]string{"--clear"}, "clear"},
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	novuspack "github.com/novus-engine/novuspack/api/go"
+	"github.com/spf13/cobra"
+)
+
+var rekeyCmd = &cobra.Command{
+	Use:   "rekey <package path>",
+	Short: "Re-encrypt package files under a new key",
+	Long:  "Re-encrypts every file encrypted with the old key under the new key and writes the package back to disk. Key files hold the raw 32-byte key.",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runRekey,
+}
+
+var (
+	rekeyOldKeyID   string
+	rekeyOldKeyFile string
+	rekeyNewKeyID   string
+	rekeyNewKeyFile string
+	rekeyCipher     string
+)
+
+func init() {
+	rekeyCmd.Flags().StringVar(&rekeyOldKeyID, "old-key-id", "", "Key ID the files are encrypted with (required)")
+	rekeyCmd.Flags().StringVar(&rekeyOldKeyFile, "old-key", "", "File holding the old key (required)")
+	rekeyCmd.Flags().StringVar(&rekeyNewKeyID, "new-key-id", "", "Key ID of the new key (required)")
+	rekeyCmd.Flags().StringVar(&rekeyNewKeyFile, "new-key", "", "File holding the new key (required)")
+	rekeyCmd.Flags().StringVar(&rekeyCipher, "cipher", "aes256gcm", "Cipher of the old and new keys: aes256gcm, chacha20poly1305")
+}
+
+func runRekey(_ *cobra.Command, args []string) error {
+	pkgPath := args[0]
+	ctx := context.Background()
+	if rekeyOldKeyID == "" || rekeyOldKeyFile == "" || rekeyNewKeyID == "" || rekeyNewKeyFile == "" {
+		return fmt.Errorf("--old-key-id, --old-key, --new-key-id and --new-key are required")
+	}
+	cipher, err := parseCipher(rekeyCipher)
+	if err != nil {
+		return err
+	}
+	oldKey, err := readKeyFile(rekeyOldKeyFile, rekeyOldKeyID, cipher)
+	if err != nil {
+		return err
+	}
+	defer oldKey.Clear()
+	newKey, err := readKeyFile(rekeyNewKeyFile, rekeyNewKeyID, cipher)
+	if err != nil {
+		return err
+	}
+	defer newKey.Clear()
+
+	pkg, err := openPackage(ctx, pkgPath, false)
+	if err != nil {
+		return fmt.Errorf("open package: %w", err)
+	}
+	defer func() { _ = pkg.Close() }()
+	// A confidential index encrypted with the old key is unlocked by it
+	if err := pkg.AddEncryptionKey(oldKey); err != nil {
+		return fmt.Errorf("add key: %w", err)
+	}
+	if err := pkg.UnlockIndex(ctx); err != nil {
+		return fmt.Errorf("unlock index: %w", err)
+	}
+
+	n, err := pkg.RotateEncryptionKey(ctx, oldKey, newKey)
+	if err != nil {
+		return fmt.Errorf("rekey: %w", err)
+	}
+	if err := pkg.Write(ctx); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	_, _ = fmt.Fprintf(os.Stdout, "Re-encrypted %d file(s) in %s under key %s\n", n, pkgPath, rekeyNewKeyID)
+	return nil
+}
+
+// parseCipher maps a --cipher value to the encryption key type.
+func parseCipher(s string) (novuspack.EncryptionAlgorithm, error) {
+	switch s {
+	case "aes256gcm", "":
+		return novuspack.EncryptionAlgorithmAES256GCM, nil
+	case "chacha20poly1305":
+		return novuspack.EncryptionAlgorithmChaCha20Poly1305, nil
+	default:
+		return 0, fmt.Errorf("unknown cipher %q (use aes256gcm or chacha20poly1305)", s)
+	}
+}
+
+// readKeyFile reads a raw key from path as an encryption key with the given ID.
+func readKeyFile(path, keyID string, cipher novuspack.EncryptionAlgorithm) (*novuspack.EncryptionKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read key file: %w", err)
+	}
+	defer clear(data)
+	if len(data) != 32 {
+		return nil, fmt.Errorf("key file %s: want 32 bytes, got %d", path, len(data))
+	}
+	return novuspack.NewEncryptionKey(cipher, keyID, data), nil
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	novuspack "github.com/novus-engine/novuspack/api/go"
+)
+
+func TestRunRekey(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	pkgPath := filepath.Join(dir, "rekey.nvpk")
+	oldMaterial := bytes.Repeat([]byte{0x11}, 32)
+	newMaterial := bytes.Repeat([]byte{0x22}, 32)
+	oldFile := filepath.Join(dir, "old.key")
+	newFile := filepath.Join(dir, "new.key")
+	if err := os.WriteFile(oldFile, oldMaterial, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := os.WriteFile(newFile, newMaterial, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	pkg, err := novuspack.NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage: %v", err)
+	}
+	if err := pkg.Create(ctx, pkgPath); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	opts := &novuspack.AddFileOptions{}
+	opts.EncryptionKey.Set(novuspack.NewEncryptionKey(novuspack.EncryptionAlgorithmAES256GCM, "2025", oldMaterial))
+	if _, err := pkg.AddFileFromMemory(ctx, "/secret.txt", []byte("launch codes"), opts); err != nil {
+		t.Fatalf("AddFileFromMemory: %v", err)
+	}
+	if err := pkg.Write(ctx); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	_ = pkg.Close()
+
+	rekeyOldKeyID, rekeyOldKeyFile = "2025", oldFile
+	rekeyNewKeyID, rekeyNewKeyFile = "2026", newFile
+	defer func() {
+		rekeyOldKeyID, rekeyOldKeyFile, rekeyNewKeyID, rekeyNewKeyFile = "", "", "", ""
+	}()
+	if err := runRekey(rekeyCmd, []string{pkgPath}); err != nil {
+		t.Fatalf("runRekey: %v", err)
+	}
+
+	reopened, err := novuspack.OpenPackage(ctx, pkgPath)
+	if err != nil {
+		t.Fatalf("OpenPackage: %v", err)
+	}
+	defer func() { _ = reopened.Close() }()
+	if err := reopened.AddEncryptionKey(novuspack.NewEncryptionKey(novuspack.EncryptionAlgorithmAES256GCM, "2026", newMaterial)); err != nil {
+		t.Fatalf("AddEncryptionKey: %v", err)
+	}
+	if got, err := reopened.ReadFile(ctx, "/secret.txt"); err != nil || string(got) != "launch codes" {
+		t.Errorf("ReadFile after rekey = %q, err = %v", got, err)
+	}
+}
+
+func TestRunRekey_Errors(t *testing.T) {
+	dir := t.TempDir()
+	pkgPath := createTestPackage(t, "rekey.nvpk")
+	shortKey := filepath.Join(dir, "short.key")
+	if err := os.WriteFile(shortKey, []byte("short"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	defer func() {
+		rekeyOldKeyID, rekeyOldKeyFile, rekeyNewKeyID, rekeyNewKeyFile, rekeyCipher = "", "", "", "", "aes256gcm"
+	}()
+
+	if err := runRekey(rekeyCmd, []string{pkgPath}); err == nil || !strings.Contains(err.Error(), "required") {
+		t.Errorf("runRekey without keys: want 'required' error, got %v", err)
+	}
+	rekeyOldKeyID, rekeyOldKeyFile = "old", shortKey
+	rekeyNewKeyID, rekeyNewKeyFile = "new", shortKey
+	if err := runRekey(rekeyCmd, []string{pkgPath}); err == nil || !strings.Contains(err.Error(), "32 bytes") {
+		t.Errorf("runRekey with short key: want '32 bytes' error, got %v", err)
+	}
+	rekeyCipher = "rot13"
+	if err := runRekey(rekeyCmd, []string{pkgPath}); err == nil || !strings.Contains(err.Error(), "unknown cipher") {
+		t.Errorf("runRekey with bad cipher: want 'unknown cipher' error, got %v", err)
+	}
+}
@@ -1,6 +1,12 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
 	"github.com/spf13/cobra"
 )
 
@@ -8,13 +14,31 @@ var rootCmd = &cobra.Command{
 	Use:   "nvpkg",
 	Short: "NovusPack package manager CLI",
 	Long:  "nvpkg is a CLI for creating, inspecting, and modifying NovusPack (.nvpk) packages. Use 'nvpkg interactive' (or 'nvpkg i') for REPL mode.",
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+		return resolvePackagePassword(cmd)
+	},
 }
 
+// packagePasswordEnv is the environment variable holding the package passphrase
+// when neither --password nor --password-file is given.
+const packagePasswordEnv = "NVPKG_PASSWORD"
+
+// packagePassword is the package passphrase: it protects packages created by
+// create and add, and unlocks packages opened by other commands. It is set from
+// --password, --password-file or NVPKG_PASSWORD by resolvePackagePassword.
+var packagePassword string
+
+// packagePasswordFile is the --password-file flag: a file holding the passphrase,
+// or "-" for standard input.
+var packagePasswordFile string
+
 func Execute() error {
 	return rootCmd.Execute()
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&packagePassword, "password", "", "Package passphrase (protects new packages, unlocks existing ones; visible in process lists, prefer --password-file or "+packagePasswordEnv+")")
+	rootCmd.PersistentFlags().StringVar(&packagePasswordFile, "password-file", "", "Read the package passphrase from a file (\"-\" for standard input)")
 	rootCmd.AddCommand(createCmd)
 	rootCmd.AddCommand(infoCmd)
 	rootCmd.AddCommand(listCmd)
@@ -27,5 +51,39 @@ func init() {
 	rootCmd.AddCommand(commentCmd)
 	rootCmd.AddCommand(identityCmd)
 	rootCmd.AddCommand(metadataCmd)
+	rootCmd.AddCommand(rekeyCmd)
 	rootCmd.AddCommand(interactiveCmd)
 }
+
+// resolvePackagePassword sets packagePassword from --password-file or the
+// NVPKG_PASSWORD environment variable when --password is not given. The two flags
+// are mutually exclusive. One trailing newline is removed from a password file.
+func resolvePackagePassword(cmd *cobra.Command) error {
+	passwordSet := cmd.Flags().Changed("password")
+	if packagePasswordFile == "" {
+		if !passwordSet {
+			packagePassword = os.Getenv(packagePasswordEnv)
+		}
+		return nil
+	}
+	if passwordSet {
+		return errors.New("--password and --password-file cannot be used together")
+	}
+
+	var data []byte
+	var err error
+	if packagePasswordFile == "-" {
+		data, err = io.ReadAll(cmd.InOrStdin())
+	} else {
+		data, err = os.ReadFile(packagePasswordFile)
+	}
+	if err != nil {
+		return fmt.Errorf("read password file: %w", err)
+	}
+	password := strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+	if password == "" {
+		return fmt.Errorf("password file %s is empty", packagePasswordFile)
+	}
+	packagePassword = password
+	return nil
+}
@@ -1,6 +1,9 @@
 package cmd
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -29,3 +32,47 @@ func TestExecute_Create(t *testing.T) {
 		t.Errorf("Execute create: %v", err)
 	}
 }
+
+func TestResolvePackagePassword(t *testing.T) {
+	defer func() {
+		packagePassword = ""
+		packagePasswordFile = ""
+		rootCmd.PersistentFlags().Lookup("password").Changed = false
+		rootCmd.SetIn(nil)
+	}()
+	dir := t.TempDir()
+	passwordPath := filepath.Join(dir, "password.txt")
+	if err := os.WriteFile(passwordPath, []byte("from-file\r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	emptyPath := filepath.Join(dir, "empty.txt")
+	if err := os.WriteFile(emptyPath, []byte("\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(packagePasswordEnv, "from-env")
+	rootCmd.SetArgs([]string{"create", filepath.Join(dir, "env.nvpk")})
+	if err := Execute(); err != nil || packagePassword != "from-env" {
+		t.Errorf("password from environment = %q, err = %v", packagePassword, err)
+	}
+
+	rootCmd.SetArgs([]string{"create", filepath.Join(dir, "file.nvpk"), "--password-file", passwordPath})
+	if err := Execute(); err != nil || packagePassword != "from-file" {
+		t.Errorf("password from file = %q, err = %v", packagePassword, err)
+	}
+
+	rootCmd.SetIn(strings.NewReader("from-stdin\n"))
+	rootCmd.SetArgs([]string{"create", filepath.Join(dir, "stdin.nvpk"), "--password-file", "-"})
+	if err := Execute(); err != nil || packagePassword != "from-stdin" {
+		t.Errorf("password from stdin = %q, err = %v", packagePassword, err)
+	}
+
+	rootCmd.SetArgs([]string{"create", filepath.Join(dir, "empty.nvpk"), "--password-file", emptyPath})
+	if err := Execute(); err == nil {
+		t.Error("Execute with an empty password file should fail")
+	}
+	rootCmd.SetArgs([]string{"create", filepath.Join(dir, "both.nvpk"), "--password", "x", "--password-file", passwordPath})
+	if err := Execute(); err == nil {
+		t.Error("Execute with --password and --password-file should fail")
+	}
+}
@@ -2,9 +2,13 @@ package cmd
 
 import (
 	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"os"
 
+	novuspack "github.com/novus-engine/novuspack/api/go"
 	"github.com/spf13/cobra"
 )
 
@@ -16,10 +20,16 @@ var validateCmd = &cobra.Command{
 	RunE:  runValidate,
 }
 
-var validateReadOnly bool
+var (
+	validateReadOnly         bool
+	validateRequireSignature bool
+	validateTrustedKeys      []string
+)
 
 func init() {
 	validateCmd.Flags().BoolVar(&validateReadOnly, "read-only", false, "Open package read-only (no write risk)")
+	validateCmd.Flags().BoolVar(&validateRequireSignature, "require-signature", false, "Fail unless a signature verifies with a --trusted-key")
+	validateCmd.Flags().StringArrayVar(&validateTrustedKeys, "trusted-key", nil, "PEM public key file trusted for signatures (repeatable)")
 }
 
 func runValidate(_ *cobra.Command, args []string) error {
@@ -32,9 +42,45 @@ func runValidate(_ *cobra.Command, args []string) error {
 	}
 	defer func() { _ = pkg.Close() }()
 
-	if err := pkg.Validate(ctx); err != nil {
+	var opts *novuspack.ValidateOptions
+	if validateRequireSignature {
+		if len(validateTrustedKeys) == 0 {
+			return fmt.Errorf("--require-signature requires --trusted-key")
+		}
+		keys := make([]crypto.PublicKey, 0, len(validateTrustedKeys))
+		for _, keyPath := range validateTrustedKeys {
+			key, err := readPublicKeyFile(keyPath)
+			if err != nil {
+				return err
+			}
+			keys = append(keys, key)
+		}
+		opts = &novuspack.ValidateOptions{TrustStore: novuspack.NewTrustStore(keys...), RequiredSignatures: 1}
+	}
+	if err := pkg.ValidateWithOptions(ctx, opts); err != nil {
 		return fmt.Errorf("validate: %w", err)
 	}
+	if opts != nil {
+		_, _ = fmt.Fprintf(os.Stdout, "OK %s (signature trusted)\n", path)
+		return nil
+	}
 	_, _ = fmt.Fprintf(os.Stdout, "OK %s\n", path)
 	return nil
 }
+
+// readPublicKeyFile reads a PEM "PUBLIC KEY" (PKIX) file.
+func readPublicKeyFile(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read trusted key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("trusted key %s: not a PEM public key", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("trusted key %s: %w", path, err)
+	}
+	return key, nil
+}
@@ -1,7 +1,13 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -44,3 +50,82 @@ func TestRunValidate_OpenPackage(t *testing.T) {
 		t.Errorf("runValidate on written package: %v", err)
 	}
 }
+
+// writeSignedTestPackage writes a package signed with a new Ed25519 key and returns
+// the package path and the path of the PEM public key file.
+func writeSignedTestPackage(t *testing.T) (string, string) {
+	t.Helper()
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "signed.nvpk")
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	pkg, err := novuspack.NewPackage()
+	if err != nil {
+		t.Fatalf("NewPackage: %v", err)
+	}
+	defer func() { _ = pkg.Close() }()
+	if err := pkg.Create(ctx, path); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := pkg.AddFileFromMemory(ctx, "/a.txt", []byte("signed content"), nil); err != nil {
+		t.Fatalf("AddFileFromMemory: %v", err)
+	}
+	if err := pkg.Write(ctx); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := pkg.Sign(ctx, private, "test"); err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey: %v", err)
+	}
+	keyPath := filepath.Join(dir, "signer.pub")
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path, keyPath
+}
+
+func TestRunValidate_RequireSignature(t *testing.T) {
+	defer func() { validateRequireSignature = false; validateTrustedKeys = nil }()
+	path, keyPath := writeSignedTestPackage(t)
+	validateRequireSignature = true
+
+	validateTrustedKeys = nil
+	if err := runValidate(nil, []string{path}); err == nil {
+		t.Error("runValidate --require-signature without --trusted-key should fail")
+	}
+
+	validateTrustedKeys = []string{keyPath}
+	if err := runValidate(nil, []string{path}); err != nil {
+		t.Errorf("runValidate on signed package: %v", err)
+	}
+
+	_, otherKeyPath := writeSignedTestPackage(t)
+	validateTrustedKeys = []string{otherKeyPath}
+	if err := runValidate(nil, []string{path}); err == nil {
+		t.Error("runValidate with an untrusted signer should fail")
+	}
+
+	validateTrustedKeys = []string{keyPath}
+	unsigned := createTestPackage(t, "unsigned.nvpk")
+	if err := runValidate(nil, []string{unsigned}); err == nil {
+		t.Error("runValidate --require-signature on unsigned package should fail")
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	raw[bytes.Index(raw, []byte("signed content"))] ^= 0xFF
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := runValidate(nil, []string{path}); err == nil {
+		t.Error("runValidate --require-signature on tampered package should fail")
+	}
+}
@@ -2,24 +2,25 @@
 Feature: SetTargetPath Signature Clearing
 
   @REQ-API_BASIC-099 @happy
-  Scenario: SetTargetPath clears signatures when path differs
+  Scenario: SetTargetPath keeps signatures when path differs
     Given a signed package with current path
     When SetTargetPath is called with a different path
-    Then all signature information is cleared from memory
-    And package becomes unsigned
+    Then signature information is kept in memory
+    And package remains signed and immutable
     And new path is set for writing
 
   @REQ-API_BASIC-099 @happy
-  Scenario: SetTargetPath clears signatures for signed package
+  Scenario: Writing a retargeted signed package clears signatures
     Given a package is signed
     And package has signature metadata
     When SetTargetPath is called with new path different from current
-    Then signature data is removed
+    And the package is written
+    Then signature data is removed from memory
     And signature flags are cleared
     And package is marked as unsigned
 
   @REQ-API_BASIC-099 @happy
-  Scenario: Signature clearing creates new unsigned package
+  Scenario: Writing to a new path creates new unsigned package
     Given a signed package at original location
     When SetTargetPath changes path to new location
     Then writing creates new unsigned package at new location
@@ -50,10 +51,11 @@ Feature: SetTargetPath Signature Clearing
     And package remains unsigned
     And target path is updated normally
 
-  @REQ-API_BASIC-099 @happy
-  Scenario: Signature clearing is required for immutability
-    Given a signed package with cryptographic signatures
-    When SetTargetPath changes location
-    Then signature clearing preserves immutability principle
-    And signed packages remain immutable at original location
-    And new location creates new unsigned instance
+  @REQ-API_BASIC-099 @error
+  Scenario: Retargeting back to the signed file restores write protection
+    Given a signed package with current path
+    When SetTargetPath is called with a different path
+    And SetTargetPath is called with the original path
+    Then Write returns ErrTypeSecurity
+    And the signed package is not overwritten
+    And signatures are cleared only by ClearAllSignatures or a write to a new path
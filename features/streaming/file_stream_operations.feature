@@ -2,9 +2,9 @@
 Feature: File stream operations
 
   @happy
-  Scenario: NewFileStream creates file stream for large files
+  Scenario: Package.OpenFile creates file stream for large files
     Given an open package with large file
-    When NewFileStream is called with file path
+    When OpenFile is called with file path
     Then FileStream is created
     And stream is ready for reading
     And stream is not closed
@@ -102,9 +102,9 @@ Feature: File stream operations
     Then structured validation error is returned
 
   @REQ-STREAM-013 @REQ-STREAM-014 @error
-  Scenario: NewFileStream validates file path parameter
+  Scenario: Package.OpenFile validates file path parameter
     Given an open package
-    When NewFileStream is called with empty path
+    When OpenFile is called with empty path
     Then structured validation error is returned
     And error indicates invalid path
 